@@ -0,0 +1,63 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package ast
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/88250/lute/util"
+)
+
+// NodeError 描述了处理某个节点时发生的错误，解析和渲染过程中发生的 panic 也会被转换为该错误。
+type NodeError struct {
+	NodeType NodeType // 出错时正在处理的节点类型，无法确定节点时为 NodeDocument
+	Pos      *Pos     // 出错节点在 Markdown 原始文本中的位置，仅在打开 SourcePos 解析选项时记录
+	Err      error    // 具体的错误
+	Stack    string   // 发生 panic 时的调用栈，错误不是由 panic 引起时为空
+}
+
+// NewNodeError 创建处理节点 node 时发生的错误 err，node 为 nil 时表示无法确定出错的节点。
+func NewNodeError(node *Node, err error) *NodeError {
+	ret := &NodeError{Err: err}
+	if nil != node {
+		ret.NodeType, ret.Pos = node.Type, node.Pos
+	}
+	return ret
+}
+
+// NewPanicError 将处理节点 node 时 recover 得到的 panic 值 v 转换为错误，并记录调用栈。
+func NewPanicError(node *Node, v interface{}) *NodeError {
+	var err error
+	switch x := v.(type) {
+	case error:
+		err = x
+	case string:
+		err = errors.New(x)
+	default:
+		err = fmt.Errorf("%v", x)
+	}
+	ret := NewNodeError(node, err)
+	ret.Stack = util.PanicStack()
+	return ret
+}
+
+func (e *NodeError) Error() string {
+	ret := "node [type=" + e.NodeType.String()
+	if nil != e.Pos {
+		ret += ", pos=" + e.Pos.String()
+	}
+	return ret + "]: " + e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
@@ -56,6 +56,10 @@ type Node struct {
 	LastLineBlank   bool `json:"-"` // 标识最后一行是否是空行
 	LastLineChecked bool `json:"-"` // 标识最后一行是否检查过
 
+	// 源码位置
+
+	Pos *Pos `json:"-"` // 节点在 Markdown 原始文本中的位置，仅在打开 SourcePos 解析选项时记录
+
 	// 代码
 
 	CodeMarkerLen int `json:",omitempty"` // ` 个数，1 或 2
@@ -142,13 +146,24 @@ type Node struct {
 	// 自定义块 https://github.com/siyuan-note/siyuan/issues/8418
 
 	CustomBlockFenceOffset int    `json:",omitempty"` // 自定义块标记符起始偏移量
-	CustomBlockInfo        string `json:",omitempty"` // 自定义块信息
+	CustomBlockInfo        string `json:",omitempty"` // 自定义块信息，自定义行级节点也使用该字段记录信息
 
 	// 提示块 https://github.com/88250/lute/issues/203 > [!Type] Title
 	CalloutType     string `json:",omitempty"` // 提示块类型
 	CalloutTitle    string `json:",omitempty"` // 提示块标题
 	CalloutIcon     string `json:",omitempty"` // 提示块图标
 	CalloutIconType int    `json:",omitempty"` // 提示块图标类型，0：Emoji Unicode，1：自定义图标
+
+	// 指令 :::name[label]{attrs}、::name[label]{attrs} 和 :name[label]{attrs}
+	DirectiveName     string     `json:",omitempty"` // 指令名称
+	DirectiveLabel    string     `json:",omitempty"` // 容器指令标签，叶子指令和文本指令的标签解析为子节点
+	DirectiveAttrs    [][]string `json:",omitempty"` // 指令属性
+	DirectiveFenceLen int        `json:",omitempty"` // 容器指令围栏标记符 : 的长度
+
+	// 维基链接 [[target#heading|alias]]
+	WikiLinkTarget  string `json:",omitempty"` // 维基链接目标
+	WikiLinkHeading string `json:",omitempty"` // 维基链接目标中的标题（# 后的部分）
+	WikiLinkAlias   string `json:",omitempty"` // 维基链接别名（| 后的部分）
 }
 
 // EffectiveTaskListItemMarker 返回任务列表项的有效标记字符（已转义，适用于 HTML 属性值输出）。
@@ -953,7 +968,7 @@ func (n *Node) IsBlock() bool {
 	case NodeDocument, NodeParagraph, NodeHeading, NodeThematicBreak, NodeBlockquote, NodeList, NodeListItem, NodeHTMLBlock,
 		NodeCodeBlock, NodeTable, NodeMathBlock, NodeFootnotesDefBlock, NodeFootnotesDef, NodeToC, NodeYamlFrontMatter,
 		NodeBlockQueryEmbed, NodeKramdownBlockIAL, NodeSuperBlock, NodeGitConflict, NodeAudio, NodeVideo, NodeIFrame, NodeWidget,
-		NodeAttributeView, NodeCustomBlock, NodeCallout:
+		NodeAttributeView, NodeCustomBlock, NodeCallout, NodeContainerDirective, NodeLeafDirective:
 		return true
 	}
 	return false
@@ -962,7 +977,8 @@ func (n *Node) IsBlock() bool {
 // IsContainerBlock 判断 n 是否为容器块。
 func (n *Node) IsContainerBlock() bool {
 	switch n.Type {
-	case NodeDocument, NodeBlockquote, NodeList, NodeListItem, NodeFootnotesDefBlock, NodeFootnotesDef, NodeSuperBlock, NodeCallout:
+	case NodeDocument, NodeBlockquote, NodeList, NodeListItem, NodeFootnotesDefBlock, NodeFootnotesDef, NodeSuperBlock, NodeCallout,
+		NodeContainerDirective:
 		return true
 	}
 	return false
@@ -1276,5 +1292,20 @@ const (
 
 	NodeCallout NodeType = 580 // 提示块
 
+	// 自定义行级节点，由行级语法扩展 parse.InlineSyntax 生成
+
+	NodeCustomInline NodeType = 590 // 自定义行级节点
+
+	// 指令 https://talk.commonmark.org/t/generic-directives-plugins-syntax/444
+
+	NodeContainerDirective NodeType = 600 // 容器指令 :::name[label]{attrs}
+	NodeLeafDirective      NodeType = 601 // 叶子指令 ::name[label]{attrs}
+	NodeTextDirective      NodeType = 602 // 文本指令 :name[label]{attrs}
+
+	// 维基链接 [[target#heading|alias]]
+
+	NodeWikiLink      NodeType = 610 // 维基链接 [[target#heading|alias]]
+	NodeWikiLinkEmbed NodeType = 611 // 维基链接嵌入 ![[target#heading|alias]]
+
 	NodeTypeMaxVal NodeType = 1024 // 节点类型最大值
 )
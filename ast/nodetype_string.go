@@ -146,10 +146,16 @@ func _() {
 	_ = x[NodeHTMLTagOpen-571]
 	_ = x[NodeHTMLTagClose-572]
 	_ = x[NodeCallout-580]
+	_ = x[NodeCustomInline-590]
+	_ = x[NodeContainerDirective-600]
+	_ = x[NodeLeafDirective-601]
+	_ = x[NodeTextDirective-602]
+	_ = x[NodeWikiLink-610]
+	_ = x[NodeWikiLinkEmbed-611]
 	_ = x[NodeTypeMaxVal-1024]
 }
 
-const _NodeType_name = "NodeDocumentNodeParagraphNodeHeadingNodeHeadingC8hMarkerNodeThematicBreakNodeBlockquoteNodeBlockquoteMarkerNodeListNodeListItemNodeHTMLBlockNodeInlineHTMLNodeCodeBlockNodeCodeBlockFenceOpenMarkerNodeCodeBlockFenceCloseMarkerNodeCodeBlockFenceInfoMarkerNodeCodeBlockCodeNodeTextNodeEmphasisNodeEmA6kOpenMarkerNodeEmA6kCloseMarkerNodeEmU8eOpenMarkerNodeEmU8eCloseMarkerNodeStrongNodeStrongA6kOpenMarkerNodeStrongA6kCloseMarkerNodeStrongU8eOpenMarkerNodeStrongU8eCloseMarkerNodeCodeSpanNodeCodeSpanOpenMarkerNodeCodeSpanContentNodeCodeSpanCloseMarkerNodeHardBreakNodeSoftBreakNodeLinkNodeImageNodeBangNodeOpenBracketNodeCloseBracketNodeOpenParenNodeCloseParenNodeLinkTextNodeLinkDestNodeLinkTitleNodeLinkSpaceNodeHTMLEntityNodeLinkRefDefBlockNodeLinkRefDefNodeLessNodeGreaterNodeTaskListItemMarkerNodeStrikethroughNodeStrikethrough1OpenMarkerNodeStrikethrough1CloseMarkerNodeStrikethrough2OpenMarkerNodeStrikethrough2CloseMarkerNodeTableNodeTableHeadNodeTableRowNodeTableCellNodeEmojiNodeEmojiUnicodeNodeEmojiImgNodeEmojiAliasNodeMathBlockNodeMathBlockOpenMarkerNodeMathBlockContentNodeMathBlockCloseMarkerNodeInlineMathNodeInlineMathOpenMarkerNodeInlineMathContentNodeInlineMathCloseMarkerNodeBackslashNodeBackslashContentNodeVditorCaretNodeFootnotesDefBlockNodeFootnotesDefNodeFootnotesRefNodeToCNodeHeadingIDNodeYamlFrontMatterNodeYamlFrontMatterOpenMarkerNodeYamlFrontMatterContentNodeYamlFrontMatterCloseMarkerNodeBlockRefNodeBlockRefIDNodeBlockRefSpaceNodeBlockRefTextNodeBlockRefDynamicTextNodeMarkNodeMark1OpenMarkerNodeMark1CloseMarkerNodeMark2OpenMarkerNodeMark2CloseMarkerNodeKramdownBlockIALNodeKramdownSpanIALNodeTagNodeTagOpenMarkerNodeTagCloseMarkerNodeBlockQueryEmbedNodeOpenBraceNodeCloseBraceNodeBlockQueryEmbedScriptNodeSuperBlockNodeSuperBlockOpenMarkerNodeSuperBlockLayoutMarkerNodeSuperBlockCloseMarkerNodeSupNodeSupOpenMarkerNodeSupCloseMarkerNodeSubNodeSubOpenMarkerNodeSubCloseMarkerNodeGitConflictNodeGitConflictOpenMarkerNodeGitConflictContentNodeGitConflictCloseMarkerNodeIFrameNodeAudioNodeVideoNodeKbdNodeKbdOpenMarkerNodeKbdCloseMarkerNodeUnderlineNodeUnderlineOpenMarkerNodeUnderlineCloseMarkerNodeBrNodeTextMarkNodeWidgetNodeFileAnnotationRefNodeFileAnnotationRefIDNodeFileAnnotationRefSpaceNodeFileAnnotationRefTextNodeAttributeViewNodeCustomBlockNodeHTMLTagNodeHTMLTagOpenNodeHTMLTagCloseNodeCalloutNodeTypeMaxVal"
+const _NodeType_name = "NodeDocumentNodeParagraphNodeHeadingNodeHeadingC8hMarkerNodeThematicBreakNodeBlockquoteNodeBlockquoteMarkerNodeListNodeListItemNodeHTMLBlockNodeInlineHTMLNodeCodeBlockNodeCodeBlockFenceOpenMarkerNodeCodeBlockFenceCloseMarkerNodeCodeBlockFenceInfoMarkerNodeCodeBlockCodeNodeTextNodeEmphasisNodeEmA6kOpenMarkerNodeEmA6kCloseMarkerNodeEmU8eOpenMarkerNodeEmU8eCloseMarkerNodeStrongNodeStrongA6kOpenMarkerNodeStrongA6kCloseMarkerNodeStrongU8eOpenMarkerNodeStrongU8eCloseMarkerNodeCodeSpanNodeCodeSpanOpenMarkerNodeCodeSpanContentNodeCodeSpanCloseMarkerNodeHardBreakNodeSoftBreakNodeLinkNodeImageNodeBangNodeOpenBracketNodeCloseBracketNodeOpenParenNodeCloseParenNodeLinkTextNodeLinkDestNodeLinkTitleNodeLinkSpaceNodeHTMLEntityNodeLinkRefDefBlockNodeLinkRefDefNodeLessNodeGreaterNodeTaskListItemMarkerNodeStrikethroughNodeStrikethrough1OpenMarkerNodeStrikethrough1CloseMarkerNodeStrikethrough2OpenMarkerNodeStrikethrough2CloseMarkerNodeTableNodeTableHeadNodeTableRowNodeTableCellNodeEmojiNodeEmojiUnicodeNodeEmojiImgNodeEmojiAliasNodeMathBlockNodeMathBlockOpenMarkerNodeMathBlockContentNodeMathBlockCloseMarkerNodeInlineMathNodeInlineMathOpenMarkerNodeInlineMathContentNodeInlineMathCloseMarkerNodeBackslashNodeBackslashContentNodeVditorCaretNodeFootnotesDefBlockNodeFootnotesDefNodeFootnotesRefNodeToCNodeHeadingIDNodeYamlFrontMatterNodeYamlFrontMatterOpenMarkerNodeYamlFrontMatterContentNodeYamlFrontMatterCloseMarkerNodeBlockRefNodeBlockRefIDNodeBlockRefSpaceNodeBlockRefTextNodeBlockRefDynamicTextNodeMarkNodeMark1OpenMarkerNodeMark1CloseMarkerNodeMark2OpenMarkerNodeMark2CloseMarkerNodeKramdownBlockIALNodeKramdownSpanIALNodeTagNodeTagOpenMarkerNodeTagCloseMarkerNodeBlockQueryEmbedNodeOpenBraceNodeCloseBraceNodeBlockQueryEmbedScriptNodeSuperBlockNodeSuperBlockOpenMarkerNodeSuperBlockLayoutMarkerNodeSuperBlockCloseMarkerNodeSupNodeSupOpenMarkerNodeSupCloseMarkerNodeSubNodeSubOpenMarkerNodeSubCloseMarkerNodeGitConflictNodeGitConflictOpenMarkerNodeGitConflictContentNodeGitConflictCloseMarkerNodeIFrameNodeAudioNodeVideoNodeKbdNodeKbdOpenMarkerNodeKbdCloseMarkerNodeUnderlineNodeUnderlineOpenMarkerNodeUnderlineCloseMarkerNodeBrNodeTextMarkNodeWidgetNodeFileAnnotationRefNodeFileAnnotationRefIDNodeFileAnnotationRefSpaceNodeFileAnnotationRefTextNodeAttributeViewNodeCustomBlockNodeHTMLTagNodeHTMLTagOpenNodeHTMLTagCloseNodeCalloutNodeCustomInlineNodeContainerDirectiveNodeLeafDirectiveNodeTextDirectiveNodeWikiLinkNodeWikiLinkEmbedNodeTypeMaxVal"
 
 var _NodeType_map = map[NodeType]string{
 	0:    _NodeType_name[0:12],
@@ -290,7 +296,13 @@ var _NodeType_map = map[NodeType]string{
 	571:  _NodeType_name[2289:2304],
 	572:  _NodeType_name[2304:2320],
 	580:  _NodeType_name[2320:2331],
-	1024: _NodeType_name[2331:2345],
+	590:  _NodeType_name[2331:2347],
+	600:  _NodeType_name[2347:2369],
+	601:  _NodeType_name[2369:2386],
+	602:  _NodeType_name[2386:2403],
+	610:  _NodeType_name[2403:2415],
+	611:  _NodeType_name[2415:2432],
+	1024: _NodeType_name[2432:2446],
 }
 
 func (i NodeType) String() string {
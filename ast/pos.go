@@ -0,0 +1,35 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package ast
+
+import "strconv"
+
+// Pos 描述了节点在 Markdown 原始文本中的位置。
+//
+// 偏移按字节计算，结束偏移不包含在范围内；行号和列号均从 1 开始，列号按字节计算，结束列包含在范围内。
+type Pos struct {
+	StartOffset int // 起始字节偏移
+	EndOffset   int // 结束字节偏移
+	StartLine   int // 起始行号
+	StartColumn int // 起始列号
+	EndLine     int // 结束行号
+	EndColumn   int // 结束列号
+}
+
+// String 返回 cmark data-sourcepos 格式的位置字符串，比如 1:1-2:5。
+func (p *Pos) String() string {
+	return strconv.Itoa(p.StartLine) + ":" + strconv.Itoa(p.StartColumn) + "-" + strconv.Itoa(p.EndLine) + ":" + strconv.Itoa(p.EndColumn)
+}
+
+// Contains 判断字节偏移 offset 是否落在该位置范围内。
+func (p *Pos) Contains(offset int) bool {
+	return p.StartOffset <= offset && offset < p.EndOffset
+}
@@ -0,0 +1,285 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// lute 命令行工具，从文件或者标准输入读取内容，调用 Lute 引擎处理后输出到标准输出。
+//
+// 用法：
+//
+//	lute <command> [flags] [files...]
+//
+// 没有指定文件或者文件为 - 时读取标准输入。引擎选项通过参数设置，参数名由 lute.go 中的 Set* 方法名转换而来，
+// 比如 SetGFMTable 对应 -gfm-table，SetCodeSyntaxHighlightStyleName 对应 -code-syntax-highlight-style-name，
+// 少数不便阅读的名称单独指定，比如 SetSoftBreak2HardBreak 对应 -soft-break-to-hard-break。参数默认值为引擎选项的默认值。
+// 格式化风格通过 -format-style 指定的 JSON 文件设置，字段参考 render.FormatStyle。处理限制通过 -max-input-bytes 等参数设置，
+// 超过限制的文件会输出错误并以状态码 1 退出。
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+// command 描述了一个子命令。
+type command struct {
+	name  string                                                                            // 子命令名
+	usage string                                                                            // 说明
+	run   func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) // 处理一个输入，返回输出
+}
+
+// options 描述了子命令专用的参数。
+type options struct {
+	check                 bool     // format 时只检查是否已经格式化
+	linkPrefixes          []string // textbundle 时需要转换的链接前缀
+	reserveEmptyParagraph bool     // md2blockdom 时保留空段落
+}
+
+var commands = []*command{
+	{"markdown", "将 Markdown 渲染为 HTML", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		return engine.MarkdownContext(context.Background(), name, input)
+	}},
+	{"format", "格式化 Markdown", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		return engine.FormatContext(context.Background(), name, input)
+	}},
+	{"html2md", "将 HTML 转换为 Markdown", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		markdown, err := engine.HTML2Markdown(string(input))
+		return []byte(markdown), err
+	}},
+	{"json", "将 Markdown 渲染为 JSON", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		data, err := engine.RenderJSONContext(context.Background(), string(input))
+		return []byte(data), err
+	}},
+	{"textbundle", "将 Markdown 中的链接地址转换为 TextBundle 资源路径", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		textbundle, _ := engine.TextBundle(name, input, opts.linkPrefixes)
+		return textbundle, nil
+	}},
+	{"md2blockdom", "将 Markdown 渲染为块级 DOM", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		vHTML, err := engine.Md2BlockDOMContext(context.Background(), string(input), opts.reserveEmptyParagraph)
+		return []byte(vHTML), err
+	}},
+	{"blockdom2md", "将块级 DOM 转换为 Markdown", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		return []byte(engine.BlockDOM2Md(string(input))), nil
+	}},
+	{"echarts-json", "将 Markdown 渲染为 ECharts 思维导图 JSON", func(engine *lute.Lute, opts *options, name string, input []byte) ([]byte, error) {
+		return []byte(engine.RenderEChartsJSON(string(input))), nil
+	}},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 执行命令行 args，返回进程退出码：0 表示成功，1 表示处理失败或者检查未通过，2 表示用法错误。
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if 1 > len(args) || "-h" == args[0] || "--help" == args[0] || "help" == args[0] {
+		usage(stderr)
+		if 1 > len(args) {
+			return 2
+		}
+		return 0
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == args[0] {
+			cmd = c
+			break
+		}
+	}
+	if nil == cmd {
+		fmt.Fprintf(stderr, "lute: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	engine := lute.New()
+	opts := &options{}
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	setters := optionFlags(flags, engine)
+	flags.BoolVar(&opts.check, "check", false, "format 时只检查文件是否已经格式化，未格式化的文件名会被输出并以状态码 1 退出")
+	flags.Func("link-prefixes", "textbundle 时需要转换的链接前缀，多个前缀使用逗号分隔", func(value string) error {
+		opts.linkPrefixes = strings.Split(value, ",")
+		return nil
+	})
+	flags.Func("format-style", "格式化风格 JSON 文件，字段参考 render.FormatStyle", func(value string) error {
+		data, err := os.ReadFile(value)
+		if nil != err {
+			return err
+		}
+		style := &render.FormatStyle{}
+		if err = json.Unmarshal(data, style); nil != err {
+			return err
+		}
+		engine.SetFormatStyle(style)
+		return nil
+	})
+	flags.BoolVar(&opts.reserveEmptyParagraph, "reserve-empty-paragraph", false, "md2blockdom 时保留空段落")
+	flags.IntVar(&engine.ParseOptions.MaxInputBytes, "max-input-bytes", 0, "输入的最大字节数，0 表示不限制")
+	flags.IntVar(&engine.ParseOptions.MaxBlockDepth, "max-block-depth", 0, "块级节点的最大嵌套层级，0 表示不限制")
+	flags.IntVar(&engine.ParseOptions.MaxInlineNesting, "max-inline-nesting", 0, "行级节点的最大嵌套层级，0 表示不限制")
+	flags.IntVar(&engine.RenderOptions.MaxOutputBytes, "max-output-bytes", 0, "输出的最大字节数，0 表示不限制")
+	if err := flags.Parse(args[1:]); nil != err {
+		if flag.ErrHelp == err {
+			return 0
+		}
+		return 2
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if setter := setters[f.Name]; nil != setter {
+			setter(f.Value.(flag.Getter).Get())
+		}
+	})
+	if opts.check && "format" != cmd.name {
+		fmt.Fprintln(stderr, "lute: -check is only supported by format command")
+		return 2
+	}
+
+	files := flags.Args()
+	if 1 > len(files) {
+		files = []string{"-"}
+	}
+	ret := 0
+	for _, file := range files {
+		input, err := readInput(file, stdin)
+		if nil != err {
+			fmt.Fprintf(stderr, "lute: %s\n", err)
+			ret = 1
+			continue
+		}
+
+		output, err := cmd.run(engine, opts, file, input)
+		if nil != err {
+			fmt.Fprintf(stderr, "lute: %s: %s\n", file, err)
+			ret = 1
+			continue
+		}
+		if opts.check {
+			if !bytes.Equal(input, output) {
+				fmt.Fprintln(stdout, file)
+				ret = 1
+			}
+			continue
+		}
+		if _, err = stdout.Write(output); nil != err {
+			fmt.Fprintf(stderr, "lute: %s\n", err)
+			return 1
+		}
+	}
+	return ret
+}
+
+// readInput 读取文件 file 的内容，file 为 - 时读取标准输入。
+func readInput(file string, stdin io.Reader) ([]byte, error) {
+	if "-" == file {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// optionFlags 为 engine 上参数类型为 bool 或者 string 的 Set* 方法注册参数，返回参数名到设置函数的映射。
+func optionFlags(flags *flag.FlagSet, engine *lute.Lute) (ret map[string]func(value any)) {
+	ret = map[string]func(value any){}
+	value := reflect.ValueOf(engine)
+	typ := value.Type()
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if !strings.HasPrefix(method.Name, "Set") || 2 != method.Type.NumIn() {
+			continue
+		}
+
+		option := strings.TrimPrefix(method.Name, "Set")
+		name := flagName(option)
+		setter := value.Method(i)
+		kind := method.Type.In(1).Kind()
+		def := optionDefault(engine, option)
+		switch kind {
+		case reflect.Bool:
+			flags.Bool(name, def.IsValid() && kind == def.Kind() && def.Bool(), "调用 "+method.Name)
+		case reflect.String:
+			var s string
+			if def.IsValid() && kind == def.Kind() {
+				s = def.String()
+			}
+			flags.String(name, s, "调用 "+method.Name)
+		default:
+			continue
+		}
+		ret[name] = func(v any) {
+			setter.Call([]reflect.Value{reflect.ValueOf(v)})
+		}
+	}
+	return
+}
+
+// optionFields 是和字段名不一致的选项对应的解析选项或者渲染选项字段名。
+var optionFields = map[string]string{
+	"KramdownIAL": "KramdownBlockIAL",
+}
+
+// optionDefault 返回选项 option 在 engine 上的当前值，优先使用解析选项中的同名字段，没有对应字段时返回零值 reflect.Value。
+func optionDefault(engine *lute.Lute, option string) (ret reflect.Value) {
+	if field, ok := optionFields[option]; ok {
+		option = field
+	}
+	if ret = reflect.ValueOf(engine.ParseOptions).Elem().FieldByName(option); ret.IsValid() {
+		return
+	}
+	return reflect.ValueOf(engine.RenderOptions).Elem().FieldByName(option)
+}
+
+// flagNames 是自动转换后不便阅读的参数名。
+var flagNames = map[string]string{
+	"SoftBreak2HardBreak":     "soft-break-to-hard-break",
+	"HTMLTag2TextMark":        "html-tag-to-text-mark",
+	"KramdownIALIDRenderName": "kramdown-ial-id-render-name",
+	"GFMStrikethrough1":       "gfm-strikethrough-single-tilde",
+	"ToC":                     "toc",
+}
+
+// flagName 将驼峰式的方法名转换为使用短横线分隔的小写参数名，比如 GFMTable 转换为 gfm-table。flagNames 中的名称直接使用指定的参数名。
+func flagName(name string) string {
+	if ret, ok := flagNames[name]; ok {
+		return ret
+	}
+
+	runes := []rune(name)
+	buf := strings.Builder{}
+	for i, r := range runes {
+		if 0 < i && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			buf.WriteByte('-')
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// usage 输出用法说明。
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: lute <command> [flags] [files...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'lute <command> -h' to list flags. Files default to stdin.")
+}
@@ -0,0 +1,116 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var runTests = []struct {
+	args   []string
+	stdin  string
+	code   int
+	stdout string
+}{
+	{[]string{"markdown", "-code-syntax-highlight=false", "-soft-break-to-hard-break=false"}, "# foo\n\nbar\nbaz\n", 0, "<h1>foo</h1>\n<p>bar\nbaz</p>\n"},
+	{[]string{"format"}, "#  foo\n", 0, "# foo\n"},
+	{[]string{"format", "-check"}, "# foo\n", 0, ""},
+	{[]string{"format", "--check"}, "#  foo\n", 1, "-\n"},
+	{[]string{"markdown", "-check"}, "foo\n", 2, ""},
+	{[]string{"format", "-check", "-max-input-bytes", "3"}, "#  foo\n", 1, ""},
+	{[]string{"md2blockdom", "-max-input-bytes", "3"}, "foo\n", 1, ""},
+	{[]string{"json", "-max-block-depth", "2"}, "> > > foo\n", 1, ""},
+	{[]string{"markdown", "-max-output-bytes", "3"}, "foo\n", 1, ""},
+	{[]string{"html2md"}, "<p><strong>foo</strong></p>", 0, "**foo**\n"},
+	{[]string{"unknown"}, "", 2, ""},
+	{nil, "", 2, ""},
+}
+
+func TestRun(t *testing.T) {
+	for i, test := range runTests {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		code := run(test.args, strings.NewReader(test.stdin), stdout, stderr)
+		if test.code != code || test.stdout != stdout.String() {
+			t.Fatalf("test case [%d] %q failed\nexpected\n\t%d %q\ngot\n\t%d %q\nstderr\n\t%s", i, test.args, test.code, test.stdout, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	formatted, unformatted := filepath.Join(dir, "formatted.md"), filepath.Join(dir, "unformatted.md")
+	os.WriteFile(formatted, []byte("* foo\n"), 0644)
+	os.WriteFile(unformatted, []byte("*   foo\n"), 0644)
+
+	stdout := &bytes.Buffer{}
+	if code := run([]string{"format", "-check", formatted, unformatted}, nil, stdout, &bytes.Buffer{}); 1 != code || unformatted+"\n" != stdout.String() {
+		t.Fatalf("expected unformatted file [%s] reported with exit code 1, got [%d] %q", unformatted, code, stdout.String())
+	}
+
+	// 处理出错的文件输出错误，而不是作为未格式化的文件输出
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run([]string{"format", "-check", "-max-input-bytes", "6", formatted, unformatted}, nil, stdout, stderr); 1 != code || "" != stdout.String() || !strings.Contains(stderr.String(), unformatted+": ") {
+		t.Fatalf("expected error of file [%s] reported with exit code 1, got [%d] %q %q", unformatted, code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunFormatStyle(t *testing.T) {
+	style := filepath.Join(t.TempDir(), "style.json")
+	os.WriteFile(style, []byte(`{"emphasis": "_", "heading": "setext"}`), 0644)
+
+	stdout := &bytes.Buffer{}
+	if code := run([]string{"format", "-format-style", style}, strings.NewReader("# *foo*\n"), stdout, &bytes.Buffer{}); 0 != code || "_foo_\n===\n" != stdout.String() {
+		t.Fatalf("expected styled output with exit code 0, got [%d] %q", code, stdout.String())
+	}
+	if code := run([]string{"format", "-format-style", style + ".missing"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); 2 != code {
+		t.Fatalf("expected exit code 2 for missing style file, got [%d]", code)
+	}
+}
+
+func TestFlagName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"GFMTable":                "gfm-table",
+		"VditorWYSIWYG":           "vditor-wysiwyg",
+		"CodeSyntaxHighlight":     "code-syntax-highlight",
+		"KramdownIALIDRenderName": "kramdown-ial-id-render-name",
+		"SoftBreak2HardBreak":     "soft-break-to-hard-break",
+		"ToC":                     "toc",
+		"Sup":                     "sup",
+	} {
+		if got := flagName(name); expected != got {
+			t.Fatalf("flag name of [%s] expected [%s], got [%s]", name, expected, got)
+		}
+	}
+}
+
+func TestOptionFlagDefaults(t *testing.T) {
+	flags := flag.NewFlagSet("", flag.ContinueOnError)
+	optionFlags(flags, lute.New())
+	for name, expected := range map[string]string{
+		"gfm-table":                        "true",
+		"soft-break-to-hard-break":         "true",
+		"code-syntax-highlight-style-name": "github",
+		"kramdown-ial-id-render-name":      "id",
+		"kramdown-ial":                     "false",
+		"sanitize":                         "false",
+	} {
+		if f := flags.Lookup(name); nil == f || expected != f.DefValue {
+			t.Fatalf("default value of flag [%s] expected [%s], got %v", name, expected, f)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/render"
+)
+
+// recoverNodeError 将转换过程中发生的 panic 转换为 *ast.NodeError 写入 err，renderer 指向正在使用的渲染器，用于定位出错的节点。
+//
+// 需要直接通过 defer 调用，渲染器创建后再赋值给 renderer 指向的变量。解析过程中的 panic 已经由 parse.ParseContext 转换为错误。
+func recoverNodeError(err *error, renderer **render.BaseRenderer) {
+	if r := recover(); nil != r {
+		var node *ast.Node
+		if nil != *renderer {
+			node = (*renderer).CurrentNode()
+		}
+		*err = ast.NewPanicError(node, r)
+	}
+}
@@ -0,0 +1,165 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// Flavor 描述了 Markdown 方言预设，预设会同时设置解析选项和渲染选项。
+type Flavor int
+
+const (
+	FlavorDefault    Flavor = iota // Lute 默认选项，即 New() 创建的引擎使用的选项
+	FlavorCommonMark               // 严格的 CommonMark，关闭所有扩展语法，输出与规范一致
+	FlavorGFM                      // GitHub Flavored Markdown，在 CommonMark 的基础上打开表格、任务列表项、删除线、自动链接、脚注和 HTML 标签过滤
+	FlavorGitLab                   // GitLab Flavored Markdown，在 GFM 的基础上打开 Emoji、行级公式、公式块、目录和 YAML Front Matter
+	FlavorObsidian                 // Obsidian，在 GFM 的基础上打开维基链接、提示块、==标记==、行级公式、公式块、YAML Front Matter 以及软换行转硬换行
+	FlavorSiYuan                   // 思源笔记，打开 Protyle 所见即所得、块引用、kramdown 内联属性列表和超级块等扩展
+)
+
+// String 返回方言预设的名称。
+func (flavor Flavor) String() string {
+	switch flavor {
+	case FlavorCommonMark:
+		return "commonmark"
+	case FlavorGFM:
+		return "gfm"
+	case FlavorGitLab:
+		return "gitlab"
+	case FlavorObsidian:
+		return "obsidian"
+	case FlavorSiYuan:
+		return "siyuan"
+	default:
+		return "default"
+	}
+}
+
+// NewWithFlavor 创建一个使用 flavor 方言预设的 Lute 引擎，opts 在预设之后应用，可用于在预设的基础上调整选项。
+func NewWithFlavor(flavor Flavor, opts ...ParseOption) (ret *Lute) {
+	ret = New()
+	ret.SetFlavor(flavor)
+	for _, opt := range opts {
+		opt(ret)
+	}
+	return
+}
+
+// SetFlavor 使用 flavor 方言预设设置解析选项和渲染选项中的语法开关，之前通过 Set* 设置的开关都会先恢复为默认值再应用预设。
+//
+// 注册的扩展语法、Emoji 和术语字典、过滤策略、维基链接解析器、各项限制以及链接前缀等非开关选项保持不变。
+func (lute *Lute) SetFlavor(flavor Flavor) {
+	lute.resetFlags()
+	switch flavor {
+	case FlavorCommonMark:
+		lute.setCommonMarkFlavor()
+	case FlavorGFM:
+		lute.setGFMFlavor()
+	case FlavorGitLab:
+		lute.setGFMFlavor()
+		lute.SetEmoji(true)
+		lute.SetInlineMath(true)
+		lute.SetMathBlock(true)
+		lute.SetToC(true)
+		lute.SetYamlFrontMatter(true)
+	case FlavorObsidian:
+		lute.setGFMFlavor()
+		lute.SetGFMTagFilter(false)
+		lute.SetWikiLink(true)
+		lute.SetCallout(true)
+		lute.SetMark(true)
+		lute.SetInlineMath(true)
+		lute.SetMathBlock(true)
+		lute.SetYamlFrontMatter(true)
+		lute.SetSoftBreak2HardBreak(true)
+	case FlavorSiYuan:
+		lute.setSiYuanFlavor()
+	}
+}
+
+// resetFlags 将解析选项和渲染选项中的开关恢复为默认值，保留其他选项。选项对象本身不会被替换。
+func (lute *Lute) resetFlags() {
+	parseOptions, renderOptions := *lute.ParseOptions, *lute.RenderOptions
+	*lute.ParseOptions, *lute.RenderOptions = *parse.NewOptions(), *render.NewOptions()
+
+	lute.ParseOptions.AliasEmoji, lute.ParseOptions.EmojiAlias, lute.ParseOptions.EmojiSite = parseOptions.AliasEmoji, parseOptions.EmojiAlias, parseOptions.EmojiSite
+	lute.ParseOptions.HTML2MarkdownAttrs = parseOptions.HTML2MarkdownAttrs
+	lute.ParseOptions.BlockSyntaxes, lute.ParseOptions.InlineSyntaxes = parseOptions.BlockSyntaxes, parseOptions.InlineSyntaxes
+	lute.ParseOptions.MaxInputBytes, lute.ParseOptions.MaxBlockDepth, lute.ParseOptions.MaxInlineNesting = parseOptions.MaxInputBytes, parseOptions.MaxBlockDepth, parseOptions.MaxInlineNesting
+
+	lute.RenderOptions.SanitizePolicy, lute.RenderOptions.WikiLinkResolver = renderOptions.SanitizePolicy, renderOptions.WikiLinkResolver
+	lute.RenderOptions.Terms = renderOptions.Terms
+	lute.RenderOptions.CodeSyntaxHighlightStyleName, lute.RenderOptions.ImageLazyLoading = renderOptions.CodeSyntaxHighlightStyleName, renderOptions.ImageLazyLoading
+	lute.RenderOptions.KramdownIALIDRenderName = renderOptions.KramdownIALIDRenderName
+	lute.RenderOptions.LinkBase, lute.RenderOptions.LinkPrefix = renderOptions.LinkBase, renderOptions.LinkPrefix
+	lute.RenderOptions.NodeIndexStart, lute.RenderOptions.UnorderedListMarker = renderOptions.NodeIndexStart, renderOptions.UnorderedListMarker
+	lute.RenderOptions.MaxOutputBytes, lute.RenderOptions.FormatStyle = renderOptions.MaxOutputBytes, renderOptions.FormatStyle
+}
+
+func (lute *Lute) setCommonMarkFlavor() {
+	lute.SetGFMTable(false)
+	lute.SetGFMTaskListItem(false)
+	lute.SetGFMStrikethrough(false)
+	lute.SetGFMStrikethrough1(false)
+	lute.SetGFMAutoLink(false)
+	lute.SetFootnotes(false)
+	lute.SetEmoji(false)
+	lute.SetInlineMath(false)
+	lute.SetMathBlock(false)
+	lute.SetYamlFrontMatter(false)
+	lute.SetHeadingID(false)
+	lute.SetToC(false)
+	lute.SetSoftBreak2HardBreak(false)
+	lute.SetCodeSyntaxHighlight(false)
+	lute.SetAutoSpace(false)
+	lute.SetFixTermTypo(false)
+}
+
+func (lute *Lute) setGFMFlavor() {
+	lute.setCommonMarkFlavor()
+	lute.SetGFMTable(true)
+	lute.SetGFMTaskListItem(true)
+	lute.SetGFMTaskListItemClass("")
+	lute.SetGFMStrikethrough(true)
+	lute.SetGFMStrikethrough1(true)
+	lute.SetGFMAutoLink(true)
+	lute.SetGFMTagFilter(true)
+	lute.SetFootnotes(true)
+}
+
+func (lute *Lute) setSiYuanFlavor() {
+	lute.SetProtyleWYSIWYG(true)
+	lute.SetBlockRef(true)
+	lute.SetFileAnnotationRef(true)
+	lute.SetKramdownIAL(true)
+	lute.SetTag(true)
+	lute.SetSuperBlock(true)
+	lute.SetImgPathAllowSpace(true)
+	lute.SetGitConflict(true)
+	lute.SetMark(true)
+	lute.SetSup(true)
+	lute.SetSub(true)
+	lute.SetTextMark(true)
+	lute.SetHTMLTag2TextMark(true)
+	lute.SetCallout(true)
+	lute.SetInlineMathAllowDigitAfterOpenMarker(true)
+	lute.SetToC(false)
+	lute.SetIndentCodeBlock(false)
+	lute.SetParagraphBeginningSpace(true)
+	lute.SetHeadingID(false)
+	lute.SetSetext(false)
+	lute.SetYamlFrontMatter(false)
+	lute.SetLinkRef(false)
+	lute.SetCodeSyntaxHighlight(false)
+	lute.SetSanitize(true)
+}
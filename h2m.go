@@ -29,8 +29,11 @@ import (
 	"github.com/88250/lute/util"
 )
 
-// HTML2Markdown 将 HTML 转换为 Markdown。
+// HTML2Markdown 将 HTML 转换为 Markdown，转换过程中发生的 panic 会被转换为 *ast.NodeError 返回。
 func (lute *Lute) HTML2Markdown(htmlStr string) (markdown string, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
 	//fmt.Println(htmlStr)
 	// 将字符串解析为 DOM 树
 	tree := lute.HTML2Tree(htmlStr)
@@ -38,10 +41,14 @@ func (lute *Lute) HTML2Markdown(htmlStr string) (markdown string, err error) {
 	// 将 AST 进行 Markdown 格式化渲染
 	var formatted []byte
 	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
 	for nodeType, rendererFunc := range lute.HTML2MdRendererFuncs {
 		renderer.ExtRendererFuncs[nodeType] = rendererFunc
 	}
 	formatted = renderer.Render()
+	if err = renderer.CheckOutput(formatted); nil != err {
+		return
+	}
 	markdown = util.BytesToStr(formatted)
 	return
 }
@@ -449,6 +456,10 @@ func (lute *Lute) genASTByDOM(n *html.Node, tree *parse.Tree) {
 		return
 	}
 
+	if lute.genASTByWikiLinkDOM(n, tree) {
+		return
+	}
+
 	if 0 == n.DataAtom && html.ElementNode == n.Type { // 自定义标签
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			lute.genASTByDOM(c, tree)
@@ -2282,3 +2293,31 @@ func appendSpace(n *html.Node, tree *parse.Tree, lute *Lute) {
 		}
 	}
 }
+
+// genASTByWikiLinkDOM 识别 HTML 渲染器生成的维基链接 <a class="wikilink"> 和 <img class="wikilink-embed">，使用 data-wikilink 属性还原维基链接节点。
+func (lute *Lute) genASTByWikiLinkDOM(n *html.Node, tree *parse.Tree) bool {
+	if !lute.ParseOptions.WikiLink || (atom.A != n.DataAtom && atom.Img != n.DataAtom) {
+		return false
+	}
+
+	embed := domClassContains(n, "wikilink-embed")
+	if !embed && !domClassContains(n, "wikilink") {
+		return false
+	}
+
+	content := util.DomAttrValue(n, "data-wikilink")
+	if "" == content || strings.ContainsAny(content, "[]\n") {
+		return false
+	}
+
+	if nil != tree.Context.Tip && (ast.NodeTableCell == tree.Context.Tip.Type || tree.Context.Tip.ParentIs(ast.NodeTableCell)) {
+		// 表格中的别名分隔符需要转义
+		content = strings.Replace(content, "|", "\\|", 1)
+	}
+	wikiLink := parse.NewWikiLink(content, embed)
+	if nil == wikiLink {
+		return false
+	}
+	tree.Context.Tip.AppendChild(wikiLink)
+	return true
+}
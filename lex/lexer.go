@@ -10,72 +10,100 @@
 
 package lex
 
-import "unicode/utf8"
+import "bytes"
+
+// BOM 是 UTF-8 字节顺序标记。
+var BOM = []byte{0xEF, 0xBB, 0xBF}
 
 // Lexer 描述了词法分析器结构。
 type Lexer struct {
-	input  []byte // 输入的文本字节数组
+	input  []byte // 输入的文本字节数组，切行时不会被修改
 	length int    // 输入的文本字节数组的长度
-	offset int    // 当前读取字节位置
-	width  int    // 最新一个字符的长度（字节数）
+	offset int    // 下一行在输入中的字节偏移
+
+	lineOffset int    // 最新返回的行在原始输入中的字节偏移
+	lineEnding string // 输入中第一个换行符
+	bom        bool   // 输入是否以 UTF-8 BOM 开头
 }
 
-// NewLexer 创建一个词法分析器。
+// NewLexer 创建一个词法分析器。输入开头的 UTF-8 BOM 会被跳过，行偏移仍然相对于包含 BOM 的原始输入。
 func NewLexer(input []byte) (ret *Lexer) {
 	ret = &Lexer{input: input, length: len(input)}
-	if 0 < ret.length && ItemNewline != ret.input[ret.length-1] {
-		// 以 \n 结尾预处理
-		ret.input = append(ret.input, ItemNewline)
-		ret.length++
+	if bytes.HasPrefix(input, BOM) {
+		ret.offset = len(BOM)
+		ret.bom = true
 	}
 	return
 }
 
-// NextLine 返回下一行。
+// NextLine 返回下一行，返回的行总是以 \n 结尾：\r\n 和单独的 \r 都会被规范化为 \n，\u0000 会被替换为 �，最后一行没有换行符时补上 \n。
+// 不需要规范化的行直接引用输入（容量截止到行尾，追加内容不会覆盖后续输入），需要规范化的行返回一份副本，因此切行的总开销和输入长度成线性关系。
 func (l *Lexer) NextLine() (ret []byte) {
 	if l.offset >= l.length {
 		return
 	}
 
-	var b, nb byte
-	i := l.offset
-	for ; i < l.length; i += l.width {
-		b = l.input[i]
+	start := l.offset
+	end, next := l.length, l.length // 行内容（不包含换行符）的结束位置和下一行的起始位置
+	normalize := false
+	for i := start; i < l.length; i++ {
+		b := l.input[i]
 		if ItemNewline == b {
-			i++
+			end, next = i, i+1
 			break
-		} else if ItemCarriageReturn == b {
-			if i < l.length-1 {
-				nb = l.input[i+1]
-				if ItemNewline == nb { // \r\n
-					l.input = append(l.input[:i], l.input[i+1:]...) // 移除 \r，依靠下一个的 \n 切行
-					l.length--                                      // 重新计算总长
-				} else { // \rX
-					l.input[i] = ItemNewline // 将 \r 替换为 \n
-				}
-			} else { // \rEOF
-				l.input[i] = ItemNewline // 将 \r 替换为 \n
+		}
+		if ItemCarriageReturn == b {
+			end, next = i, i+1
+			if next < l.length && ItemNewline == l.input[next] { // \r\n
+				next++
 			}
-			i++
+			normalize = true
 			break
-		} else if '\u0000' == b {
-			// 将 \u0000 替换为 \uFFFD
-			l.input = append(l.input, 0, 0)
-			copy(l.input[i+2:], l.input[i:])
-			// \uFFFD 的 UTF-8 编码为 \xEF\xBF\xBD 共三个字节
-			l.input[i], l.input[i+1], l.input[i+2] = '\xEF', '\xBF', '\xBD'
-			l.length += 2 // 重新计算总长
-			l.width = 3
-			continue
 		}
+		if '\u0000' == b {
+			normalize = true
+		}
+	}
+	if "" == l.lineEnding && end < next {
+		l.lineEnding = string(l.input[end:next])
+	}
+
+	if normalize || end == next {
+		ret = NormalizeLine(l.input[start:end])
+	} else {
+		ret = l.input[start:next:next]
+	}
+	l.lineOffset = start
+	l.offset = next
+	return
+}
 
-		if utf8.RuneSelf <= b { // 说明占用多个字节
-			_, l.width = utf8.DecodeRune(l.input[i:])
-		} else {
-			l.width = 1
+// LineOffset 返回最新一次 NextLine 返回的行在原始输入中的字节偏移。
+func (l *Lexer) LineOffset() int {
+	return l.lineOffset
+}
+
+// LineEnding 返回已经读取的行中第一个换行符，"\n"、"\r\n" 或者 "\r"，还没有读取到换行符时返回空字符串。
+func (l *Lexer) LineEnding() string {
+	return l.lineEnding
+}
+
+// BOM 判断输入是否以 UTF-8 BOM 开头。
+func (l *Lexer) BOM() bool {
+	return l.bom
+}
+
+// NormalizeLine 复制不包含换行符的行内容 content，将其中的 \u0000 替换为 � 并在末尾追加 \n。
+func NormalizeLine(content []byte) (ret []byte) {
+	ret = make([]byte, 0, len(content)+1)
+	for _, b := range content {
+		if '\u0000' == b {
+			// � 的 UTF-8 编码为 \xEF\xBF\xBD 共三个字节
+			ret = append(ret, '\xEF', '\xBF', '\xBD')
+			continue
 		}
+		ret = append(ret, b)
 	}
-	ret = l.input[l.offset:i]
-	l.offset = i
+	ret = append(ret, ItemNewline)
 	return
 }
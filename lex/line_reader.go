@@ -0,0 +1,113 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lex
+
+import (
+	"bytes"
+	"io"
+)
+
+// LineReader 描述了从 io.Reader 中逐行读取文本的行读取器，行的预处理规则和 Lexer 一致。
+type LineReader struct {
+	reader  io.Reader // 输入源
+	buf     []byte    // 已读取但还未切行的内容
+	scanned int       // buf 中已经查找过换行符的长度
+	chunk   []byte    // 读取缓冲
+	eof     bool      // 输入源是否已经读取完毕
+	started bool      // 是否已经检查过输入开头的 UTF-8 BOM
+	err     error     // 读取输入源时发生的错误
+
+	srcOffset  int // 下一行在原始输入中的字节偏移
+	lineOffset int // 最新返回的行在原始输入中的字节偏移
+}
+
+// NewLineReader 创建一个行读取器。
+func NewLineReader(reader io.Reader) *LineReader {
+	return &LineReader{reader: reader, chunk: make([]byte, 64*1024)}
+}
+
+// NextLine 返回下一行，返回的行总是以 \n 结尾且不会被后续读取覆盖。读取完毕或者发生错误时返回 nil，错误可通过 Err 获取。
+func (l *LineReader) NextLine() (ret []byte) {
+	for {
+		if !l.started && (len(BOM) <= len(l.buf) || l.eof) {
+			l.started = true
+			if bytes.HasPrefix(l.buf, BOM) {
+				l.buf = l.buf[len(BOM):]
+				l.srcOffset = len(BOM)
+			}
+		}
+		if !l.started {
+			if l.fill(); nil != l.err {
+				return
+			}
+			continue
+		}
+
+		i := bytes.IndexAny(l.buf[l.scanned:], "\r\n")
+		if -1 < i {
+			i += l.scanned
+		}
+		if -1 < i && (ItemCarriageReturn != l.buf[i] || i < len(l.buf)-1 || l.eof) {
+			consumed := i + 1
+			if ItemCarriageReturn == l.buf[i] && i < len(l.buf)-1 && ItemNewline == l.buf[i+1] { // \r\n
+				consumed++
+			}
+			ret = l.line(l.buf[:i], consumed)
+			return
+		}
+		if l.eof {
+			if 0 < len(l.buf) { // 以 \n 结尾预处理
+				ret = l.line(l.buf, len(l.buf))
+			}
+			return
+		}
+		if -1 < i { // 末尾的 \r 需要读取下一个字节后才能判断是否是 \r\n
+			l.scanned = i
+		} else {
+			l.scanned = len(l.buf)
+		}
+		if l.fill(); nil != l.err {
+			return
+		}
+	}
+}
+
+// LineOffset 返回最新一次 NextLine 返回的行在原始输入中的字节偏移。
+func (l *LineReader) LineOffset() int {
+	return l.lineOffset
+}
+
+// Err 返回读取输入源时发生的错误。
+func (l *LineReader) Err() error {
+	return l.err
+}
+
+// line 复制并规范化行内容 content，然后从缓冲中移除 consumed 个字节。
+func (l *LineReader) line(content []byte, consumed int) (ret []byte) {
+	ret = NormalizeLine(content)
+	l.buf = l.buf[consumed:]
+	l.scanned = 0
+	l.lineOffset = l.srcOffset
+	l.srcOffset += consumed
+	return
+}
+
+// fill 从输入源读取更多内容到缓冲中。
+func (l *LineReader) fill() {
+	n, err := l.reader.Read(l.chunk)
+	l.buf = append(l.buf, l.chunk[:n]...)
+	if nil != err {
+		l.eof = true
+		if io.EOF != err {
+			l.err = err
+		}
+	}
+}
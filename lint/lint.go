@@ -0,0 +1,215 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package lint 实现了 Markdown 风格检查，规则参考 markdownlint https://github.com/DavidAnson/markdownlint
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+// Rule 描述了一条检查规则。
+type Rule struct {
+	ID          string                 // 规则 ID，比如 MD001
+	Alias       string                 // 规则别名，比如 heading-increment
+	Description string                 // 规则描述
+	Config      map[string]interface{} // 规则参数的默认值
+	Check       func(ctx *Context)     // 检查语法树，发现的问题通过 Context.Report 报告
+}
+
+// rules 维护了注册的规则，键为规则 ID。
+var rules = map[string]*Rule{}
+
+// Register 注册检查规则 rule，已经注册过相同 ID 的规则时会替换原来的规则。
+func Register(rule *Rule) {
+	rules[rule.ID] = rule
+}
+
+// Rules 返回所有注册的规则，按照规则 ID 排序。
+func Rules() (ret []*Rule) {
+	for _, rule := range rules {
+		ret = append(ret, rule)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return
+}
+
+// Config 描述了检查配置，格式和 markdownlint 的配置文件一致：
+//
+//   - 键为规则 ID 或者别名，值为 false 时关闭规则，为 true 时使用默认参数打开规则，为对象时使用其中的参数打开规则
+//   - 键 default 设置没有配置的规则是否打开，默认打开
+type Config map[string]interface{}
+
+// ParseConfig 解析 JSON 格式的检查配置。
+func ParseConfig(data []byte) (ret Config, err error) {
+	err = json.Unmarshal(data, &ret)
+	return
+}
+
+// ruleConfig 返回规则 rule 是否打开以及合并默认值后的参数。
+func (c Config) ruleConfig(rule *Rule) (enabled bool, params map[string]interface{}) {
+	enabled = true
+	if v, ok := c["default"].(bool); ok {
+		enabled = v
+	}
+
+	params = map[string]interface{}{}
+	for k, v := range rule.Config {
+		params[k] = v
+	}
+
+	for key, value := range c {
+		if !strings.EqualFold(key, rule.ID) && key != rule.Alias {
+			continue
+		}
+		switch v := value.(type) {
+		case bool:
+			enabled = v
+		case map[string]interface{}:
+			enabled = true
+			for k, param := range v {
+				params[k] = param
+			}
+		}
+	}
+	return
+}
+
+// Problem 描述了检查发现的一个问题。
+type Problem struct {
+	RuleID    string    `json:"ruleId"`    // 规则 ID
+	RuleAlias string    `json:"ruleAlias"` // 规则别名
+	Message   string    `json:"message"`   // 问题描述
+	Pos       *ast.Pos  `json:"pos"`       // 问题在 Markdown 原始文本中的位置，语法树需要在打开 SourcePos 解析选项时构建
+	Fixable   bool      `json:"fixable"`   // 是否可以自动修复
+	Node      *ast.Node `json:"-"`         // 问题所在的节点，基于文本行检查的规则报告的问题没有节点
+
+	fix func() // 自动修复，修改语法树上的节点
+}
+
+// Context 描述了规则检查时使用的上下文。
+type Context struct {
+	Tree   *parse.Tree // 待检查的语法树
+	Source []byte      // Markdown 原始文本
+	Lines  [][]byte    // 原始文本按行拆分后的结果，不包含换行符，第 i 行的下标为 i-1
+
+	offsets  []int // 每行在原始文本中的起始字节偏移
+	rule     *Rule
+	params   map[string]interface{}
+	problems []*Problem
+}
+
+// Report 报告节点 node 上的问题，fix 不为 nil 时表示问题可以通过修改语法树自动修复。
+func (ctx *Context) Report(node *ast.Node, message string, fix func()) {
+	var pos *ast.Pos
+	if nil != node && nil != node.Pos {
+		p := *node.Pos
+		pos = &p
+	}
+	ctx.problems = append(ctx.problems, &Problem{RuleID: ctx.rule.ID, RuleAlias: ctx.rule.Alias, Message: message, Pos: pos, Fixable: nil != fix, Node: node, fix: fix})
+}
+
+// ReportPos 报告位置 pos 处的问题，用于基于文本行检查的规则。
+func (ctx *Context) ReportPos(pos *ast.Pos, message string) {
+	ctx.problems = append(ctx.problems, &Problem{RuleID: ctx.rule.ID, RuleAlias: ctx.rule.Alias, Message: message, Pos: pos})
+}
+
+// LinePos 返回第 line 行（从 1 开始）中字节下标 [start, end) 的位置。
+func (ctx *Context) LinePos(line, start, end int) *ast.Pos {
+	offset := ctx.offsets[line-1]
+	return &ast.Pos{StartOffset: offset + start, EndOffset: offset + end, StartLine: line, StartColumn: start + 1, EndLine: line, EndColumn: end}
+}
+
+// OffsetPos 返回原始文本中字节偏移 [start, end) 的位置，范围不能跨行。
+func (ctx *Context) OffsetPos(start, end int) *ast.Pos {
+	line := sort.Search(len(ctx.offsets), func(i int) bool { return ctx.offsets[i] > start })
+	return ctx.LinePos(line, start-ctx.offsets[line-1], end-ctx.offsets[line-1])
+}
+
+// Int 返回整数类型的规则参数 key。
+func (ctx *Context) Int(key string) int {
+	switch v := ctx.params[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// String 返回字符串类型的规则参数 key。
+func (ctx *Context) String(key string) string {
+	v, _ := ctx.params[key].(string)
+	return v
+}
+
+// Bool 返回布尔类型的规则参数 key。
+func (ctx *Context) Bool(key string) bool {
+	v, _ := ctx.params[key].(bool)
+	return v
+}
+
+// Lint 使用 config 中打开的规则检查语法树 tree，source 为构建语法树的 Markdown 原始文本。返回的问题按照位置排序。
+//
+// 问题的位置依赖节点位置，语法树需要在打开 SourcePos 解析选项时构建。
+func Lint(tree *parse.Tree, source []byte, config Config) (ret []*Problem) {
+	lines := bytes.Split(source, []byte("\n"))
+	if 0 < len(lines) && 0 == len(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	offsets := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		offsets[i] = offset
+		offset += len(line) + 1
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	for _, rule := range Rules() {
+		enabled, params := config.ruleConfig(rule)
+		if !enabled {
+			continue
+		}
+
+		ctx := &Context{Tree: tree, Source: source, Lines: lines, offsets: offsets, rule: rule, params: params}
+		rule.Check(ctx)
+		ret = append(ret, ctx.problems...)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		a, b := ret[i].Pos, ret[j].Pos
+		if nil == a || nil == b {
+			return nil != a
+		}
+		if a.StartOffset != b.StartOffset {
+			return a.StartOffset < b.StartOffset
+		}
+		return ret[i].RuleID < ret[j].RuleID
+	})
+	return
+}
+
+// Fix 按顺序应用 problems 中可以自动修复的问题，返回修复的问题数。修复会修改问题所在的语法树，修复后通过 FormatRenderer
+// 渲染语法树即可得到修复后的 Markdown。
+func Fix(problems []*Problem) (ret int) {
+	for _, problem := range problems {
+		if nil != problem.fix {
+			problem.fix()
+			ret++
+		}
+	}
+	return
+}
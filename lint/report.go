@@ -0,0 +1,159 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// JSON 将文件 name 的检查结果 problems 序列化为 JSON。
+func JSON(name string, problems []*Problem) ([]byte, error) {
+	if nil == problems {
+		problems = []*Problem{}
+	}
+	return json.Marshal(map[string]interface{}{"name": name, "problems": problems})
+}
+
+// SARIF 将文件 name 的检查结果 problems 序列化为 SARIF 2.1.0 格式 https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+// 以便代码扫描平台展示检查结果。source 为检查的 Markdown 原始文本，用于将按字节计算的列号转换为 SARIF 默认使用的 UTF-16 码元列号。
+func SARIF(name string, source []byte, problems []*Problem) ([]byte, error) {
+	lineStarts := []int{0}
+	for i, b := range source {
+		if '\n' == b {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	driver := &sarifDriver{Name: "lute", InformationURI: "https://github.com/88250/lute", Rules: []*sarifRule{}}
+	ruleIndex := map[string]int{}
+	results := []*sarifResult{}
+	for _, problem := range problems {
+		idx, ok := ruleIndex[problem.RuleID]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIndex[problem.RuleID] = idx
+			rule := &sarifRule{ID: problem.RuleID, Name: problem.RuleAlias}
+			if r := rules[problem.RuleID]; nil != r {
+				rule.ShortDescription = &sarifMessage{Text: r.Description}
+			}
+			driver.Rules = append(driver.Rules, rule)
+		}
+
+		location := &sarifPhysicalLocation{ArtifactLocation: &sarifArtifactLocation{URI: name}}
+		if pos := problem.Pos; nil != pos {
+			location.Region = &sarifRegion{
+				StartLine: pos.StartLine, StartColumn: utf16Column(source, lineStarts, pos.StartLine, pos.StartColumn),
+				EndLine: pos.EndLine, EndColumn: utf16Column(source, lineStarts, pos.EndLine, pos.EndColumn+1),
+			}
+		}
+		results = append(results, &sarifResult{
+			RuleID:    problem.RuleID,
+			RuleIndex: idx,
+			Level:     "warning",
+			Message:   &sarifMessage{Text: problem.Message},
+			Locations: []*sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	log := &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []*sarifRun{{Tool: &sarifTool{Driver: driver}, Results: results}},
+	}
+	return json.Marshal(log)
+}
+
+// utf16Column 将第 line 行按字节计算的列号 column 转换为按 UTF-16 码元计算的列号，行号和列号都从 1 开始。
+func utf16Column(source []byte, lineStarts []int, line, column int) (ret int) {
+	if 1 > line || len(lineStarts) < line {
+		return column
+	}
+	start := lineStarts[line-1]
+	end := start + column - 1
+	if lineEnd := bytes.IndexByte(source[start:], '\n'); 0 <= lineEnd && start+lineEnd < end {
+		end = start + lineEnd
+	} else if len(source) < end {
+		end = len(source)
+	}
+
+	ret = 1
+	for text := source[start:end]; 0 < len(text); {
+		r, size := utf8.DecodeRune(text)
+		if 0x10000 <= r { // 增补平面字符使用代理对表示
+			ret += 2
+		} else {
+			ret++
+		}
+		text = text[size:]
+	}
+	return
+}
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    *sarifTool     `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name,omitempty"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	RuleIndex int              `json:"ruleIndex"`
+	Level     string           `json:"level"`
+	Message   *sarifMessage    `json:"message"`
+	Locations []*sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
@@ -0,0 +1,304 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lint
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+func init() {
+	Register(&Rule{ID: "MD001", Alias: "heading-increment", Description: "Heading levels should only increment by one level at a time", Check: checkHeadingIncrement})
+	Register(&Rule{ID: "MD004", Alias: "ul-style", Description: "Unordered list style", Config: map[string]interface{}{"style": "consistent"}, Check: checkULStyle})
+	Register(&Rule{ID: "MD013", Alias: "line-length", Description: "Line length", Config: map[string]interface{}{"line_length": 80, "code_blocks": true, "tables": true, "headings": true}, Check: checkLineLength})
+	Register(&Rule{ID: "MD025", Alias: "single-h1", Description: "Multiple top-level headings in the same document", Config: map[string]interface{}{"level": 1}, Check: checkSingleH1})
+	Register(&Rule{ID: "MD026", Alias: "no-trailing-punctuation", Description: "Trailing punctuation in heading", Config: map[string]interface{}{"punctuation": ".,;:!。，；：！"}, Check: checkNoTrailingPunctuation})
+	Register(&Rule{ID: "MD034", Alias: "no-bare-urls", Description: "Bare URL used", Check: checkNoBareURLs})
+	Register(&Rule{ID: "MD040", Alias: "fenced-code-language", Description: "Fenced code blocks should have a language specified", Check: checkFencedCodeLanguage})
+	Register(&Rule{ID: "MD041", Alias: "first-line-h1", Description: "First line in a file should be a top-level heading", Config: map[string]interface{}{"level": 1}, Check: checkFirstLineH1})
+	Register(&Rule{ID: "MD042", Alias: "no-empty-links", Description: "No empty links", Check: checkNoEmptyLinks})
+	Register(&Rule{ID: "MD045", Alias: "no-alt-text", Description: "Images should have alternate text (alt text)", Check: checkNoAltText})
+}
+
+// walk 按文档顺序遍历语法树中类型为 nodeType 的节点。
+func walk(ctx *Context, nodeType ast.NodeType, visit func(n *ast.Node)) {
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && nodeType == n.Type {
+			visit(n)
+		}
+		return ast.WalkContinue
+	})
+}
+
+func checkHeadingIncrement(ctx *Context) {
+	prev := 0
+	walk(ctx, ast.NodeHeading, func(n *ast.Node) {
+		level := n.HeadingLevel
+		if 0 < prev && level > prev+1 {
+			expected := prev + 1
+			ctx.Report(n, "Expected: h"+strconv.Itoa(expected)+"; Actual: h"+strconv.Itoa(level), func() {
+				n.HeadingLevel = expected
+			})
+			// 后续标题按照修复后的级别检查，否则修复后可能出现新的跳级
+			level = expected
+		}
+		prev = level
+	})
+}
+
+// isBulletList 判断列表节点 n 是否是无序列表（包括使用 * - + 标记的任务列表）。
+func isBulletList(n *ast.Node) bool {
+	return nil != n && 0 != n.ListData.BulletChar && (0 == n.ListData.Typ || 3 == n.ListData.Typ)
+}
+
+func checkULStyle(ctx *Context) {
+	var expected byte
+	switch ctx.String("style") {
+	case "asterisk":
+		expected = lex.ItemAsterisk
+	case "dash":
+		expected = lex.ItemHyphen
+	case "plus":
+		expected = lex.ItemPlus
+	}
+
+	walk(ctx, ast.NodeList, func(n *ast.Node) {
+		if !isBulletList(n) {
+			return
+		}
+		if 0 == expected {
+			expected = n.ListData.BulletChar
+			return
+		}
+		if expected == n.ListData.BulletChar {
+			return
+		}
+
+		// 相邻的无序列表修改为相同的标记后会合并为一个列表，这时不能自动修复
+		var fix func()
+		if !isBulletList(siblingList(n.Previous, false)) && !isBulletList(siblingList(n.Next, true)) {
+			bullet := expected
+			fix = func() {
+				n.ListData.BulletChar, n.ListData.Marker = bullet, []byte{bullet}
+				for li := n.FirstChild; nil != li; li = li.Next {
+					if ast.NodeListItem == li.Type {
+						li.ListData.BulletChar, li.ListData.Marker = bullet, []byte{bullet}
+					}
+				}
+			}
+		}
+		ctx.Report(n, "Expected: "+string(expected)+"; Actual: "+string(n.ListData.BulletChar), fix)
+	})
+}
+
+// siblingList 返回从 n 开始跳过块级 IAL 后的第一个节点，next 为 true 时向后查找，否则向前查找。该节点不是列表时返回 nil。
+func siblingList(n *ast.Node, next bool) *ast.Node {
+	for nil != n && ast.NodeKramdownBlockIAL == n.Type {
+		if next {
+			n = n.Next
+		} else {
+			n = n.Previous
+		}
+	}
+	if nil == n || ast.NodeList != n.Type {
+		return nil
+	}
+	return n
+}
+
+func checkLineLength(ctx *Context) {
+	limit := ctx.Int("line_length")
+	if 1 > limit {
+		return
+	}
+
+	// 标记不需要检查的行
+	skip := map[int]bool{}
+	skipNode := func(n *ast.Node) {
+		if nil == n.Pos {
+			return
+		}
+		for line := n.Pos.StartLine; line <= n.Pos.EndLine; line++ {
+			skip[line] = true
+		}
+	}
+	if !ctx.Bool("code_blocks") {
+		walk(ctx, ast.NodeCodeBlock, skipNode)
+	}
+	if !ctx.Bool("tables") {
+		walk(ctx, ast.NodeTable, skipNode)
+	}
+	if !ctx.Bool("headings") {
+		walk(ctx, ast.NodeHeading, skipNode)
+	}
+
+	for i, line := range ctx.Lines {
+		if skip[i+1] || utf8.RuneCount(line) <= limit {
+			continue
+		}
+
+		// 超出部分没有空白时（比如很长的链接）无法换行，不视为问题
+		start := 0
+		for count := 0; count < limit; count++ {
+			_, size := utf8.DecodeRune(line[start:])
+			start += size
+		}
+		if 0 > bytes.IndexAny(line[start:], " \t") {
+			continue
+		}
+		ctx.ReportPos(ctx.LinePos(i+1, start, len(line)), "Expected: "+strconv.Itoa(limit)+"; Actual: "+strconv.Itoa(utf8.RuneCount(line)))
+	}
+}
+
+func checkSingleH1(ctx *Context) {
+	level := ctx.Int("level")
+	found := false
+	walk(ctx, ast.NodeHeading, func(n *ast.Node) {
+		if level != n.HeadingLevel || ast.NodeDocument != n.Parent.Type {
+			return
+		}
+		if found {
+			ctx.Report(n, "Multiple top-level headings: "+n.Text(), nil)
+		}
+		found = true
+	})
+}
+
+func checkNoTrailingPunctuation(ctx *Context) {
+	punctuation := ctx.String("punctuation")
+	if "" == punctuation {
+		return
+	}
+
+	walk(ctx, ast.NodeHeading, func(n *ast.Node) {
+		text := strings.TrimSpace(n.Text())
+		last, _ := utf8.DecodeLastRuneInString(text)
+		if "" == text || !strings.ContainsRune(punctuation, last) {
+			return
+		}
+
+		// 只有标题以文本节点结尾时才能自动修复
+		var textNode *ast.Node
+		for c := n.LastChild; nil != c; c = c.Previous {
+			if ast.NodeText == c.Type {
+				textNode = c
+				break
+			}
+			if ast.NodeHeadingID != c.Type && ast.NodeKramdownSpanIAL != c.Type {
+				break
+			}
+		}
+		var fix func()
+		if nil != textNode {
+			fix = func() {
+				tokens := bytes.TrimRight(textNode.Tokens, " \t")
+				textNode.Tokens = []byte(strings.TrimRight(string(tokens), punctuation))
+			}
+		}
+		ctx.Report(n, "Punctuation: '"+string(last)+"'", fix)
+	})
+}
+
+func checkNoBareURLs(ctx *Context) {
+	// GFM 自动链接解析时生成的节点没有位置，需要在所在块的原始文本中按顺序查找链接文本来确定位置
+	cursors := map[*ast.Node]int{}
+	walk(ctx, ast.NodeLink, func(n *ast.Node) {
+		if 2 != n.LinkType {
+			return
+		}
+		// 只包含自动链接的表格单元格没有位置，表格中的链接统一在整个表格的原始文本中查找
+		block := n.Parent
+		for ; nil != block && (nil == block.Pos || block.ParentIs(ast.NodeTable)); block = block.Parent {
+		}
+		if nil == block {
+			return
+		}
+		if nil != n.Pos {
+			// 使用尖括号包裹的自动链接 <https://example.com> 不是裸链接
+			cursors[block] = n.Pos.EndOffset
+			return
+		}
+
+		text := []byte(n.Text())
+		from := cursors[block]
+		if from < block.Pos.StartOffset {
+			from = block.Pos.StartOffset
+		}
+		to := block.Pos.EndOffset
+		if to > len(ctx.Source) || from >= to {
+			return
+		}
+		idx := bytes.Index(ctx.Source[from:to], text)
+		if 0 > idx {
+			return
+		}
+		start := from + idx
+		cursors[block] = start + len(text)
+		if 0 < start && lex.ItemLess == ctx.Source[start-1] {
+			return
+		}
+
+		problem := len(ctx.problems)
+		ctx.Report(n, "Bare URL used: "+string(text), func() {
+			n.LinkType = 0
+		})
+		ctx.problems[problem].Pos = ctx.OffsetPos(start, start+len(text))
+	})
+}
+
+func checkFencedCodeLanguage(ctx *Context) {
+	walk(ctx, ast.NodeCodeBlock, func(n *ast.Node) {
+		if n.IsFencedCodeBlock && 0 == len(bytes.TrimSpace(n.CodeBlockInfo)) {
+			ctx.Report(n, "Fenced code block without language", nil)
+		}
+	})
+}
+
+func checkFirstLineH1(ctx *Context) {
+	level := ctx.Int("level")
+	for n := ctx.Tree.Root.FirstChild; nil != n; n = n.Next {
+		switch n.Type {
+		case ast.NodeYamlFrontMatter, ast.NodeKramdownBlockIAL:
+			continue
+		case ast.NodeHeading:
+			if level == n.HeadingLevel {
+				return
+			}
+		}
+		ctx.Report(n, "First line should be a h"+strconv.Itoa(level)+" heading", nil)
+		return
+	}
+}
+
+func checkNoEmptyLinks(ctx *Context) {
+	walk(ctx, ast.NodeLink, func(n *ast.Node) {
+		if 1 == n.LinkType {
+			return
+		}
+		dest := n.ChildByType(ast.NodeLinkDest)
+		if nil == dest || 0 == len(dest.Tokens) || "#" == string(dest.Tokens) {
+			ctx.Report(n, "No empty links: "+n.Text(), nil)
+		}
+	})
+}
+
+func checkNoAltText(ctx *Context) {
+	walk(ctx, ast.NodeImage, func(n *ast.Node) {
+		if "" == strings.TrimSpace(n.Text()) {
+			ctx.Report(n, "Image without alt text", nil)
+		}
+	})
+}
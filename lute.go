@@ -13,12 +13,15 @@ package lute
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"strings"
 	"sync"
 
 	"github.com/88250/lute/ast"
 	"github.com/88250/lute/lex"
+	"github.com/88250/lute/lint"
 	"github.com/88250/lute/parse"
 	"github.com/88250/lute/render"
 	"github.com/88250/lute/util"
@@ -42,6 +45,8 @@ type Lute struct {
 	Md2VditorIRDOMRendererFuncs   map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2VditorIRDOM 渲染器函数
 	Md2BlockDOMRendererFuncs      map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2BlockDOM 渲染器函数
 	Md2VditorSVDOMRendererFuncs   map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2VditorSVDOM 渲染器函数
+
+	transforms []*transform // 注册的语法树变换，按照执行顺序排列
 }
 
 // New 创建一个新的 Lute 引擎。
@@ -79,13 +84,37 @@ func New(opts ...ParseOption) (ret *Lute) {
 }
 
 // Markdown 将 markdown 文本字节数组处理为相应的 html 字节数组。name 参数仅用于标识文本，比如可传入 id 或者标题，也可以传入 ""。
+// 处理出错时（比如超出设置的限制或者语法树变换返回错误）会以该错误 panic，需要获取错误时请使用 MarkdownContext。
 func (lute *Lute) Markdown(name string, markdown []byte) (html []byte) {
-	tree := parse.Parse(name, markdown, lute.ParseOptions)
+	html, err := lute.MarkdownContext(context.Background(), name, markdown)
+	if nil != err {
+		panic(err)
+	}
+	return
+}
+
+// MarkdownContext 将 markdown 文本字节数组处理为相应的 html 字节数组，在 ctx 取消或者超出解析选项和渲染选项中设置的限制
+// （MaxInputBytes、MaxBlockDepth、MaxInlineNesting 和 MaxOutputBytes）时中止处理并返回错误。
+//
+// 处理过程中发生的 panic 会被转换为 *ast.NodeError 返回，错误中记录了出错时正在处理的节点类型和位置。
+func (lute *Lute) MarkdownContext(ctx context.Context, name string, markdown []byte) (html []byte, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err := lute.parseMarkdownContext(ctx, name, markdown)
+	if nil != err {
+		return
+	}
 	renderer := render.NewHtmlRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
 	for nodeType, rendererFunc := range lute.Md2HTMLRendererFuncs {
 		renderer.ExtRendererFuncs[nodeType] = rendererFunc
 	}
+	renderer.SetContext(ctx)
 	html = renderer.Render()
+	if err = renderer.CheckOutput(html); nil != err {
+		html = nil
+	}
 	return
 }
 
@@ -96,14 +125,164 @@ func (lute *Lute) MarkdownStr(name, markdown string) (html string) {
 	return
 }
 
-// Format 将 markdown 文本字节数组进行格式化。
+// MarkdownTo 从 r 中流式读取 markdown 文本，每解析完成一个顶层块就将其渲染为 html 写入 w，适用于渲染超大文档或者需要边解析边输出的场景。
+//
+// 流式处理无法向后查看，链接引用定义和脚注定义只对其后出现的引用生效，脚注内容在最后统一输出，具体限制请参考 parse.Stream。
+// 注册的语法树变换会在每次输出前执行，变换拿到的是仅包含本次输出的顶层块的语法树。
+func (lute *Lute) MarkdownTo(w io.Writer, r io.Reader) error {
+	return lute.MarkdownToContext(context.Background(), w, r)
+}
+
+// MarkdownToContext 和 MarkdownTo 一样流式处理 markdown，在 ctx 取消或者超出解析选项和渲染选项中设置的限制时中止处理并返回错误。
+// MaxInputBytes 限制累计读取的字节数，MaxOutputBytes 限制累计写入 w 的字节数，中止前已经写入 w 的内容不会撤回。
+//
+// 处理过程中发生的 panic 会被转换为 *ast.NodeError 返回，错误中记录了出错时正在处理的节点类型和位置。
+func (lute *Lute) MarkdownToContext(ctx context.Context, w io.Writer, r io.Reader) error {
+	var written int
+	return parse.StreamContext(ctx, "", r, lute.ParseOptions, func(tree *parse.Tree) error {
+		html, err := lute.renderChunk(ctx, tree)
+		if nil != err {
+			return err
+		}
+		if written += len(html); 0 < lute.RenderOptions.MaxOutputBytes && written > lute.RenderOptions.MaxOutputBytes {
+			return render.ErrOutputTooLarge
+		}
+		_, err = w.Write(html)
+		return err
+	})
+}
+
+// renderChunk 对流式解析得到的语法树 tree 执行注册的语法树变换后渲染为 html。
+func (lute *Lute) renderChunk(ctx context.Context, tree *parse.Tree) (html []byte, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	if err = lute.ApplyTransforms(tree); nil != err {
+		return
+	}
+	renderer := render.NewHtmlRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
+	for nodeType, rendererFunc := range lute.Md2HTMLRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
+	renderer.SetContext(ctx)
+	html = renderer.Render()
+	if err = renderer.CheckOutput(html); nil != err {
+		html = nil
+	}
+	return
+}
+
+// Format 将 markdown 文本字节数组进行格式化，处理出错时会以该错误 panic，需要获取错误时请使用 FormatContext。
 func (lute *Lute) Format(name string, markdown []byte) (formatted []byte) {
-	tree := parse.Parse(name, markdown, lute.ParseOptions)
+	formatted, err := lute.FormatContext(context.Background(), name, markdown)
+	if nil != err {
+		panic(err)
+	}
+	return
+}
+
+// FormatContext 将 markdown 文本字节数组进行格式化，在 ctx 取消或者超出解析选项和渲染选项中设置的限制时中止处理并返回错误。
+// 处理过程中发生的 panic 会被转换为 *ast.NodeError 返回。
+func (lute *Lute) FormatContext(ctx context.Context, name string, markdown []byte) (formatted []byte, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	if lute.RenderOptions.LosslessFormat {
+		return lute.losslessFormat(ctx, name, markdown, &base)
+	}
+
+	tree, err := lute.parseMarkdownContext(ctx, name, markdown)
+	if nil != err {
+		return
+	}
 	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
+	renderer.SetContext(ctx)
 	formatted = renderer.Render()
+	if err = renderer.CheckOutput(formatted); nil != err {
+		formatted = nil
+	}
 	return
 }
 
+// losslessFormat 使用无损模式格式化 markdown：解析后记录语法树快照再执行注册的语法树变换，只有被变换修改过的块会重新格式化。
+func (lute *Lute) losslessFormat(ctx context.Context, name string, markdown []byte, base **render.BaseRenderer) (formatted []byte, err error) {
+	options := lute.ParseOptions.Clone()
+	options.SourcePos = true
+	tree, err := parse.ParseContext(ctx, name, markdown, options)
+	if nil != err {
+		return
+	}
+	if err = tree.Snapshot(); nil != err {
+		return
+	}
+	if err = lute.ApplyTransforms(tree); nil != err {
+		return
+	}
+
+	renderer := render.NewLosslessFormatRenderer(tree, lute.RenderOptions, options)
+	*base = renderer.BaseRenderer
+	renderer.SetContext(ctx)
+	formatted = renderer.Render()
+	if err = renderer.CheckOutput(formatted); nil != err {
+		formatted = nil
+	}
+	return
+}
+
+// Check 解析 markdown 并返回诊断信息，比如未闭合的代码块、数学公式块、超级块和 Git 冲突标记，找不到定义的引用链接和脚注，
+// 重复的标题 ID 等，可以在文档构建时用来发现有问题的 Markdown。诊断信息总是带有位置，检查不会修改引擎的解析选项。
+func (lute *Lute) Check(name string, markdown []byte) (diagnostics []*parse.Diagnostic, err error) {
+	options := lute.ParseOptions.Clone()
+	options.SourcePos = true
+	options.Diagnostics = true
+	tree, err := parse.ParseContext(context.Background(), name, markdown, options)
+	if nil != err {
+		return
+	}
+	diagnostics = tree.Diagnostics
+	return
+}
+
+// Lint 使用 config 中打开的规则检查 markdown 的风格，config 为 nil 时使用所有注册规则的默认配置。检查不会修改引擎的解析选项。
+func (lute *Lute) Lint(name string, markdown []byte, config lint.Config) (problems []*lint.Problem, err error) {
+	tree, err := lute.parseLintTree(name, markdown)
+	if nil != err {
+		return
+	}
+	problems = lint.Lint(tree, markdown, config)
+	return
+}
+
+// LintFix 检查 markdown 并自动修复可以修复的问题，修复后的语法树通过 FormatRenderer 渲染得到 fixed。problems 是修复后仍然存在的问题。
+func (lute *Lute) LintFix(name string, markdown []byte, config lint.Config) (fixed []byte, problems []*lint.Problem, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err := lute.parseLintTree(name, markdown)
+	if nil != err {
+		return
+	}
+	lint.Fix(lint.Lint(tree, markdown, config))
+	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
+	fixed = renderer.Render()
+	if err = renderer.CheckOutput(fixed); nil != err {
+		fixed = nil
+		return
+	}
+	problems, err = lute.Lint(name, fixed, config)
+	return
+}
+
+// parseLintTree 使用记录节点位置的解析选项解析 markdown，用于风格检查。
+func (lute *Lute) parseLintTree(name string, markdown []byte) (*parse.Tree, error) {
+	options := lute.ParseOptions.Clone()
+	options.SourcePos = true
+	return parse.ParseContext(context.Background(), name, markdown, options)
+}
+
 // FormatStr 接受 string 类型的 markdown 后直接调用 Format 进行处理。
 func (lute *Lute) FormatStr(name, markdown string) (formatted string) {
 	formattedBytes := lute.Format(name, []byte(markdown))
@@ -113,7 +292,7 @@ func (lute *Lute) FormatStr(name, markdown string) (formatted string) {
 
 // TextBundle 将 markdown 文本字节数组进行 TextBundle 处理。
 func (lute *Lute) TextBundle(name string, markdown []byte, linkPrefixes []string) (textbundle []byte, originalLinks []string) {
-	tree := parse.Parse(name, markdown, lute.ParseOptions)
+	tree := lute.parseMarkdown(name, markdown)
 	renderer := render.NewTextBundleRenderer(tree, linkPrefixes, lute.RenderOptions, lute.ParseOptions)
 	textbundle, originalLinks = renderer.Render()
 	return
@@ -135,15 +314,43 @@ func (lute *Lute) HTML2Text(dom string) string {
 	return tree.Root.Text()
 }
 
-// RenderJSON 用于渲染 JSON 格式数据。
+// RenderJSON 用于渲染 JSON 格式数据，处理出错时会以该错误 panic，需要获取错误时请使用 RenderJSONContext。
 func (lute *Lute) RenderJSON(markdown string) (json string) {
-	tree := parse.Parse("", []byte(markdown), lute.ParseOptions)
-	renderer := render.NewJSONRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	json, err := lute.RenderJSONContext(context.Background(), markdown)
+	if nil != err {
+		panic(err)
+	}
+	return
+}
+
+// RenderJSONContext 用于渲染 JSON 格式数据，在 ctx 取消、超出解析和渲染限制、节点序列化失败或者发生 panic 时返回错误。
+func (lute *Lute) RenderJSONContext(ctx context.Context, markdown string) (json string, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err := lute.parseMarkdownContext(ctx, "", []byte(markdown))
+	if nil != err {
+		return
+	}
+	renderer := render.NewJSONRenderer(tree, lute.RenderOptions, lute.ParseOptions).(*render.JSONRenderer)
+	base = renderer.BaseRenderer
+	renderer.SetContext(ctx)
 	output := renderer.Render()
+	if err = renderer.CheckOutput(output); nil != err {
+		return
+	}
 	json = util.BytesToStr(output)
 	return
 }
 
+// JSON2Tree 将 RenderJSON 输出的 JSON 格式语法树转换为语法树，格式见 parse.JSONSpec。
+func (lute *Lute) JSON2Tree(name, json string) (tree *parse.Tree, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	return parse.JSON2Tree(name, []byte(json), lute.ParseOptions)
+}
+
 // Space 用于在 text 中的中西文之间插入空格。
 func (lute *Lute) Space(text string) string {
 	return render.Space0(text)
@@ -194,9 +401,6 @@ func (lute *Lute) GetLinkDest(str string) string {
 
 // GetEmojis 返回 Emoji 别名和对应 Unicode 字符的字典列表。
 func (lute *Lute) GetEmojis() (ret map[string]string) {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
 	ret = make(map[string]string, len(lute.ParseOptions.AliasEmoji))
 	placeholder := util.BytesToStr(parse.EmojiSitePlaceholder)
 	for k, v := range lute.ParseOptions.AliasEmoji {
@@ -208,38 +412,60 @@ func (lute *Lute) GetEmojis() (ret map[string]string) {
 	return
 }
 
-// PutEmojis 将指定的 emojiMap 合并覆盖已有的 Emoji 字典。
+// PutEmojis 将指定的 emojiMap 合并覆盖已有的 Emoji 字典，仅对当前引擎生效。
 func (lute *Lute) PutEmojis(emojiMap map[string]string) {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
-	for k, v := range emojiMap {
-		lute.ParseOptions.AliasEmoji[k] = v
-		lute.ParseOptions.EmojiAlias[v] = k
-	}
+	lute.ParseOptions.PutEmojis(emojiMap)
 }
 
 // RemoveEmoji 用于删除 str 中的 Emoji Unicode。
 func (lute *Lute) RemoveEmoji(str string) string {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
 	for u := range lute.ParseOptions.EmojiAlias {
 		str = strings.ReplaceAll(str, u, "")
 	}
 	return strings.TrimSpace(str)
 }
 
-// GetTerms 返回术语字典。
-func (lute *Lute) GetTerms() map[string]string {
-	return lute.RenderOptions.Terms
+// GetTerms 返回术语字典的副本。
+func (lute *Lute) GetTerms() (ret map[string]string) {
+	ret = make(map[string]string, len(lute.RenderOptions.Terms))
+	for k, v := range lute.RenderOptions.Terms {
+		ret[k] = v
+	}
+	return
 }
 
-// PutTerms 将制定的 termMap 合并覆盖已有的术语字典。
+// PutTerms 将指定的 termMap 合并覆盖已有的术语字典，仅对当前引擎生效。
 func (lute *Lute) PutTerms(termMap map[string]string) {
-	for k, v := range termMap {
-		lute.RenderOptions.Terms[k] = v
+	lute.RenderOptions.PutTerms(termMap)
+}
+
+// Clone 复制一个新的 Lute 引擎，新引擎的解析选项、渲染选项（包括 SanitizePolicy 和 FormatStyle）、自定义渲染器函数和语法树变换
+// 都是独立的副本，修改后不会影响原引擎。渲染选项中的 WikiLinkResolver 等函数按引用共享。
+func (lute *Lute) Clone() (ret *Lute) {
+	ret = &Lute{
+		ParseOptions:                  lute.ParseOptions.Clone(),
+		RenderOptions:                 lute.RenderOptions.Clone(),
+		HTML2MdRendererFuncs:          cloneRendererFuncs(lute.HTML2MdRendererFuncs),
+		HTML2VditorDOMRendererFuncs:   cloneRendererFuncs(lute.HTML2VditorDOMRendererFuncs),
+		HTML2VditorIRDOMRendererFuncs: cloneRendererFuncs(lute.HTML2VditorIRDOMRendererFuncs),
+		HTML2BlockDOMRendererFuncs:    cloneRendererFuncs(lute.HTML2BlockDOMRendererFuncs),
+		HTML2VditorSVDOMRendererFuncs: cloneRendererFuncs(lute.HTML2VditorSVDOMRendererFuncs),
+		Md2HTMLRendererFuncs:          cloneRendererFuncs(lute.Md2HTMLRendererFuncs),
+		Md2VditorDOMRendererFuncs:     cloneRendererFuncs(lute.Md2VditorDOMRendererFuncs),
+		Md2VditorIRDOMRendererFuncs:   cloneRendererFuncs(lute.Md2VditorIRDOMRendererFuncs),
+		Md2BlockDOMRendererFuncs:      cloneRendererFuncs(lute.Md2BlockDOMRendererFuncs),
+		Md2VditorSVDOMRendererFuncs:   cloneRendererFuncs(lute.Md2VditorSVDOMRendererFuncs),
+		transforms:                    append([]*transform(nil), lute.transforms...),
+	}
+	return
+}
+
+func cloneRendererFuncs(funcs map[ast.NodeType]render.ExtRendererFunc) (ret map[ast.NodeType]render.ExtRendererFunc) {
+	ret = make(map[ast.NodeType]render.ExtRendererFunc, len(funcs))
+	for nodeType, fn := range funcs {
+		ret[nodeType] = fn
 	}
+	return
 }
 
 var (
@@ -322,7 +548,7 @@ func (lute *Lute) ProtylePreview(tree *parse.Tree, options *render.Options, pars
 // ProtylePreviewStr 接受 string 类型的 markdown，内部 parse 后调用 ProtylePreview 渲染为预览 HTML。
 // 等价于后端导出预览的 markdown → parse → ProtylePreview 链路，供前端 lute.min.js 直接调用。
 func (lute *Lute) ProtylePreviewStr(name, markdown string) string {
-	tree := parse.Parse(name, []byte(markdown), lute.ParseOptions)
+	tree := lute.parseMarkdown(name, []byte(markdown))
 	return lute.ProtylePreview(tree, lute.RenderOptions, lute.ParseOptions)
 }
 
@@ -354,6 +580,11 @@ func (lute *Lute) SetGFMTaskListItemClass(class string) {
 	lute.RenderOptions.GFMTaskListItemClass = class
 }
 
+// SetGFMTagFilter 设置是否打开“GFM 过滤 HTML 标签”支持。
+func (lute *Lute) SetGFMTagFilter(b bool) {
+	lute.RenderOptions.GFMTagFilter = b
+}
+
 func (lute *Lute) SetDataTask(b bool) {
 	lute.RenderOptions.DataTask = b
 }
@@ -429,7 +660,7 @@ func (lute *Lute) SetEmoji(b bool) {
 }
 
 func (lute *Lute) SetEmojis(emojis map[string]string) {
-	lute.ParseOptions.AliasEmoji = emojis
+	lute.ParseOptions.SetEmojis(emojis)
 }
 
 func (lute *Lute) SetEmojiSite(emojiSite string) {
@@ -441,7 +672,7 @@ func (lute *Lute) SetHeadingAnchor(b bool) {
 }
 
 func (lute *Lute) SetTerms(terms map[string]string) {
-	lute.RenderOptions.Terms = terms
+	lute.RenderOptions.SetTerms(terms)
 }
 
 func (lute *Lute) SetVditorWYSIWYG(b bool) {
@@ -468,6 +699,10 @@ func (lute *Lute) SetInlineMath(b bool) {
 	lute.ParseOptions.InlineMath = b
 }
 
+func (lute *Lute) SetMathBlock(b bool) {
+	lute.ParseOptions.MathBlock = b
+}
+
 func (lute *Lute) SetInlineMathAllowDigitAfterOpenMarker(b bool) {
 	lute.ParseOptions.InlineMathAllowDigitAfterOpenMarker = b
 }
@@ -520,6 +755,16 @@ func (lute *Lute) SetSanitize(b bool) {
 	lute.RenderOptions.Sanitize = b
 }
 
+// SetSanitizePolicy 设置基于白名单的 XSS 安全过滤策略，传入 nil 时取消策略。可使用 render.NewPolicy() 创建严格的默认策略后按需调整。
+func (lute *Lute) SetSanitizePolicy(policy *render.Policy) {
+	lute.RenderOptions.SanitizePolicy = policy
+}
+
+// SetWikiLinkResolver 设置维基链接目标解析器，用于将维基链接目标映射为链接地址或者标记为失效链接。
+func (lute *Lute) SetWikiLinkResolver(resolver render.WikiLinkResolver) {
+	lute.RenderOptions.WikiLinkResolver = resolver
+}
+
 func (lute *Lute) SetImageLazyLoading(dataSrc string) {
 	lute.RenderOptions.ImageLazyLoading = dataSrc
 }
@@ -655,10 +900,51 @@ func (lute *Lute) SetPreventEncodeLinkSpace(b bool) {
 	lute.RenderOptions.PreventEncodeLinkSpace = b
 }
 
+// SetDiagnostics 设置是否在解析时收集诊断信息（parse.Tree.Diagnostics）。
+func (lute *Lute) SetDiagnostics(b bool) {
+	lute.ParseOptions.Diagnostics = b
+}
+
+// SetSourcePos 设置是否记录节点的源码位置并在 HTML 块级元素上渲染 data-sourcepos 属性。
+func (lute *Lute) SetSourcePos(b bool) {
+	lute.ParseOptions.SourcePos = b
+	lute.RenderOptions.SourcePos = b
+}
+
+// SetLosslessFormat 设置格式化时是否使用无损模式，打开后没有被语法树变换修改过的块会原样保留原始文本。
+func (lute *Lute) SetLosslessFormat(b bool) {
+	lute.RenderOptions.LosslessFormat = b
+}
+
+// SetFormatStyle 设置格式化输出 Markdown 的风格，style 为 nil 时恢复默认风格。
+func (lute *Lute) SetFormatStyle(style *render.FormatStyle) {
+	lute.RenderOptions.FormatStyle = style
+}
+
+// AddBlockSyntax 注册自定义块级语法扩展，扩展节点的渲染可以通过 *RendererFuncs 自定义。
+func (lute *Lute) AddBlockSyntax(syntax *parse.BlockSyntax) {
+	lute.ParseOptions.BlockSyntaxes = append(lute.ParseOptions.BlockSyntaxes, syntax)
+}
+
+// AddInlineSyntax 注册自定义行级语法扩展，扩展节点的渲染可以通过 *RendererFuncs 自定义。
+func (lute *Lute) AddInlineSyntax(syntax *parse.InlineSyntax) {
+	lute.ParseOptions.InlineSyntaxes = append(lute.ParseOptions.InlineSyntaxes, syntax)
+}
+
 func (lute *Lute) SetCallout(b bool) {
 	lute.ParseOptions.Callout = b
 }
 
+// SetWikiLink 设置是否开启维基链接（[[target#heading|alias]] 和 ![[embed]]）支持。
+func (lute *Lute) SetWikiLink(b bool) {
+	lute.ParseOptions.WikiLink = b
+}
+
+// SetDirective 设置是否开启通用指令（:::name[label]{attrs}、::name[label]{attrs} 和 :name[label]{attrs}）支持。
+func (lute *Lute) SetDirective(b bool) {
+	lute.ParseOptions.Directive = b
+}
+
 func (lute *Lute) SetEnsureListItemParagraph(b bool) {
 	lute.ParseOptions.EnsureListItemParagraph = b
 }
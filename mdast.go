@@ -0,0 +1,59 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"context"
+	gojson "encoding/json"
+
+	"github.com/88250/lute/mdast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// Md2Mdast 将 markdown 解析为 mdast https://github.com/syntax-tree/mdast 格式的 JSON 语法树，节点映射规则见 mdast 包。
+func (lute *Lute) Md2Mdast(name string, markdown []byte) (json []byte, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err := lute.parseMarkdownContext(context.Background(), name, markdown)
+	if nil != err {
+		return
+	}
+	return gojson.Marshal(mdast.FromTree(tree, lute.RenderOptions))
+}
+
+// Mdast2Tree 将 mdast 格式的 JSON 语法树转换为 Lute 语法树。
+func (lute *Lute) Mdast2Tree(name string, json []byte) (tree *parse.Tree, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	root, err := mdast.Parse(json)
+	if nil != err {
+		return
+	}
+	return mdast.ToTree(name, root, lute.ParseOptions)
+}
+
+// Mdast2Md 将 mdast 格式的 JSON 语法树格式化为 markdown。
+func (lute *Lute) Mdast2Md(name string, json []byte) (markdown []byte, err error) {
+	tree, err := lute.Mdast2Tree(name, json)
+	if nil != err {
+		return
+	}
+
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
+	markdown = renderer.Render()
+	return
+}
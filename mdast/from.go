@@ -0,0 +1,453 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package mdast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// FromTree 将 Lute 语法树 tree 转换为 mdast 语法树。
+//
+// options 用于将没有对应 mdast 节点的 Lute 节点格式化为 Markdown，为 nil 时使用默认渲染选项。
+func FromTree(tree *parse.Tree, options *render.Options) *Node {
+	if nil == options {
+		options = render.NewOptions()
+	}
+	parseOptions := parse.NewOptions()
+	if nil != tree.Context && nil != tree.Context.ParseOption {
+		parseOptions = tree.Context.ParseOption
+	}
+	e := &exporter{tree: tree, options: options, parseOptions: parseOptions}
+	return e.root(tree.Root)
+}
+
+// exporter 用于将 Lute 语法树转换为 mdast 语法树。
+type exporter struct {
+	tree         *parse.Tree
+	options      *render.Options
+	parseOptions *parse.Options
+	formatter    *render.FormatRenderer // 格式化没有对应 mdast 节点的 Lute 节点，首次使用时创建
+}
+
+func (e *exporter) root(root *ast.Node) (ret *Node) {
+	ret = &Node{Type: "root", Position: position(root)}
+	ret.Children = e.children(root)
+	if last := root.LastChild; nil != last && ast.NodeKramdownBlockIAL == last.Type && !ownedIAL(last) {
+		// 文档 IAL 挂在根节点的最后一个子节点上
+		setData(ret, "ial", parse.Tokens2IAL(last.Tokens))
+	}
+	return
+}
+
+// children 转换 n 的子节点，相邻的文本节点会被合并。
+func (e *exporter) children(n *ast.Node) (ret []*Node) {
+	for c := n.FirstChild; nil != c; c = c.Next {
+		for _, node := range e.node(c) {
+			ret = appendNode(ret, node)
+		}
+	}
+	return
+}
+
+// node 将 Lute 节点 n 转换为 mdast 节点，标记符等没有对应 mdast 节点的辅助节点返回空。
+func (e *exporter) node(n *ast.Node) (ret []*Node) {
+	var node *Node
+	switch n.Type {
+	case ast.NodeKramdownBlockIAL:
+		// 块级 IAL 记录在所属节点的 data.ial 中，文档 IAL 记录在根节点的 data.ial 中
+		return
+	case ast.NodeParagraph:
+		node = &Node{Type: "paragraph", Children: e.children(n)}
+	case ast.NodeHeading:
+		node = &Node{Type: "heading", Depth: n.HeadingLevel, Children: e.children(n)}
+		if id := n.ChildByType(ast.NodeHeadingID); nil != id {
+			setData(node, "id", strings.TrimPrefix(id.TokensStr(), "#"))
+		}
+	case ast.NodeHeadingC8hMarker, ast.NodeHeadingID, ast.NodeBlockquoteMarker, ast.NodeTaskListItemMarker:
+		return
+	case ast.NodeThematicBreak:
+		node = &Node{Type: "thematicBreak"}
+	case ast.NodeBlockquote:
+		node = &Node{Type: "blockquote", Children: e.children(n)}
+	case ast.NodeList:
+		node = e.list(n)
+	case ast.NodeListItem:
+		node = e.listItem(n)
+	case ast.NodeHTMLBlock, ast.NodeInlineHTML:
+		node = &Node{Type: "html", Value: n.TokensStr()}
+	case ast.NodeCodeBlock:
+		node = &Node{Type: "code"}
+		if code := n.ChildByType(ast.NodeCodeBlockCode); nil != code {
+			node.Value = strings.TrimSuffix(code.TokensStr(), "\n")
+		}
+		if info := string(n.CodeBlockInfo); n.IsFencedCodeBlock && "" != info {
+			node.Lang = &info
+		}
+	case ast.NodeMathBlock:
+		node = &Node{Type: "math", Value: tokensStr(n.ChildByType(ast.NodeMathBlockContent))}
+	case ast.NodeInlineMath:
+		node = &Node{Type: "inlineMath", Value: tokensStr(n.ChildByType(ast.NodeInlineMathContent))}
+	case ast.NodeCodeSpan:
+		node = &Node{Type: "inlineCode", Value: tokensStr(n.ChildByType(ast.NodeCodeSpanContent))}
+	case ast.NodeYamlFrontMatter:
+		node = &Node{Type: "yaml", Value: tokensStr(n.ChildByType(ast.NodeYamlFrontMatterContent))}
+	case ast.NodeText, ast.NodeLinkText, ast.NodeHTMLEntity:
+		node = &Node{Type: "text", Value: n.TokensStr()}
+	case ast.NodeBackslash:
+		node = &Node{Type: "text", Value: tokensStr(n.ChildByType(ast.NodeBackslashContent))}
+	case ast.NodeSoftBreak:
+		node = &Node{Type: "text", Value: "\n"}
+	case ast.NodeHardBreak:
+		node = &Node{Type: "break"}
+	case ast.NodeEmphasis:
+		node = &Node{Type: "emphasis", Children: e.children(n)}
+	case ast.NodeStrong:
+		node = &Node{Type: "strong", Children: e.children(n)}
+	case ast.NodeStrikethrough:
+		node = &Node{Type: "delete", Children: e.children(n)}
+	case ast.NodeLink, ast.NodeImage:
+		node = e.link(n)
+	case ast.NodeLinkRefDefBlock:
+		for c := n.FirstChild; nil != c; c = c.Next {
+			ret = append(ret, e.node(c)...)
+		}
+		return
+	case ast.NodeLinkRefDef:
+		link := n.ChildByType(ast.NodeLink)
+		if nil == link {
+			return
+		}
+		label := n.TokensStr()
+		node = &Node{Type: "definition", Identifier: identifier(label), Label: label, URL: tokensStr(link.ChildByType(ast.NodeLinkDest))}
+		if title := link.ChildByType(ast.NodeLinkTitle); nil != title {
+			node.Title = stringPtr(title.TokensStr())
+		}
+	case ast.NodeFootnotesDefBlock:
+		for c := n.FirstChild; nil != c; c = c.Next {
+			ret = append(ret, e.node(c)...)
+		}
+		return
+	case ast.NodeFootnotesDef:
+		label := strings.TrimPrefix(n.TokensStr(), "^")
+		node = &Node{Type: "footnoteDefinition", Identifier: identifier(label), Label: label, Children: e.children(n)}
+	case ast.NodeFootnotesRef:
+		label := strings.TrimPrefix(string(n.FootnotesRefLabel), "^")
+		node = &Node{Type: "footnoteReference", Identifier: identifier(label), Label: label}
+	case ast.NodeTable:
+		node = &Node{Type: "table"}
+		for _, align := range n.TableAligns {
+			node.Align = append(node.Align, alignName(align))
+		}
+		for c := n.FirstChild; nil != c; c = c.Next {
+			if ast.NodeTableHead == c.Type {
+				node.Children = append(node.Children, e.children(c)...)
+			} else {
+				node.Children = append(node.Children, e.node(c)...)
+			}
+		}
+	case ast.NodeTableRow:
+		node = &Node{Type: "tableRow", Children: e.children(n)}
+	case ast.NodeTableCell:
+		node = &Node{Type: "tableCell", Children: e.children(n)}
+	case ast.NodeEmoji:
+		if unicode := n.ChildByType(ast.NodeEmojiUnicode); nil != unicode {
+			node = &Node{Type: "text", Value: unicode.TokensStr()}
+		} else {
+			node = e.luteNode(n)
+		}
+	case ast.NodeSuperBlock:
+		node = &Node{Type: "superBlock", Children: e.children(n)}
+		setData(node, "layout", tokensStr(n.ChildByType(ast.NodeSuperBlockLayoutMarker)))
+	case ast.NodeCallout:
+		node = &Node{Type: "callout", Children: e.children(n)}
+		setData(node, "calloutType", n.CalloutType)
+		if "" != n.CalloutTitle {
+			setData(node, "title", n.CalloutTitle)
+		}
+		if "" != n.CalloutIcon {
+			setData(node, "icon", n.CalloutIcon)
+		}
+		if 1 == n.CalloutIconType {
+			setData(node, "iconImage", true)
+		}
+	case ast.NodeBlockRef:
+		node = &Node{Type: "blockRef"}
+		setData(node, "id", tokensStr(n.ChildByType(ast.NodeBlockRefID)))
+		if text := n.ChildByType(ast.NodeBlockRefText); nil != text {
+			setData(node, "subtype", "s")
+			node.Children = []*Node{{Type: "text", Value: text.TokensStr()}}
+		} else if text = n.ChildByType(ast.NodeBlockRefDynamicText); nil != text {
+			setData(node, "subtype", "d")
+			node.Children = []*Node{{Type: "text", Value: text.TokensStr()}}
+		}
+	case ast.NodeTag:
+		node = &Node{Type: "tag", Children: e.children(n)}
+	case ast.NodeMark:
+		node = &Node{Type: "mark", Children: e.children(n)}
+	case ast.NodeSup:
+		node = &Node{Type: "superscript", Children: e.children(n)}
+	case ast.NodeSub:
+		node = &Node{Type: "subscript", Children: e.children(n)}
+	case ast.NodeWikiLink, ast.NodeWikiLinkEmbed:
+		node = &Node{Type: "wikiLink", Value: n.WikiLinkTarget}
+		if "" != n.WikiLinkHeading {
+			setData(node, "heading", n.WikiLinkHeading)
+		}
+		if "" != n.WikiLinkAlias {
+			setData(node, "alias", n.WikiLinkAlias)
+		}
+		if ast.NodeWikiLinkEmbed == n.Type {
+			setData(node, "embed", true)
+		}
+	case ast.NodeContainerDirective, ast.NodeLeafDirective, ast.NodeTextDirective:
+		node = e.directive(n)
+	case ast.NodeEmA6kOpenMarker, ast.NodeEmA6kCloseMarker, ast.NodeEmU8eOpenMarker, ast.NodeEmU8eCloseMarker,
+		ast.NodeStrongA6kOpenMarker, ast.NodeStrongA6kCloseMarker, ast.NodeStrongU8eOpenMarker, ast.NodeStrongU8eCloseMarker,
+		ast.NodeStrikethrough1OpenMarker, ast.NodeStrikethrough1CloseMarker, ast.NodeStrikethrough2OpenMarker, ast.NodeStrikethrough2CloseMarker,
+		ast.NodeSuperBlockOpenMarker, ast.NodeSuperBlockLayoutMarker, ast.NodeSuperBlockCloseMarker,
+		ast.NodeTagOpenMarker, ast.NodeTagCloseMarker, ast.NodeMark1OpenMarker, ast.NodeMark1CloseMarker, ast.NodeMark2OpenMarker, ast.NodeMark2CloseMarker,
+		ast.NodeSupOpenMarker, ast.NodeSupCloseMarker, ast.NodeSubOpenMarker, ast.NodeSubCloseMarker:
+		return
+	default:
+		node = e.luteNode(n)
+	}
+
+	node.Position = position(n)
+	if 0 < len(n.KramdownIAL) && n.IsBlock() {
+		setData(node, "ial", n.KramdownIAL)
+	}
+	return []*Node{node}
+}
+
+func (e *exporter) list(n *ast.Node) (ret *Node) {
+	ret = &Node{Type: "list", Spread: boolPtr(!n.ListData.Tight), Children: e.children(n)}
+	ordered := 1 == n.ListData.Typ || (3 == n.ListData.Typ && 0 != n.ListData.Delimiter)
+	ret.Ordered = &ordered
+	if ordered {
+		start := n.ListData.Start
+		ret.Start = &start
+	}
+	return
+}
+
+func (e *exporter) listItem(n *ast.Node) (ret *Node) {
+	ret = &Node{Type: "listItem", Children: e.children(n)}
+	ret.Spread = boolPtr(!n.ListData.Tight && nil != n.FirstChild && nil != n.FirstChild.Next)
+	if p := n.FirstChild; nil != p && ast.NodeParagraph == p.Type && nil != p.FirstChild && ast.NodeTaskListItemMarker == p.FirstChild.Type {
+		marker := p.FirstChild
+		ret.Checked = boolPtr(marker.TaskListItemChecked)
+		if ' ' != marker.TaskListItemMarker && 'x' != marker.TaskListItemMarker && 'X' != marker.TaskListItemMarker && 0 != marker.TaskListItemMarker {
+			setData(ret, "taskMarker", string(marker.TaskListItemMarker))
+		}
+		if 0 < len(ret.Children) && "paragraph" == ret.Children[0].Type {
+			// 去掉任务列表项标记符和内容之间的空格
+			para := ret.Children[0]
+			if 0 < len(para.Children) && "text" == para.Children[0].Type {
+				para.Children[0].Value = strings.TrimPrefix(para.Children[0].Value, " ")
+				if "" == para.Children[0].Value {
+					para.Children = para.Children[1:]
+				}
+			}
+		}
+	}
+	return
+}
+
+func (e *exporter) link(n *ast.Node) (ret *Node) {
+	var children []*Node
+	inText := false
+	for c := n.FirstChild; nil != c; c = c.Next {
+		switch c.Type {
+		case ast.NodeOpenBracket:
+			inText = true
+			continue
+		case ast.NodeCloseBracket:
+			inText = false
+			continue
+		case ast.NodeLinkText:
+			if 2 == n.LinkType {
+				children = appendNode(children, &Node{Type: "text", Value: c.TokensStr()})
+				continue
+			}
+		}
+		if inText {
+			for _, node := range e.node(c) {
+				children = appendNode(children, node)
+			}
+		}
+	}
+
+	dest := tokensStr(n.ChildByType(ast.NodeLinkDest))
+	var title *string
+	if titleNode := n.ChildByType(ast.NodeLinkTitle); nil != titleNode {
+		title = stringPtr(titleNode.TokensStr())
+	}
+
+	image := ast.NodeImage == n.Type
+	if 3 == n.LinkType {
+		label := string(n.LinkRefLabel)
+		referenceType := "full"
+		if plainText(children) == label {
+			referenceType = "shortcut"
+		}
+		if image {
+			return &Node{Type: "imageReference", Alt: stringPtr(plainText(children)), Identifier: identifier(label), Label: label, ReferenceType: referenceType}
+		}
+		return &Node{Type: "linkReference", Identifier: identifier(label), Label: label, ReferenceType: referenceType, Children: children}
+	}
+	if image {
+		return &Node{Type: "image", URL: dest, Title: title, Alt: stringPtr(plainText(children))}
+	}
+	return &Node{Type: "link", URL: dest, Title: title, Children: children}
+}
+
+func (e *exporter) directive(n *ast.Node) (ret *Node) {
+	ret = &Node{Type: "textDirective", Name: n.DirectiveName}
+	switch n.Type {
+	case ast.NodeContainerDirective:
+		ret.Type = "containerDirective"
+	case ast.NodeLeafDirective:
+		ret.Type = "leafDirective"
+	}
+	for _, attr := range n.DirectiveAttrs {
+		if nil == ret.Attributes {
+			ret.Attributes = map[string]string{}
+		}
+		ret.Attributes[attr[0]] = attr[1]
+	}
+	if "" != n.DirectiveLabel {
+		// 和 remark-directive 一致，容器指令的标签作为第一个段落子节点
+		label := &Node{Type: "paragraph", Children: []*Node{{Type: "text", Value: n.DirectiveLabel}}}
+		setData(label, "directiveLabel", true)
+		ret.Children = append(ret.Children, label)
+	}
+	ret.Children = append(ret.Children, e.children(n)...)
+	return
+}
+
+// luteNode 将没有对应 mdast 节点的 Lute 节点 n 格式化为 Markdown，作为 luteNode 自定义节点的值。
+func (e *exporter) luteNode(n *ast.Node) (ret *Node) {
+	if nil == e.formatter {
+		e.formatter = render.NewFormatRenderer(e.tree, e.options, e.parseOptions)
+	}
+	r := e.formatter
+	r.Writer = &bytes.Buffer{}
+	r.NodeWriterStack = []*bytes.Buffer{r.Writer}
+	r.LastOut = lex.ItemNewline
+	ast.Walk(n, func(n *ast.Node, entering bool) ast.WalkStatus {
+		rendererFunc := r.RendererFuncs[n.Type]
+		if nil == rendererFunc {
+			return ast.WalkContinue
+		}
+		return rendererFunc(n, entering)
+	})
+
+	ret = &Node{Type: "luteNode", Value: strings.TrimSpace(r.Writer.String())}
+	setData(ret, "luteType", n.Type.String())
+	setData(ret, "block", n.IsBlock())
+	return
+}
+
+// ownedIAL 判断块级 IAL 节点 ial 是否属于前一个节点，属于前一个节点的 IAL 记录在前一个节点的 data.ial 中。
+func ownedIAL(ial *ast.Node) bool {
+	return nil != ial.Previous && 0 < len(ial.Previous.KramdownIAL)
+}
+
+// appendNode 将 node 追加到 nodes 末尾，和前一个文本节点相邻的文本节点会被合并。
+func appendNode(nodes []*Node, node *Node) []*Node {
+	if 0 < len(nodes) {
+		last := nodes[len(nodes)-1]
+		if "text" == node.Type && "text" == last.Type && nil == node.Data && nil == last.Data {
+			last.Value += node.Value
+			if nil != last.Position && nil != node.Position {
+				last.Position.End = node.Position.End
+			} else if nil == last.Position {
+				last.Position = node.Position
+			}
+			return nodes
+		}
+	}
+	return append(nodes, node)
+}
+
+// position 返回节点 n 在原始文本中的位置，仅在解析时打开了 SourcePos 选项时存在。
+func position(n *ast.Node) *Position {
+	if nil == n.Pos {
+		return nil
+	}
+	return &Position{
+		Start: &Point{Line: n.Pos.StartLine, Column: n.Pos.StartColumn, Offset: n.Pos.StartOffset},
+		End:   &Point{Line: n.Pos.EndLine, Column: n.Pos.EndColumn + 1, Offset: n.Pos.EndOffset},
+	}
+}
+
+// identifier 规范化引用标签，和 mdast 一致：合并空白并转换为小写。
+func identifier(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// plainText 返回 mdast 节点的纯文本内容。
+func plainText(nodes []*Node) string {
+	buf := &strings.Builder{}
+	for _, n := range nodes {
+		switch n.Type {
+		case "text", "inlineCode", "inlineMath":
+			buf.WriteString(n.Value)
+		case "image", "imageReference":
+			if nil != n.Alt {
+				buf.WriteString(*n.Alt)
+			}
+		default:
+			buf.WriteString(plainText(n.Children))
+		}
+	}
+	return buf.String()
+}
+
+func alignName(align int) *string {
+	switch align {
+	case 1:
+		return stringPtr("left")
+	case 2:
+		return stringPtr("center")
+	case 3:
+		return stringPtr("right")
+	}
+	return nil
+}
+
+func tokensStr(n *ast.Node) string {
+	if nil == n {
+		return ""
+	}
+	return n.TokensStr()
+}
+
+func setData(n *Node, key string, value interface{}) {
+	if nil == n.Data {
+		n.Data = map[string]interface{}{}
+	}
+	n.Data[key] = value
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
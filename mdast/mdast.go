@@ -0,0 +1,99 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package mdast 实现了 Lute 语法树和 mdast https://github.com/syntax-tree/mdast 之间的相互转换，用于和 unified/remark 生态交换语法树。
+//
+// 标准节点和 GFM（表格、删除线、任务列表项、脚注）、remark-math（math、inlineMath）、remark-frontmatter（yaml）、
+// remark-directive（containerDirective、leafDirective、textDirective）扩展节点按照对应规范转换。Lute 特有的节点转换为以下自定义节点：
+//
+//   - superBlock：超级块，data.layout 为 row 或者 col，子节点为块级节点
+//   - callout：提示块 > [!NOTE]，data.calloutType 为提示块类型，data.title 和 data.icon 为标题和图标，data.iconImage 为 true 时图标是图片地址，子节点为块级节点
+//   - blockRef：内容块引用 ((id "text"))，data.id 为被引用块 ID，data.subtype 为 s（静态锚文本）或者 d（动态锚文本），子节点为锚文本
+//   - tag：标签 #tag#，子节点为行级节点
+//   - mark：标记 ==mark==，子节点为行级节点
+//   - superscript、subscript：上标 ^sup^ 和下标 ~sub~，子节点为行级节点
+//   - wikiLink：维基链接 [[target#heading|alias]]，value 为 target，data.heading 和 data.alias 为标题和别名，data.embed 为 true 时是嵌入 ![[target]]
+//   - luteNode：其他没有对应 mdast 节点的 Lute 节点，value 为该节点格式化后的 Markdown，data.luteType 为 Lute 节点类型，
+//     data.block 说明是否是块级节点。转换回 Lute 语法树时重新解析 value
+//
+// 标题的自定义 ID 记录在 data.id 中，Kramdown 内联属性列表记录在 data.ial 中。节点位置的行号和列号从 1 开始，列号按字节计算。
+package mdast
+
+import (
+	"encoding/json"
+)
+
+// Node 描述了 mdast 节点，字段含义参考 mdast 规范，没有用到的字段序列化时会被省略。
+type Node struct {
+	Type     string  `json:"type"`
+	Children []*Node `json:"children,omitempty"`
+	Value    string  `json:"value,omitempty"` // 字面量节点的值，字面量节点序列化时总是输出该字段
+
+	Depth         int                    `json:"depth,omitempty"`         // heading
+	Ordered       *bool                  `json:"ordered,omitempty"`       // list
+	Start         *int                   `json:"start,omitempty"`         // list
+	Spread        *bool                  `json:"spread,omitempty"`        // list、listItem
+	Checked       *bool                  `json:"checked,omitempty"`       // listItem
+	Lang          *string                `json:"lang,omitempty"`          // code
+	Meta          *string                `json:"meta,omitempty"`          // code、math
+	URL           string                 `json:"url,omitempty"`           // link、image、definition
+	Title         *string                `json:"title,omitempty"`         // link、image、definition
+	Alt           *string                `json:"alt,omitempty"`           // image、imageReference
+	Identifier    string                 `json:"identifier,omitempty"`    // definition、footnoteDefinition、linkReference、imageReference、footnoteReference
+	Label         string                 `json:"label,omitempty"`         // 同 identifier
+	ReferenceType string                 `json:"referenceType,omitempty"` // linkReference、imageReference：full、collapsed 或者 shortcut
+	Align         []*string              `json:"align,omitempty"`         // table：left、right、center 或者 null
+	Name          string                 `json:"name,omitempty"`          // 指令名称
+	Attributes    map[string]string      `json:"attributes,omitempty"`    // 指令属性
+	Position      *Position              `json:"position,omitempty"`      // 节点在原始文本中的位置
+	Data          map[string]interface{} `json:"data,omitempty"`          // 扩展数据
+}
+
+// Position 描述了节点在原始文本中的位置，结束点指向节点之后的第一个字符。
+type Position struct {
+	Start *Point `json:"start"`
+	End   *Point `json:"end"`
+}
+
+// Point 描述了原始文本中的一个位置。
+type Point struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// literals 记录了字面量节点类型。
+var literals = map[string]bool{
+	"text": true, "inlineCode": true, "code": true, "html": true, "yaml": true, "math": true, "inlineMath": true, "wikiLink": true, "luteNode": true,
+}
+
+// MarshalJSON 序列化节点，字面量节点总是输出 value 字段。
+func (n *Node) MarshalJSON() ([]byte, error) {
+	type node Node
+	data, err := json.Marshal((*node)(n))
+	if nil != err || "" != n.Value || !literals[n.Type] {
+		return data, err
+	}
+	return append(data[:len(data)-1], `,"value":""}`...), nil
+}
+
+// IAL 返回节点 data.ial 中记录的 Kramdown 内联属性列表。
+func (n *Node) IAL() [][]string {
+	return dataIAL(n)
+}
+
+// Parse 解析 JSON 格式的 mdast 语法树。
+func Parse(data []byte) (ret *Node, err error) {
+	ret = &Node{}
+	if err = json.Unmarshal(data, ret); nil != err {
+		ret = nil
+	}
+	return
+}
@@ -0,0 +1,877 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package mdast
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// ToTree 将 mdast 语法树 root 转换为 Lute 语法树，转换结果和解析等价 Markdown 得到的语法树结构一致。
+//
+// options 中的行级语法开关用于决定文本中哪些字符需要转义，luteNode 节点也使用 options 重新解析。
+// mdast 中没有记录的 Markdown 书写细节（比如强调标记符、列表标记符和 Setext 标题）使用默认值。
+// 包含换行的一二级标题转换为 Setext 标题，信息字符串包含反引号的代码块使用波浪线围栏。
+func ToTree(name string, root *Node, options *parse.Options) (tree *parse.Tree, err error) {
+	if nil == root || "root" != root.Type {
+		return nil, errors.New("mdast root node is required")
+	}
+
+	tree = &parse.Tree{Name: name, Root: &ast.Node{Type: ast.NodeDocument}, Context: &parse.Context{ParseOption: options}}
+	tree.Context.Tree = tree
+	i := &importer{options: options, definitions: map[string]*Node{}}
+	i.collectDefinitions(root)
+	if err = i.children(tree.Root, root.Children); nil != err {
+		return nil, err
+	}
+	if ial := dataIAL(root); 0 < len(ial) {
+		tree.Root.KramdownIAL = ial
+		tree.Root.ID = tree.Root.IALAttr("id")
+		tree.ID = tree.Root.ID
+		tree.Root.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: parse.IAL2Tokens(ial)})
+	}
+	i.resolveFootnotesRefs(tree.Root)
+	return
+}
+
+// importer 用于将 mdast 语法树转换为 Lute 语法树。
+type importer struct {
+	options     *parse.Options
+	definitions map[string]*Node // 链接引用定义，键为规范化后的标签
+	footnotes   []*ast.Node      // 脚注引用，按文档顺序排列
+}
+
+// collectDefinitions 收集链接引用定义，链接引用转换时需要使用定义中的地址和标题。
+func (i *importer) collectDefinitions(n *Node) {
+	for _, c := range n.Children {
+		if "definition" == c.Type {
+			// 和解析一致，标签相同的定义以第一个为准
+			if id := referenceID(c); nil == i.definitions[id] {
+				i.definitions[id] = c
+			}
+			continue
+		}
+		i.collectDefinitions(c)
+	}
+}
+
+// children 转换 mdast 节点列表 nodes，并作为子节点追加到 parent 下。
+func (i *importer) children(parent *ast.Node, nodes []*Node) error {
+	for _, n := range nodes {
+		if err := i.node(parent, n); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// node 转换 mdast 节点 n，并作为子节点追加到 parent 下。
+func (i *importer) node(parent *ast.Node, n *Node) (err error) {
+	var node *ast.Node
+	switch n.Type {
+	case "text":
+		i.text(parent, n.Value)
+		return
+	case "paragraph":
+		node = &ast.Node{Type: ast.NodeParagraph}
+		err = i.children(node, n.Children)
+	case "heading":
+		if 1 > n.Depth || 6 < n.Depth {
+			return errors.New("invalid mdast heading depth [" + strconv.Itoa(n.Depth) + "]")
+		}
+		node = &ast.Node{Type: ast.NodeHeading, HeadingLevel: n.Depth}
+		node.AppendChild(&ast.Node{Type: ast.NodeHeadingC8hMarker, Tokens: []byte(strings.Repeat("#", n.Depth) + " ")})
+		err = i.children(node, n.Children)
+		multiline(node)
+		if id := dataString(n, "id"); "" != id {
+			node.AppendChild(&ast.Node{Type: ast.NodeHeadingID, Tokens: []byte("#" + id)})
+		}
+	case "thematicBreak":
+		node = &ast.Node{Type: ast.NodeThematicBreak}
+	case "blockquote":
+		node = &ast.Node{Type: ast.NodeBlockquote}
+		node.AppendChild(&ast.Node{Type: ast.NodeBlockquoteMarker, Tokens: []byte(">")})
+		err = i.children(node, n.Children)
+	case "list":
+		node, err = i.list(n)
+	case "html":
+		if ast.NodeParagraph == parent.Type || isInline(parent) {
+			node = &ast.Node{Type: ast.NodeInlineHTML, Tokens: []byte(n.Value)}
+		} else {
+			node = &ast.Node{Type: ast.NodeHTMLBlock, Tokens: []byte(n.Value)}
+		}
+	case "code":
+		node = codeBlock(n)
+	case "math":
+		node = &ast.Node{Type: ast.NodeMathBlock}
+		node.AppendChild(&ast.Node{Type: ast.NodeMathBlockOpenMarker})
+		node.AppendChild(&ast.Node{Type: ast.NodeMathBlockContent, Tokens: []byte(n.Value)})
+		node.AppendChild(&ast.Node{Type: ast.NodeMathBlockCloseMarker})
+	case "inlineMath":
+		node = &ast.Node{Type: ast.NodeInlineMath}
+		node.AppendChild(&ast.Node{Type: ast.NodeInlineMathOpenMarker})
+		node.AppendChild(&ast.Node{Type: ast.NodeInlineMathContent, Tokens: []byte(n.Value)})
+		node.AppendChild(&ast.Node{Type: ast.NodeInlineMathCloseMarker})
+	case "inlineCode":
+		marker := "`"
+		if strings.Contains(n.Value, "`") {
+			marker = "``"
+		}
+		node = &ast.Node{Type: ast.NodeCodeSpan, CodeMarkerLen: len(marker)}
+		node.AppendChild(&ast.Node{Type: ast.NodeCodeSpanOpenMarker, Tokens: []byte(marker)})
+		node.AppendChild(&ast.Node{Type: ast.NodeCodeSpanContent, Tokens: []byte(n.Value)})
+		node.AppendChild(&ast.Node{Type: ast.NodeCodeSpanCloseMarker, Tokens: []byte(marker)})
+	case "yaml":
+		node = &ast.Node{Type: ast.NodeYamlFrontMatter, Tokens: []byte(n.Value)}
+		node.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterOpenMarker})
+		node.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterContent, Tokens: []byte(n.Value)})
+		node.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterCloseMarker})
+	case "break":
+		node = &ast.Node{Type: ast.NodeHardBreak, Tokens: []byte("\n")}
+	case "emphasis":
+		node, err = i.span(ast.NodeEmphasis, ast.NodeEmA6kOpenMarker, ast.NodeEmA6kCloseMarker, "*", n)
+	case "strong":
+		node, err = i.span(ast.NodeStrong, ast.NodeStrongA6kOpenMarker, ast.NodeStrongA6kCloseMarker, "**", n)
+	case "delete":
+		node, err = i.span(ast.NodeStrikethrough, ast.NodeStrikethrough2OpenMarker, ast.NodeStrikethrough2CloseMarker, "~~", n)
+	case "link", "image", "linkReference", "imageReference":
+		node, err = i.link(parent, n)
+	case "definition":
+		node = linkRefDef(n)
+	case "footnoteDefinition":
+		block := parent.LastChild
+		if nil == block || ast.NodeFootnotesDefBlock != block.Type {
+			// 连续的脚注定义放在同一个脚注定义块中
+			block = &ast.Node{Type: ast.NodeFootnotesDefBlock}
+			parent.AppendChild(block)
+		}
+		node = &ast.Node{Type: ast.NodeFootnotesDef, Tokens: []byte("^" + referenceLabel(n))}
+		err = i.children(node, n.Children)
+		parent = block
+	case "footnoteReference":
+		label := "^" + referenceLabel(n)
+		node = &ast.Node{Type: ast.NodeFootnotesRef, Tokens: []byte(label), FootnotesRefLabel: []byte(label)}
+		i.footnotes = append(i.footnotes, node)
+	case "table":
+		node, err = i.table(n)
+	case "superBlock":
+		node = &ast.Node{Type: ast.NodeSuperBlock}
+		node.AppendChild(&ast.Node{Type: ast.NodeSuperBlockOpenMarker})
+		layout := dataString(n, "layout")
+		if "" == layout {
+			layout = "row"
+		}
+		node.AppendChild(&ast.Node{Type: ast.NodeSuperBlockLayoutMarker, Tokens: []byte(layout)})
+		err = i.children(node, n.Children)
+		node.AppendChild(&ast.Node{Type: ast.NodeSuperBlockCloseMarker})
+	case "callout":
+		node = &ast.Node{Type: ast.NodeCallout, CalloutType: dataString(n, "calloutType"), CalloutTitle: dataString(n, "title"), CalloutIcon: dataString(n, "icon")}
+		if "" == node.CalloutType {
+			node.CalloutType = ast.CalloutTypeNote
+		}
+		if iconImage, _ := n.Data["iconImage"].(bool); iconImage {
+			node.CalloutIconType = 1
+		}
+		err = i.children(node, n.Children)
+	case "blockRef":
+		node = blockRef(n)
+	case "tag":
+		node, err = i.span(ast.NodeTag, ast.NodeTagOpenMarker, ast.NodeTagCloseMarker, "#", n)
+	case "mark":
+		node, err = i.span(ast.NodeMark, ast.NodeMark2OpenMarker, ast.NodeMark2CloseMarker, "==", n)
+	case "superscript":
+		node, err = i.span(ast.NodeSup, ast.NodeSupOpenMarker, ast.NodeSupCloseMarker, "^", n)
+	case "subscript":
+		node, err = i.span(ast.NodeSub, ast.NodeSubOpenMarker, ast.NodeSubCloseMarker, "~", n)
+	case "wikiLink":
+		node = wikiLink(n)
+	case "containerDirective", "leafDirective", "textDirective":
+		node, err = i.directive(n)
+	case "luteNode":
+		return i.luteNode(parent, n)
+	default:
+		return errors.New("unsupported mdast node [type=" + n.Type + "]")
+	}
+	if nil != err || nil == node {
+		return
+	}
+
+	parent.AppendChild(node)
+	if ial := dataIAL(n); 0 < len(ial) {
+		node.KramdownIAL = ial
+		node.ID = node.IALAttr("id")
+		if node.IsBlock() {
+			node.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: parse.IAL2Tokens(ial)})
+		}
+	}
+	return
+}
+
+// span 转换强调、加粗等由开始标记符、行级子节点和结束标记符组成的节点。
+func (i *importer) span(typ, openMarkerType, closeMarkerType ast.NodeType, marker string, n *Node) (ret *ast.Node, err error) {
+	ret = &ast.Node{Type: typ}
+	ret.AppendChild(&ast.Node{Type: openMarkerType, Tokens: []byte(marker)})
+	if err = i.children(ret, n.Children); nil != err {
+		return
+	}
+	ret.AppendChild(&ast.Node{Type: closeMarkerType, Tokens: []byte(marker)})
+	return
+}
+
+func (i *importer) list(n *Node) (ret *ast.Node, err error) {
+	ordered := nil != n.Ordered && *n.Ordered
+	start := 1
+	if nil != n.Start {
+		start = *n.Start
+	}
+	tight := nil == n.Spread || !*n.Spread
+	for _, item := range n.Children {
+		if nil != item.Spread && *item.Spread {
+			tight = false
+		}
+	}
+
+	ret = &ast.Node{Type: ast.NodeList}
+	for idx, item := range n.Children {
+		if "listItem" != item.Type {
+			return nil, errors.New("unexpected mdast node [type=" + item.Type + "] in list")
+		}
+
+		data := &ast.ListData{Tight: tight, Num: -1}
+		if ordered {
+			num := start + idx
+			data.Typ, data.Start, data.Delimiter, data.Num = 1, num, '.', num
+			data.Marker = []byte(strconv.Itoa(num) + ".")
+		} else {
+			data.BulletChar = '*'
+			data.Marker = []byte("*")
+		}
+		data.Padding = len(data.Marker) + 1
+		if nil != item.Checked {
+			data.Typ = 3
+			data.Checked = *item.Checked
+		}
+		if 0 == idx {
+			listData := *data
+			ret.ListData = &listData
+		}
+
+		li := &ast.Node{Type: ast.NodeListItem, ListData: data, Tokens: data.Marker}
+		ret.AppendChild(li)
+		if err = i.children(li, item.Children); nil != err {
+			return
+		}
+		if nil != item.Checked {
+			i.taskListItemMarker(li, item)
+		}
+		if ial := dataIAL(item); 0 < len(ial) {
+			li.KramdownIAL = ial
+			li.ID = li.IALAttr("id")
+			li.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: parse.IAL2Tokens(ial)})
+		}
+	}
+	return
+}
+
+// taskListItemMarker 在任务列表项的第一个段落开头插入任务列表项标记符。
+func (i *importer) taskListItemMarker(li *ast.Node, item *Node) {
+	p := li.FirstChild
+	if nil == p || ast.NodeParagraph != p.Type {
+		p = &ast.Node{Type: ast.NodeParagraph}
+		if nil == li.FirstChild {
+			li.AppendChild(p)
+		} else {
+			li.FirstChild.InsertBefore(p)
+		}
+	}
+
+	marker := byte(' ')
+	if *item.Checked {
+		marker = 'X'
+		if taskMarker := dataString(item, "taskMarker"); 1 == len(taskMarker) {
+			marker = taskMarker[0]
+		}
+	}
+	markerNode := &ast.Node{Type: ast.NodeTaskListItemMarker, Tokens: []byte("[" + string(marker) + "]")}
+	markerNode.ReviveFromMarker(marker)
+	// 和解析结果一致，标记符后面的空格保留在文本节点中
+	if nil != p.FirstChild && ast.NodeText == p.FirstChild.Type {
+		p.FirstChild.Tokens = append([]byte{' '}, p.FirstChild.Tokens...)
+	} else {
+		p.PrependChild(&ast.Node{Type: ast.NodeText, Tokens: []byte{' '}})
+	}
+	p.PrependChild(markerNode)
+}
+
+// multiline 处理包含换行的标题：ATX 标题只能有一行，一二级标题转换为 Setext 标题，其他级别的标题将换行替换为空格。
+func multiline(heading *ast.Node) {
+	var breaks []*ast.Node
+	ast.Walk(heading, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && (ast.NodeSoftBreak == n.Type || ast.NodeHardBreak == n.Type) {
+			breaks = append(breaks, n)
+		}
+		return ast.WalkContinue
+	})
+	if 1 > len(breaks) {
+		return
+	}
+
+	if 2 >= heading.HeadingLevel {
+		heading.HeadingSetext = true
+		heading.FirstChild.Unlink() // ATX 标记符
+		return
+	}
+	for _, br := range breaks {
+		br.InsertBefore(&ast.Node{Type: ast.NodeText, Tokens: []byte{' '}})
+		br.Unlink()
+	}
+}
+
+func codeBlock(n *Node) (ret *ast.Node) {
+	var info []byte
+	if nil != n.Lang {
+		info = []byte(*n.Lang)
+	}
+	// 反引号围栏的信息字符串中不能包含反引号，这时使用波浪线围栏
+	fenceChar := lex.ItemBacktick
+	if 0 <= bytes.IndexByte(info, lex.ItemBacktick) {
+		fenceChar = lex.ItemTilde
+	}
+	fenceLen := 3
+	for _, line := range strings.Split(n.Value, "\n") {
+		if l := lex.Accept([]byte(strings.TrimLeft(line, " ")), fenceChar); l >= fenceLen {
+			fenceLen = l + 1
+		}
+	}
+	fence := bytes.Repeat([]byte{fenceChar}, fenceLen)
+	ret = &ast.Node{Type: ast.NodeCodeBlock, IsFencedCodeBlock: true, CodeBlockFenceChar: fenceChar, CodeBlockFenceLen: fenceLen,
+		CodeBlockOpenFence: fence, CodeBlockInfo: info, CodeBlockCloseFence: fence}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceOpenMarker, Tokens: fence, CodeBlockFenceLen: fenceLen})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceInfoMarker, CodeBlockInfo: info})
+	code := []byte(n.Value)
+	if 0 < len(code) {
+		code = append(code, '\n')
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockCode, Tokens: code})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceCloseMarker, Tokens: fence, CodeBlockFenceLen: fenceLen})
+	return
+}
+
+func (i *importer) link(parent *ast.Node, n *Node) (ret *ast.Node, err error) {
+	image := "image" == n.Type || "imageReference" == n.Type
+	typ := ast.NodeLink
+	if image {
+		typ = ast.NodeImage
+	}
+	ret = &ast.Node{Type: typ}
+
+	url, title := n.URL, n.Title
+	if "linkReference" == n.Type || "imageReference" == n.Type {
+		def := i.definitions[referenceID(n)]
+		if nil == def {
+			// 没有对应的链接引用定义时作为普通文本
+			return nil, i.undefinedReference(parent, n)
+		}
+		ret.LinkType = 3
+		ret.LinkRefLabel = []byte(referenceLabel(n))
+		if "full" != n.ReferenceType {
+			ret.LinkRefLabel = []byte(plainText(n.Children))
+			if image && nil != n.Alt {
+				ret.LinkRefLabel = []byte(*n.Alt)
+			}
+		}
+		url, title = def.URL, def.Title
+	} else if !image && isAutoLink(n) {
+		ret.LinkType = 2
+		if strings.HasPrefix(url, "mailto:") && !strings.HasPrefix(n.Children[0].Value, "mailto:") {
+			ret.AppendChild(&ast.Node{Type: ast.NodeLinkText, Tokens: []byte(n.Children[0].Value)})
+			ret.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: []byte(url)})
+			return
+		}
+		ret.AppendChild(&ast.Node{Type: ast.NodeOpenBracket})
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkText, Tokens: []byte(n.Children[0].Value)})
+		ret.AppendChild(&ast.Node{Type: ast.NodeCloseBracket})
+		ret.AppendChild(&ast.Node{Type: ast.NodeOpenParen})
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: []byte(url)})
+		ret.AppendChild(&ast.Node{Type: ast.NodeCloseParen})
+		return
+	}
+
+	if image {
+		ret.AppendChild(&ast.Node{Type: ast.NodeBang, Tokens: []byte("!")})
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: []byte("[")})
+	textParent := &ast.Node{Type: ast.NodeParagraph}
+	if image {
+		if nil != n.Alt {
+			i.text(textParent, *n.Alt)
+		}
+	} else if err = i.children(textParent, n.Children); nil != err {
+		return
+	}
+	for c := textParent.FirstChild; nil != c; c = textParent.FirstChild {
+		ret.AppendChild(c)
+	}
+	ast.Walk(ret, func(c *ast.Node, entering bool) ast.WalkStatus {
+		// 和解析结果一致，链接文本中的文本节点使用链接文本节点
+		if entering && ast.NodeText == c.Type {
+			c.Type = ast.NodeLinkText
+		}
+		return ast.WalkContinue
+	})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCloseBracket, Tokens: []byte("]")})
+
+	dest := []byte(strings.ReplaceAll(url, " ", "%20"))
+	if 3 == ret.LinkType {
+		ret.AppendChild(&ast.Node{Type: ast.NodeOpenParen})
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: dest})
+		if nil != title && "" != *title {
+			ret.AppendChild(&ast.Node{Type: ast.NodeLinkTitle, Tokens: []byte(*title)})
+		}
+		ret.AppendChild(&ast.Node{Type: ast.NodeCloseParen})
+		return
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeOpenParen, Tokens: []byte("(")})
+	ret.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: dest})
+	if nil != title && "" != *title {
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkSpace, Tokens: []byte(" ")})
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkTitle, Tokens: []byte(*title)})
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCloseParen, Tokens: []byte(")")})
+	return
+}
+
+// undefinedReference 将没有对应定义的链接引用按照原始写法转换为文本。
+func (i *importer) undefinedReference(parent *ast.Node, n *Node) (err error) {
+	open := "["
+	if "imageReference" == n.Type {
+		open = "!["
+	}
+	parent.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: []byte(open)})
+	if "imageReference" == n.Type {
+		if nil != n.Alt {
+			i.text(parent, *n.Alt)
+		}
+	} else if err = i.children(parent, n.Children); nil != err {
+		return
+	}
+	closer := "]"
+	switch n.ReferenceType {
+	case "full":
+		closer += "[" + referenceLabel(n) + "]"
+	case "collapsed":
+		closer += "[]"
+	}
+	parent.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: []byte(closer)})
+	return
+}
+
+func linkRefDef(n *Node) (ret *ast.Node) {
+	label := []byte(referenceLabel(n))
+	link := &ast.Node{Type: ast.NodeLink, LinkType: 1, LinkRefLabel: label}
+	link.AppendChild(&ast.Node{Type: ast.NodeOpenBracket})
+	link.AppendChild(&ast.Node{Type: ast.NodeLinkText, Tokens: label})
+	link.AppendChild(&ast.Node{Type: ast.NodeCloseBracket})
+	link.AppendChild(&ast.Node{Type: ast.NodeOpenParen})
+	link.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: []byte(n.URL)})
+	if nil != n.Title && "" != *n.Title {
+		link.AppendChild(&ast.Node{Type: ast.NodeLinkTitle, Tokens: []byte(*n.Title)})
+	}
+	link.AppendChild(&ast.Node{Type: ast.NodeCloseParen})
+
+	def := &ast.Node{Type: ast.NodeLinkRefDef, Tokens: label}
+	def.AppendChild(link)
+	ret = &ast.Node{Type: ast.NodeLinkRefDefBlock}
+	ret.AppendChild(def)
+	return
+}
+
+func (i *importer) table(n *Node) (ret *ast.Node, err error) {
+	var aligns []int
+	for _, align := range n.Align {
+		aligns = append(aligns, alignValue(align))
+	}
+	columns := len(aligns)
+	for _, row := range n.Children {
+		if columns < len(row.Children) {
+			columns = len(row.Children)
+		}
+	}
+	for len(aligns) < columns {
+		aligns = append(aligns, 0)
+	}
+
+	ret = &ast.Node{Type: ast.NodeTable, TableAligns: aligns}
+	for idx, row := range n.Children {
+		if "tableRow" != row.Type {
+			return nil, errors.New("unexpected mdast node [type=" + row.Type + "] in table")
+		}
+
+		tr := &ast.Node{Type: ast.NodeTableRow, TableAligns: aligns}
+		for col := 0; col < columns; col++ {
+			cell := &ast.Node{Type: ast.NodeTableCell, TableCellAlign: aligns[col]}
+			tr.AppendChild(cell)
+			if col < len(row.Children) {
+				if err = i.children(cell, row.Children[col].Children); nil != err {
+					return
+				}
+			}
+		}
+		if 0 == idx {
+			head := &ast.Node{Type: ast.NodeTableHead}
+			head.AppendChild(tr)
+			ret.AppendChild(head)
+		} else {
+			ret.AppendChild(tr)
+		}
+	}
+	return
+}
+
+func blockRef(n *Node) (ret *ast.Node) {
+	ret = &ast.Node{Type: ast.NodeBlockRef}
+	ret.AppendChild(&ast.Node{Type: ast.NodeOpenParen})
+	ret.AppendChild(&ast.Node{Type: ast.NodeOpenParen})
+	ret.AppendChild(&ast.Node{Type: ast.NodeBlockRefID, Tokens: []byte(dataString(n, "id"))})
+	if text := plainText(n.Children); "" != text {
+		ret.AppendChild(&ast.Node{Type: ast.NodeBlockRefSpace})
+		if "d" == dataString(n, "subtype") {
+			ret.AppendChild(&ast.Node{Type: ast.NodeBlockRefDynamicText, Tokens: []byte(text)})
+		} else {
+			ret.AppendChild(&ast.Node{Type: ast.NodeBlockRefText, Tokens: []byte(text)})
+		}
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCloseParen})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCloseParen})
+	return
+}
+
+func wikiLink(n *Node) (ret *ast.Node) {
+	ret = &ast.Node{Type: ast.NodeWikiLink, WikiLinkTarget: n.Value, WikiLinkHeading: dataString(n, "heading"), WikiLinkAlias: dataString(n, "alias")}
+	content := ret.WikiLinkTarget
+	if "" != ret.WikiLinkHeading {
+		content += "#" + ret.WikiLinkHeading
+	}
+	if "" != ret.WikiLinkAlias {
+		content += "|" + ret.WikiLinkAlias
+	}
+	ret.Tokens = []byte("[[" + content + "]]")
+	if embed, _ := n.Data["embed"].(bool); embed {
+		ret.Type = ast.NodeWikiLinkEmbed
+		ret.Tokens = append([]byte("!"), ret.Tokens...)
+	}
+	return
+}
+
+func (i *importer) directive(n *Node) (ret *ast.Node, err error) {
+	ret = &ast.Node{Type: ast.NodeTextDirective, DirectiveName: n.Name}
+	// 和 DirectiveAttrs2Tokens 的输出习惯一致，id 和 class 排在最前面，其他属性按名称排序
+	var names []string
+	for name := range n.Attributes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		ra, rb := attrRank(names[a]), attrRank(names[b])
+		if ra != rb {
+			return ra < rb
+		}
+		return names[a] < names[b]
+	})
+	for _, name := range names {
+		ret.DirectiveAttrs = append(ret.DirectiveAttrs, []string{name, n.Attributes[name]})
+	}
+
+	switch n.Type {
+	case "containerDirective":
+		ret.Type = ast.NodeContainerDirective
+		ret.DirectiveFenceLen = 3 + containerDirectiveDepth(n)
+		children := n.Children
+		if 0 < len(children) && "paragraph" == children[0].Type {
+			if label, _ := children[0].Data["directiveLabel"].(bool); label {
+				ret.DirectiveLabel = plainText(children[0].Children)
+				children = children[1:]
+			}
+		}
+		err = i.children(ret, children)
+	case "leafDirective":
+		ret.Type = ast.NodeLeafDirective
+		err = i.children(ret, n.Children)
+	default:
+		err = i.children(ret, n.Children)
+	}
+	return
+}
+
+// containerDirectiveDepth 返回容器指令 n 中嵌套的容器指令层数，外层容器指令的围栏需要比内层长。
+func containerDirectiveDepth(n *Node) (ret int) {
+	for _, c := range n.Children {
+		depth := containerDirectiveDepth(c)
+		if "containerDirective" == c.Type {
+			depth++
+		}
+		if ret < depth {
+			ret = depth
+		}
+	}
+	return
+}
+
+func attrRank(name string) int {
+	switch name {
+	case "id":
+		return 0
+	case "class":
+		return 1
+	}
+	return 2
+}
+
+// luteNode 重新解析 luteNode 节点的 Markdown，并将解析结果追加到 parent 下。块级节点的内联属性列表设置到解析得到的第一个块上。
+func (i *importer) luteNode(parent *ast.Node, n *Node) error {
+	if block, _ := n.Data["block"].(bool); block {
+		tree := parse.Block("", []byte(n.Value), i.options)
+		if last := tree.Root.LastChild; nil != last && ast.NodeKramdownBlockIAL == last.Type && util.IsDocIAL(last.Tokens) {
+			// 打开 KramdownBlockIAL 时解析结果最后会追加文档 IAL，这里只需要块本身
+			last.Unlink()
+		}
+		first := tree.Root.FirstChild
+		for c := first; nil != c; c = tree.Root.FirstChild {
+			parent.AppendChild(c)
+		}
+		if ial := dataIAL(n); 0 < len(ial) && nil != first && (nil == first.Next || ast.NodeKramdownBlockIAL != first.Next.Type) {
+			first.KramdownIAL = ial
+			first.ID = first.IALAttr("id")
+			first.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: parse.IAL2Tokens(ial)})
+		}
+		return nil
+	}
+
+	tree := parse.Inline("", []byte(n.Value), i.options)
+	if p := tree.Root.FirstChild; nil != p {
+		for c := p.FirstChild; nil != c; c = p.FirstChild {
+			parent.AppendChild(c)
+		}
+	}
+	return nil
+}
+
+// resolveFootnotesRefs 按照文档顺序设置脚注引用的 ID 并将引用关联到脚注定义，没有对应定义的脚注引用转换为文本。
+func (i *importer) resolveFootnotesRefs(root *ast.Node) {
+	defs := map[string]*ast.Node{}
+	nums := map[string]int{}
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeFootnotesDef == n.Type {
+			label := strings.ToLower(n.TokensStr())
+			if _, ok := defs[label]; !ok {
+				defs[label] = n
+				nums[label] = len(defs)
+			}
+		}
+		return ast.WalkContinue
+	})
+
+	for _, ref := range i.footnotes {
+		label := strings.ToLower(ref.TokensStr())
+		def := defs[label]
+		if nil == def {
+			ref.Type = ast.NodeText
+			ref.Tokens = []byte("[" + ref.TokensStr() + "]")
+			ref.FootnotesRefLabel = nil
+			continue
+		}
+		ref.FootnotesRefId = strconv.Itoa(nums[label])
+		if refs := len(def.FootnotesRefs); 0 < refs {
+			ref.FootnotesRefId += ":" + strconv.Itoa(refs+1)
+		}
+		def.FootnotesRefs = append(def.FootnotesRefs, ref)
+	}
+}
+
+// text 将文本 value 转换为文本节点追加到 parent 下。换行转换为软换行，会被解析为 Markdown 语法的字符使用转义节点。
+func (i *importer) text(parent *ast.Node, value string) {
+	lines := strings.Split(value, "\n")
+	for idx, line := range lines {
+		if 0 < idx {
+			parent.AppendChild(&ast.Node{Type: ast.NodeSoftBreak, Tokens: []byte("\n")})
+		}
+		i.escape(parent, line)
+	}
+}
+
+// escape 将一行文本 line 转换为文本节点和转义节点追加到 parent 下。
+func (i *importer) escape(parent *ast.Node, line string) {
+	lineStart := nil == parent.LastChild || ast.NodeSoftBreak == parent.LastChild.Type || ast.NodeHardBreak == parent.LastChild.Type
+	lineStart = lineStart && (ast.NodeParagraph == parent.Type || ast.NodeHeading == parent.Type)
+	inTable := ast.NodeTableCell == parent.Type || parent.ParentIs(ast.NodeTableCell)
+
+	start := 0
+	for idx := 0; idx < len(line); idx++ {
+		if !i.needEscape(line, idx, lineStart, inTable) {
+			continue
+		}
+		if start < idx {
+			appendText(parent, line[start:idx])
+		}
+		backslash := &ast.Node{Type: ast.NodeBackslash}
+		backslash.AppendChild(&ast.Node{Type: ast.NodeBackslashContent, Tokens: []byte{line[idx]}})
+		parent.AppendChild(backslash)
+		start = idx + 1
+	}
+	if start < len(line) {
+		appendText(parent, line[start:])
+	}
+}
+
+// needEscape 判断 line 中下标为 idx 的字符是否需要转义，lineStart 说明 line 是否位于块的行首。
+func (i *importer) needEscape(line string, idx int, lineStart, inTable bool) bool {
+	c := line[idx]
+	var next byte
+	if idx+1 < len(line) {
+		next = line[idx+1]
+	}
+	atLineStart := lineStart && isLineStart(line, idx)
+	switch c {
+	case '\\', '`', '*', '[', ']', '<':
+		return true
+	case '_':
+		// 单词内部的下划线不会被解析为强调
+		return 0 == idx || idx+1 == len(line) || !isWordChar(line[idx-1]) || !isWordChar(next)
+	case '&':
+		return lex.IsASCIILetter(next) || '#' == next
+	case '|':
+		return inTable
+	case '$':
+		return i.options.InlineMath
+	case '~':
+		return i.options.GFMStrikethrough || i.options.Sub
+	case '^':
+		return i.options.Sup
+	case '=':
+		return atLineStart || (i.options.Mark && ('=' == next || (0 < idx && '=' == line[idx-1])))
+	case '#':
+		return atLineStart || i.options.Tag
+	case ':':
+		return i.options.Directive && lex.IsASCIILetter(next) && (0 == idx || !isWordChar(line[idx-1]))
+	case '>', '-', '+':
+		return atLineStart
+	case '.', ')':
+		// 行首的数字加 . 或者 ) 会被解析为有序列表
+		if 0 == idx || !lex.IsDigit(line[idx-1]) {
+			return false
+		}
+		j := idx - 1
+		for ; 0 < j && lex.IsDigit(line[j-1]); j-- {
+		}
+		return lineStart && isLineStart(line, j)
+	}
+	return false
+}
+
+// isLineStart 判断 line 中下标为 idx 的字符前面是否只有空格。
+func isLineStart(line string, idx int) bool {
+	return "" == strings.TrimLeft(line[:idx], " ")
+}
+
+func isWordChar(c byte) bool {
+	return lex.IsASCIILetterNum(c) || 0x80 <= c
+}
+
+// appendText 追加文本节点，和前一个文本节点相邻时合并。
+func appendText(parent *ast.Node, text string) {
+	if last := parent.LastChild; nil != last && ast.NodeText == last.Type {
+		last.Tokens = append(last.Tokens, text...)
+		return
+	}
+	parent.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: []byte(text)})
+}
+
+func isInline(n *ast.Node) bool {
+	switch n.Type {
+	case ast.NodeEmphasis, ast.NodeStrong, ast.NodeStrikethrough, ast.NodeLink, ast.NodeImage, ast.NodeHeading, ast.NodeTableCell,
+		ast.NodeTag, ast.NodeMark, ast.NodeSup, ast.NodeSub, ast.NodeLeafDirective, ast.NodeTextDirective:
+		return true
+	}
+	return false
+}
+
+// isAutoLink 判断链接 n 是否可以写为自动链接，即链接文本和地址相同。
+func isAutoLink(n *Node) bool {
+	if nil != n.Title || 1 != len(n.Children) || "text" != n.Children[0].Type || !strings.Contains(n.URL, ":") {
+		return false
+	}
+	text := n.Children[0].Value
+	return text == n.URL || "mailto:"+text == n.URL
+}
+
+// referenceID 返回引用节点规范化后的标签，和解析时的匹配规则一致。
+func referenceID(n *Node) string {
+	if "" != n.Identifier {
+		return parse.NormalizeLabel(n.Identifier)
+	}
+	return parse.NormalizeLabel(n.Label)
+}
+
+// referenceLabel 返回引用节点的原始标签。
+func referenceLabel(n *Node) string {
+	if "" != n.Label {
+		return n.Label
+	}
+	return n.Identifier
+}
+
+func alignValue(align *string) int {
+	if nil == align {
+		return 0
+	}
+	switch *align {
+	case "left":
+		return 1
+	case "center":
+		return 2
+	case "right":
+		return 3
+	}
+	return 0
+}
+
+func dataString(n *Node, key string) string {
+	ret, _ := n.Data[key].(string)
+	return ret
+}
+
+// dataIAL 返回节点 data.ial 中的内联属性列表，兼容 JSON 反序列化得到的 []interface{}。
+func dataIAL(n *Node) (ret [][]string) {
+	switch ial := n.Data["ial"].(type) {
+	case [][]string:
+		return ial
+	case []interface{}:
+		for _, item := range ial {
+			kv, ok := item.([]interface{})
+			if !ok || 2 != len(kv) {
+				continue
+			}
+			k, _ := kv[0].(string)
+			v, _ := kv[1].(string)
+			if "" != k {
+				ret = append(ret, []string{k, v})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,60 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"context"
+	gojson "encoding/json"
+
+	"github.com/88250/lute/mdast"
+	"github.com/88250/lute/pandoc"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// Md2Pandoc 将 markdown 解析为 pandoc JSON AST，可以直接作为 pandoc -f json 的输入，元素映射规则见 pandoc 包。
+func (lute *Lute) Md2Pandoc(name string, markdown []byte) (json []byte, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err := lute.parseMarkdownContext(context.Background(), name, markdown)
+	if nil != err {
+		return
+	}
+	return gojson.Marshal(pandoc.FromMdast(mdast.FromTree(tree, lute.RenderOptions)))
+}
+
+// Pandoc2Tree 将 pandoc JSON AST（比如 pandoc -t json 的输出）转换为 Lute 语法树。
+func (lute *Lute) Pandoc2Tree(name string, json []byte) (tree *parse.Tree, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	root, err := pandoc.ToMdast(json)
+	if nil != err {
+		return
+	}
+	return mdast.ToTree(name, root, lute.ParseOptions)
+}
+
+// Pandoc2Md 将 pandoc JSON AST 格式化为 markdown。
+func (lute *Lute) Pandoc2Md(name string, json []byte) (markdown []byte, err error) {
+	tree, err := lute.Pandoc2Tree(name, json)
+	if nil != err {
+		return
+	}
+
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
+	markdown = renderer.Render()
+	return
+}
@@ -0,0 +1,492 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package pandoc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/88250/lute/mdast"
+	"github.com/88250/lute/parse"
+)
+
+// FromMdast 将 mdast 语法树 root 转换为 pandoc 文档，元素映射规则见包说明。
+//
+// 链接引用定义和脚注定义不单独输出，而是分别合并到链接引用和脚注引用中，没有被引用的脚注定义输出在文档末尾。
+func FromMdast(root *mdast.Node) *Document {
+	w := &writer{definitions: map[string]*mdast.Node{}, footnotes: map[string]*mdast.Node{}, referenced: map[string]bool{}, visiting: map[string]bool{}}
+	w.collect(root)
+	ret := &Document{APIVersion: APIVersion, Meta: map[string]*Element{}, Blocks: w.blocks(root.Children, false)}
+	for _, id := range w.footnoteIDs {
+		if w.referenced[id] {
+			continue
+		}
+		// 转换过程中可能引用后续的脚注定义，所以逐个判断
+		w.referenced[id] = true
+		def := w.footnotes[id]
+		label := def.Label
+		if "" == label {
+			label = def.Identifier
+		}
+		ret.Blocks = append(ret.Blocks, &Element{T: "Div", C: []interface{}{attr("", []string{"footnote-def"}, [][]string{{"label", label}}), w.blocks(def.Children, false)}})
+	}
+	if ial := root.IAL(); 0 < len(ial) {
+		meta := map[string]*Element{}
+		for _, kv := range ial {
+			meta[kv[0]] = &Element{T: "MetaString", C: kv[1]}
+		}
+		ret.Meta["ial"] = &Element{T: "MetaMap", C: meta}
+	}
+	return ret
+}
+
+// writer 用于将 mdast 语法树转换为 pandoc 文档。
+type writer struct {
+	definitions map[string]*mdast.Node // 链接引用定义，键为规范化后的标签
+	footnotes   map[string]*mdast.Node // 脚注定义，键为规范化后的标签
+	footnoteIDs []string               // 脚注定义规范化后的标签，按文档顺序排列
+	referenced  map[string]bool        // 已经被引用的脚注定义
+	visiting    map[string]bool        // 正在转换的脚注定义，用于避免脚注定义引用自身时无限递归
+}
+
+// collect 收集链接引用定义和脚注定义，和解析一致，标签相同的定义以第一个为准。
+func (w *writer) collect(n *mdast.Node) {
+	for _, c := range n.Children {
+		switch c.Type {
+		case "definition":
+			if id := referenceID(c); nil == w.definitions[id] {
+				w.definitions[id] = c
+			}
+			continue
+		case "footnoteDefinition":
+			if id := referenceID(c); nil == w.footnotes[id] {
+				w.footnotes[id] = c
+				w.footnoteIDs = append(w.footnoteIDs, id)
+			}
+		}
+		w.collect(c)
+	}
+}
+
+// blocks 转换块级节点列表 nodes，tight 为 true 时段落转换为 Plain。
+func (w *writer) blocks(nodes []*mdast.Node, tight bool) (ret []*Element) {
+	ret = []*Element{}
+	for _, n := range nodes {
+		block, withAttr := w.block(n, tight)
+		if nil == block {
+			continue
+		}
+		if ial := n.IAL(); 0 < len(ial) && !withAttr {
+			// 没有属性的元素使用 Div 记录 IAL
+			id, kvs := splitIAL(ial)
+			block = &Element{T: "Div", C: []interface{}{attr(id, []string{"ial"}, kvs), []*Element{block}}}
+		}
+		ret = append(ret, block)
+	}
+	return
+}
+
+// block 转换块级节点 n，withAttr 说明 IAL 是否已经记录在元素属性中。
+func (w *writer) block(n *mdast.Node, tight bool) (ret *Element, withAttr bool) {
+	switch n.Type {
+	case "paragraph":
+		return paragraph(w.inlines(n.Children), tight), false
+	case "heading":
+		id, _ := n.Data["id"].(string)
+		return &Element{T: "Header", C: []interface{}{n.Depth, attr(id, nil, n.IAL()), w.inlines(n.Children)}}, true
+	case "thematicBreak":
+		return &Element{T: "HorizontalRule"}, false
+	case "blockquote":
+		return &Element{T: "BlockQuote", C: w.blocks(n.Children, false)}, false
+	case "list":
+		return w.list(n), false
+	case "code":
+		id, kvs := splitIAL(n.IAL())
+		var classes []string
+		if nil != n.Lang && "" != *n.Lang {
+			classes = []string{*n.Lang}
+		}
+		if nil != n.Meta && "" != *n.Meta {
+			kvs = append([][]string{{"code-meta", *n.Meta}}, kvs...)
+		}
+		return &Element{T: "CodeBlock", C: []interface{}{attr(id, classes, kvs), n.Value}}, true
+	case "math":
+		return paragraph([]*Element{{T: "Math", C: []interface{}{&Element{T: "DisplayMath"}, n.Value}}}, tight), false
+	case "html":
+		return &Element{T: "RawBlock", C: []interface{}{"html", n.Value}}, false
+	case "yaml":
+		return &Element{T: "RawBlock", C: []interface{}{"markdown", "---\n" + n.Value + "\n---"}}, false
+	case "table":
+		return w.table(n), true
+	case "superBlock":
+		layout, _ := n.Data["layout"].(string)
+		id, kvs := splitIAL(n.IAL())
+		kvs = append([][]string{{"layout", layout}}, kvs...)
+		return &Element{T: "Div", C: []interface{}{attr(id, []string{"super-block"}, kvs), w.blocks(n.Children, false)}}, true
+	case "callout":
+		return w.callout(n), true
+	case "containerDirective", "leafDirective":
+		return w.directive(n), false
+	case "luteNode":
+		return &Element{T: "RawBlock", C: []interface{}{"markdown", n.Value}}, false
+	case "definition", "footnoteDefinition":
+		return nil, false
+	}
+	// 块级上下文中的行级节点
+	return paragraph(w.inlines([]*mdast.Node{n}), true), false
+}
+
+func paragraph(inlines []*Element, tight bool) *Element {
+	if tight {
+		return &Element{T: "Plain", C: inlines}
+	}
+	return &Element{T: "Para", C: inlines}
+}
+
+// list 转换列表，任务列表项和 pandoc 一致使用 ☐ 和 ☒ 作为第一个段落的开头。
+func (w *writer) list(n *mdast.Node) *Element {
+	tight := nil == n.Spread || !*n.Spread
+	items := [][]*Element{}
+	for _, item := range n.Children {
+		blocks := w.blocks(item.Children, tight)
+		if nil != item.Checked {
+			box := &Element{T: "Str", C: "☐"}
+			if *item.Checked {
+				box.C = "☒"
+			}
+			if 0 < len(blocks) && ("Plain" == blocks[0].T || "Para" == blocks[0].T) {
+				inlines := blocks[0].C.([]*Element)
+				if 0 < len(inlines) {
+					inlines = append([]*Element{box, {T: "Space"}}, inlines...)
+				} else {
+					inlines = []*Element{box}
+				}
+				blocks[0].C = inlines
+			} else {
+				blocks = append([]*Element{paragraph([]*Element{box}, tight)}, blocks...)
+			}
+		}
+
+		id, kvs := splitIAL(item.IAL())
+		if taskMarker, _ := item.Data["taskMarker"].(string); "" != taskMarker {
+			kvs = append([][]string{{"task-marker", taskMarker}}, kvs...)
+		}
+		if "" != id || 0 < len(kvs) {
+			blocks = []*Element{{T: "Div", C: []interface{}{attr(id, []string{"list-item"}, kvs), blocks}}}
+		}
+		items = append(items, blocks)
+	}
+
+	if nil != n.Ordered && *n.Ordered {
+		start := 1
+		if nil != n.Start {
+			start = *n.Start
+		}
+		attrs := []interface{}{start, &Element{T: "Decimal"}, &Element{T: "Period"}}
+		return &Element{T: "OrderedList", C: []interface{}{attrs, items}}
+	}
+	return &Element{T: "BulletList", C: items}
+}
+
+func (w *writer) table(n *mdast.Node) *Element {
+	colSpecs := []interface{}{}
+	aligns := []*Element{}
+	for _, align := range n.Align {
+		a := &Element{T: "AlignDefault"}
+		if nil != align {
+			switch *align {
+			case "left":
+				a.T = "AlignLeft"
+			case "center":
+				a.T = "AlignCenter"
+			case "right":
+				a.T = "AlignRight"
+			}
+		}
+		aligns = append(aligns, a)
+		colSpecs = append(colSpecs, []interface{}{a, &Element{T: "ColWidthDefault"}})
+	}
+
+	var head, body []interface{}
+	for idx, row := range n.Children {
+		cells := []interface{}{}
+		for j, cell := range row.Children {
+			align := &Element{T: "AlignDefault"}
+			if j < len(aligns) {
+				align = aligns[j]
+			}
+			cells = append(cells, []interface{}{attr("", nil, nil), align, 1, 1, []*Element{paragraph(w.inlines(cell.Children), true)}})
+		}
+		r := []interface{}{attr("", nil, nil), cells}
+		if 0 == idx {
+			head = append(head, r)
+		} else {
+			body = append(body, r)
+		}
+	}
+	if nil == head {
+		head = []interface{}{}
+	}
+	if nil == body {
+		body = []interface{}{}
+	}
+
+	id, kvs := splitIAL(n.IAL())
+	caption := []interface{}{nil, []*Element{}}
+	bodies := []interface{}{[]interface{}{attr("", nil, nil), 0, []interface{}{}, body}}
+	foot := []interface{}{attr("", nil, nil), []interface{}{}}
+	return &Element{T: "Table", C: []interface{}{attr(id, nil, kvs), caption, colSpecs, []interface{}{attr("", nil, nil), head}, bodies, foot}}
+}
+
+func (w *writer) callout(n *mdast.Node) *Element {
+	typ, _ := n.Data["calloutType"].(string)
+	title, _ := n.Data["title"].(string)
+	icon, _ := n.Data["icon"].(string)
+	id, kvs := splitIAL(n.IAL())
+	meta := [][]string{{"callout-type", typ}}
+	if "" != icon {
+		meta = append(meta, []string{"callout-icon", icon})
+	}
+	if iconImage, _ := n.Data["iconImage"].(bool); iconImage {
+		meta = append(meta, []string{"callout-icon-image", "true"})
+	}
+	kvs = append(meta, kvs...)
+
+	blocks := []*Element{}
+	if "" != title {
+		blocks = append(blocks, &Element{T: "Div", C: []interface{}{attr("", []string{"title"}, nil), []*Element{{T: "Para", C: str(title)}}}})
+	}
+	blocks = append(blocks, w.blocks(n.Children, false)...)
+	return &Element{T: "Div", C: []interface{}{attr(id, []string{"callout", strings.ToLower(typ)}, kvs), blocks}}
+}
+
+// directive 转换指令，指令的 id 和 class 属性作为元素的标识和类名。
+func (w *writer) directive(n *mdast.Node) *Element {
+	id, classes, kvs := directiveAttr(n)
+	switch n.Type {
+	case "leafDirective":
+		classes = append([]string{"leaf-directive"}, classes...)
+		return &Element{T: "Div", C: []interface{}{attr(id, classes, kvs), []*Element{paragraph(w.inlines(n.Children), true)}}}
+	case "textDirective":
+		classes = append([]string{"text-directive"}, classes...)
+		return &Element{T: "Span", C: []interface{}{attr(id, classes, kvs), w.inlines(n.Children)}}
+	}
+
+	classes = append([]string{"container-directive"}, classes...)
+	children := n.Children
+	blocks := []*Element{}
+	if 0 < len(children) {
+		if label, _ := children[0].Data["directiveLabel"].(bool); label {
+			blocks = append(blocks, &Element{T: "Div", C: []interface{}{attr("", []string{"directive-label"}, nil), []*Element{paragraph(w.inlines(children[0].Children), true)}}})
+			children = children[1:]
+		}
+	}
+	blocks = append(blocks, w.blocks(children, false)...)
+	return &Element{T: "Div", C: []interface{}{attr(id, classes, kvs), blocks}}
+}
+
+func directiveAttr(n *mdast.Node) (id string, classes []string, kvs [][]string) {
+	kvs = [][]string{{"directive-name", n.Name}}
+	var names []string
+	for name := range n.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := n.Attributes[name]
+		switch name {
+		case "id":
+			id = value
+		case "class":
+			classes = strings.Fields(value)
+		default:
+			kvs = append(kvs, []string{name, value})
+		}
+	}
+	return
+}
+
+// inlines 转换行级节点列表 nodes。
+func (w *writer) inlines(nodes []*mdast.Node) (ret []*Element) {
+	ret = []*Element{}
+	for _, n := range nodes {
+		ret = append(ret, w.inline(n)...)
+	}
+	return
+}
+
+func (w *writer) inline(n *mdast.Node) []*Element {
+	var ret *Element
+	switch n.Type {
+	case "text":
+		return str(n.Value)
+	case "emphasis":
+		ret = &Element{T: "Emph", C: w.inlines(n.Children)}
+	case "strong":
+		ret = &Element{T: "Strong", C: w.inlines(n.Children)}
+	case "delete":
+		ret = &Element{T: "Strikeout", C: w.inlines(n.Children)}
+	case "superscript":
+		ret = &Element{T: "Superscript", C: w.inlines(n.Children)}
+	case "subscript":
+		ret = &Element{T: "Subscript", C: w.inlines(n.Children)}
+	case "mark", "tag":
+		ret = &Element{T: "Span", C: []interface{}{attr("", []string{n.Type}, nil), w.inlines(n.Children)}}
+	case "blockRef":
+		id, _ := n.Data["id"].(string)
+		subtype, _ := n.Data["subtype"].(string)
+		kvs := [][]string{{"ref-id", id}}
+		if "" != subtype {
+			kvs = append(kvs, []string{"ref-subtype", subtype})
+		}
+		ret = &Element{T: "Span", C: []interface{}{attr("", []string{"block-ref"}, kvs), w.inlines(n.Children)}}
+	case "inlineCode":
+		ret = &Element{T: "Code", C: []interface{}{attr("", nil, nil), n.Value}}
+	case "inlineMath":
+		ret = &Element{T: "Math", C: []interface{}{&Element{T: "InlineMath"}, n.Value}}
+	case "html":
+		ret = &Element{T: "RawInline", C: []interface{}{"html", n.Value}}
+	case "break":
+		ret = &Element{T: "LineBreak"}
+	case "link", "image", "linkReference", "imageReference":
+		ret = w.link(n)
+	case "footnoteReference":
+		ret = w.footnoteReference(n)
+	case "wikiLink":
+		ret = wikiLink(n)
+	case "textDirective":
+		ret = w.directive(n)
+	case "luteNode":
+		ret = &Element{T: "RawInline", C: []interface{}{"markdown", n.Value}}
+	default:
+		return w.inlines(n.Children)
+	}
+	return []*Element{ret}
+}
+
+// link 转换链接和图片，链接引用使用对应定义的地址和标题，并在属性中记录引用标签。
+func (w *writer) link(n *mdast.Node) *Element {
+	url, title := n.URL, n.Title
+	var kvs [][]string
+	if "linkReference" == n.Type || "imageReference" == n.Type {
+		label := n.Label
+		if "" == label {
+			label = n.Identifier
+		}
+		kvs = [][]string{{"reference", label}, {"reference-type", n.ReferenceType}}
+		if def := w.definitions[referenceID(n)]; nil != def {
+			url, title = def.URL, def.Title
+			if defLabel := def.Label; "" != defLabel && defLabel != label {
+				// 定义的标签和引用的标签可能大小写不同，读取时使用定义的标签生成定义
+				kvs = append(kvs, []string{"reference-definition", defLabel})
+			}
+		}
+	}
+	target := []interface{}{url, ""}
+	if nil != title {
+		target[1] = *title
+	}
+
+	if "image" == n.Type || "imageReference" == n.Type {
+		alt := []*Element{}
+		if nil != n.Alt {
+			alt = str(*n.Alt)
+		}
+		return &Element{T: "Image", C: []interface{}{attr("", nil, kvs), alt, target}}
+	}
+	return &Element{T: "Link", C: []interface{}{attr("", nil, kvs), w.inlines(n.Children), target}}
+}
+
+// footnoteReference 转换脚注引用，脚注定义的内容作为 Note 放在记录标签的 Span 中。
+func (w *writer) footnoteReference(n *mdast.Node) *Element {
+	label := n.Label
+	if "" == label {
+		label = n.Identifier
+	}
+	blocks := []*Element{}
+	id := referenceID(n)
+	w.referenced[id] = true
+	if def := w.footnotes[id]; nil != def && !w.visiting[id] {
+		w.visiting[id] = true
+		blocks = w.blocks(def.Children, false)
+		delete(w.visiting, id)
+	}
+	note := &Element{T: "Note", C: blocks}
+	return &Element{T: "Span", C: []interface{}{attr("", []string{"footnote-ref"}, [][]string{{"label", label}}), []*Element{note}}}
+}
+
+func wikiLink(n *mdast.Node) *Element {
+	target := n.Value
+	if heading, _ := n.Data["heading"].(string); "" != heading {
+		target += "#" + heading
+	}
+	text := target
+	if alias, _ := n.Data["alias"].(string); "" != alias {
+		text = alias
+	}
+	typ := "Link"
+	if embed, _ := n.Data["embed"].(bool); embed {
+		typ = "Image"
+	}
+	return &Element{T: typ, C: []interface{}{attr("", []string{"wikilink"}, nil), str(text), []interface{}{target, "wikilink"}}}
+}
+
+// str 将文本转换为 Str、Space 和 SoftBreak 元素，连续的多个空格保留在 Str 中。
+func str(text string) (ret []*Element) {
+	ret = []*Element{}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if 0 < i {
+			ret = append(ret, &Element{T: "SoftBreak"})
+		}
+		for 0 < len(line) {
+			idx := strings.IndexByte(line, ' ')
+			if 0 > idx {
+				ret = append(ret, &Element{T: "Str", C: line})
+				break
+			}
+			if 0 < idx {
+				ret = append(ret, &Element{T: "Str", C: line[:idx]})
+				line = line[idx:]
+				continue
+			}
+			spaces := len(line) - len(strings.TrimLeft(line, " "))
+			if 1 == spaces {
+				ret = append(ret, &Element{T: "Space"})
+			} else {
+				ret = append(ret, &Element{T: "Str", C: line[:spaces]})
+			}
+			line = line[spaces:]
+		}
+	}
+	return
+}
+
+// splitIAL 将 IAL 拆分为 id 和其余的键值对。
+func splitIAL(ial [][]string) (id string, kvs [][]string) {
+	for _, kv := range ial {
+		if "id" == kv[0] {
+			id = kv[1]
+			continue
+		}
+		kvs = append(kvs, kv)
+	}
+	return
+}
+
+// referenceID 返回引用节点规范化后的标签，和解析时的匹配规则一致。
+func referenceID(n *mdast.Node) string {
+	label := n.Identifier
+	if "" == label {
+		label = n.Label
+	}
+	return parse.NormalizeLabel(label)
+}
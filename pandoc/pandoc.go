@@ -0,0 +1,60 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package pandoc 实现了 mdast 语法树和 pandoc JSON AST https://pandoc.org/filters.html 之间的相互转换，
+// 配合 mdast 包即可在 Lute 语法树和 pandoc 之间交换文档（pandoc -f json / pandoc -t json）。
+//
+// 标准 Markdown 节点转换为对应的 pandoc 元素，Lute 特有的节点使用带属性的 Div 和 Span 表示：
+//
+//   - 超级块：Div，类名 super-block，属性 layout 为 row 或者 col
+//   - 提示块：Div，类名 callout 和小写的提示块类型，属性 callout-type、callout-icon 和 callout-icon-image，
+//     标题放在类名为 title 的子 Div 中，和 pandoc alerts 扩展的结构一致
+//   - 内容块引用：Span，类名 block-ref，属性 ref-id 为被引用块 ID，ref-subtype 为 s（静态锚文本）或者 d（动态锚文本）
+//   - 标签和标记：Span，类名分别为 tag 和 mark
+//   - 指令：Div 或者 Span，类名为 container-directive、leaf-directive 或者 text-directive 加上指令的 class 属性，
+//     属性 directive-name 为指令名称，容器指令的标签放在类名为 directive-label 的子 Div 中
+//   - 脚注引用：Span，类名 footnote-ref，属性 label 为脚注标签，唯一的子元素是包含脚注定义内容的 Note
+//   - 没有被引用的脚注定义：文档末尾的 Div，类名 footnote-def，属性 label 为脚注标签
+//   - 维基链接：标题为 wikilink 的 Link（嵌入时为 Image），和 pandoc wikilinks 扩展一致
+//   - 链接引用：Link 或者 Image，属性 reference 为引用标签，reference-type 为 mdast 的引用类型，
+//     定义的标签和引用标签不同时（比如大小写不同）属性 reference-definition 为定义的标签
+//   - YAML Front Matter 和其他没有对应元素的节点：format 为 markdown 的 RawBlock 或者 RawInline，读取时重新解析
+//
+// 块级节点的 Kramdown 内联属性列表（IAL）中的 id 作为元素的标识，其余属性作为元素的键值对属性。
+// 标题的标识为自定义标题 ID，IAL 全部作为键值对属性。没有属性的元素（比如段落和列表）外层包裹一个类名为 ial 的 Div，
+// 列表项包裹一个类名为 list-item 的 Div，非标准任务列表项标记符记录在属性 task-marker 中。文档的 IAL 记录在元数据 ial 中。
+package pandoc
+
+// APIVersion 是输出的 pandoc JSON AST 版本。
+var APIVersion = []int{1, 23, 1}
+
+// Document 描述了 pandoc 文档。
+type Document struct {
+	APIVersion []int               `json:"pandoc-api-version"`
+	Meta       map[string]*Element `json:"meta"`
+	Blocks     []*Element          `json:"blocks"`
+}
+
+// Element 描述了 pandoc 的块级元素、行级元素或者元数据值，T 为类型，C 为内容，没有内容的元素（比如 Space）省略 C。
+type Element struct {
+	T string      `json:"t"`
+	C interface{} `json:"c,omitempty"`
+}
+
+// attr 构造 pandoc 属性 [identifier, [classes], [[key, value]]]。
+func attr(id string, classes []string, kvs [][]string) []interface{} {
+	if nil == classes {
+		classes = []string{}
+	}
+	if nil == kvs {
+		kvs = [][]string{}
+	}
+	return []interface{}{id, classes, kvs}
+}
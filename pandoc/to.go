@@ -0,0 +1,743 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package pandoc
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/mdast"
+	"github.com/88250/lute/parse"
+)
+
+// ToMdast 将 JSON 格式的 pandoc 文档 data 转换为 mdast 语法树，元素映射规则见包说明。
+//
+// 脚注和链接引用的定义追加在文档末尾。没有对应 mdast 节点的元素（比如 Underline 和普通的 Div）只保留其内容，
+// 非 html 和 markdown 格式的 RawBlock 和 RawInline 会被忽略。
+func ToMdast(data []byte) (ret *mdast.Node, err error) {
+	doc := struct {
+		APIVersion []int                  `json:"pandoc-api-version"`
+		Meta       map[string]interface{} `json:"meta"`
+		Blocks     []interface{}          `json:"blocks"`
+	}{}
+	if err = json.Unmarshal(data, &doc); nil != err {
+		return
+	}
+	if 2 > len(doc.APIVersion) || 1 != doc.APIVersion[0] || 21 > doc.APIVersion[1] {
+		return nil, errors.New("unsupported pandoc api version [" + apiVersion(doc.APIVersion) + "]")
+	}
+
+	r := &reader{definitions: map[string]bool{}, footnoteLabels: map[string]bool{}, notes: map[string]string{}}
+	ret = &mdast.Node{Type: "root", Children: r.blocks(doc.Blocks)}
+	ret.Children = append(ret.Children, r.definitionNodes...)
+	ret.Children = append(ret.Children, r.footnoteNodes...)
+	if t, c := r.element(doc.Meta["ial"]); "MetaMap" == t {
+		var ial [][]string
+		for k, v := range r.object(c) {
+			switch vt, vc := r.element(v); vt {
+			case "MetaString":
+				ial = append(ial, []string{k, r.string(vc)})
+			case "MetaInlines":
+				ial = append(ial, []string{k, r.stringify(vc)})
+			}
+		}
+		sortIAL(ial)
+		setIAL(ret, ial)
+	}
+	if nil != r.err {
+		return nil, r.err
+	}
+	return
+}
+
+// reader 用于将 pandoc 文档转换为 mdast 语法树，转换过程中遇到的第一个错误记录在 err 中。
+type reader struct {
+	err             error
+	definitions     map[string]bool   // 已经生成的链接引用定义，键为规范化后的标签
+	definitionNodes []*mdast.Node     // 链接引用定义
+	footnoteLabels  map[string]bool   // 已经使用的脚注标签
+	footnoteNodes   []*mdast.Node     // 脚注定义
+	notes           map[string]string // 没有标签的脚注内容对应的标签，内容相同的脚注使用同一个定义
+}
+
+func (r *reader) fail(msg string) {
+	if nil == r.err {
+		r.err = errors.New(msg)
+	}
+}
+
+// blocks 转换块级元素列表 v。
+func (r *reader) blocks(v interface{}) (ret []*mdast.Node) {
+	for _, b := range r.array(v) {
+		ret = append(ret, r.block(b)...)
+	}
+	return
+}
+
+func (r *reader) block(v interface{}) []*mdast.Node {
+	t, c := r.element(v)
+	var ret *mdast.Node
+	switch t {
+	case "Plain", "Para":
+		inlines := r.array(c)
+		if 1 == len(inlines) {
+			if it, ic := r.element(inlines[0]); "Math" == it {
+				if mt, _ := r.element(r.index(ic, 0)); "DisplayMath" == mt {
+					return []*mdast.Node{{Type: "math", Value: r.string(r.index(ic, 1))}}
+				}
+			}
+		}
+		ret = &mdast.Node{Type: "paragraph", Children: r.inlines(c)}
+	case "LineBlock":
+		ret = &mdast.Node{Type: "paragraph"}
+		for i, line := range r.array(c) {
+			if 0 < i {
+				ret.Children = append(ret.Children, &mdast.Node{Type: "break"})
+			}
+			ret.Children = append(ret.Children, r.inlines(line)...)
+		}
+	case "Header":
+		id, _, kvs := r.attr(r.index(c, 1))
+		ret = &mdast.Node{Type: "heading", Depth: r.int(r.index(c, 0)), Children: r.inlines(r.index(c, 2))}
+		if 1 > ret.Depth || 6 < ret.Depth {
+			r.fail("invalid pandoc header level [" + strconv.Itoa(ret.Depth) + "]")
+		}
+		if "" != id {
+			ret.Data = map[string]interface{}{"id": id}
+		}
+		setIAL(ret, kvs)
+	case "CodeBlock":
+		id, classes, kvs := r.attr(r.index(c, 0))
+		ret = &mdast.Node{Type: "code", Value: r.string(r.index(c, 1))}
+		if 0 < len(classes) {
+			ret.Lang = &classes[0]
+		}
+		if meta, ok := takeKV(&kvs, "code-meta"); ok {
+			ret.Meta = &meta
+		}
+		setIAL(ret, withID(id, kvs))
+	case "RawBlock":
+		return r.raw(c, true)
+	case "BlockQuote":
+		ret = &mdast.Node{Type: "blockquote", Children: r.blocks(c)}
+	case "OrderedList":
+		ret = r.list(r.index(c, 1))
+		ordered, start := true, r.int(r.index(r.index(c, 0), 0))
+		ret.Ordered, ret.Start = &ordered, &start
+	case "BulletList":
+		ret = r.list(c)
+	case "DefinitionList":
+		// 定义列表转换为术语段落和定义内容
+		var nodes []*mdast.Node
+		for _, item := range r.array(c) {
+			nodes = append(nodes, &mdast.Node{Type: "paragraph", Children: []*mdast.Node{{Type: "strong", Children: r.inlines(r.index(item, 0))}}})
+			for _, def := range r.array(r.index(item, 1)) {
+				nodes = append(nodes, r.blocks(def)...)
+			}
+		}
+		return nodes
+	case "HorizontalRule":
+		ret = &mdast.Node{Type: "thematicBreak"}
+	case "Table":
+		ret = r.table(c)
+	case "Figure":
+		return r.blocks(r.index(c, 2))
+	case "Div":
+		return r.div(c)
+	case "Null":
+		return nil
+	default:
+		r.fail("unsupported pandoc block [t=" + t + "]")
+		return nil
+	}
+	return []*mdast.Node{ret}
+}
+
+// div 按照类名转换 Div，没有对应节点的 Div 只保留其内容。
+func (r *reader) div(c interface{}) (ret []*mdast.Node) {
+	id, classes, kvs := r.attr(r.index(c, 0))
+	blocks := r.array(r.index(c, 1))
+	var node *mdast.Node
+	switch {
+	case hasClass(classes, "ial"):
+		ret = r.blocks(blocks)
+		if 1 == len(ret) {
+			setIAL(ret[0], withID(id, kvs))
+		}
+		return
+	case hasClass(classes, "footnote-def"):
+		// 没有被引用的脚注定义
+		if label, _ := takeKV(&kvs, "label"); !r.footnoteLabels[label] {
+			r.footnoteDefinition(label, blocks)
+		}
+		return
+	case hasClass(classes, "super-block"):
+		node = &mdast.Node{Type: "superBlock", Children: r.blocks(blocks)}
+		layout, _ := takeKV(&kvs, "layout")
+		if "col" != layout {
+			layout = "row"
+		}
+		node.Data = map[string]interface{}{"layout": layout}
+	case hasClass(classes, "callout") || (0 < len(classes) && isAlert(classes[0]) && 0 < len(blocks) && r.isDivOf(blocks[0], "title")):
+		node = r.callout(classes, &kvs, blocks)
+	case hasClass(classes, "container-directive"), hasClass(classes, "leaf-directive"):
+		node = &mdast.Node{Type: "containerDirective", Name: directiveName(&kvs)}
+		if hasClass(classes, "leaf-directive") {
+			node.Type = "leafDirective"
+			for _, b := range r.blocks(blocks) {
+				node.Children = append(node.Children, b.Children...)
+			}
+		} else {
+			if 0 < len(blocks) && r.isDivOf(blocks[0], "directive-label") {
+				label := &mdast.Node{Type: "paragraph", Data: map[string]interface{}{"directiveLabel": true}}
+				for _, b := range r.blocks(r.index(r.element2(blocks[0]), 1)) {
+					label.Children = append(label.Children, b.Children...)
+				}
+				node.Children = append(node.Children, label)
+				blocks = blocks[1:]
+			}
+			node.Children = append(node.Children, r.blocks(blocks)...)
+		}
+		node.Attributes = directiveAttributes(id, withoutClass(classes, "container-directive", "leaf-directive"), kvs)
+		return []*mdast.Node{node}
+	default:
+		return r.blocks(blocks)
+	}
+	setIAL(node, withID(id, kvs))
+	return []*mdast.Node{node}
+}
+
+// callout 转换提示块，兼容 pandoc alerts 扩展输出的 Div。
+func (r *reader) callout(classes []string, kvs *[][]string, blocks []interface{}) (ret *mdast.Node) {
+	ret = &mdast.Node{Type: "callout", Data: map[string]interface{}{}}
+	typ, ok := takeKV(kvs, "callout-type")
+	if !ok {
+		for _, class := range classes {
+			if "callout" != class {
+				typ = strings.ToUpper(class)
+				break
+			}
+		}
+	}
+	ret.Data["calloutType"] = typ
+	if icon, ok := takeKV(kvs, "callout-icon"); ok {
+		ret.Data["icon"] = icon
+	}
+	if iconImage, ok := takeKV(kvs, "callout-icon-image"); ok && "true" == iconImage {
+		ret.Data["iconImage"] = true
+	}
+	if 0 < len(blocks) && r.isDivOf(blocks[0], "title") {
+		var title []string
+		for _, b := range r.array(r.index(r.element2(blocks[0]), 1)) {
+			_, bc := r.element(b)
+			title = append(title, r.stringify(bc))
+		}
+		ret.Data["title"] = strings.Join(title, " ")
+		blocks = blocks[1:]
+	}
+	ret.Children = r.blocks(blocks)
+	return
+}
+
+// list 转换列表项，包含 Para 的列表为松散列表。
+func (r *reader) list(items interface{}) (ret *mdast.Node) {
+	ordered, spread := false, false
+	ret = &mdast.Node{Type: "list", Ordered: &ordered, Spread: &spread}
+	for _, blocks := range r.array(items) {
+		item := &mdast.Node{Type: "listItem"}
+		itemBlocks := r.array(blocks)
+		if 1 == len(itemBlocks) && r.isDivOf(itemBlocks[0], "list-item") {
+			id, _, kvs := r.attr(r.index(r.element2(itemBlocks[0]), 0))
+			if taskMarker, ok := takeKV(&kvs, "task-marker"); ok {
+				item.Data = map[string]interface{}{"taskMarker": taskMarker}
+			}
+			setIAL(item, withID(id, kvs))
+			itemBlocks = r.array(r.index(r.element2(itemBlocks[0]), 1))
+		}
+		for _, b := range itemBlocks {
+			if t, _ := r.element(b); "Para" == t {
+				spread = true
+			}
+		}
+		item.Children = r.blocks(itemBlocks)
+		r.taskListItem(item)
+		ret.Children = append(ret.Children, item)
+	}
+	return
+}
+
+// taskListItem 识别列表项第一个段落开头的 ☐ 和 ☒。
+func (r *reader) taskListItem(item *mdast.Node) {
+	if 1 > len(item.Children) || "paragraph" != item.Children[0].Type || 1 > len(item.Children[0].Children) {
+		return
+	}
+	para := item.Children[0]
+	text := para.Children[0]
+	if "text" != text.Type {
+		return
+	}
+	var checked bool
+	switch {
+	case strings.HasPrefix(text.Value, "☐"):
+		text.Value = strings.TrimPrefix(text.Value, "☐")
+	case strings.HasPrefix(text.Value, "☒"):
+		text.Value = strings.TrimPrefix(text.Value, "☒")
+		checked = true
+	default:
+		return
+	}
+	item.Checked = &checked
+	text.Value = strings.TrimPrefix(text.Value, " ")
+	if "" == text.Value {
+		para.Children = para.Children[1:]
+	}
+	if 1 > len(para.Children) {
+		item.Children = item.Children[1:]
+	}
+}
+
+func (r *reader) table(c interface{}) (ret *mdast.Node) {
+	id, _, kvs := r.attr(r.index(c, 0))
+	ret = &mdast.Node{Type: "table"}
+	for _, spec := range r.array(r.index(c, 2)) {
+		var align *string
+		switch t, _ := r.element(r.index(spec, 0)); t {
+		case "AlignLeft":
+			align = stringPtr("left")
+		case "AlignCenter":
+			align = stringPtr("center")
+		case "AlignRight":
+			align = stringPtr("right")
+		}
+		ret.Align = append(ret.Align, align)
+	}
+
+	var rows []interface{}
+	rows = append(rows, r.array(r.index(r.index(c, 3), 1))...)
+	for _, body := range r.array(r.index(c, 4)) {
+		rows = append(rows, r.array(r.index(body, 2))...)
+		rows = append(rows, r.array(r.index(body, 3))...)
+	}
+	rows = append(rows, r.array(r.index(r.index(c, 5), 1))...)
+	for _, row := range rows {
+		tableRow := &mdast.Node{Type: "tableRow"}
+		for _, cell := range r.array(r.index(row, 1)) {
+			tableCell := &mdast.Node{Type: "tableCell"}
+			for i, b := range r.blocks(r.index(cell, 4)) {
+				if 0 < i {
+					tableCell.Children = append(tableCell.Children, &mdast.Node{Type: "text", Value: " "})
+				}
+				tableCell.Children = append(tableCell.Children, b.Children...)
+			}
+			tableRow.Children = append(tableRow.Children, tableCell)
+		}
+		ret.Children = append(ret.Children, tableRow)
+	}
+	setIAL(ret, withID(id, kvs))
+	return
+}
+
+// raw 转换 RawBlock 和 RawInline，markdown 格式的内容作为 luteNode 在转换为 Lute 语法树时重新解析。
+func (r *reader) raw(c interface{}, block bool) []*mdast.Node {
+	format, text := r.string(r.index(c, 0)), r.string(r.index(c, 1))
+	switch format {
+	case "html":
+		return []*mdast.Node{{Type: "html", Value: text}}
+	case "markdown":
+		return []*mdast.Node{{Type: "luteNode", Value: text, Data: map[string]interface{}{"block": block}}}
+	}
+	return nil
+}
+
+// inlines 转换行级元素列表 v，相邻的文本合并为一个文本节点。
+func (r *reader) inlines(v interface{}) (ret []*mdast.Node) {
+	for _, i := range r.array(v) {
+		for _, n := range r.inline(i) {
+			if last := len(ret) - 1; 0 <= last && "text" == n.Type && "text" == ret[last].Type {
+				ret[last].Value += n.Value
+				continue
+			}
+			ret = append(ret, n)
+		}
+	}
+	return
+}
+
+func (r *reader) inline(v interface{}) []*mdast.Node {
+	t, c := r.element(v)
+	var ret *mdast.Node
+	switch t {
+	case "Str":
+		ret = &mdast.Node{Type: "text", Value: r.string(c)}
+	case "Space":
+		ret = &mdast.Node{Type: "text", Value: " "}
+	case "SoftBreak":
+		ret = &mdast.Node{Type: "text", Value: "\n"}
+	case "LineBreak":
+		ret = &mdast.Node{Type: "break"}
+	case "Emph":
+		ret = &mdast.Node{Type: "emphasis", Children: r.inlines(c)}
+	case "Strong":
+		ret = &mdast.Node{Type: "strong", Children: r.inlines(c)}
+	case "Strikeout":
+		ret = &mdast.Node{Type: "delete", Children: r.inlines(c)}
+	case "Superscript":
+		ret = &mdast.Node{Type: "superscript", Children: r.inlines(c)}
+	case "Subscript":
+		ret = &mdast.Node{Type: "subscript", Children: r.inlines(c)}
+	case "Underline", "SmallCaps":
+		return r.inlines(c)
+	case "Quoted":
+		quote := "\""
+		if qt, _ := r.element(r.index(c, 0)); "SingleQuote" == qt {
+			quote = "'"
+		}
+		nodes := append([]*mdast.Node{{Type: "text", Value: quote}}, r.inlines(r.index(c, 1))...)
+		return append(nodes, &mdast.Node{Type: "text", Value: quote})
+	case "Cite":
+		return r.inlines(r.index(c, 1))
+	case "Code":
+		ret = &mdast.Node{Type: "inlineCode", Value: r.string(r.index(c, 1))}
+	case "Math":
+		ret = &mdast.Node{Type: "inlineMath", Value: r.string(r.index(c, 1))}
+	case "RawInline":
+		return r.raw(c, false)
+	case "Link", "Image":
+		ret = r.link(t, c)
+	case "Note":
+		ret = r.footnoteReference("", c)
+	case "Span":
+		return r.span(c)
+	default:
+		r.fail("unsupported pandoc inline [t=" + t + "]")
+		return nil
+	}
+	return []*mdast.Node{ret}
+}
+
+// span 按照类名转换 Span，没有对应节点的 Span 只保留其内容。
+func (r *reader) span(c interface{}) []*mdast.Node {
+	id, classes, kvs := r.attr(r.index(c, 0))
+	inlines := r.index(c, 1)
+	var ret *mdast.Node
+	switch {
+	case hasClass(classes, "mark"):
+		ret = &mdast.Node{Type: "mark", Children: r.inlines(inlines)}
+	case hasClass(classes, "tag"):
+		ret = &mdast.Node{Type: "tag", Children: r.inlines(inlines)}
+	case hasClass(classes, "block-ref"):
+		refID, _ := takeKV(&kvs, "ref-id")
+		subtype, _ := takeKV(&kvs, "ref-subtype")
+		ret = &mdast.Node{Type: "blockRef", Children: r.inlines(inlines), Data: map[string]interface{}{"id": refID}}
+		if "s" == subtype || "d" == subtype {
+			ret.Data["subtype"] = subtype
+		}
+	case hasClass(classes, "footnote-ref"):
+		if items := r.array(inlines); 1 == len(items) {
+			if t, nc := r.element(items[0]); "Note" == t {
+				label, _ := takeKV(&kvs, "label")
+				return []*mdast.Node{r.footnoteReference(label, nc)}
+			}
+		}
+		return r.inlines(inlines)
+	case hasClass(classes, "text-directive"):
+		ret = &mdast.Node{Type: "textDirective", Name: directiveName(&kvs), Children: r.inlines(inlines)}
+		ret.Attributes = directiveAttributes(id, withoutClass(classes, "text-directive"), kvs)
+	default:
+		return r.inlines(inlines)
+	}
+	return []*mdast.Node{ret}
+}
+
+// link 转换链接和图片，标题为 wikilink 的转换为维基链接，记录了引用标签的转换为链接引用。
+func (r *reader) link(t string, c interface{}) (ret *mdast.Node) {
+	_, _, kvs := r.attr(r.index(c, 0))
+	target := r.index(c, 2)
+	url, title := r.string(r.index(target, 0)), r.string(r.index(target, 1))
+	image := "Image" == t
+	if "wikilink" == title {
+		ret = &mdast.Node{Type: "wikiLink", Data: map[string]interface{}{}}
+		ret.Value = url
+		if idx := strings.Index(url, "#"); 0 <= idx {
+			ret.Value = url[:idx]
+			ret.Data["heading"] = url[idx+1:]
+		}
+		if text := r.stringify(r.index(c, 1)); text != url {
+			ret.Data["alias"] = text
+		}
+		if image {
+			ret.Data["embed"] = true
+		}
+		return
+	}
+
+	if label, ok := takeKV(&kvs, "reference"); ok {
+		referenceType, _ := takeKV(&kvs, "reference-type")
+		if "full" != referenceType && "collapsed" != referenceType {
+			referenceType = "shortcut"
+		}
+		ret = &mdast.Node{Type: "linkReference", Label: label, ReferenceType: referenceType, Children: r.inlines(r.index(c, 1))}
+		if image {
+			ret = &mdast.Node{Type: "imageReference", Label: label, ReferenceType: referenceType, Alt: stringPtr(r.stringify(r.index(c, 1)))}
+		}
+		if id := parse.NormalizeLabel(label); !r.definitions[id] {
+			r.definitions[id] = true
+			if defLabel, ok := takeKV(&kvs, "reference-definition"); ok {
+				label = defLabel
+			}
+			def := &mdast.Node{Type: "definition", Label: label, URL: url}
+			if "" != title {
+				def.Title = stringPtr(title)
+			}
+			r.definitionNodes = append(r.definitionNodes, def)
+		}
+		return
+	}
+
+	ret = &mdast.Node{Type: "link", URL: url, Children: r.inlines(r.index(c, 1))}
+	if image {
+		ret = &mdast.Node{Type: "image", URL: url, Alt: stringPtr(r.stringify(r.index(c, 1)))}
+	}
+	if "" != title {
+		ret.Title = stringPtr(title)
+	}
+	return
+}
+
+// footnoteReference 转换脚注，label 为空时按顺序生成标签，内容相同的脚注使用同一个定义。
+func (r *reader) footnoteReference(label string, blocks interface{}) *mdast.Node {
+	if "" == label {
+		data, _ := json.Marshal(blocks)
+		if label = r.notes[string(data)]; "" == label {
+			for n := len(r.notes) + 1; "" == label || r.footnoteLabels[label]; n++ {
+				label = strconv.Itoa(n)
+			}
+			r.notes[string(data)] = label
+		}
+	}
+	if !r.footnoteLabels[label] {
+		r.footnoteDefinition(label, blocks)
+	}
+	return &mdast.Node{Type: "footnoteReference", Label: label}
+}
+
+// footnoteDefinition 生成脚注定义，先追加定义再转换内容，保证嵌套引用的脚注定义排在后面。
+func (r *reader) footnoteDefinition(label string, blocks interface{}) {
+	r.footnoteLabels[label] = true
+	def := &mdast.Node{Type: "footnoteDefinition", Label: label}
+	r.footnoteNodes = append(r.footnoteNodes, def)
+	def.Children = r.blocks(blocks)
+}
+
+// element 返回元素 v 的类型和内容。
+func (r *reader) element(v interface{}) (t string, c interface{}) {
+	if nil == v {
+		return
+	}
+	e, ok := v.(map[string]interface{})
+	if !ok {
+		r.fail("invalid pandoc element")
+		return
+	}
+	t, _ = e["t"].(string)
+	return t, e["c"]
+}
+
+// element2 返回元素 v 的内容。
+func (r *reader) element2(v interface{}) interface{} {
+	_, c := r.element(v)
+	return c
+}
+
+// isDivOf 判断元素 v 是否是类名包含 class 的 Div。
+func (r *reader) isDivOf(v interface{}, class string) bool {
+	t, c := r.element(v)
+	if "Div" != t {
+		return false
+	}
+	_, classes, _ := r.attr(r.index(c, 0))
+	return hasClass(classes, class)
+}
+
+// attr 解析 pandoc 属性 [identifier, [classes], [[key, value]]]。
+func (r *reader) attr(v interface{}) (id string, classes []string, kvs [][]string) {
+	id = r.string(r.index(v, 0))
+	for _, class := range r.array(r.index(v, 1)) {
+		classes = append(classes, r.string(class))
+	}
+	for _, kv := range r.array(r.index(v, 2)) {
+		kvs = append(kvs, []string{r.string(r.index(kv, 0)), r.string(r.index(kv, 1))})
+	}
+	return
+}
+
+func (r *reader) array(v interface{}) []interface{} {
+	if nil == v {
+		return nil
+	}
+	ret, ok := v.([]interface{})
+	if !ok {
+		r.fail("invalid pandoc array")
+	}
+	return ret
+}
+
+func (r *reader) object(v interface{}) map[string]interface{} {
+	ret, ok := v.(map[string]interface{})
+	if !ok {
+		r.fail("invalid pandoc object")
+	}
+	return ret
+}
+
+func (r *reader) index(v interface{}, i int) interface{} {
+	arr := r.array(v)
+	if i >= len(arr) {
+		r.fail("invalid pandoc element content")
+		return nil
+	}
+	return arr[i]
+}
+
+func (r *reader) string(v interface{}) string {
+	ret, ok := v.(string)
+	if !ok && nil != v {
+		r.fail("invalid pandoc string")
+	}
+	return ret
+}
+
+func (r *reader) int(v interface{}) int {
+	ret, ok := v.(float64)
+	if !ok {
+		r.fail("invalid pandoc number")
+	}
+	return int(ret)
+}
+
+// stringify 返回行级元素列表 v 的纯文本。
+func (r *reader) stringify(v interface{}) string {
+	buf := &strings.Builder{}
+	var walk func(nodes []*mdast.Node)
+	walk = func(nodes []*mdast.Node) {
+		for _, n := range nodes {
+			if "text" == n.Type || "inlineCode" == n.Type || "inlineMath" == n.Type {
+				buf.WriteString(n.Value)
+			}
+			walk(n.Children)
+		}
+	}
+	walk(r.inlines(v))
+	return buf.String()
+}
+
+// takeKV 从键值对属性 kvs 中取出 key 对应的值。
+func takeKV(kvs *[][]string, key string) (value string, ok bool) {
+	for i, kv := range *kvs {
+		if key == kv[0] {
+			*kvs = append((*kvs)[:i:i], (*kvs)[i+1:]...)
+			return kv[1], true
+		}
+	}
+	return
+}
+
+func withID(id string, kvs [][]string) [][]string {
+	if "" == id {
+		return kvs
+	}
+	return append([][]string{{"id", id}}, kvs...)
+}
+
+func setIAL(n *mdast.Node, ial [][]string) {
+	if 1 > len(ial) {
+		return
+	}
+	if nil == n.Data {
+		n.Data = map[string]interface{}{}
+	}
+	n.Data["ial"] = ial
+}
+
+// sortIAL 排序从元数据中读取的 IAL，元数据是无序的，按照键排序以保证输出稳定，id 排在最前面。
+func sortIAL(ial [][]string) {
+	sort.Slice(ial, func(i, j int) bool {
+		if "id" == ial[i][0] || "id" == ial[j][0] {
+			return "id" == ial[i][0]
+		}
+		return ial[i][0] < ial[j][0]
+	})
+}
+
+func hasClass(classes []string, class string) bool {
+	for _, c := range classes {
+		if class == c {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutClass 返回去掉 excludes 后的类名。
+func withoutClass(classes []string, excludes ...string) (ret []string) {
+	for _, c := range classes {
+		if !hasClass(excludes, c) {
+			ret = append(ret, c)
+		}
+	}
+	return
+}
+
+// isAlert 判断类名是否是 pandoc alerts 扩展的提示块类型。
+func isAlert(class string) bool {
+	switch class {
+	case "note", "tip", "important", "warning", "caution":
+		return true
+	}
+	return false
+}
+
+func directiveName(kvs *[][]string) string {
+	name, _ := takeKV(kvs, "directive-name")
+	return name
+}
+
+func directiveAttributes(id string, classes []string, kvs [][]string) (ret map[string]string) {
+	ret = map[string]string{}
+	if "" != id {
+		ret["id"] = id
+	}
+	if 0 < len(classes) {
+		ret["class"] = strings.Join(classes, " ")
+	}
+	for _, kv := range kvs {
+		ret[kv[0]] = kv[1]
+	}
+	if 1 > len(ret) {
+		ret = nil
+	}
+	return
+}
+
+func apiVersion(version []int) string {
+	var ret []string
+	for _, v := range version {
+		ret = append(ret, strconv.Itoa(v))
+	}
+	return strings.Join(ret, ".")
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,646 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/88250/lute/ast"
+)
+
+// BinaryVersion 是二进制格式语法树的版本号，格式变化时需要递增，解码时拒绝其他版本。
+//
+// 版本 1 的格式如下，其中 uvarint 和 varint 分别为 encoding/binary 的无符号和有符号变长整数：
+//
+//   - 头部：魔数 "LUTE"，uvarint 版本号
+//   - 字符串表：uvarint 字符串个数，每个字符串为 uvarint 长度加上字节，之后所有字符串都使用 uvarint 表示的下标引用
+//   - 语法树字段：Name、ID、Box、Path、HPath、Marks、Created、Updated、Hash、LineEnding、BOM
+//   - 节点：先序遍历，每个节点为 uvarint 节点类型、uvarint 字段掩码、掩码中各个字段的值以及 uvarint 子节点个数
+//
+// 字段掩码的第 i 位表示 binaryFields 中第 i 个字段有值，布尔字段的值就是该位，其余字段的值为：
+// 整数为 varint，字节为单个字节，字符串为字符串表下标，字节切片（比如 Tokens）为 uvarint 长度加上字节，
+// KramdownIAL、DirectiveAttrs 和 Properties（按键排序）为 uvarint 键值对个数加上键值字符串下标，
+// 脚注定义的 FootnotesRefs 为 uvarint 个数加上引用节点的先序下标，不在语法树中的脚注引用不编码。
+const BinaryVersion = 1
+
+// binaryMagic 是二进制格式语法树的魔数。
+var binaryMagic = []byte("LUTE")
+
+// Tree2Binary 将语法树 tree 编码为二进制格式，格式见 BinaryVersion。
+//
+// 节点的父子和兄弟关系通过先序遍历和子节点个数记录，仅用于 JSON 的 Children、TypeStr 和 Data 字段不编码。
+func Tree2Binary(tree *Tree) []byte {
+	e := &binaryEncoder{strs: map[string]uint64{}, nodes: map[*ast.Node]uint64{}}
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			e.nodes[n] = uint64(len(e.nodes))
+		}
+		return ast.WalkContinue
+	})
+
+	body := &e.body
+	for _, s := range []string{tree.Name, tree.ID, tree.Box, tree.Path, tree.HPath} {
+		e.str(body, s)
+	}
+	e.uvarint(body, uint64(len(tree.Marks)))
+	for _, mark := range tree.Marks {
+		e.str(body, mark)
+	}
+	e.varint(body, tree.Created)
+	e.varint(body, tree.Updated)
+	e.str(body, tree.Hash)
+	e.str(body, tree.LineEnding)
+	if tree.BOM {
+		body.WriteByte(1)
+	} else {
+		body.WriteByte(0)
+	}
+	e.node(tree.Root)
+
+	ret := &bytes.Buffer{}
+	ret.Write(binaryMagic)
+	e.uvarint(ret, BinaryVersion)
+	e.uvarint(ret, uint64(len(e.strList)))
+	for _, s := range e.strList {
+		e.uvarint(ret, uint64(len(s)))
+		ret.WriteString(s)
+	}
+	ret.Write(body.Bytes())
+	return ret.Bytes()
+}
+
+// Binary2Tree 将 Tree2Binary 编码的二进制数据 data 解码为语法树。
+//
+// 数据不完整、版本不一致或者内容非法时返回错误，不会引发 panic。
+func Binary2Tree(data []byte, options *Options) (ret *Tree, err error) {
+	d := &binaryDecoder{data: data}
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return nil, errors.New("invalid binary tree magic")
+	}
+	d.pos = len(binaryMagic)
+	if version := d.uvarint(); nil == d.err && BinaryVersion != version {
+		return nil, errors.New("unsupported binary tree version [" + strconv.FormatUint(version, 10) + "]")
+	}
+	count := d.count()
+	for i := 0; i < count && nil == d.err; i++ {
+		d.strs = append(d.strs, string(d.bytes()))
+	}
+
+	ret = &Tree{Context: &Context{ParseOption: options}}
+	ret.Context.Tree = ret
+	ret.Name, ret.ID, ret.Box, ret.Path, ret.HPath = d.str(), d.str(), d.str(), d.str(), d.str()
+	count = d.count()
+	for i := 0; i < count && nil == d.err; i++ {
+		ret.Marks = append(ret.Marks, d.str())
+	}
+	ret.Created, ret.Updated = d.varint(), d.varint()
+	ret.Hash, ret.LineEnding = d.str(), d.str()
+	ret.BOM = 1 == d.byte()
+	ret.Root = d.nodes()
+	if nil == d.err && d.pos != len(d.data) {
+		d.fail("trailing data")
+	}
+	if nil != d.err {
+		return nil, d.err
+	}
+	if ast.NodeDocument != ret.Root.Type {
+		return nil, errors.New("binary tree root node must be a document node")
+	}
+	return
+}
+
+// binaryEncoder 用于编码二进制格式语法树。
+type binaryEncoder struct {
+	strs    map[string]uint64    // 字符串到字符串表下标的映射
+	strList []string             // 字符串表
+	nodes   map[*ast.Node]uint64 // 节点到先序下标的映射
+	body    bytes.Buffer         // 字符串表之后的内容
+	fields  bytes.Buffer         // 当前节点的字段值
+}
+
+func (e *binaryEncoder) node(n *ast.Node) {
+	e.fields.Reset()
+	var mask uint64
+	for i, field := range binaryFields {
+		if field.encode(e, n) {
+			mask |= 1 << uint(i)
+		}
+	}
+	e.uvarint(&e.body, uint64(n.Type))
+	e.uvarint(&e.body, mask)
+	e.body.Write(e.fields.Bytes())
+
+	var children uint64
+	for c := n.FirstChild; nil != c; c = c.Next {
+		children++
+	}
+	e.uvarint(&e.body, children)
+	for c := n.FirstChild; nil != c; c = c.Next {
+		e.node(c)
+	}
+}
+
+func (e *binaryEncoder) uvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (e *binaryEncoder) varint(buf *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (e *binaryEncoder) str(buf *bytes.Buffer, s string) {
+	idx, ok := e.strs[s]
+	if !ok {
+		idx = uint64(len(e.strList))
+		e.strs[s] = idx
+		e.strList = append(e.strList, s)
+	}
+	e.uvarint(buf, idx)
+}
+
+func (e *binaryEncoder) strPairs(pairs [][]string) {
+	e.uvarint(&e.fields, uint64(len(pairs)))
+	for _, pair := range pairs {
+		var name, value string
+		if 0 < len(pair) {
+			name = pair[0]
+		}
+		if 1 < len(pair) {
+			value = pair[1]
+		}
+		e.str(&e.fields, name)
+		e.str(&e.fields, value)
+	}
+}
+
+// binaryDecoder 用于解码二进制格式语法树，遇到错误后记录第一个错误，后续读取均返回零值。
+type binaryDecoder struct {
+	data []byte
+	pos  int
+	strs []string
+	err  error
+
+	all  []*ast.Node // 按先序排列的已解码节点
+	defs []*ast.Node // 引用了脚注引用的脚注定义
+	refs [][]uint64  // 脚注定义引用的脚注引用节点先序下标，和 defs 一一对应
+}
+
+// binaryDecodingNode 描述了解码过程中尚未读完子节点的节点。
+type binaryDecodingNode struct {
+	node     *ast.Node
+	children int
+}
+
+// nodes 以非递归的方式解码节点，避免恶意构造的深层嵌套数据导致栈溢出。
+func (d *binaryDecoder) nodes() (root *ast.Node) {
+	var stack []*binaryDecodingNode
+	for nil == d.err {
+		n := d.node()
+		if nil != d.err {
+			return
+		}
+		if 0 < len(stack) {
+			parent := stack[len(stack)-1]
+			parent.node.AppendChild(n)
+			if parent.children--; 0 == parent.children {
+				stack = stack[:len(stack)-1]
+			}
+		} else {
+			root = n
+		}
+		if children := d.count(); 0 < children {
+			stack = append(stack, &binaryDecodingNode{node: n, children: children})
+		}
+		if 1 > len(stack) {
+			break
+		}
+	}
+	if nil != d.err {
+		return
+	}
+
+	for i, def := range d.defs {
+		for _, idx := range d.refs[i] {
+			if uint64(len(d.all)) <= idx || ast.NodeFootnotesRef != d.all[idx].Type {
+				d.fail("invalid footnotes ref")
+				return
+			}
+			def.FootnotesRefs = append(def.FootnotesRefs, d.all[idx])
+		}
+	}
+	return
+}
+
+// node 解码一个节点的类型和字段。
+func (d *binaryDecoder) node() (ret *ast.Node) {
+	typ := d.uvarint()
+	mask := d.uvarint()
+	if nil != d.err {
+		return
+	}
+	ret = &ast.Node{Type: ast.NodeType(typ)}
+	if uint64(ast.NodeTypeMaxVal) <= typ || ret.Type != ast.Str2NodeType(ret.Type.String()) {
+		d.fail("unknown node type [" + strconv.FormatUint(typ, 10) + "]")
+		return
+	}
+	if 0 != mask>>uint(len(binaryFields)) {
+		d.fail("unknown node fields")
+		return
+	}
+	for i, field := range binaryFields {
+		if 0 != mask&(1<<uint(i)) {
+			field.decode(d, ret)
+		}
+	}
+	d.all = append(d.all, ret)
+	return
+}
+
+func (d *binaryDecoder) fail(msg string) {
+	if nil == d.err {
+		d.err = errors.New("malformed binary tree: " + msg + " at offset " + strconv.Itoa(d.pos))
+	}
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if nil != d.err {
+		return 0
+	}
+	ret, n := binary.Uvarint(d.data[d.pos:])
+	if 0 >= n {
+		d.fail("invalid uvarint")
+		return 0
+	}
+	d.pos += n
+	return ret
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if nil != d.err {
+		return 0
+	}
+	ret, n := binary.Varint(d.data[d.pos:])
+	if 0 >= n {
+		d.fail("invalid varint")
+		return 0
+	}
+	d.pos += n
+	return ret
+}
+
+func (d *binaryDecoder) int() int {
+	ret := d.varint()
+	if int64(int(ret)) != ret {
+		d.fail("int overflow")
+		return 0
+	}
+	return int(ret)
+}
+
+func (d *binaryDecoder) byte() byte {
+	if nil != d.err {
+		return 0
+	}
+	if len(d.data) <= d.pos {
+		d.fail("unexpected end")
+		return 0
+	}
+	d.pos++
+	return d.data[d.pos-1]
+}
+
+// count 读取元素个数，每个元素至少占用一个字节，所以个数不能超过剩余的字节数。
+func (d *binaryDecoder) count() int {
+	ret := d.uvarint()
+	if uint64(len(d.data)-d.pos) < ret {
+		d.fail("invalid count")
+		return 0
+	}
+	return int(ret)
+}
+
+func (d *binaryDecoder) bytes() []byte {
+	length := d.uvarint()
+	if nil != d.err {
+		return nil
+	}
+	if uint64(len(d.data)-d.pos) < length {
+		d.fail("unexpected end")
+		return nil
+	}
+	ret := make([]byte, length)
+	copy(ret, d.data[d.pos:])
+	d.pos += int(length)
+	return ret
+}
+
+func (d *binaryDecoder) str() string {
+	idx := d.uvarint()
+	if nil != d.err {
+		return ""
+	}
+	if uint64(len(d.strs)) <= idx {
+		d.fail("invalid string index")
+		return ""
+	}
+	return d.strs[idx]
+}
+
+func (d *binaryDecoder) strPairs() (ret [][]string) {
+	count := d.count()
+	for i := 0; i < count && nil == d.err; i++ {
+		ret = append(ret, []string{d.str(), d.str()})
+	}
+	return
+}
+
+// binaryField 描述了节点的一个可编码字段，encode 在字段有值时写入字段值并返回 true。
+type binaryField struct {
+	encode func(e *binaryEncoder, n *ast.Node) bool
+	decode func(d *binaryDecoder, n *ast.Node)
+}
+
+func binaryBool(field func(n *ast.Node) *bool) binaryField {
+	return binaryField{
+		encode: func(e *binaryEncoder, n *ast.Node) bool { return *field(n) },
+		decode: func(d *binaryDecoder, n *ast.Node) { *field(n) = true },
+	}
+}
+
+func binaryInt(field func(n *ast.Node) *int) binaryField {
+	return binaryField{
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if v := *field(n); 0 != v {
+				e.varint(&e.fields, int64(v))
+				return true
+			}
+			return false
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) { *field(n) = d.int() },
+	}
+}
+
+func binaryByte(field func(n *ast.Node) *byte) binaryField {
+	return binaryField{
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if v := *field(n); 0 != v {
+				e.fields.WriteByte(v)
+				return true
+			}
+			return false
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) { *field(n) = d.byte() },
+	}
+}
+
+func binaryStr(field func(n *ast.Node) *string) binaryField {
+	return binaryField{
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if v := *field(n); "" != v {
+				e.str(&e.fields, v)
+				return true
+			}
+			return false
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) { *field(n) = d.str() },
+	}
+}
+
+func binaryBytes(field func(n *ast.Node) *[]byte) binaryField {
+	return binaryField{
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if v := *field(n); nil != v {
+				e.uvarint(&e.fields, uint64(len(v)))
+				e.fields.Write(v)
+				return true
+			}
+			return false
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) { *field(n) = d.bytes() },
+	}
+}
+
+// binaryFields 是节点的可编码字段，下标即字段掩码中的位，只能在末尾追加，调整已有字段时需要递增 BinaryVersion。
+var binaryFields = []binaryField{
+	binaryStr(func(n *ast.Node) *string { return &n.ID }),
+	binaryStr(func(n *ast.Node) *string { return &n.Box }),
+	binaryStr(func(n *ast.Node) *string { return &n.Path }),
+	binaryStr(func(n *ast.Node) *string { return &n.Spec }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.Tokens }),
+	binaryBool(func(n *ast.Node) *bool { return &n.Close }),
+	binaryBool(func(n *ast.Node) *bool { return &n.LastLineBlank }),
+	binaryBool(func(n *ast.Node) *bool { return &n.LastLineChecked }),
+	{ // Pos
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if nil == n.Pos {
+				return false
+			}
+			for _, v := range []int{n.Pos.StartOffset, n.Pos.EndOffset, n.Pos.StartLine, n.Pos.StartColumn, n.Pos.EndLine, n.Pos.EndColumn} {
+				e.varint(&e.fields, int64(v))
+			}
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			n.Pos = &ast.Pos{StartOffset: d.int(), EndOffset: d.int(), StartLine: d.int(), StartColumn: d.int(), EndLine: d.int(), EndColumn: d.int()}
+		},
+	},
+	binaryInt(func(n *ast.Node) *int { return &n.CodeMarkerLen }),
+	binaryBool(func(n *ast.Node) *bool { return &n.IsFencedCodeBlock }),
+	binaryByte(func(n *ast.Node) *byte { return &n.CodeBlockFenceChar }),
+	binaryInt(func(n *ast.Node) *int { return &n.CodeBlockFenceLen }),
+	binaryInt(func(n *ast.Node) *int { return &n.CodeBlockFenceOffset }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.CodeBlockOpenFence }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.CodeBlockInfo }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.CodeBlockCloseFence }),
+	binaryInt(func(n *ast.Node) *int { return &n.HtmlBlockType }),
+	{ // ListData
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			l := n.ListData
+			if nil == l {
+				return false
+			}
+			e.varint(&e.fields, int64(l.Typ))
+			var flags byte
+			if l.Tight {
+				flags |= 1
+			}
+			if l.Checked {
+				flags |= 2
+			}
+			if nil != l.Marker {
+				flags |= 4
+			}
+			e.fields.Write([]byte{flags, l.BulletChar, l.Delimiter})
+			e.varint(&e.fields, int64(l.Start))
+			e.varint(&e.fields, int64(l.Padding))
+			e.varint(&e.fields, int64(l.MarkerOffset))
+			e.varint(&e.fields, int64(l.Num))
+			if nil != l.Marker {
+				e.uvarint(&e.fields, uint64(len(l.Marker)))
+				e.fields.Write(l.Marker)
+			}
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			l := &ast.ListData{Typ: d.int()}
+			flags := d.byte()
+			l.Tight, l.Checked = 0 != flags&1, 0 != flags&2
+			l.BulletChar, l.Delimiter = d.byte(), d.byte()
+			l.Start, l.Padding, l.MarkerOffset, l.Num = d.int(), d.int(), d.int(), d.int()
+			if 0 != flags&4 {
+				l.Marker = d.bytes()
+			}
+			n.ListData = l
+		},
+	},
+	binaryBool(func(n *ast.Node) *bool { return &n.TaskListItemChecked }),
+	binaryByte(func(n *ast.Node) *byte { return &n.TaskListItemMarker }),
+	{ // TableAligns
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if nil == n.TableAligns {
+				return false
+			}
+			e.uvarint(&e.fields, uint64(len(n.TableAligns)))
+			for _, align := range n.TableAligns {
+				e.varint(&e.fields, int64(align))
+			}
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			count := d.count()
+			n.TableAligns = make([]int, 0, count)
+			for i := 0; i < count && nil == d.err; i++ {
+				n.TableAligns = append(n.TableAligns, d.int())
+			}
+		},
+	},
+	binaryInt(func(n *ast.Node) *int { return &n.TableCellAlign }),
+	binaryInt(func(n *ast.Node) *int { return &n.TableCellContentWidth }),
+	binaryInt(func(n *ast.Node) *int { return &n.TableCellContentMaxWidth }),
+	binaryInt(func(n *ast.Node) *int { return &n.LinkType }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.LinkRefLabel }),
+	binaryInt(func(n *ast.Node) *int { return &n.HeadingLevel }),
+	binaryBool(func(n *ast.Node) *bool { return &n.HeadingSetext }),
+	binaryStr(func(n *ast.Node) *string { return &n.HeadingNormalizedID }),
+	binaryInt(func(n *ast.Node) *int { return &n.MathBlockDollarOffset }),
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.FootnotesRefLabel }),
+	binaryStr(func(n *ast.Node) *string { return &n.FootnotesRefId }),
+	{ // FootnotesRefs，解码完所有节点后再关联
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			var refs []uint64
+			for _, ref := range n.FootnotesRefs {
+				if idx, ok := e.nodes[ref]; ok {
+					refs = append(refs, idx)
+				}
+			}
+			if 1 > len(refs) {
+				return false
+			}
+			e.uvarint(&e.fields, uint64(len(refs)))
+			for _, idx := range refs {
+				e.uvarint(&e.fields, idx)
+			}
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			var refs []uint64
+			count := d.count()
+			for i := 0; i < count && nil == d.err; i++ {
+				refs = append(refs, d.uvarint())
+			}
+			d.defs = append(d.defs, n)
+			d.refs = append(d.refs, refs)
+		},
+	},
+	binaryBytes(func(n *ast.Node) *[]byte { return &n.HtmlEntityTokens }),
+	{ // KramdownIAL
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if nil == n.KramdownIAL {
+				return false
+			}
+			e.strPairs(n.KramdownIAL)
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			if n.KramdownIAL = d.strPairs(); nil == n.KramdownIAL {
+				n.KramdownIAL = [][]string{}
+			}
+		},
+	},
+	{ // Properties
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if nil == n.Properties {
+				return false
+			}
+			var names []string
+			for name := range n.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var pairs [][]string
+			for _, name := range names {
+				pairs = append(pairs, []string{name, n.Properties[name]})
+			}
+			e.strPairs(pairs)
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			n.Properties = map[string]string{}
+			for _, pair := range d.strPairs() {
+				n.Properties[pair[0]] = pair[1]
+			}
+		},
+	},
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkType }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkAHref }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkATitle }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkInlineMathContent }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkInlineMemoContent }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkBlockRefID }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkBlockRefSubtype }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkFileAnnotationRefID }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkFlashcardOcclusionID }),
+	binaryStr(func(n *ast.Node) *string { return &n.TextMarkTextContent }),
+	binaryStr(func(n *ast.Node) *string { return &n.AttributeViewID }),
+	binaryStr(func(n *ast.Node) *string { return &n.AttributeViewType }),
+	binaryInt(func(n *ast.Node) *int { return &n.CustomBlockFenceOffset }),
+	binaryStr(func(n *ast.Node) *string { return &n.CustomBlockInfo }),
+	binaryStr(func(n *ast.Node) *string { return &n.CalloutType }),
+	binaryStr(func(n *ast.Node) *string { return &n.CalloutTitle }),
+	binaryStr(func(n *ast.Node) *string { return &n.CalloutIcon }),
+	binaryInt(func(n *ast.Node) *int { return &n.CalloutIconType }),
+	binaryStr(func(n *ast.Node) *string { return &n.DirectiveName }),
+	binaryStr(func(n *ast.Node) *string { return &n.DirectiveLabel }),
+	{ // DirectiveAttrs
+		encode: func(e *binaryEncoder, n *ast.Node) bool {
+			if nil == n.DirectiveAttrs {
+				return false
+			}
+			e.strPairs(n.DirectiveAttrs)
+			return true
+		},
+		decode: func(d *binaryDecoder, n *ast.Node) {
+			if n.DirectiveAttrs = d.strPairs(); nil == n.DirectiveAttrs {
+				n.DirectiveAttrs = [][]string{}
+			}
+		},
+	},
+	binaryInt(func(n *ast.Node) *int { return &n.DirectiveFenceLen }),
+	binaryStr(func(n *ast.Node) *string { return &n.WikiLinkTarget }),
+	binaryStr(func(n *ast.Node) *string { return &n.WikiLinkHeading }),
+	binaryStr(func(n *ast.Node) *string { return &n.WikiLinkAlias }),
+}
@@ -35,6 +35,7 @@ func BlockQueryEmbedStart(t *Tree, container *ast.Node) int {
 	}
 	t.Context.Tip.AppendChild(node)
 	t.Context.Tip = node
+	t.Context.startPos(node, t.Context.nextNonspace)
 	return 2
 }
 
@@ -23,6 +23,7 @@ func init() {
 	blockStartsFuncs = []blockStartFunc{
 		GitConflictStart,
 		CalloutStart,
+		DirectiveStart,
 		BlockquoteStart,
 		ATXHeadingStart,
 		FenceCodeBlockStart,
@@ -41,11 +42,6 @@ func init() {
 	}
 }
 
-// blockStarts 返回块起始模式函数切片。
-func blockStarts() []blockStartFunc {
-	return blockStartsFuncs
-}
-
 // blockStartFunc 定义了用于判断块是否开始的函数签名，返回值：
 //
 //	0：不匹配
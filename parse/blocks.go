@@ -38,6 +38,12 @@ func (t *Tree) parseBlocks() {
 			}
 		}
 
+		if t.Context.ParseOption.SourcePos {
+			t.Context.beginLine(line, t.lexer.LineOffset())
+		}
+		if t.Context.limited {
+			t.checkCancel()
+		}
 		t.incorporateLine(line)
 		lines++
 	}
@@ -103,8 +109,10 @@ func (t *Tree) incorporateLine(line []byte) {
 			allMatched = false
 			break
 		case 2: // 匹配围栏代码块闭合，处理下一行
+			t.Context.touchPos(container)
 			return
 		case 3: // 匹配超级块闭合，处理下一行
+			t.Context.touchPos(container)
 			t.Context.closeSuperBlockChildren() // 闭合超级块下的子节点
 			if ast.NodeSuperBlock != t.Context.Tip.Type {
 				sb := t.Context.Tip.Parent
@@ -131,7 +139,7 @@ func (t *Tree) incorporateLine(line []byte) {
 	t.Context.lastMatchedContainer = container
 
 	matchedLeaf := container.Type != ast.NodeParagraph && container.AcceptLines()
-	blockParsers := blockStarts()
+	blockParsers := t.blockStarts()
 	startsLen := len(blockParsers)
 
 	// 除非最后一个匹配到的是代码块，否则的话就起始一个新的块级节点
@@ -155,7 +163,9 @@ func (t *Tree) incorporateLine(line []byte) {
 			lex.ItemOpenBrace != maybeMarker && // kramdown 内联属性列表或超级块开始
 			lex.ItemCloseBrace != maybeMarker && // 超级块闭合
 			lex.ItemBang != maybeMarker && "！"[0] != maybeMarker && // 内容块嵌入
-			editor.Caret[0] != maybeMarker { // Vditor 编辑器支持
+			editor.Caret[0] != maybeMarker && // Vditor 编辑器支持
+			lex.ItemColon != maybeMarker && // 指令
+			(1 > len(t.Context.ParseOption.BlockSyntaxes) || !t.Context.maybeSyntaxMarker(maybeMarker)) { // 块级语法扩展
 			t.Context.advanceNextNonspace()
 			break
 		}
@@ -202,6 +212,7 @@ func (t *Tree) incorporateLine(line []byte) {
 		lastLineBlank := t.Context.blank &&
 			!(typ == ast.NodeFootnotesDef ||
 				typ == ast.NodeBlockquote || typ == ast.NodeCallout || // 引述、提示块肯定不会是空行因为至少有一个 >
+				(typ == ast.NodeContainerDirective) || // 容器指令不计入空行判断
 				(typ == ast.NodeCodeBlock && isFenced) || // 围栏代码块不计入空行判断
 				(typ == ast.NodeCustomBlock) || // 自定义块不计入空行判断
 				(typ == ast.NodeMathBlock) || // 数学公式块不计入空行判断
@@ -270,16 +281,26 @@ func (t *Tree) addLine() {
 
 	startWithSpace := 1 < t.Context.currentLineLen && (' ' == t.Context.currentLine[0] || '\t' == t.Context.currentLine[0])
 	docChildPara := ast.NodeDocument == t.Context.Tip.Parent.Type
+	tokenOffset := len(t.Context.Tip.Tokens)
 	if t.Context.ParseOption.ParagraphBeginningSpace && startWithSpace && docChildPara {
 		t.Context.Tip.AppendTokens(t.Context.currentLine)
+		t.Context.addSourceLine(t.Context.Tip, tokenOffset, 0)
 	} else {
 		t.Context.Tip.AppendTokens(t.Context.currentLine[t.Context.offset:])
+		t.Context.addSourceLine(t.Context.Tip, tokenOffset, t.Context.offset)
 	}
+	t.Context.touchPos(t.Context.Tip)
 }
 
 // _continue 判断节点是否可以继续处理，比如引述需要 >，缩进代码块需要 4 空格，围栏代码块需要 ```。
 // 如果可以继续处理返回 0，如果不能接续处理返回 1，如果返回 2（仅在围栏代码块、超级块或自定义块闭合时）则说明可以继续下一行处理了。
 func _continue(n *ast.Node, context *Context) int {
+	if nil != context.syntaxBlocks {
+		if ret, ok := context.syntaxContinue(n); ok {
+			return ret
+		}
+	}
+
 	switch n.Type {
 	case ast.NodeCodeBlock:
 		return CodeBlockContinue(n, context)
@@ -305,7 +326,9 @@ func _continue(n *ast.Node, context *Context) int {
 		return CustomBlockContinue(n, context)
 	case ast.NodeCallout:
 		return CalloutContinue(n, context)
-	case ast.NodeHeading, ast.NodeThematicBreak, ast.NodeKramdownBlockIAL, ast.NodeLinkRefDefBlock, ast.NodeBlockQueryEmbed,
+	case ast.NodeContainerDirective:
+		return DirectiveContinue(n, context)
+	case ast.NodeHeading, ast.NodeLeafDirective, ast.NodeThematicBreak, ast.NodeKramdownBlockIAL, ast.NodeLinkRefDefBlock, ast.NodeBlockQueryEmbed,
 		ast.NodeIFrame, ast.NodeVideo, ast.NodeAudio, ast.NodeWidget, ast.NodeAttributeView:
 		return 1
 	}
@@ -89,7 +89,7 @@ func (context *Context) calloutFinalize(callout *ast.Node) {
 			callout.CalloutIcon = icon
 			title = strings.TrimSpace(title[len(icon):])
 		} else {
-			emoji := EmojiAliasUnicode[strings.ReplaceAll(icon, ":", "")]
+			emoji := context.ParseOption.AliasEmoji[strings.ReplaceAll(icon, ":", "")]
 			if "" != emoji {
 				callout.CalloutIcon = emoji
 				title = strings.TrimSpace(title[len(icon):])
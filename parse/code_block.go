@@ -49,9 +49,12 @@ func IndentCodeBlockStart(t *Tree, container *ast.Node) int {
 	}
 
 	if t.Context.Tip.Type != ast.NodeParagraph && !t.Context.blank {
+		offset := t.Context.offset
 		t.Context.advanceOffset(4, true)
 		t.Context.closeUnmatchedBlocks()
-		t.Context.addChild(ast.NodeCodeBlock)
+		codeBlock := t.Context.addChild(ast.NodeCodeBlock)
+		// 缩进代码块的位置从缩进开始
+		t.Context.startPos(codeBlock, offset)
 		return 2
 	}
 	return 0
@@ -92,6 +95,9 @@ func CodeBlockContinue(codeBlock *ast.Node, context *Context) int {
 
 func (context *Context) codeBlockFinalize(codeBlock *ast.Node) {
 	if codeBlock.IsFencedCodeBlock {
+		if nil == codeBlock.CodeBlockCloseFence {
+			context.diagnose(SeverityError, DiagUnclosedCodeBlock, "fenced code block is not closed", codeBlock)
+		}
 		content := codeBlock.Tokens
 		length := len(content)
 		if 1 > length {
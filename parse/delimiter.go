@@ -308,6 +308,10 @@ func (t *Tree) processEmphasis(stackBottom *delimiter, ctx *InlineContext) {
 				tmp = next
 			}
 
+			if t.Context.ParseOption.SourcePos {
+				delimPos(openerInl, closerInl, openMarker, closeMarker, openerTokenLen, closerTokenLen)
+			}
+
 			emStrongDelMark.PrependChild(openMarker) // 插入起始标记符
 			emStrongDelMark.AppendChild(closeMarker) // 插入结束标记符
 			openerInl.InsertAfter(emStrongDelMark)
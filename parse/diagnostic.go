@@ -0,0 +1,120 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/util"
+)
+
+// Severity 描述了诊断信息的严重程度。
+type Severity int
+
+const (
+	SeverityError   Severity = iota // 错误，文档结构很可能和作者的预期不一致
+	SeverityWarning                 // 警告，文档可能存在问题
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// 诊断代码。
+const (
+	DiagUnclosedCodeBlock   = "unclosed-code-block"   // 围栏代码块没有闭合
+	DiagUnclosedMathBlock   = "unclosed-math-block"   // 数学公式块没有闭合
+	DiagUnclosedSuperBlock  = "unclosed-super-block"  // 超级块 {{{ 没有闭合
+	DiagUnclosedGitConflict = "unclosed-git-conflict" // Git 冲突标记 <<<<<<< 没有对应的 >>>>>>>
+	DiagUnresolvedLinkRef   = "unresolved-link-ref"   // 引用链接 [text][label] 找不到链接引用定义
+	DiagUndefinedFootnote   = "undefined-footnote"    // 脚注引用 [^label] 找不到脚注定义
+	DiagDuplicateHeadingID  = "duplicate-heading-id"  // 多个标题使用了相同的自定义 ID {#id}
+)
+
+// Diagnostic 描述了解析时发现的一个可能有问题的 Markdown 结构。
+type Diagnostic struct {
+	Severity Severity  `json:"severity"` // 严重程度
+	Code     string    `json:"code"`     // 诊断代码
+	Message  string    `json:"message"`  // 诊断信息
+	Pos      *ast.Pos  `json:"pos"`      // 在 Markdown 原始文本中的位置，仅在打开 SourcePos 解析选项时记录
+	Node     *ast.Node `json:"-"`        // 相关的节点
+}
+
+func (d *Diagnostic) String() string {
+	ret := d.Severity.String() + " " + d.Code + ": " + d.Message
+	if nil != d.Pos {
+		ret = strconv.Itoa(d.Pos.StartLine) + ":" + strconv.Itoa(d.Pos.StartColumn) + ": " + ret
+	}
+	return ret
+}
+
+// diagnose 在打开 Diagnostics 解析选项时记录一条关于节点 node 的诊断信息。
+func (context *Context) diagnose(severity Severity, code, message string, node *ast.Node) {
+	if !context.ParseOption.Diagnostics || nil == context.Tree {
+		return
+	}
+	context.Tree.Diagnostics = append(context.Tree.Diagnostics, &Diagnostic{Severity: severity, Code: code, Message: message, Node: node})
+}
+
+// diagnoseInline 记录行级解析时 tokens[start:end] 处的诊断信息，位置根据正在解析的块节点计算。
+func (t *Tree) diagnoseInline(severity Severity, code, message string, tokens []byte, start, end int) {
+	if !t.Context.ParseOption.Diagnostics {
+		return
+	}
+	block := t.Context.inlineBlock
+	t.Context.diagnose(severity, code, message, block)
+	if t.Context.ParseOption.SourcePos && nil != block {
+		t.Diagnostics[len(t.Diagnostics)-1].Pos = t.inlinePos(block, tokens, start, end)
+	}
+}
+
+// finalizeDiagnostics 检查需要整棵语法树才能发现的问题，补全诊断信息的位置并按位置排序。
+func (t *Tree) finalizeDiagnostics() {
+	if !t.Context.ParseOption.Diagnostics {
+		return
+	}
+
+	headingIDs := map[string]bool{}
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeadingID != n.Type {
+			return ast.WalkContinue
+		}
+		id := strings.TrimLeft(util.BytesToStr(n.Tokens), "#")
+		if headingIDs[id] {
+			t.Context.diagnose(SeverityError, DiagDuplicateHeadingID, "duplicate heading id ["+id+"]", n.Parent)
+		}
+		headingIDs[id] = true
+		return ast.WalkContinue
+	})
+
+	for _, d := range t.Diagnostics {
+		if nil == d.Pos && nil != d.Node && nil != d.Node.Pos {
+			pos := *d.Node.Pos
+			d.Pos = &pos
+		}
+	}
+	sort.SliceStable(t.Diagnostics, func(i, j int) bool {
+		a, b := t.Diagnostics[i].Pos, t.Diagnostics[j].Pos
+		if nil == a || nil == b {
+			return nil != a
+		}
+		return a.StartOffset < b.StartOffset
+	})
+}
@@ -0,0 +1,320 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/lex"
+)
+
+// DirectiveStart 判断容器指令（:::name[label]{attrs}，围栏后允许空格）或者叶子指令（::name[label]{attrs}）是否开始。
+func DirectiveStart(t *Tree, container *ast.Node) int {
+	if !t.Context.ParseOption.Directive || t.Context.indented {
+		return 0
+	}
+
+	line := t.Context.currentLine[t.Context.nextNonspace:]
+	fenceLen := directiveFenceLen(line)
+	if 2 > fenceLen {
+		return 0
+	}
+
+	nameStart := fenceLen
+	if 2 < fenceLen {
+		// 容器指令允许围栏和名称之间有空格，比如 ::: tip
+		for ; nameStart < len(line) && (lex.ItemSpace == line[nameStart] || lex.ItemTab == line[nameStart]); nameStart++ {
+		}
+	}
+	directive, label, consumed := parseDirective(line[nameStart:])
+	if nil == directive || !lex.IsBlankLine(line[nameStart+consumed:]) {
+		return 0
+	}
+
+	t.Context.advanceNextNonspace()
+	t.Context.closeUnmatchedBlocks()
+	if 2 == fenceLen {
+		leaf := t.Context.addChild(ast.NodeLeafDirective)
+		leaf.DirectiveName, leaf.DirectiveAttrs = directive.DirectiveName, directive.DirectiveAttrs
+		leaf.Tokens = label
+		t.Context.advanceOffset(t.Context.currentLineLen-t.Context.offset, false)
+		return 2
+	}
+
+	// 保留行尾换行，容器指令起始行后余下的内容为空行
+	t.Context.advanceOffset(t.Context.currentLineLen-t.Context.offset-1, false)
+	containerDirective := t.Context.addChild(ast.NodeContainerDirective)
+	containerDirective.DirectiveName, containerDirective.DirectiveAttrs = directive.DirectiveName, directive.DirectiveAttrs
+	containerDirective.DirectiveLabel = string(label)
+	containerDirective.DirectiveFenceLen = fenceLen
+	return 1
+}
+
+// DirectiveContinue 判断容器指令是否可以延续，遇到不短于开始围栏的 : 闭合行时闭合容器指令。
+func DirectiveContinue(directive *ast.Node, context *Context) int {
+	if last := directive.LastChild; nil != last && !last.Close && ast.NodeContainerDirective == last.Type {
+		// 闭合行优先留给嵌套的容器指令
+		return 0
+	}
+
+	if context.indented {
+		return 0
+	}
+
+	line := context.currentLine[context.nextNonspace:]
+	fenceLen := directiveFenceLen(line)
+	if fenceLen < directive.DirectiveFenceLen || !lex.IsBlankLine(line[fenceLen:]) {
+		return 0
+	}
+
+	// 先闭合容器指令中尚未闭合的子块
+	for !directive.Close {
+		context.finalize(context.Tip)
+	}
+	return 2
+}
+
+// parseTextDirective 解析文本指令（:name[label]{attrs}），标签和属性至少需要有一个。
+func (t *Tree) parseTextDirective(ctx *InlineContext) *ast.Node {
+	if 0 < ctx.pos {
+		if prev := ctx.tokens[ctx.pos-1]; lex.ItemColon == prev || lex.IsASCIILetterNum(prev) {
+			return nil
+		}
+	}
+
+	tokens := ctx.tokens[ctx.pos+1:]
+	ret, label, consumed := parseDirective(tokens)
+	if nil == ret || consumed == len(ret.DirectiveName) {
+		return nil
+	}
+
+	ret.Type = ast.NodeTextDirective
+	if 0 < len(label) {
+		labelCtx := &InlineContext{tokens: label, tokensLen: len(label)}
+		t.parseInline(ret, labelCtx)
+		t.processEmphasis(nil, labelCtx)
+		t.mergeText(ret)
+	}
+	ctx.pos += 1 + consumed
+	return ret
+}
+
+// directiveFenceLen 返回 tokens 开头连续的 : 的个数。
+func directiveFenceLen(tokens []byte) (ret int) {
+	for ; ret < len(tokens) && lex.ItemColon == tokens[ret]; ret++ {
+	}
+	return
+}
+
+// parseDirective 解析 name[label]{attrs}，返回记录了名称和属性的指令节点、标签以及消耗的字节数，不匹配时返回 nil。
+func parseDirective(tokens []byte) (ret *ast.Node, label []byte, consumed int) {
+	length := len(tokens)
+	if 1 > length || !lex.IsASCIILetter(tokens[0]) {
+		return
+	}
+
+	i := 1
+	for ; i < length && (lex.IsASCIILetterNumHyphen(tokens[i]) || lex.ItemUnderscore == tokens[i]); i++ {
+	}
+	ret = &ast.Node{DirectiveName: string(tokens[:i])}
+
+	if i < length && lex.ItemOpenBracket == tokens[i] {
+		end := directiveLabelEnd(tokens[i:])
+		if 0 > end {
+			return nil, nil, 0
+		}
+		label = tokens[i+1 : i+end]
+		i += end + 1
+	}
+
+	if i < length && lex.ItemOpenBrace == tokens[i] {
+		end := directiveAttrsEnd(tokens[i:])
+		if 0 > end {
+			return nil, nil, 0
+		}
+		attrs, ok := parseDirectiveAttrs(tokens[i+1 : i+end])
+		if !ok {
+			return nil, nil, 0
+		}
+		ret.DirectiveAttrs = attrs
+		i += end + 1
+	}
+	consumed = i
+	return
+}
+
+// directiveLabelEnd 返回 tokens 开头 [ 对应的 ] 的位置，支持嵌套的方括号和转义，不匹配时返回 -1。
+func directiveLabelEnd(tokens []byte) int {
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case lex.ItemBackslash:
+			i++
+		case lex.ItemOpenBracket:
+			depth++
+		case lex.ItemCloseBracket:
+			depth--
+			if 0 == depth {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// directiveAttrsEnd 返回 tokens 开头 { 对应的 } 的位置，跳过引号内的内容，不匹配时返回 -1。
+func directiveAttrsEnd(tokens []byte) int {
+	var quote byte
+	for i := 1; i < len(tokens); i++ {
+		switch c := tokens[i]; {
+		case 0 != quote:
+			if quote == c {
+				quote = 0
+			}
+		case lex.ItemDoublequote == c || lex.ItemSinglequote == c:
+			// 仅在属性值开头的引号才开始引用，和 parseDirectiveAttrs 保持一致
+			if lex.ItemEqual == tokens[i-1] {
+				quote = c
+			}
+		case lex.ItemCloseBrace == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseDirectiveAttrs 解析指令属性 #id .class key=value key="value" key='value' key，多个 .class 会合并为一个 class 属性。
+func parseDirectiveAttrs(tokens []byte) (ret [][]string, ok bool) {
+	classIdx := -1
+	length := len(tokens)
+	for i := 0; i < length; {
+		if lex.IsWhitespace(tokens[i]) {
+			if lex.ItemNewline == tokens[i] {
+				return nil, false
+			}
+			i++
+			continue
+		}
+
+		var key, val string
+		switch tokens[i] {
+		case lex.ItemCrosshatch, lex.ItemDot:
+			start := i + 1
+			for i = start; i < length && !lex.IsWhitespace(tokens[i]) && lex.ItemDoublequote != tokens[i] && lex.ItemSinglequote != tokens[i]; i++ {
+			}
+			if start == i {
+				return nil, false
+			}
+			key, val = "id", string(tokens[start:i])
+			if lex.ItemDot == tokens[start-1] {
+				key = "class"
+			}
+		default:
+			start := i
+			for ; i < length && (lex.IsASCIILetterNumHyphen(tokens[i]) || lex.ItemUnderscore == tokens[i] || lex.ItemColon == tokens[i] || lex.ItemDot == tokens[i]); i++ {
+			}
+			if start == i {
+				return nil, false
+			}
+			key = string(tokens[start:i])
+			if i < length && lex.ItemEqual == tokens[i] {
+				i++
+				if i >= length {
+					return nil, false
+				}
+				if quote := tokens[i]; lex.ItemDoublequote == quote || lex.ItemSinglequote == quote {
+					end := bytes.IndexByte(tokens[i+1:], quote)
+					if 0 > end {
+						return nil, false
+					}
+					val = string(tokens[i+1 : i+1+end])
+					i += end + 2
+				} else {
+					start = i
+					for ; i < length && !lex.IsWhitespace(tokens[i]) && lex.ItemDoublequote != tokens[i] && lex.ItemSinglequote != tokens[i] && lex.ItemEqual != tokens[i]; i++ {
+					}
+					if start == i {
+						return nil, false
+					}
+					val = string(tokens[start:i])
+				}
+				val = html.UnescapeHTMLStr(val)
+			}
+		}
+
+		if "class" == key {
+			if 0 <= classIdx {
+				ret[classIdx][1] += " " + val
+				continue
+			}
+			classIdx = len(ret)
+		}
+		ret = append(ret, []string{key, val})
+	}
+	if nil == ret {
+		ret = [][]string{}
+	}
+	return ret, true
+}
+
+// Tokens2DirectiveAttrs 将 {#id .class key="value"} 形式的指令属性文本解析为属性列表，不合法时返回 nil。
+func Tokens2DirectiveAttrs(tokens []byte) [][]string {
+	tokens = bytes.TrimSpace(tokens)
+	if 2 > len(tokens) || lex.ItemOpenBrace != tokens[0] || lex.ItemCloseBrace != tokens[len(tokens)-1] {
+		return nil
+	}
+
+	ret, _ := parseDirectiveAttrs(tokens[1 : len(tokens)-1])
+	return ret
+}
+
+// DirectiveAttrs2Tokens 将指令属性列表转换为 {#id .class key="value"} 形式的文本，属性为空时返回 nil。
+func DirectiveAttrs2Tokens(attrs [][]string) []byte {
+	if 1 > len(attrs) {
+		return nil
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteByte(lex.ItemOpenBrace)
+	for i, kv := range attrs {
+		if 0 < i {
+			buf.WriteByte(lex.ItemSpace)
+		}
+		switch {
+		case "id" == kv[0] && "" != kv[1] && !strings.ContainsAny(kv[1], " \t\"'}"):
+			buf.WriteByte(lex.ItemCrosshatch)
+			buf.WriteString(kv[1])
+		case "class" == kv[0] && "" != kv[1] && !strings.ContainsAny(kv[1], "\"'}"):
+			for j, class := range strings.Fields(kv[1]) {
+				if 0 < j {
+					buf.WriteByte(lex.ItemSpace)
+				}
+				buf.WriteByte(lex.ItemDot)
+				buf.WriteString(class)
+			}
+		default:
+			buf.WriteString(kv[0])
+			if "" == kv[1] {
+				continue
+			}
+			quote := "\""
+			if strings.Contains(kv[1], quote) {
+				quote = "'"
+			}
+			buf.WriteString("=" + quote + kv[1] + quote)
+		}
+	}
+	buf.WriteByte(lex.ItemCloseBrace)
+	return buf.Bytes()
+}
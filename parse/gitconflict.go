@@ -40,13 +40,18 @@ func GitConflictStart(t *Tree, container *ast.Node) int {
 
 func GitConflictContinue(gitConflictBlock *ast.Node, context *Context) int {
 	if context.isGitConflictClose() {
+		context.markerClosed = true
 		context.finalize(gitConflictBlock)
+		context.markerClosed = false
 		return 2
 	}
 	return 0
 }
 
 func (context *Context) gitConflictFinalize(gitConflictBlock *ast.Node) {
+	if !context.markerClosed {
+		context.diagnose(SeverityError, DiagUnclosedGitConflict, "git conflict marker is not closed", gitConflictBlock)
+	}
 	tokens := gitConflictBlock.Tokens
 	contentParts := bytes.Split(tokens, []byte("\n"))
 	openMarkerTokens := contentParts[0]
@@ -32,6 +32,12 @@ func ATXHeadingStart(t *Tree, container *ast.Node) int {
 		heading := t.Context.addChild(ast.NodeHeading)
 		heading.HeadingLevel = level
 		heading.Tokens = content
+		if t.Context.ParseOption.SourcePos {
+			contentStart := t.Context.nextNonspace + len(markers)
+			for ; contentStart < t.Context.currentLineLen && lex.IsWhitespace(t.Context.currentLine[contentStart]); contentStart++ {
+			}
+			t.Context.addSourceLine(heading, 0, contentStart)
+		}
 		crosshatchMarker := &ast.Node{Type: ast.NodeHeadingC8hMarker, Tokens: markers}
 		heading.AppendChild(crosshatchMarker)
 		t.Context.advanceOffset(t.Context.currentLineLen-t.Context.offset, false)
@@ -88,6 +94,14 @@ func SetextHeadingStart(t *Tree, container *ast.Node) int {
 	if 0 < len(container.Tokens) {
 		child := &ast.Node{Type: ast.NodeHeading, HeadingLevel: level, HeadingSetext: true}
 		child.Tokens = lex.TrimWhitespace(container.Tokens)
+		if nil != container.Pos {
+			pos := *container.Pos
+			child.Pos = &pos
+			if sm := t.Context.sourceMaps[container]; nil != sm {
+				t.Context.sourceMaps[child] = sm
+			}
+			t.Context.touchPos(child)
+		}
 		container.InsertAfter(child)
 		container.Unlink()
 		t.Context.Tip = child
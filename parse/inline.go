@@ -30,6 +30,14 @@ func (t *Tree) parseInline(block *ast.Node, ctx *InlineContext) {
 			continue
 		}
 		token := ctx.tokens[ctx.pos]
+		startPos := ctx.pos
+		if 0 < len(t.Context.ParseOption.InlineSyntaxes) {
+			if n := t.parseInlineSyntax(block, ctx); nil != n {
+				t.appendInline(block, ctx, n, startPos)
+				continue
+			}
+		}
+
 		var n *ast.Node
 		switch token {
 		case lex.ItemBackslash:
@@ -59,46 +67,73 @@ func (t *Tree) parseInline(block *ast.Node, ctx *InlineContext) {
 				}
 			}
 		case lex.ItemOpenBracket:
-			n = t.parseOpenBracket(ctx)
+			if t.Context.ParseOption.WikiLink {
+				n = t.parseWikiLink(ctx)
+			}
+			if nil == n {
+				n = t.parseOpenBracket(ctx)
+			}
 		case lex.ItemCloseBracket:
 			n = t.parseCloseBracket(ctx)
 		case lex.ItemAmpersand:
 			n = t.parseEntity(ctx)
 		case lex.ItemBang:
-			n = t.parseBang(ctx)
+			if t.Context.ParseOption.WikiLink {
+				n = t.parseWikiLink(ctx)
+			}
+			if nil == n {
+				n = t.parseBang(ctx)
+			}
 		case lex.ItemDollar:
 			n = t.parseInlineMath(ctx)
 		case lex.ItemOpenBrace:
 			n = t.parseHeadingID(block, ctx)
 		case lex.ItemOpenParen:
 			n = t.parseBlockRef(ctx)
+		case lex.ItemColon:
+			if t.Context.ParseOption.Directive {
+				n = t.parseTextDirective(ctx)
+			}
+			if nil == n {
+				n = t.parseText(ctx)
+			}
 		default:
 			n = t.parseText(ctx)
 		}
+		t.appendInline(block, ctx, n, startPos)
+	}
+	block.Tokens = nil
+}
 
-		if nil != n {
-			if nil == n.Previous && nil == n.Next {
-				// 绝大多数情况下解析函数返回的是没有关联兄弟节点的单个节点，直接挂载
-				block.AppendChild(n)
-				continue
-			}
+// appendInline 将从 startPos 开始解析得到的行级节点 n（以及 n 的兄弟节点）挂到块节点 block 上。
+func (t *Tree) appendInline(block *ast.Node, ctx *InlineContext, n *ast.Node, startPos int) {
+	if t.Context.ParseOption.SourcePos {
+		t.setInlinePos(block, ctx, n, startPos)
+	}
 
-			var nodes []*ast.Node
-			first := n
-			for ; ; first = first.Previous {
-				if nil == first.Previous {
-					break
-				}
-			}
-			for node := first; nil != node; node = node.Next {
-				nodes = append(nodes, node)
-			}
-			for _, node := range nodes {
-				block.AppendChild(node)
-			}
+	if nil == n {
+		return
+	}
+
+	if nil == n.Previous && nil == n.Next {
+		// 绝大多数情况下解析函数返回的是没有关联兄弟节点的单个节点，直接挂载
+		block.AppendChild(n)
+		return
+	}
+
+	var nodes []*ast.Node
+	first := n
+	for ; ; first = first.Previous {
+		if nil == first.Previous {
+			break
 		}
 	}
-	block.Tokens = nil
+	for node := first; nil != node; node = node.Next {
+		nodes = append(nodes, node)
+	}
+	for _, node := range nodes {
+		block.AppendChild(node)
+	}
 }
 
 func (t *Tree) parseEntity(ctx *InlineContext) (ret *ast.Node) {
@@ -292,6 +327,7 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 			ctx.pos = startPos
 		}
 		if nil != reflabel {
+			isFootnote := t.Context.ParseOption.Footnotes && 0 < len(reflabel) && lex.ItemCaret == reflabel[0]
 			if t.Context.ParseOption.Footnotes {
 				// 查找脚注
 				if idx, footnotesDef := t.FindFootnotesDef(reflabel); nil != footnotesDef {
@@ -325,6 +361,16 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 				}
 				matched = true
 				linkType = 3
+			} else if isFootnote {
+				t.diagnoseInline(SeverityError, DiagUndefinedFootnote, "footnote ["+util.BytesToStr(reflabel)+"] is not defined", ctx.tokens, opener.index, ctx.pos)
+			} else if 0 < n {
+				// 只诊断 [text][label] 和 [text][]，[text] 通常只是普通文本
+				start := opener.index
+				if isImage {
+					// 图片开始标记符入栈的下标位于 ![ 之后，诊断位置指向 !
+					start -= 2
+				}
+				t.diagnoseInline(SeverityWarning, DiagUnresolvedLinkRef, "link reference ["+util.BytesToStr(reflabel)+"] is not defined", ctx.tokens, start, ctx.pos)
 			}
 		}
 	}
@@ -333,10 +379,10 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 		node := &ast.Node{Type: ast.NodeLink, LinkType: linkType, LinkRefLabel: reflabel}
 		if isImage {
 			node.Type = ast.NodeImage
-			node.AppendChild(&ast.Node{Type: ast.NodeBang, Tokens: opener.node.Tokens[:1]})
+			node.AppendChild(&ast.Node{Type: ast.NodeBang, Tokens: opener.node.Tokens[:1], Pos: copyPos(opener.node.Pos)})
 			opener.node.Tokens = opener.node.Tokens[1:]
 		}
-		node.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: opener.node.Tokens})
+		node.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: opener.node.Tokens, Pos: copyPos(opener.node.Pos)})
 
 		var tmp, next *ast.Node
 		tmp = opener.node.Next
@@ -18,6 +18,7 @@ import (
 // parseInlines 解析并生成行级节点。
 func (t *Tree) parseInlines() {
 	t.walkParseInline(t.Root)
+	t.Context.inlineBlock = nil
 
 	if t.Context.ParseOption.KramdownSpanIAL {
 		t.parseKramdownSpanIAL()
@@ -43,7 +44,7 @@ func (t *Tree) walkParseInline(node *ast.Node) {
 	}
 
 	// 只有如下几种类型的块节点需要生成行级子节点
-	if ast.NodeParagraph == typ || ast.NodeHeading == typ || ast.NodeTableCell == typ {
+	if ast.NodeParagraph == typ || ast.NodeHeading == typ || ast.NodeTableCell == typ || ast.NodeLeafDirective == typ {
 		tokens := node.Tokens
 		if ast.NodeParagraph == typ {
 			if nil == tokens && nil == node.FirstChild {
@@ -78,7 +79,12 @@ func (t *Tree) walkParseInline(node *ast.Node) {
 			return
 		}
 
+		if t.Context.limited {
+			t.checkCancel()
+		}
+
 		ctx := &InlineContext{tokens: tokens, tokensLen: length}
+		t.Context.inlineBlock = node
 
 		// 生成该块节点的行级子节点
 		t.parseInline(node, ctx)
@@ -86,6 +92,10 @@ func (t *Tree) walkParseInline(node *ast.Node) {
 		// 处理该块节点中的强调、加粗和删除线
 		t.processEmphasis(nil, ctx)
 
+		if t.Context.limited {
+			t.checkInlineNesting(node)
+		}
+
 		// 将连续的文本节点进行合并。
 		// 规范只是定义了从输入的 Markdown 文本到输出的 HTML 的解析渲染规则，并未定义中间语法树的规则。
 		// 也就是说语法树的节点结构没有标准，可以自行发挥。这里进行文本节点合并主要有两个目的：
@@ -0,0 +1,150 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/88250/lute/ast"
+)
+
+// JSONSpec 是 JSON 格式语法树的规范版本号，JSON 渲染器将其输出到根节点的 Spec 字段。
+//
+// 规范版本 1 的格式如下：
+//
+//   - 每个节点是一个 JSON 对象，字段名和 ast.Node 中的字段名一致，没有值的字段省略
+//   - Type 为节点类型字符串（比如 NodeParagraph），Data 为节点的 Tokens，Children 为子节点数组
+//   - ListData、TableAligns、TextMark*、Callout*、Directive*、WikiLink* 等类型化字段按照 ast.Node 的 JSON 标签序列化，[]byte 类型的字段使用 Base64 编码
+//   - 节点的 Kramdown 内联属性列表（IAL）输出为 Properties 对象，属性顺序和 IAL 一致，不输出 refcount 和 av-names 属性（使用时计算的引用计数和属性视图名称，不属于持久化内容）
+//   - 块级 IAL 节点（NodeKramdownBlockIAL）不输出，转换回语法树时根据所属块节点的 Properties 在该块后重新生成；行级 IAL 节点作为普通节点输出
+//   - 根节点的 Spec 为规范版本号，没有 Spec 的根节点按照版本 1 处理
+//   - 根节点的 LineEnding 为原始文本使用的换行符 "\r\n" 或者 "\r"，BOM 为 true 说明原始文本以 UTF-8 BOM 开头，换行符为 "\n" 和没有 BOM 时省略
+const JSONSpec = "1"
+
+// JSON2Tree 将 JSON 渲染器输出的 JSON 格式语法树 data 转换为语法树，格式见 JSONSpec。
+//
+// 转换时会重建父子和兄弟节点关系、块级 IAL 节点以及脚注定义到脚注引用的关联。
+func JSON2Tree(name string, data []byte, options *Options) (ret *Tree, err error) {
+	root := &jsonNode{}
+	if err = json.Unmarshal(data, root); nil != err {
+		return
+	}
+	if "" != root.Spec && JSONSpec != root.Spec {
+		return nil, errors.New("unsupported json spec [" + root.Spec + "]")
+	}
+	if ast.NodeDocument.String() != root.TypeStr {
+		return nil, errors.New("json root node must be a document node")
+	}
+
+	ret = &Tree{Name: name, Context: &Context{ParseOption: options}}
+	ret.Context.Tree = ret
+	if ret.Root, err = root.build(); nil != err {
+		return nil, err
+	}
+	ret.ID = ret.Root.ID
+	ret.LineEnding, ret.BOM = root.LineEnding, root.BOM
+	relinkFootnotesRefs(ret.Root)
+	return
+}
+
+// jsonNode 用于反序列化 JSON 格式的节点，Properties 按照 JSON 中的顺序读取。
+type jsonNode struct {
+	ast.Node
+	Properties jsonProperties
+	Children   []*jsonNode
+	LineEnding string // 仅根节点，语法树原始文本的换行符
+	BOM        bool   // 仅根节点，原始文本是否以 UTF-8 BOM 开头
+}
+
+// build 将 jsonNode 转换为节点并连接子节点。
+func (n *jsonNode) build() (ret *ast.Node, err error) {
+	ret = &n.Node
+	if ret.Type = ast.Str2NodeType(ret.TypeStr); 0 > ret.Type {
+		return nil, errors.New("unknown json node type [" + ret.TypeStr + "]")
+	}
+	if "" != ret.Data {
+		ret.Tokens = []byte(ret.Data)
+	}
+	ret.Data, ret.TypeStr = "", ""
+	ret.KramdownIAL = n.Properties
+
+	for i, c := range n.Children {
+		child, err := c.build()
+		if nil != err {
+			return nil, err
+		}
+		ret.AppendChild(child)
+		if 0 < len(child.KramdownIAL) && child.IsBlock() && ast.NodeKramdownBlockIAL != child.Type {
+			if ast.NodeDocument == ret.Type && len(n.Children)-1 == i && bytes.Equal(IAL2Tokens(child.KramdownIAL), IAL2Tokens(ret.KramdownIAL)) {
+				// 文档 IAL 被最后一个块（比如 HTML 块）吞掉时该块的 IAL 和文档相同，不生成块级 IAL 节点
+				continue
+			}
+			ret.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: IAL2Tokens(child.KramdownIAL)})
+		}
+	}
+	if ast.NodeDocument == ret.Type && 0 < len(ret.KramdownIAL) {
+		ret.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: IAL2Tokens(ret.KramdownIAL)})
+	}
+	return
+}
+
+// relinkFootnotesRefs 将脚注定义中反序列化得到的脚注引用替换为语法树中对应的脚注引用节点。
+func relinkFootnotesRefs(root *ast.Node) {
+	refs := map[string]*ast.Node{}
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeFootnotesRef == n.Type {
+			refs[n.FootnotesRefId] = n
+		}
+		return ast.WalkContinue
+	})
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeFootnotesDef != n.Type {
+			return ast.WalkContinue
+		}
+		for i, ref := range n.FootnotesRefs {
+			if r := refs[ref.FootnotesRefId]; nil != r {
+				n.FootnotesRefs[i] = r
+			}
+		}
+		return ast.WalkContinue
+	})
+}
+
+// jsonProperties 描述了按照 JSON 对象中的顺序读取的属性列表。
+type jsonProperties [][]string
+
+func (p *jsonProperties) UnmarshalJSON(data []byte) (err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if nil != err {
+		return
+	}
+	if nil == token {
+		return
+	}
+	if json.Delim('{') != token {
+		return errors.New("json node properties must be an object")
+	}
+	for decoder.More() {
+		var value string
+		if token, err = decoder.Token(); nil != err {
+			return
+		}
+		name, _ := token.(string)
+		if err = decoder.Decode(&value); nil != err {
+			return
+		}
+		*p = append(*p, []string{name, value})
+	}
+	return
+}
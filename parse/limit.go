@@ -0,0 +1,135 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"context"
+	"errors"
+
+	"github.com/88250/lute/ast"
+)
+
+var (
+	// ErrInputTooLarge 表示输入超过了 Options.MaxInputBytes。
+	ErrInputTooLarge = errors.New("input exceeds max input bytes")
+	// ErrBlockTooDeep 表示块级节点嵌套层级超过了 Options.MaxBlockDepth。
+	ErrBlockTooDeep = errors.New("block nesting exceeds max block depth")
+	// ErrInlineTooDeep 表示行级节点的嵌套层级超过了 Options.MaxInlineNesting。
+	ErrInlineTooDeep = errors.New("inline nesting exceeds max inline nesting")
+)
+
+// cancelCheckInterval 指定每处理多少行或者块检查一次上下文是否已经取消。
+const cancelCheckInterval = 64
+
+// parseAbort 用于在解析过程中超出限制或者上下文被取消时中止解析，由 ParseContext 恢复后作为错误返回。
+type parseAbort struct {
+	err error
+}
+
+// ParseContext 和 Parse 一样将 markdown 解析为语法树，但是会在 ctx 取消或者超出 options 中的 MaxInputBytes、MaxBlockDepth
+// 和 MaxInlineNesting 限制时中止解析并返回错误。Parse 不检查这些限制。
+//
+// 解析过程中发生的 panic 会被转换为 *ast.NodeError 返回，错误中记录了出错时正在解析的节点类型和位置。
+func ParseContext(ctx context.Context, name string, markdown []byte, options *Options) (tree *Tree, err error) {
+	if 0 < options.MaxInputBytes && len(markdown) > options.MaxInputBytes {
+		return nil, ErrInputTooLarge
+	}
+	if err = ctx.Err(); nil != err {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); nil != r {
+			if abort, ok := r.(*parseAbort); ok {
+				tree, err = nil, abort.err
+				return
+			}
+			var node *ast.Node
+			if nil != tree {
+				node = tree.Context.errorNode()
+			}
+			tree, err = nil, ast.NewPanicError(node, r)
+		}
+	}()
+
+	tree = newTree(name, markdown, options)
+	if nil != ctx.Done() {
+		tree.Context.ctx = ctx
+	}
+	tree.Context.limited = nil != tree.Context.ctx || 0 < options.MaxBlockDepth || 0 < options.MaxInlineNesting
+	tree.parse()
+	return
+}
+
+// checkCancel 每调用 cancelCheckInterval 次检查一次上下文是否已经取消，取消时中止解析。
+func (t *Tree) checkCancel() {
+	if nil == t.Context.ctx {
+		return
+	}
+
+	t.Context.checks++
+	if 0 != t.Context.checks%cancelCheckInterval {
+		return
+	}
+	if err := t.Context.ctx.Err(); nil != err {
+		panic(&parseAbort{err})
+	}
+}
+
+// checkBlockDepth 检查新添加的块级节点 node 的嵌套层级，超过 MaxBlockDepth 时中止解析。
+func (t *Tree) checkBlockDepth(node *ast.Node) {
+	max := t.Context.ParseOption.MaxBlockDepth
+	if 1 > max {
+		return
+	}
+
+	depth := 0
+	for p := node.Parent; nil != p && ast.NodeDocument != p.Type; p = p.Parent {
+		depth++
+	}
+	if depth >= max {
+		panic(&parseAbort{ErrBlockTooDeep})
+	}
+}
+
+// checkInlineNesting 检查块节点 block 中已经配对的行级节点（强调、链接等）的嵌套层级，超过 MaxInlineNesting 时中止解析。
+// 未闭合的括号和分隔符只是文本，不计入嵌套层级。
+func (t *Tree) checkInlineNesting(block *ast.Node) {
+	max := t.Context.ParseOption.MaxInlineNesting
+	if 1 > max {
+		return
+	}
+
+	if inlineDepth(block, max) > max {
+		panic(&parseAbort{ErrInlineTooDeep})
+	}
+}
+
+// inlineDepth 返回 node 下包含子节点的行级节点的最大嵌套层级，超过 max 后不再继续向下遍历。
+func inlineDepth(node *ast.Node, max int) (ret int) {
+	for c := node.FirstChild; nil != c && ret <= max; c = c.Next {
+		if nil == c.FirstChild {
+			continue
+		}
+		if depth := 1 + inlineDepth(c, max-1); depth > ret {
+			ret = depth
+		}
+	}
+	return
+}
+
+// errorNode 返回解析出错时正在处理的节点：解析行级内容时为所在的块节点，解析块级结构时为末梢节点。
+func (context *Context) errorNode() *ast.Node {
+	if nil != context.inlineBlock {
+		return context.inlineBlock
+	}
+	return context.Tip
+}
@@ -88,7 +88,7 @@ func (context *Context) parseLinkRefDef(tokens []byte) []byte {
 	def.AppendChild(link)
 	defBlock := context.Tip
 	if ast.NodeLinkRefDefBlock != defBlock.Type {
-		defBlock = &ast.Node{Type: ast.NodeLinkRefDefBlock}
+		defBlock = &ast.Node{Type: ast.NodeLinkRefDefBlock, Pos: copyPos(context.Tip.Pos)}
 	}
 	defBlock.AppendChild(def)
 	context.Tip.Parent.AppendChild(defBlock)
@@ -12,6 +12,7 @@ package parse
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/88250/lute/ast"
 	"github.com/88250/lute/editor"
@@ -54,6 +55,12 @@ func (t *Tree) indexFootnotesDefs() {
 	})
 }
 
+// NormalizeLabel 返回链接引用标签 label 规范化后的结果：合并连续的空白并进行大小写全折叠，和 FindLinkRefDefLink 的匹配规则一致，
+// 比如 [SS] 和 [ß] 的规范化结果相同。
+func NormalizeLabel(label string) string {
+	return string(foldBytes([]byte(strings.ToLower(strings.Join(strings.Fields(label), " ")))))
+}
+
 func (t *Tree) FindLinkRefDefLink(label []byte) (link *ast.Node) {
 	if !t.Context.ParseOption.LinkRef {
 		return
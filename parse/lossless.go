@@ -0,0 +1,323 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"hash/fnv"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// snapshotSegment 记录了 Snapshot 时一个顶层片段的原始状态。
+type snapshotSegment struct {
+	start, end   int         // 片段在原始文本中的字节范围，从首个节点的起始行开始，直到下一个片段的起始行之前
+	nodes        []*ast.Node // 片段上的顶层节点
+	fingerprints []uint64    // 片段上每个顶层节点的指纹
+}
+
+// snapshotBlock 记录了 Snapshot 时一个块的原始状态。
+type snapshotBlock struct {
+	parent      *ast.Node // 父节点
+	first       *ast.Node // 第一个子块，仅在子块可以单独输出时记录
+	start, end  int       // 块在原始文本中的字节范围，从块的起始行开始，直到下一个兄弟块的起始行之前，最后一个子块直到父块的结束行为止
+	contentEnd  int       // 块的结束行之后的字节偏移，[contentEnd, end) 为块后面的空行
+	prefix      []byte    // 块的起始行上块之前的原始文本，比如 "> "、"* "
+	fingerprint uint64    // 块及其所有子节点的指纹
+	shallow     uint64    // 不包含子节点的指纹
+}
+
+// LosslessSegment 描述了无损格式化时的一段输出。Source 不为 nil 时说明 Nodes 自 Snapshot 后没有被修改过，可以原样输出
+// 原始文本 Source（包含其后的空行）；否则 Nodes 需要重新格式化。
+//
+// 容器块（引述、列表和列表项）本身没有修改过时只重新格式化其中修改过的子块，此时 Nested 为 true，Nodes 为一个子块，
+// 重新格式化后第一行前面需要加上 Prefix，其余行前面需要加上 Indent，最后原样输出子块后面的原始空行 Trailing。
+type LosslessSegment struct {
+	Nodes    []*ast.Node
+	Source   []byte
+	Nested   bool
+	Prefix   []byte
+	Indent   []byte
+	Trailing []byte
+}
+
+// Snapshot 记录语法树中块的原始状态，用于无损格式化时判断哪些块在解析后被修改过。语法树需要由打开 SourcePos 解析选项
+// 的 Parse 构建，并且需要在修改语法树（包括执行语法树变换）之前调用。增量解析 Reparse 会清除记录的状态。
+func (t *Tree) Snapshot() error {
+	if nil == t.source {
+		return ErrNoSource
+	}
+
+	segments, tail := t.segments()
+	if nil != tail {
+		// 文档末尾的 kramdown IAL 归入最后一个片段
+		if 1 > len(segments) {
+			segments = append(segments, &segment{line: 1})
+		}
+		segments[len(segments)-1].nodes = append(segments[len(segments)-1].nodes, tail)
+	}
+
+	t.blocks = map[*ast.Node]*snapshotBlock{}
+	for n := t.Root.FirstChild; nil != n; n = n.Next {
+		t.snapshotBlock(n, t.Root)
+	}
+	t.snapshot = map[*ast.Node]*snapshotSegment{}
+	for i, s := range segments {
+		end := len(t.source)
+		if i+1 < len(segments) {
+			end = segments[i+1].offset
+		}
+		snapshot := &snapshotSegment{start: s.offset, end: end, nodes: s.nodes}
+		for _, n := range s.nodes {
+			snapshot.fingerprints = append(snapshot.fingerprints, fingerprint(n))
+		}
+		t.snapshot[s.nodes[0]] = snapshot
+	}
+	return nil
+}
+
+// snapshotBlock 记录块 n 及其子块的原始状态。容器块的子块都从单独的一行开始并且第一个子块和容器块在同一行开始时，
+// 记录子块的字节范围，以便无损格式化时子块可以单独输出。
+func (t *Tree) snapshotBlock(n, parent *ast.Node) *snapshotBlock {
+	if nil == n.Pos {
+		return nil
+	}
+
+	ret := &snapshotBlock{parent: parent, fingerprint: fingerprint(n), shallow: shallowFingerprint(n)}
+	ret.start = lineStart(n)
+	ret.contentEnd = t.lineEnd(n.Pos.EndOffset)
+	ret.prefix = t.source[ret.start:n.Pos.StartOffset]
+	ret.end = ret.contentEnd
+	t.blocks[n] = ret
+
+	if ast.NodeBlockquote != n.Type && ast.NodeList != n.Type && ast.NodeListItem != n.Type {
+		return ret
+	}
+	var first *ast.Node
+	var children []*snapshotBlock
+	for c := n.FirstChild; nil != c; c = c.Next {
+		if ast.NodeBlockquoteMarker == c.Type {
+			continue
+		}
+		if nil == first {
+			first = c
+		}
+		child := t.snapshotBlock(c, n)
+		if nil == child || (0 < len(children) && children[len(children)-1].start >= child.start) || ret.contentEnd < child.contentEnd {
+			return ret
+		}
+		children = append(children, child)
+	}
+	if 1 > len(children) || ret.start != children[0].start {
+		return ret
+	}
+	for i, child := range children[:len(children)-1] {
+		child.end = children[i+1].start
+	}
+	children[len(children)-1].end = ret.contentEnd
+	ret.first = first
+	return ret
+}
+
+// lineStart 返回节点 n 的起始行在原始文本中的字节偏移。
+func lineStart(n *ast.Node) int {
+	return n.Pos.StartOffset - n.Pos.StartColumn + 1
+}
+
+// lineEnd 返回原始文本中字节偏移 end 之前最后一个字节所在行的换行符之后的字节偏移。
+func (t *Tree) lineEnd(end int) int {
+	if 0 < end && end <= len(t.source) && lex.ItemNewline == t.source[end-1] {
+		return end
+	}
+	if i := bytes.IndexByte(t.source[end:], lex.ItemNewline); 0 <= i {
+		return end + i + 1
+	}
+	return len(t.source)
+}
+
+// LosslessSegments 将当前的顶层块划分为无损格式化的输出片段：和 Snapshot 时节点相同且都没有修改过的片段原样输出原始文本，
+// 只修改了子块的容器块只重新格式化其中修改过的子块，其余连续的顶层块合并为一个需要重新格式化的片段。没有调用过 Snapshot 时返回 nil。
+func (t *Tree) LosslessSegments() (ret []*LosslessSegment) {
+	if nil == t.snapshot {
+		return
+	}
+
+	ret = []*LosslessSegment{}
+	var modified *LosslessSegment
+	for n := t.Root.FirstChild; nil != n; {
+		if s := t.snapshot[n]; nil != s && s.unmodified(n) {
+			if nil != modified {
+				ret = append(ret, modified)
+				modified = nil
+			}
+			ret = append(ret, &LosslessSegment{Nodes: s.nodes, Source: t.source[s.start:s.end]})
+			n = s.nodes[len(s.nodes)-1].Next
+			continue
+		}
+		if s := t.snapshot[n]; nil != s && s.unmodifiedAfter(n) {
+			if children := t.losslessChildren(n); nil != children {
+				if nil != modified {
+					ret = append(ret, modified)
+					modified = nil
+				}
+				b := t.blocks[n]
+				ret = appendSource(ret, nil, t.source[s.start:b.start])
+				ret = append(ret, children...)
+				ret = appendSource(ret, nil, t.source[b.contentEnd:s.end])
+				n = s.nodes[len(s.nodes)-1].Next
+				continue
+			}
+		}
+
+		if nil == modified {
+			modified = &LosslessSegment{}
+		}
+		modified.Nodes = append(modified.Nodes, n)
+		n = n.Next
+	}
+	if nil != modified {
+		ret = append(ret, modified)
+	}
+	return
+}
+
+// losslessChildren 将容器块 n 中的子块划分为无损格式化的输出片段，n 本身被修改过或者子块不能单独输出时返回 nil。
+func (t *Tree) losslessChildren(n *ast.Node) (ret []*LosslessSegment) {
+	b := t.blocks[n]
+	if nil == b || nil == b.first || b.shallow != shallowFingerprint(n) {
+		return nil
+	}
+
+	prevStart := -1
+	for c := n.FirstChild; nil != c; c = c.Next {
+		if ast.NodeBlockquoteMarker == c.Type {
+			continue
+		}
+		child := t.blocks[c]
+		if (-1 == prevStart && b.first != c) || nil == child || n != child.parent || prevStart >= child.start {
+			// 插入或者移动了子块
+			return nil
+		}
+		prevStart = child.start
+
+		if child.fingerprint == fingerprint(c) {
+			ret = appendSource(ret, c, t.source[child.start:child.end])
+			continue
+		}
+		if children := t.losslessChildren(c); nil != children {
+			ret = append(ret, children...)
+			ret = appendSource(ret, nil, t.source[child.contentEnd:child.end])
+			continue
+		}
+		if ast.NodeList == n.Type {
+			// 列表项不能脱离列表单独格式化
+			return nil
+		}
+		ret = append(ret, &LosslessSegment{Nodes: []*ast.Node{c}, Nested: true, Prefix: child.prefix, Indent: indent(child.prefix), Trailing: t.source[child.contentEnd:child.end]})
+	}
+	return
+}
+
+// appendSource 在 segments 后面添加一个原样输出原始文本 source 的片段，source 为空时不添加。
+func appendSource(segments []*LosslessSegment, n *ast.Node, source []byte) []*LosslessSegment {
+	if 1 > len(source) {
+		return segments
+	}
+	ret := &LosslessSegment{Source: source}
+	if nil != n {
+		ret.Nodes = []*ast.Node{n}
+	}
+	return append(segments, ret)
+}
+
+// indent 返回子块起始行的前缀 prefix 对应的后续行前缀：保留引述标记符 > 和空白，列表项标记符替换为空格。
+func indent(prefix []byte) (ret []byte) {
+	ret = make([]byte, len(prefix))
+	for i, b := range prefix {
+		if lex.ItemGreater == b || lex.ItemSpace == b || lex.ItemTab == b {
+			ret[i] = b
+		} else {
+			ret[i] = lex.ItemSpace
+		}
+	}
+	return
+}
+
+// unmodifiedAfter 判断片段中顶层块 n 后面的节点是否都没有被修改过并且没有原始文本（比如解析时生成的 IAL 节点），
+// 此时片段的原始文本只属于 n。
+func (s *snapshotSegment) unmodifiedAfter(n *ast.Node) bool {
+	if s.nodes[0] != n {
+		return false
+	}
+	for i, node := range s.nodes[1:] {
+		if n = n.Next; node != n || nil != n.Pos || s.fingerprints[i+1] != fingerprint(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// unmodified 判断从 n 开始的顶层块是否和片段记录的节点一致并且都没有被修改过。
+func (s *snapshotSegment) unmodified(n *ast.Node) bool {
+	for i, node := range s.nodes {
+		if node != n || s.fingerprints[i] != fingerprint(n) {
+			return false
+		}
+		n = n.Next
+	}
+	return true
+}
+
+// fingerprint 计算节点 node 及其所有子节点的指纹，节点类型、Tokens、IAL 或者其他可序列化的属性变化时指纹也会变化。
+func fingerprint(node *ast.Node) uint64 {
+	h := fnv.New64a()
+	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			h.Write([]byte{0})
+			return ast.WalkContinue
+		}
+
+		hashNode(h, n)
+		return ast.WalkContinue
+	})
+	return h.Sum64()
+}
+
+// shallowFingerprint 计算节点 node 本身的指纹，不包含子节点。
+func shallowFingerprint(node *ast.Node) uint64 {
+	h := fnv.New64a()
+	hashNode(h, node)
+	return h.Sum64()
+}
+
+func hashNode(h hash.Hash64, n *ast.Node) {
+	var buf [binary.MaxVarintLen64]byte
+	writeBytes := func(b []byte) {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(b)))])
+		h.Write(b)
+	}
+
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(n.Type))])
+	writeBytes(n.Tokens)
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(n.KramdownIAL)))])
+	for _, kv := range n.KramdownIAL {
+		for _, v := range kv {
+			writeBytes([]byte(v))
+		}
+	}
+	if data, err := json.Marshal(n); nil == err {
+		h.Write(data)
+	}
+}
@@ -21,7 +21,7 @@ import (
 
 // MathBlockStart 判断数学公式块（$$）是否开始。
 func MathBlockStart(t *Tree, container *ast.Node) int {
-	if t.Context.indented {
+	if !t.Context.ParseOption.MathBlock || t.Context.indented {
 		return 0
 	}
 
@@ -40,7 +40,9 @@ func MathBlockContinue(mathBlock *ast.Node, context *Context) int {
 	ln := context.currentLine
 	indent := context.indent
 	if 3 >= indent && context.isMathBlockClose(ln[context.nextNonspace:]) {
+		context.markerClosed = true
 		context.finalize(mathBlock)
+		context.markerClosed = false
 		return 2
 	} else {
 		// 跳过 $ 之前可能存在的空格
@@ -62,6 +64,9 @@ var MathBlockMarker = util.StrToBytes("$$")
 var MathBlockMarkerCaret = util.StrToBytes("$$" + editor.Caret)
 
 func (context *Context) mathBlockFinalize(mathBlock *ast.Node) {
+	if !context.markerClosed {
+		context.diagnose(SeverityError, DiagUnclosedMathBlock, "math block is not closed", mathBlock)
+	}
 	if 2 > len(mathBlock.Tokens) {
 		/*
 			- foo
@@ -162,8 +162,15 @@ func paragraphFinalize(p *ast.Node, context *Context) (insertTable bool) {
 
 	if context.ParseOption.GFMTable {
 		if paragraph, table := context.parseTable(p); nil != table {
+			if nil != context.sourceMaps {
+				context.tableSourceMaps(table, context.sourceMaps[p])
+			}
 			if nil != paragraph {
 				p.Tokens = paragraph.Tokens
+				if nil != context.sourceMaps {
+					context.splitTablePos(p, table)
+					context.sourceMaps[table] = context.sourceMaps[p]
+				}
 				p.InsertAfter(table)
 				// 设置末梢及其状态
 				table.Close = true
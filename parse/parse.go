@@ -11,6 +11,7 @@
 package parse
 
 import (
+	"context"
 	"sync"
 
 	"github.com/88250/lute/ast"
@@ -20,17 +21,34 @@ import (
 
 // Parse 会将 markdown 原始文本字节数组解析为一棵语法树。
 func Parse(name string, markdown []byte, options *Options) (tree *Tree) {
-	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
-	tree.Context.Tree = tree
-	tree.lexer = lex.NewLexer(markdown)
-	tree.Root = &ast.Node{Type: ast.NodeDocument}
-	tree.parseBlocks()
-	tree.parseInlines()
-	tree.finalParseBlockIAL()
-	tree.lexer = nil
+	tree = newTree(name, markdown, options)
+	tree.parse()
 	return
 }
 
+func newTree(name string, markdown []byte, options *Options) (ret *Tree) {
+	ret = &Tree{Name: name, Context: &Context{ParseOption: options}}
+	ret.Context.Tree = ret
+	if options.SourcePos {
+		// 调用方之后可能修改输入，这里保留一份原始文本副本用于增量解析
+		ret.source = append([]byte{}, markdown...)
+	}
+	ret.lexer = lex.NewLexer(markdown)
+	ret.Root = &ast.Node{Type: ast.NodeDocument}
+	return
+}
+
+func (t *Tree) parse() {
+	t.rootPos()
+	t.parseBlocks()
+	t.LineEnding, t.BOM = t.lexer.LineEnding(), t.lexer.BOM()
+	t.parseInlines()
+	t.finalizePos()
+	t.finalizeDiagnostics()
+	t.finalParseBlockIAL()
+	t.lexer = nil
+}
+
 func (t *Tree) finalParseBlockIAL() {
 	if !t.Context.ParseOption.KramdownBlockIAL {
 		return
@@ -127,7 +145,10 @@ func Block(name string, markdown []byte, options *Options) (tree *Tree) {
 	tree.Context.Tree = tree
 	tree.lexer = lex.NewLexer(markdown)
 	tree.Root = &ast.Node{Type: ast.NodeDocument}
+	tree.rootPos()
 	tree.parseBlocks()
+	tree.LineEnding, tree.BOM = tree.lexer.LineEnding(), tree.lexer.BOM()
+	tree.finalizePos()
 	tree.finalParseBlockIAL()
 	tree.lexer = nil
 	return
@@ -157,7 +178,22 @@ type Context struct {
 	indented, blank, partiallyConsumedTab, allClosed         bool      // 是否是缩进行、空行等标识
 	lastMatchedContainer                                     *ast.Node // 最后一个匹配的块节点
 
+	lineNum, lineOffset int                      // 当前行号以及当前行在原始输入中的字节偏移，仅在打开 SourcePos 选项时使用
+	lineBlank           bool                     // 当前行是否是空行，仅在打开 SourcePos 选项时使用
+	sourceMaps          map[*ast.Node]*sourceMap // 块节点 Tokens 到原始输入的映射，仅在打开 SourcePos 选项时使用
+
 	rootIAL *ast.Node // 根节点 kramdown IAL
+
+	blockStartFuncs []blockStartFunc           // 包含块级语法扩展的块起始模式函数，仅在注册了块级语法扩展时使用
+	blockSyntax     *BlockSyntax               // 正在判断是否起始的块级语法扩展
+	syntaxBlocks    map[*ast.Node]*BlockSyntax // 未闭合的扩展块到其块级语法扩展的映射
+
+	ctx     context.Context // 解析使用的上下文，仅在 ParseContext 中设置
+	limited bool            // 是否需要检查上下文取消和解析限制，仅在 ParseContext 中设置
+	checks  int             // 检查上下文取消的计数
+
+	inlineBlock  *ast.Node // 正在解析行级子节点的块节点，用于在解析出错时定位节点以及计算诊断信息的位置
+	markerClosed bool      // 正在最终化的块是否由闭合标记符闭合，用于诊断未闭合的块
 }
 
 // InlineContext 描述了行级元素解析上下文。
@@ -260,6 +296,11 @@ func (context *Context) finalize(block *ast.Node) {
 	parent := block.Parent
 	block.Close = true
 
+	if nil != context.syntaxBlocks && context.syntaxFinalize(block) {
+		context.Tip = parent
+		return
+	}
+
 	// 节点最终化处理。比如围栏代码块提取 info 部分；HTML 代码块剔除结尾空格；段落需要解析链接引用定义等。
 	switch block.Type {
 	case ast.NodeCodeBlock:
@@ -309,6 +350,10 @@ func (context *Context) addChild(nodeType ast.NodeType) (ret *ast.Node) {
 	ret = &ast.Node{Type: nodeType}
 	context.Tip.AppendChild(ret)
 	context.Tip = ret
+	context.startPos(ret, context.nextNonspace)
+	if context.limited {
+		context.Tree.checkBlockDepth(ret)
+	}
 	return
 }
 
@@ -336,11 +381,20 @@ type Tree struct {
 	Updated int64    // 更新时间
 	Hash    string   // 内容哈希
 
+	LineEnding string // 原始文本使用的换行符（取第一个换行符），"\n"、"\r\n" 或者 "\r"，原始文本没有换行时为空
+	BOM        bool   // 原始文本是否以 UTF-8 BOM 开头
+
 	// 以下字段用于惰性构建链接引用定义和脚注定义索引，避免查找时遍历整棵语法树
 	linkRefDefs        []*linkRefDef // 链接引用定义索引
 	linkRefDefIndexed  bool          // 链接引用定义索引是否已构建
 	footnotesDefs      []*ast.Node   // 脚注定义索引（文档顺序）
 	footnotesDefsIndex bool          // 脚注定义索引是否已构建
+
+	source   []byte                         // 原始 Markdown 文本，仅在打开 SourcePos 时记录，用于增量解析和无损格式化
+	snapshot map[*ast.Node]*snapshotSegment // 顶层片段的原始状态，键为片段的首个节点，仅在调用 Snapshot 后记录
+	blocks   map[*ast.Node]*snapshotBlock   // 块的原始状态，用于无损格式化时只重新格式化容器块中修改过的子块，仅在调用 Snapshot 后记录
+
+	Diagnostics []*Diagnostic // 解析诊断信息，仅在打开 Diagnostics 解析选项时由 Parse 和 ParseContext 收集，增量解析不会更新
 }
 
 // Options 描述了解析选项。
@@ -366,9 +420,9 @@ type Options struct {
 	ToC bool
 	// Emoji 设置是否对 Emoji 别名替换为原生 Unicode 字符。
 	Emoji bool
-	// AliasEmoji 存储 ASCII 别名到表情 Unicode 映射。
+	// AliasEmoji 存储 ASCII 别名到表情 Unicode 映射，默认和其他选项共享内置的只读字典，修改请使用 PutEmojis。
 	AliasEmoji map[string]string
-	// EmojiAlias 存储表情 Unicode 到 ASCII 别名映射。
+	// EmojiAlias 存储表情 Unicode 到 ASCII 别名映射，默认和其他选项共享内置的只读字典，修改请使用 PutEmojis。
 	EmojiAlias map[string]string
 	// EmojiSite 设置图片 Emoji URL 的路径前缀。
 	EmojiSite string
@@ -386,6 +440,8 @@ type Options struct {
 	InlineMath bool
 	// InlineMathAllowDigitAfterOpenMarker 设置内联数学公式是否允许起始 $ 后紧跟数字 https://github.com/b3log/lute/issues/38
 	InlineMathAllowDigitAfterOpenMarker bool
+	// MathBlock 设置是否开启公式块 $$ 支持。
+	MathBlock bool
 	// Setext 设置是否解析 Setext 标题 https://github.com/88250/lute/issues/50
 	Setext bool
 	// YamlFrontMatter 设置是否开启 YAML Front Matter 支持。
@@ -443,6 +499,10 @@ type Options struct {
 	HTML2MarkdownAttrs []string
 	// Callout 设置是否开启提示块支持。
 	Callout bool
+	// Directive 设置是否开启通用指令（:::name、::name 和 :name[label]）支持。
+	Directive bool
+	// WikiLink 设置是否开启维基链接（[[target#heading|alias]] 和 ![[embed]]）支持。
+	WikiLink bool
 	// KeepEscaped 设置是否保留转义内容（不进行反转义）。
 	KeepEscaped bool
 	// ArbitraryTaskListItemMarker 设置是否打开"任务列表任意标记符"支持。
@@ -451,6 +511,23 @@ type Options struct {
 	// EnsureListItemParagraph 为 true 时，空列表项下创建子列表前会补一个空段落，
 	// 避免出现列表项下直接挂列表的结构 https://github.com/siyuan-note/siyuan/issues/17890
 	EnsureListItemParagraph bool
+	// SourcePos 设置是否在节点上记录其在 Markdown 原始文本中的位置（ast.Node.Pos）。
+	SourcePos bool
+	// Diagnostics 设置是否在解析时收集诊断信息（Tree.Diagnostics），比如未闭合的代码块、找不到定义的引用链接等。
+	// 诊断信息的位置依赖 SourcePos 选项。
+	Diagnostics bool
+	// BlockSyntaxes 设置自定义块级语法扩展，按照注册顺序先于内置的块级语法进行匹配。
+	BlockSyntaxes []*BlockSyntax
+	// InlineSyntaxes 设置自定义行级语法扩展，按照注册顺序先于内置的行级语法进行匹配。
+	InlineSyntaxes []*InlineSyntax
+	// MaxInputBytes 设置输入的最大字节数，0 表示不限制，仅在 ParseContext 中检查。
+	MaxInputBytes int
+	// MaxBlockDepth 设置块级节点的最大嵌套层级（列表和列表项各算一层），0 表示不限制，仅在 ParseContext 中检查。
+	MaxBlockDepth int
+	// MaxInlineNesting 设置单个块中已配对的行级节点（强调、链接等）的最大嵌套层级，未闭合的括号和分隔符不计入，0 表示不限制，仅在 ParseContext 中检查。
+	MaxInlineNesting int
+
+	emojisOwned bool // AliasEmoji 和 EmojiAlias 是否由当前选项独占，未独占时需要先复制再写入
 }
 
 // IsValidTaskListItemMarker 判断 marker 是否是合法的任务列表项标记符。
@@ -460,8 +537,53 @@ func (options *Options) IsValidTaskListItemMarker(marker byte) bool {
 		(options.ArbitraryTaskListItemMarker && '[' != marker && ']' != marker)
 }
 
+// EmojiLock 已经不再使用。Emoji 字典由每个解析选项写时复制，解析时无需加锁。
+//
+// Deprecated: 使用 Options.PutEmojis 修改 Emoji 字典。
 var EmojiLock = sync.Mutex{}
 
+// PutEmojis 将 emojiMap 合并覆盖到 Emoji 字典。第一次写入时会复制共享的字典，不会影响其他选项。
+func (options *Options) PutEmojis(emojiMap map[string]string) {
+	if !options.emojisOwned {
+		options.AliasEmoji = copyStrMap(options.AliasEmoji, len(emojiMap))
+		options.EmojiAlias = copyStrMap(options.EmojiAlias, len(emojiMap))
+		options.emojisOwned = true
+	}
+
+	for k, v := range emojiMap {
+		options.AliasEmoji[k] = v
+		options.EmojiAlias[v] = k
+	}
+}
+
+// SetEmojis 使用 aliasEmoji 替换 ASCII 别名到表情 Unicode 映射，aliasEmoji 会被共享，之后调用 PutEmojis 时再复制。
+func (options *Options) SetEmojis(aliasEmoji map[string]string) {
+	options.AliasEmoji = aliasEmoji
+	options.emojisOwned = false
+}
+
+// Clone 复制一份解析选项，复制后两份选项互不影响。内置的只读 Emoji 字典继续共享，自定义过的 Emoji 字典会被复制；语法扩展按引用共享。
+func (options *Options) Clone() (ret *Options) {
+	o := *options
+	ret = &o
+	ret.HTML2MarkdownAttrs = append([]string(nil), options.HTML2MarkdownAttrs...)
+	ret.BlockSyntaxes = append([]*BlockSyntax(nil), options.BlockSyntaxes...)
+	ret.InlineSyntaxes = append([]*InlineSyntax(nil), options.InlineSyntaxes...)
+	if options.emojisOwned {
+		ret.AliasEmoji = copyStrMap(options.AliasEmoji, 0)
+		ret.EmojiAlias = copyStrMap(options.EmojiAlias, 0)
+	}
+	return
+}
+
+func copyStrMap(m map[string]string, extra int) (ret map[string]string) {
+	ret = make(map[string]string, len(m)+extra)
+	for k, v := range m {
+		ret[k] = v
+	}
+	return
+}
+
 func NewOptions() *Options {
 	return &Options{
 		GFMTable:          true,
@@ -475,6 +597,7 @@ func NewOptions() *Options {
 		EmojiAlias:        EmojiUnicodeAlias,
 		EmojiSite:         "https://cdn.jsdelivr.net/npm/vditor/dist/images/emoji",
 		InlineMath:        true,
+		MathBlock:         true,
 		Setext:            true,
 		YamlFrontMatter:   true,
 		BlockRef:          false,
@@ -488,6 +611,8 @@ func NewOptions() *Options {
 		IndentCodeBlock:   true,
 		DataImage:         true,
 		Callout:           false,
+		Directive:         false,
+		WikiLink:          false,
 	}
 }
 
@@ -0,0 +1,343 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// Edit 描述了一次对 Markdown 原始文本的编辑：从 Offset 处删除 Deleted 个字节后插入 Inserted。
+type Edit struct {
+	Offset   int    // 编辑位置在原始文本中的字节偏移
+	Deleted  int    // 删除的字节数
+	Inserted []byte // 插入的内容
+}
+
+// ErrNoSource 说明语法树没有记录原始文本，无法进行增量解析。
+var ErrNoSource = errors.New("tree has no source, parse with SourcePos option enabled")
+
+// segment 描述了一个顶层块在原始文本中占据的片段，从该块的起始行开始，直到下一个顶层块的起始行之前。
+type segment struct {
+	offset int         // 起始行在原始文本中的字节偏移
+	line   int         // 起始行号
+	nodes  []*ast.Node // 片段上的顶层节点，除第一个节点外都是没有记录位置的节点（比如链接引用定义块）
+}
+
+// Reparse 将编辑 edit 应用到语法树对应的原始文本上，仅重新解析受影响的顶层块，其他顶层块直接复用。
+// 返回被替换掉的旧顶层节点 removed 和新解析得到的顶层节点 added，调用方可以据此局部更新渲染结果。
+//
+// 增量解析依赖节点位置，语法树需要由打开 SourcePos 解析选项的 Parse 构建。编辑涉及链接引用定义、脚注定义这类会影响其他块
+// 解析结果的内容时会退化为全量解析。
+func (t *Tree) Reparse(edit *Edit) (removed, added []*ast.Node, err error) {
+	if nil == t.source {
+		err = ErrNoSource
+		return
+	}
+	if 0 > edit.Offset || 0 > edit.Deleted || len(t.source) < edit.Offset+edit.Deleted {
+		err = fmt.Errorf("edit [%d, %d) is out of source range [0, %d)", edit.Offset, edit.Offset+edit.Deleted, len(t.source))
+		return
+	}
+
+	t.snapshot, t.blocks = nil, nil
+	source := make([]byte, 0, len(t.source)-edit.Deleted+len(edit.Inserted))
+	source = append(source, t.source[:edit.Offset]...)
+	source = append(source, edit.Inserted...)
+	source = append(source, t.source[edit.Offset+edit.Deleted:]...)
+
+	segments, tail := t.segments()
+	if 1 > len(segments) {
+		removed, added = t.reparseAll(source, tail)
+		return
+	}
+
+	// 编辑所在行可能变为前一个块的延续（比如懒惰续行、Setext 标题），所以从前一个块开始重新解析
+	first := max(segmentIndex(segments, edit.Offset)-1, 0)
+	for 0 < first && t.Context.ParseOption.KramdownBlockIAL && (ialLine(t.source[segments[first].offset:]) || ialLine(source[segments[first].offset:])) {
+		// 块 IAL 可能挂到前一个块上（比如 >{: id="x"}），需要连同前一个块一起重新解析
+		first--
+	}
+	// 向后多解析一个块作为哨兵，哨兵块起始位置不变说明其后的块都不受影响
+	last := segmentIndex(segments, edit.Offset+edit.Deleted) + 1
+	delta := len(edit.Inserted) - edit.Deleted
+	start := segments[first]
+	for {
+		end := len(source)
+		if last+1 < len(segments) {
+			end = segments[last+1].offset + delta
+		}
+
+		removed = removed[:0]
+		for _, s := range segments[first:min(last, len(segments))] {
+			removed = append(removed, s.nodes...)
+		}
+		if hasCrossRefs(removed) {
+			removed, added = t.reparseAll(source, tail)
+			return
+		}
+		frag := t.parseFragment(source[start.offset:end], 0 == first)
+		if nil == frag {
+			removed, added = t.reparseAll(source, tail)
+			return
+		}
+
+		added = added[:0]
+		fragTail := frag.Root.LastChild
+		if !isDocIAL(fragTail) {
+			fragTail = nil
+		}
+		for n := frag.Root.FirstChild; nil != n && fragTail != n; n = n.Next {
+			added = append(added, n)
+		}
+		if last >= len(segments) {
+			break
+		}
+
+		sentinel := segments[last].offset + delta - start.offset
+		k := -1
+		if t.Context.ParseOption.KramdownBlockIAL && ialLine(source[segments[last].offset+delta:]) {
+			// 哨兵块中的块 IAL 可能挂到前一个块上，无法确定哨兵块不受影响
+			sentinel = -1
+		}
+		for i, n := range added {
+			if ast.NodeKramdownBlockIAL != n.Type && nil != n.Pos && sentinel == n.Pos.StartOffset-n.Pos.StartColumn+1 {
+				k = i
+				break
+			}
+		}
+		if -1 < k {
+			added = added[:k]
+			break
+		}
+		// 哨兵块被编辑影响（比如未闭合的代码块吞掉了后续内容），扩大重新解析的范围
+		last += last - first + 1
+	}
+	if hasCrossRefs(added) {
+		// 新增的定义可能被其他块引用，新增的脚注引用会影响其他脚注引用的编号
+		removed, added = t.reparseAll(source, tail)
+		return
+	}
+
+	next, lineDelta := tail, 0
+	if last < len(segments) {
+		next = segments[last].nodes[0]
+		// 片段结束于哨兵块的起始行，按整行统计换行数不会拆开 \r\n
+		lineDelta = lineCount(source[start.offset:segments[last].offset+delta]) - lineCount(t.source[start.offset:segments[last].offset])
+	}
+	for _, n := range removed {
+		n.Unlink()
+	}
+	for _, n := range added {
+		n.Unlink()
+		shiftPos(n, start.offset, start.line-1)
+		if nil != next {
+			next.InsertBefore(n)
+		} else {
+			t.Root.AppendChild(n)
+		}
+	}
+	for n := next; nil != n; n = n.Next {
+		shiftPos(n, delta, lineDelta)
+	}
+	t.setSource(source)
+	return
+}
+
+// reparseAll 全量解析 source，替换除文档 IAL 节点 tail 以外的所有顶层节点。
+func (t *Tree) reparseAll(source []byte, tail *ast.Node) (removed, added []*ast.Node) {
+	tree := Parse(t.Name, source, t.Context.ParseOption)
+	newTail := tree.Root.LastChild
+	if nil == tail || !isDocIAL(newTail) {
+		newTail = nil
+	}
+	for n := t.Root.FirstChild; nil != n && tail != n; n = n.Next {
+		removed = append(removed, n)
+	}
+	for n := tree.Root.FirstChild; nil != n && newTail != n; n = n.Next {
+		added = append(added, n)
+	}
+	for _, n := range removed {
+		n.Unlink()
+	}
+	for _, n := range added {
+		n.Unlink()
+		if nil != tail {
+			tail.InsertBefore(n)
+		} else {
+			t.Root.AppendChild(n)
+		}
+	}
+	t.Context = tree.Context
+	t.Context.Tree = t
+	t.setSource(source)
+	return
+}
+
+// setSource 设置增量解析后的原始文本，重置定义索引和标题 ID 缓存并更新根节点的结束位置。
+func (t *Tree) setSource(source []byte) {
+	t.source = source
+	t.linkRefDefs, t.linkRefDefIndexed = nil, false
+	t.footnotesDefs, t.footnotesDefsIndex = nil, false
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeHeading == n.Type {
+			// 重复的标题 ID 按文档顺序去重，复用的标题上缓存的 ID 可能已经不对
+			n.HeadingNormalizedID = ""
+		}
+		return ast.WalkContinue
+	})
+	if nil == t.Root.Pos {
+		return
+	}
+
+	t.Root.Pos.EndOffset, t.Root.Pos.EndLine, t.Root.Pos.EndColumn = 0, 1, 0
+	for n := t.Root.LastChild; nil != n; n = n.Previous {
+		if nil != n.Pos {
+			t.Root.Pos.EndOffset, t.Root.Pos.EndLine, t.Root.Pos.EndColumn = n.Pos.EndOffset, n.Pos.EndLine, n.Pos.EndColumn
+			break
+		}
+	}
+}
+
+// parseFragment 解析原始文本片段 markdown，head 说明片段是否位于文档开头，其中的链接引用和脚注引用使用语法树 t 上的定义，但不修改这些定义。片段包含文档 IAL 时返回 nil。
+func (t *Tree) parseFragment(markdown []byte, head bool) (ret *Tree) {
+	options := t.Context.ParseOption
+	if !head && options.YamlFrontMatter {
+		// YAML Front Matter 只能出现在文档开头
+		opts := *options
+		opts.YamlFrontMatter = false
+		options = &opts
+	}
+	ret = &Tree{Name: t.Name, Context: &Context{ParseOption: options}}
+	ret.Context.Tree = ret
+	ret.lexer = lex.NewLexer(markdown)
+	ret.Root = &ast.Node{Type: ast.NodeDocument}
+	ret.rootPos()
+	ret.parseBlocks()
+	if nil != ret.Context.rootIAL {
+		return nil
+	}
+
+	t.indexLinkRefDefs()
+	t.indexFootnotesDefs()
+	ret.linkRefDefs, ret.linkRefDefIndexed = t.linkRefDefs, true
+	ret.footnotesDefs, ret.footnotesDefsIndex = t.footnotesDefs, true
+	refs := make([][]*ast.Node, len(t.footnotesDefs))
+	for i, def := range t.footnotesDefs {
+		refs[i] = def.FootnotesRefs
+	}
+	ret.parseInlines()
+	for i, def := range t.footnotesDefs {
+		// 片段中的脚注引用不能记录到语法树 t 的脚注定义上
+		def.FootnotesRefs = refs[i]
+	}
+	ret.finalizePos()
+	ret.finalParseBlockIAL()
+	ret.lexer = nil
+	return
+}
+
+// segments 返回顶层块片段。打开 KramdownBlockIAL 时最后一个顶层节点是文档 IAL 节点，作为 tail 单独返回且不计入片段。
+// 块 IAL 节点和它所属的块位于同一个片段，否则从 IAL 所在行开始重新解析时 IAL 会脱离所属的块。
+func (t *Tree) segments() (ret []*segment, tail *ast.Node) {
+	if isDocIAL(t.Root.LastChild) {
+		tail = t.Root.LastChild
+	}
+
+	for n := t.Root.FirstChild; nil != n && tail != n; n = n.Next {
+		if 1 > len(ret) {
+			// 第一个片段从文档开头开始，包含前导空行
+			ret = append(ret, &segment{line: 1})
+		} else if nil != n.Pos && ast.NodeKramdownBlockIAL != n.Type {
+			if offset := n.Pos.StartOffset - n.Pos.StartColumn + 1; ret[len(ret)-1].offset < offset {
+				ret = append(ret, &segment{offset: offset, line: n.Pos.StartLine})
+			}
+		}
+		s := ret[len(ret)-1]
+		s.nodes = append(s.nodes, n)
+	}
+	return
+}
+
+// isDocIAL 判断节点 node 是否是文档 IAL 节点。
+func isDocIAL(node *ast.Node) bool {
+	if nil == node || ast.NodeKramdownBlockIAL != node.Type {
+		return false
+	}
+	for _, kv := range Tokens2IAL(node.Tokens) {
+		if "type" == kv[0] && "doc" == kv[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ialLine 判断 markdown 的第一行是否包含块 IAL 的起始标记 {:。
+func ialLine(markdown []byte) bool {
+	if end := bytes.IndexByte(markdown, lex.ItemNewline); 0 <= end {
+		markdown = markdown[:end]
+	}
+	return bytes.Contains(markdown, openCurlyBraceColon[:2])
+}
+
+// segmentIndex 返回原始文本偏移 offset 所在片段的下标。
+func segmentIndex(segments []*segment, offset int) (ret int) {
+	for i, s := range segments {
+		if s.offset > offset {
+			break
+		}
+		ret = i
+	}
+	return
+}
+
+// hasCrossRefs 判断节点 nodes 中是否包含链接引用定义、脚注定义或者脚注引用，这些节点会影响其他块的解析结果。
+func hasCrossRefs(nodes []*ast.Node) (ret bool) {
+	for _, n := range nodes {
+		ast.Walk(n, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if ast.NodeLinkRefDef == n.Type || ast.NodeFootnotesDef == n.Type || ast.NodeFootnotesRef == n.Type {
+				ret = true
+				return ast.WalkStop
+			}
+			return ast.WalkContinue
+		})
+		if ret {
+			return
+		}
+	}
+	return
+}
+
+// shiftPos 将节点 node 及其所有后代节点的位置偏移 offset 个字节、line 行。
+func shiftPos(node *ast.Node, offset, line int) {
+	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && nil != n.Pos {
+			n.Pos.StartOffset += offset
+			n.Pos.EndOffset += offset
+			n.Pos.StartLine += line
+			n.Pos.EndLine += line
+		}
+		return ast.WalkContinue
+	})
+}
+
+// lineCount 返回 tokens 中的换行数，\r\n 和单独的 \r 都计为一个换行。
+func lineCount(tokens []byte) (ret int) {
+	for i, b := range tokens {
+		if lex.ItemNewline == b || (lex.ItemCarriageReturn == b && (i+1 == len(tokens) || lex.ItemNewline != tokens[i+1])) {
+			ret++
+		}
+	}
+	return
+}
@@ -0,0 +1,320 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// sourceLine 记录了块节点 Tokens 中某一行在原始输入中的位置。
+type sourceLine struct {
+	tokenOffset int // 该行在块节点 Tokens 中的起始偏移
+	offset      int // 该行在原始输入中的起始字节偏移
+	line        int // 行号
+	column      int // 列号
+}
+
+// sourceMap 记录了块节点 Tokens 到原始输入的映射，用于计算行级节点的位置。
+type sourceMap struct {
+	base  []byte       // 块节点在块级解析阶段最后一次追加行后的 Tokens
+	lines []sourceLine // 按 tokenOffset 递增排列的行
+}
+
+// rootPos 初始化根节点的位置。
+func (t *Tree) rootPos() {
+	if t.Context.ParseOption.SourcePos {
+		t.Root.Pos = &ast.Pos{StartLine: 1, StartColumn: 1, EndLine: 1}
+	}
+}
+
+// beginLine 在块级解析处理每一行前记录该行的行号和偏移。
+func (context *Context) beginLine(line []byte, offset int) {
+	context.lineNum++
+	context.lineOffset = offset
+	context.lineBlank = lex.IsBlankLine(line)
+}
+
+// currentLineEnd 返回当前行去掉换行符后的长度。
+func (context *Context) currentLineEnd() (ret int) {
+	ret = context.currentLineLen
+	if 0 < ret && lex.ItemNewline == context.currentLine[ret-1] {
+		ret--
+	}
+	return
+}
+
+// startPos 将当前行中下标 index 处设置为节点 node 的起始位置。
+func (context *Context) startPos(node *ast.Node, index int) {
+	if !context.ParseOption.SourcePos {
+		return
+	}
+
+	node.Pos = &ast.Pos{
+		StartOffset: context.lineOffset + index,
+		StartLine:   context.lineNum,
+		StartColumn: index + 1,
+	}
+	context.touchPos(node)
+}
+
+// touchPos 将当前行设置为节点 node 及其所有祖先节点的结束位置。空行不会延伸结束位置。
+func (context *Context) touchPos(node *ast.Node) {
+	if !context.ParseOption.SourcePos || context.lineBlank {
+		return
+	}
+
+	end := context.currentLineEnd()
+	endOffset := context.lineOffset + end
+	for n := node; nil != n; n = n.Parent {
+		if nil == n.Pos || n.Pos.EndOffset >= endOffset {
+			continue
+		}
+		n.Pos.EndOffset = endOffset
+		n.Pos.EndLine = context.lineNum
+		n.Pos.EndColumn = end
+	}
+}
+
+// addSourceLine 记录 block 的 Tokens 从 tokenOffset 开始的内容来自当前行下标 index 处。
+func (context *Context) addSourceLine(block *ast.Node, tokenOffset, index int) {
+	if !context.ParseOption.SourcePos {
+		return
+	}
+
+	if nil == context.sourceMaps {
+		context.sourceMaps = map[*ast.Node]*sourceMap{}
+	}
+	sm := context.sourceMaps[block]
+	if nil == sm {
+		sm = &sourceMap{}
+		context.sourceMaps[block] = sm
+	}
+	sm.lines = append(sm.lines, sourceLine{tokenOffset: tokenOffset, offset: context.lineOffset + index, line: context.lineNum, column: index + 1})
+	sm.base = block.Tokens
+}
+
+// inlinePos 计算块节点 block 在行级解析时 tokens[start:end] 在原始输入中的位置。无法计算时返回 nil。
+func (t *Tree) inlinePos(block *ast.Node, tokens []byte, start, end int) *ast.Pos {
+	if start >= end {
+		return nil
+	}
+
+	for b := block; nil != b; b = b.Parent {
+		sm := t.Context.sourceMaps[b]
+		if nil == sm {
+			continue
+		}
+		shift := subsliceOffset(sm.base, tokens)
+		if 0 > shift {
+			continue
+		}
+
+		startOffset, startLine, startColumn := sm.locate(start + shift)
+		endOffset, endLine, endColumn := sm.locate(end + shift - 1)
+		return &ast.Pos{
+			StartOffset: startOffset,
+			EndOffset:   endOffset + 1,
+			StartLine:   startLine,
+			StartColumn: startColumn,
+			EndLine:     endLine,
+			EndColumn:   endColumn,
+		}
+	}
+	return nil
+}
+
+// tableSourceMaps 为表 table 的单元格记录 Tokens 到原始输入的映射，sm 是表所在段落的映射。
+// 单元格的 Tokens 是切分行后的副本，所以需要在表的每一行中依次查找单元格内容来确定位置。
+func (context *Context) tableSourceMaps(table *ast.Node, sm *sourceMap) {
+	if nil == sm {
+		return
+	}
+	shift := subsliceOffset(sm.base, table.Tokens)
+	if 0 > shift {
+		return
+	}
+
+	var rows []*ast.Node
+	ast.Walk(table, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeTableRow == n.Type {
+			rows = append(rows, n)
+		}
+		return ast.WalkContinue
+	})
+
+	tokens := table.Tokens
+	for i, start, row := 0, 0, 0; start < len(tokens) && row < len(rows); i++ {
+		end := bytes.IndexByte(tokens[start:], lex.ItemNewline)
+		if 0 > end {
+			end = len(tokens)
+		} else {
+			end += start
+		}
+		if 1 != i { // 第二行是分隔符行
+			context.tableRowSourceMaps(rows[row], sm, shift+start, tokens[start:end])
+			row++
+		}
+		start = end + 1
+	}
+}
+
+// splitTablePos 在段落 p 的后半部分解析为表 table 时拆分位置，p 截止到表之前的一行，表从其第一行开始。
+func (context *Context) splitTablePos(p, table *ast.Node) {
+	sm := context.sourceMaps[p]
+	if nil == sm || nil == p.Pos {
+		return
+	}
+	shift := subsliceOffset(sm.base, table.Tokens)
+	if 1 > shift {
+		return
+	}
+
+	table.Pos = copyPos(p.Pos)
+	table.Pos.StartOffset, table.Pos.StartLine, table.Pos.StartColumn = sm.locate(shift)
+	offset, line, column := sm.locate(shift - 1) // 表之前的换行符
+	p.Pos.EndOffset, p.Pos.EndLine, p.Pos.EndColumn = offset, line, column-1
+}
+
+// tableRowSourceMaps 为表行 row 的单元格记录映射，line 是该行的内容，lineOffset 是该行在 sm 中的偏移。
+func (context *Context) tableRowSourceMaps(row *ast.Node, sm *sourceMap, lineOffset int, line []byte) {
+	pos := 0
+	for cell := row.FirstChild; nil != cell; cell = cell.Next {
+		if ast.NodeTableCell != cell.Type || 1 > len(cell.Tokens) {
+			continue
+		}
+		idx := bytes.Index(line[pos:], cell.Tokens)
+		if 0 > idx {
+			return
+		}
+
+		pos += idx
+		offset, lineNum, column := sm.locate(lineOffset + pos)
+		context.sourceMaps[cell] = &sourceMap{base: cell.Tokens, lines: []sourceLine{{offset: offset, line: lineNum, column: column}}}
+		pos += len(cell.Tokens)
+	}
+}
+
+// locate 返回 Tokens 中偏移 tokenOffset 处在原始输入中的字节偏移、行号和列号。
+func (sm *sourceMap) locate(tokenOffset int) (offset, line, column int) {
+	l := sm.lines[0]
+	for _, sl := range sm.lines[1:] {
+		if sl.tokenOffset > tokenOffset {
+			break
+		}
+		l = sl
+	}
+	delta := tokenOffset - l.tokenOffset
+	return l.offset + delta, l.line, l.column + delta
+}
+
+// subsliceOffset 返回 sub 在 base 中的起始偏移，如果 sub 不是 base 的子切片则返回 -1。
+func subsliceOffset(base, sub []byte) int {
+	if 1 > cap(base) || 1 > cap(sub) || cap(sub) > cap(base) {
+		return -1
+	}
+
+	b, s := base[:cap(base)], sub[:cap(sub)]
+	if &b[len(b)-1] != &s[len(s)-1] {
+		return -1
+	}
+	return cap(base) - cap(sub)
+}
+
+// finalizePos 为没有记录位置的节点补全位置，取其所有子节点位置的并集。
+func (t *Tree) finalizePos() {
+	if !t.Context.ParseOption.SourcePos {
+		return
+	}
+
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering || nil != n.Pos {
+			return ast.WalkContinue
+		}
+
+		for c := n.FirstChild; nil != c; c = c.Next {
+			if nil == c.Pos {
+				continue
+			}
+			if nil == n.Pos {
+				pos := *c.Pos
+				n.Pos = &pos
+				continue
+			}
+			unionPos(n, c)
+		}
+		return ast.WalkContinue
+	})
+	t.Context.sourceMaps = nil
+}
+
+// copyPos 返回位置 p 的副本，避免多个节点共享同一个位置。
+func copyPos(p *ast.Pos) *ast.Pos {
+	if nil == p {
+		return nil
+	}
+	ret := *p
+	return &ret
+}
+
+// unionPos 将节点 n 的位置扩展为 n 和 other 位置的并集。
+func unionPos(n, other *ast.Node) {
+	if nil == n.Pos || nil == other.Pos {
+		return
+	}
+
+	if other.Pos.StartOffset < n.Pos.StartOffset {
+		n.Pos.StartOffset, n.Pos.StartLine, n.Pos.StartColumn = other.Pos.StartOffset, other.Pos.StartLine, other.Pos.StartColumn
+	}
+	if other.Pos.EndOffset > n.Pos.EndOffset {
+		n.Pos.EndOffset, n.Pos.EndLine, n.Pos.EndColumn = other.Pos.EndOffset, other.Pos.EndLine, other.Pos.EndColumn
+	}
+}
+
+// setInlinePos 设置行级解析函数本次生成的节点 n 的位置，startPos 是解析前的位置。
+// 解析函数没有返回节点时（比如强调分隔符）则设置块节点 block 最后一个子节点的位置。
+func (t *Tree) setInlinePos(block *ast.Node, ctx *InlineContext, n *ast.Node, startPos int) {
+	if nil == n {
+		n = block.LastChild
+		if nil == n || nil != n.Pos {
+			return
+		}
+	} else if nil != n.Previous || nil != n.Next {
+		// 返回多个节点的情况下无法区分各自的范围，由 finalizePos 根据子节点补全
+		return
+	}
+	n.Pos = t.inlinePos(block, ctx.tokens, startPos, ctx.pos)
+	for c := n.FirstChild; nil != c; c = c.Next {
+		// 链接和图片等节点会收纳之前解析的节点（比如开始的 [），需要合并子节点的范围
+		unionPos(n, c)
+	}
+}
+
+// delimPos 在强调处理消耗分隔符后设置起始、结束标记符节点的位置，并收缩分隔符文本节点的位置。
+// 起始标记符取自开始分隔符串的末尾，结束标记符取自结束分隔符串的开头。
+func delimPos(openerInl, closerInl, openMarker, closeMarker *ast.Node, openerTokenLen, closerTokenLen int) {
+	if nil != openerInl.Pos {
+		p := *openerInl.Pos
+		p.StartOffset, p.StartColumn = p.EndOffset-openerTokenLen, p.EndColumn-openerTokenLen+1
+		openMarker.Pos = &p
+		openerInl.Pos.EndOffset -= openerTokenLen
+		openerInl.Pos.EndColumn -= openerTokenLen
+	}
+	if nil != closerInl.Pos {
+		p := *closerInl.Pos
+		p.EndOffset, p.EndColumn = p.StartOffset+closerTokenLen, p.StartColumn+closerTokenLen-1
+		closeMarker.Pos = &p
+		closerInl.Pos.StartOffset += closerTokenLen
+		closerInl.Pos.StartColumn += closerTokenLen
+	}
+}
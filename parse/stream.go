@@ -0,0 +1,160 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"context"
+	"io"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// Stream 从 reader 中逐行读取 markdown 原始文本进行解析，每当有顶层块级节点解析完成（被 Context.finalize 闭合）时，
+// 就将这些节点放到一棵新的语法树上回调 flush，回调返回后这些节点不再被引用，因此解析大文档时不需要在内存中保留整棵语法树。
+//
+// 流式解析无法向后查看，所以和 Parse 相比存在如下限制：
+//   - 链接引用定义和脚注定义只对其后出现的引用生效
+//   - 脚注定义块会保留到最后一次回调时输出，以便收集所有脚注引用
+//   - 不记录节点位置，不处理 Kramdown 块级 IAL
+//
+// 传入的 options 不会被修改。Stream 不检查解析选项中的限制，需要检查时请使用 StreamContext。
+func Stream(name string, reader io.Reader, options *Options, flush func(tree *Tree) error) error {
+	return stream(nil, name, reader, options, flush)
+}
+
+// StreamContext 和 Stream 一样流式解析 markdown，但是会在 ctx 取消或者超出 options 中的 MaxInputBytes（累计读取的字节数）、
+// MaxBlockDepth 和 MaxInlineNesting 限制时中止解析并返回错误，flush 返回的错误原样返回。
+//
+// 解析过程中发生的 panic 会被转换为 *ast.NodeError 返回，错误中记录了出错时正在解析的节点类型和位置。
+func StreamContext(ctx context.Context, name string, reader io.Reader, options *Options, flush func(tree *Tree) error) (err error) {
+	if err = ctx.Err(); nil != err {
+		return
+	}
+	return stream(ctx, name, reader, options, flush)
+}
+
+// stream 实现流式解析，ctx 为 nil 时不检查限制。
+func stream(ctx context.Context, name string, reader io.Reader, options *Options, flush func(tree *Tree) error) (err error) {
+	opts := *options
+	opts.SourcePos = false
+	options = &opts
+
+	t := &Tree{Name: name, Context: &Context{ParseOption: options}}
+	t.Context.Tree = t
+	t.Root = &ast.Node{Type: ast.NodeDocument}
+	t.Context.Tip = t.Root
+	// 定义索引随着块级节点的完成逐步构建
+	t.linkRefDefIndexed, t.footnotesDefsIndex = true, true
+
+	if nil != ctx {
+		if nil != ctx.Done() {
+			t.Context.ctx = ctx
+		}
+		t.Context.limited = nil != t.Context.ctx || 0 < options.MaxBlockDepth || 0 < options.MaxInlineNesting
+
+		defer func() {
+			if r := recover(); nil != r {
+				if abort, ok := r.(*parseAbort); ok {
+					err = abort.err
+					return
+				}
+				err = ast.NewPanicError(t.Context.errorNode(), r)
+			}
+		}()
+	}
+
+	var footnotesDefBlocks []*ast.Node
+	var read int
+	lines := lex.NewLineReader(reader)
+	for line := lines.NextLine(); nil != line; line = lines.NextLine() {
+		if nil != ctx {
+			if read += len(line); 0 < options.MaxInputBytes && read > options.MaxInputBytes {
+				return ErrInputTooLarge
+			}
+		}
+		if t.Context.limited {
+			t.checkCancel()
+		}
+		t.incorporateLine(line)
+		if err = t.flushBlocks(false, &footnotesDefBlocks, flush); nil != err {
+			return
+		}
+	}
+	if err = lines.Err(); nil != err {
+		return
+	}
+
+	for nil != t.Context.Tip {
+		t.Context.finalize(t.Context.Tip)
+	}
+	err = t.flushBlocks(true, &footnotesDefBlocks, flush)
+	return
+}
+
+// flushBlocks 解析已经完成的顶层块级节点的行级子节点，然后将它们移到一棵新的语法树上回调 flush。
+// 脚注定义块会暂存到 footnotesDefBlocks 中，在最后一次回调（last 为 true）时输出。
+func (t *Tree) flushBlocks(last bool, footnotesDefBlocks *[]*ast.Node, flush func(tree *Tree) error) error {
+	var blocks []*ast.Node
+	for n := t.Root.FirstChild; nil != n; n = n.Next {
+		if !last && t.Root.LastChild == n && !n.Close {
+			break
+		}
+		blocks = append(blocks, n)
+	}
+	if 1 > len(blocks) && (!last || 1 > len(*footnotesDefBlocks)) {
+		return nil
+	}
+
+	if 0 < len(blocks) {
+		// YAML Front Matter 只能出现在文档开头，节点移走后需要避免后续内容被识别为 YAML Front Matter
+		t.Context.ParseOption.YamlFrontMatter = false
+	}
+
+	chunk := &Tree{Name: t.Name, Context: &Context{ParseOption: t.Context.ParseOption}}
+	chunk.Context.Tree = chunk
+	chunk.Root = &ast.Node{Type: ast.NodeDocument}
+	for _, n := range blocks {
+		ast.Walk(n, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if !entering {
+				return ast.WalkContinue
+			}
+			if ast.NodeLinkRefDef == n.Type {
+				t.linkRefDefs = append(t.linkRefDefs, &linkRefDef{tokens: n.Tokens, folded: foldBytes(n.Tokens), link: n.FirstChild})
+			} else if ast.NodeFootnotesDef == n.Type {
+				t.footnotesDefs = append(t.footnotesDefs, n)
+			}
+			return ast.WalkContinue
+		})
+		t.walkParseInline(n)
+		if nil == n.Parent { // 空段落会在解析行级节点时被移除
+			continue
+		}
+
+		n.Unlink()
+		if ast.NodeFootnotesDefBlock == n.Type {
+			*footnotesDefBlocks = append(*footnotesDefBlocks, n)
+			continue
+		}
+		chunk.Root.AppendChild(n)
+	}
+	if last {
+		for _, n := range *footnotesDefBlocks {
+			chunk.Root.AppendChild(n)
+		}
+	}
+	if t.Context.ParseOption.KramdownSpanIAL {
+		chunk.parseKramdownSpanIAL()
+	}
+	chunk.linkRefDefs, chunk.linkRefDefIndexed = t.linkRefDefs, true
+	chunk.footnotesDefs, chunk.footnotesDefsIndex = t.footnotesDefs, true
+	return flush(chunk)
+}
@@ -51,6 +51,9 @@ func SuperBlockContinue(superBlock *ast.Node, context *Context) int {
 }
 
 func (context *Context) superBlockFinalize(superBlock *ast.Node) {
+	// 正常闭合的超级块不会经过最终化，所以这里的超级块都是没有闭合的
+	context.diagnose(SeverityError, DiagUnclosedSuperBlock, "super block is not closed", superBlock)
+
 	// 最终化所有子块
 	for child := superBlock.FirstChild; nil != child; child = child.Next {
 		if child.Close {
@@ -0,0 +1,192 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+
+	"github.com/88250/lute/ast"
+)
+
+// BlockSyntax 描述了自定义块级语法扩展，通过 Options.BlockSyntaxes 注册。
+//
+// 扩展块可以使用已有的节点类型，比如使用 NodeBlockquote 作为可以包含其他块的容器块，或者使用 NodeCustomBlock 作为接受文本行的叶子块。
+// 节点能否包含子块、能否接受文本行由节点类型决定。
+type BlockSyntax struct {
+	// Name 扩展名称。
+	Name string
+	// Markers 块起始行第一个非空白字符的候选字符，用于快速跳过不可能匹配的行。为空时每行都会调用 Start。
+	Markers []byte
+	// Start 判断当前行是否开始一个扩展块。匹配时需要调用 Context.OpenBlock 创建节点并调用 Context.Advance 消费标记符。
+	// 返回值和内置的块起始函数一致：0 不匹配，1 匹配到容器块，2 匹配到叶子块。叶子块起始行剩余的内容会作为第一行添加到节点上。
+	// 不匹配时对当前行位置的修改（比如调用 Context.Advance）会被回滚。
+	Start func(context *Context, container *ast.Node) int
+	// Continue 判断扩展块在当前行是否可以延续，返回 0 可以延续，1 不能延续，2 当前行是扩展块的结束行（扩展块会被闭合，继续处理下一行）。
+	// 为 nil 时使用节点类型的内置判断。
+	Continue func(context *Context, node *ast.Node) int
+	// Finalize 在扩展块闭合时调用，可以用于整理节点内容。为 nil 时使用节点类型的内置处理。
+	Finalize func(context *Context, node *ast.Node)
+}
+
+// InlineSyntax 描述了自定义行级语法扩展，通过 Options.InlineSyntaxes 注册。
+type InlineSyntax struct {
+	// Name 扩展名称。
+	Name string
+	// Triggers 触发字符，行级解析遇到这些字符时会先调用 Parse，不匹配时再使用内置的解析。
+	Triggers []byte
+	// Parse 从 tokens（从触发字符开始直到块内容结尾）解析行级节点，返回节点以及消耗的字节数，不匹配时返回 nil。
+	// 返回的 NodeCustomInline 节点没有设置 Tokens 时，会使用消耗的原始文本作为 Tokens，以便格式化时原样输出。
+	Parse func(block *ast.Node, tokens []byte) (node *ast.Node, consumed int)
+}
+
+// Line 返回当前行从当前解析位置开始的剩余内容，以 \n 结尾。
+func (context *Context) Line() []byte {
+	return context.currentLine[context.offset:]
+}
+
+// Indent 返回当前行第一个非空白字符之前的缩进列数。
+func (context *Context) Indent() int {
+	return context.indent
+}
+
+// Advance 从当前解析位置开始前进 count 个字节。
+func (context *Context) Advance(count int) {
+	context.advanceOffset(count, true)
+}
+
+// AdvanceNextNonspace 前进到当前行第一个非空白字符。
+func (context *Context) AdvanceNextNonspace() {
+	context.advanceNextNonspace()
+}
+
+// OpenBlock 闭合未匹配的块后，在末梢节点上添加一个 nodeType 类型的块并将其作为新的末梢节点，用于块级语法扩展的 Start。
+func (context *Context) OpenBlock(nodeType ast.NodeType) (ret *ast.Node) {
+	context.closeUnmatchedBlocks()
+	ret = context.addChild(nodeType)
+	if nil != context.blockSyntax {
+		if nil == context.syntaxBlocks {
+			context.syntaxBlocks = map[*ast.Node]*BlockSyntax{}
+		}
+		context.syntaxBlocks[ret] = context.blockSyntax
+	}
+	return
+}
+
+// CloseBlock 闭合块 node。
+func (context *Context) CloseBlock(node *ast.Node) {
+	context.finalize(node)
+}
+
+// blockStarts 返回块起始模式函数切片，注册了块级语法扩展时扩展的起始判断排在内置的起始判断之前。
+func (t *Tree) blockStarts() []blockStartFunc {
+	syntaxes := t.Context.ParseOption.BlockSyntaxes
+	if 1 > len(syntaxes) {
+		return blockStartsFuncs
+	}
+
+	if nil == t.Context.blockStartFuncs {
+		for _, syntax := range syntaxes {
+			t.Context.blockStartFuncs = append(t.Context.blockStartFuncs, syntax.start)
+		}
+		t.Context.blockStartFuncs = append(t.Context.blockStartFuncs, blockStartsFuncs...)
+	}
+	return t.Context.blockStartFuncs
+}
+
+func (syntax *BlockSyntax) start(t *Tree, container *ast.Node) int {
+	t.Context.blockSyntax = syntax
+	defer func() { t.Context.blockSyntax = nil }()
+
+	// 扩展没有开始块时回滚其对当前行位置的修改，避免影响后续的起始判断
+	context := t.Context
+	offset, column, partiallyConsumedTab := context.offset, context.column, context.partiallyConsumedTab
+	nextNonspace, nextNonspaceColumn, indent, indented, blank := context.nextNonspace, context.nextNonspaceColumn, context.indent, context.indented, context.blank
+	ret := syntax.Start(context, container)
+	if 0 == ret {
+		context.offset, context.column, context.partiallyConsumedTab = offset, column, partiallyConsumedTab
+		context.nextNonspace, context.nextNonspaceColumn, context.indent, context.indented, context.blank = nextNonspace, nextNonspaceColumn, indent, indented, blank
+	}
+	return ret
+}
+
+// maybeSyntaxMarker 判断 token 是否可能是块级语法扩展的起始标记符。
+func (context *Context) maybeSyntaxMarker(token byte) bool {
+	for _, syntax := range context.ParseOption.BlockSyntaxes {
+		if 1 > len(syntax.Markers) || 0 <= bytes.IndexByte(syntax.Markers, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// syntaxContinue 使用块级语法扩展判断块 n 是否可以延续，n 不是扩展块或者扩展没有设置 Continue 时返回 false。
+func (context *Context) syntaxContinue(n *ast.Node) (ret int, ok bool) {
+	syntax := context.syntaxBlocks[n]
+	if nil == syntax || nil == syntax.Continue {
+		return
+	}
+
+	ret, ok = syntax.Continue(context, n), true
+	if 2 == ret {
+		// 扩展块结束时先闭合其中尚未闭合的子块
+		for !n.Close && nil != context.Tip {
+			context.finalize(context.Tip)
+		}
+	}
+	return
+}
+
+// syntaxFinalize 使用块级语法扩展闭合块 block，block 不是扩展块或者扩展没有设置 Finalize 时返回 false。
+func (context *Context) syntaxFinalize(block *ast.Node) bool {
+	syntax := context.syntaxBlocks[block]
+	if nil == syntax {
+		return false
+	}
+
+	delete(context.syntaxBlocks, block)
+	if nil == syntax.Finalize {
+		return false
+	}
+	syntax.Finalize(context, block)
+	return true
+}
+
+// isSyntaxTrigger 判断 token 是否是行级语法扩展的触发字符。
+func (t *Tree) isSyntaxTrigger(token byte) bool {
+	for _, syntax := range t.Context.ParseOption.InlineSyntaxes {
+		if 0 <= bytes.IndexByte(syntax.Triggers, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInlineSyntax 使用行级语法扩展解析当前位置的行级节点，没有扩展匹配时返回 nil。
+func (t *Tree) parseInlineSyntax(block *ast.Node, ctx *InlineContext) *ast.Node {
+	token := ctx.tokens[ctx.pos]
+	for _, syntax := range t.Context.ParseOption.InlineSyntaxes {
+		if 0 > bytes.IndexByte(syntax.Triggers, token) {
+			continue
+		}
+
+		tokens := ctx.tokens[ctx.pos:]
+		n, consumed := syntax.Parse(block, tokens)
+		if nil == n || 1 > consumed || len(tokens) < consumed {
+			continue
+		}
+		if ast.NodeCustomInline == n.Type && nil == n.Tokens {
+			n.Tokens = tokens[:consumed]
+		}
+		ctx.pos += consumed
+		return n
+	}
+	return nil
+}
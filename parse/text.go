@@ -22,8 +22,9 @@ import (
 func (t *Tree) parseText(ctx *InlineContext) *ast.Node {
 	start := ctx.pos
 	for ; ctx.pos < ctx.tokensLen; ctx.pos++ {
-		if t.isMarker(ctx.tokens[ctx.pos]) || (t.Context.ParseOption.FullWidthStrikethrough &&
-			bytes.HasPrefix(ctx.tokens[ctx.pos:], fullWidthTilde)) {
+		// 至少消费一个字节，行级语法扩展的触发字符没有匹配时会作为文本开头
+		if start < ctx.pos && (t.isMarker(ctx.tokens[ctx.pos]) || (t.Context.ParseOption.FullWidthStrikethrough &&
+			bytes.HasPrefix(ctx.tokens[ctx.pos:], fullWidthTilde))) {
 			// 遇到潜在的标记符时需要跳出该文本节点，回到行级解析主循环
 			break
 		}
@@ -40,6 +41,14 @@ func (t *Tree) isMarker(token byte) bool {
 	if t.Context.ParseOption.Sup && lex.ItemCaret == token {
 		return true
 	}
+
+	if t.Context.ParseOption.Directive && lex.ItemColon == token {
+		return true
+	}
+
+	if 0 < len(t.Context.ParseOption.InlineSyntaxes) && t.isSyntaxTrigger(token) {
+		return true
+	}
 	return false
 }
 
@@ -154,6 +163,7 @@ func (t *Tree) mergeText(node *ast.Node) {
 				merged = append(merged, child.Tokens...)
 				for nil != next && ast.NodeText == next.Type {
 					merged = append(merged, next.Tokens...)
+					unionPos(child, next)
 					next.Unlink()
 					next = child.Next
 				}
@@ -0,0 +1,91 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+var (
+	wikiLinkOpenMarker  = []byte("[[")
+	wikiLinkCloseMarker = []byte("]]")
+)
+
+// parseWikiLink 解析维基链接 [[target#heading|alias]] 和维基链接嵌入 ![[target#heading|alias]]，不匹配时返回 nil。
+func (t *Tree) parseWikiLink(ctx *InlineContext) *ast.Node {
+	tokens := ctx.tokens[ctx.pos:]
+	embed := lex.ItemBang == tokens[0]
+	if embed {
+		tokens = tokens[1:]
+	}
+	if !bytes.HasPrefix(tokens, wikiLinkOpenMarker) {
+		return nil
+	}
+
+	end := bytes.Index(tokens[2:], wikiLinkCloseMarker)
+	if 0 > end {
+		return nil
+	}
+	content := tokens[2 : 2+end]
+	if bytes.ContainsAny(content, "[]\n") {
+		return nil
+	}
+
+	ret := NewWikiLink(string(content), embed)
+	if nil == ret {
+		return nil
+	}
+	ctx.pos += len(wikiLinkOpenMarker) + end + len(wikiLinkCloseMarker)
+	if embed {
+		ctx.pos++
+	}
+	return ret
+}
+
+// NewWikiLink 使用维基链接标记符 [[ ]] 中的内容 target#heading|alias 构造维基链接节点，embed 为 true 时构造维基链接嵌入节点。
+// 表格中使用的 \| 也作为别名分隔符，目标和标题都为空时返回 nil。节点的 Tokens 记录了规范化后的维基链接文本，用于计算表格单元格宽度。
+func NewWikiLink(content string, embed bool) (ret *ast.Node) {
+	target, alias, aliasSep := content, "", "|"
+	if idx := strings.IndexByte(content, '|'); 0 <= idx {
+		target, alias = content[:idx], content[idx+1:]
+		if strings.HasSuffix(target, "\\") {
+			target, aliasSep = target[:len(target)-1], "\\|"
+		}
+	}
+
+	heading := ""
+	if idx := strings.IndexByte(target, '#'); 0 <= idx {
+		target, heading = target[:idx], target[idx+1:]
+	}
+	target, heading, alias = strings.TrimSpace(target), strings.TrimSpace(heading), strings.TrimSpace(alias)
+	if "" == target && "" == heading {
+		return nil
+	}
+
+	ret = &ast.Node{Type: ast.NodeWikiLink, WikiLinkTarget: target, WikiLinkHeading: heading, WikiLinkAlias: alias}
+	content = target
+	if "" != heading {
+		content += "#" + heading
+	}
+	if "" != alias {
+		content += aliasSep + alias
+	}
+	ret.Tokens = []byte("[[" + content + "]]")
+	if embed {
+		ret.Type = ast.NodeWikiLinkEmbed
+		ret.Tokens = append([]byte{lex.ItemBang}, ret.Tokens...)
+	}
+	return
+}
@@ -29,6 +29,7 @@ func YamlFrontMatterStart(t *Tree, container *ast.Node) int {
 		node := &ast.Node{Type: ast.NodeYamlFrontMatter}
 		t.Root.AppendChild(node)
 		t.Context.Tip = node
+		t.Context.startPos(node, t.Context.nextNonspace)
 		return 2
 	}
 	return 0
@@ -24,19 +24,7 @@ func main() {
 		panic(err)
 	}
 
-	luteEngine := lute.New()
-	luteEngine.SetGFMTaskListItem(false)
-	luteEngine.SetGFMTable(false)
-	luteEngine.SetGFMAutoLink(false)
-	luteEngine.SetGFMStrikethrough(false)
-	luteEngine.SetSoftBreak2HardBreak(false)
-	luteEngine.SetCodeSyntaxHighlight(false)
-	luteEngine.SetFootnotes(false)
-	luteEngine.SetAutoSpace(false)
-	luteEngine.SetFixTermTypo(false)
-	luteEngine.SetEmoji(false)
-	luteEngine.SetBlockRef(false)
-	luteEngine.SetMark(false)
+	luteEngine := lute.NewWithFlavor(lute.FlavorCommonMark)
 
 	cpuProfile, _ := os.Create("pprof/cpu_profile")
 	pprof.StartCPUProfile(cpuProfile)
@@ -12,6 +12,7 @@ package lute
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -164,8 +165,29 @@ func (lute *Lute) Md2BlockDOMWithAutoLink(markdown string, reserveEmptyParagraph
 	return
 }
 
+// Md2BlockDOMContext 将 markdown 转换为 Protyle 块 DOM，在 ctx 取消、超出解析和渲染限制或者转换过程中发生 panic 时返回错误。
+func (lute *Lute) Md2BlockDOMContext(ctx context.Context, markdown string, reserveEmptyParagraph bool) (vHTML string, err error) {
+	vHTML, _, err = lute.md2BlockDOMTree(ctx, markdown, reserveEmptyParagraph)
+	return
+}
+
+// Md2BlockDOMTree 将 markdown 转换为 Protyle 块 DOM 并返回语法树，处理出错时会以该错误 panic，需要获取错误时请使用 Md2BlockDOMContext。
 func (lute *Lute) Md2BlockDOMTree(markdown string, reserveEmptyParagraph bool) (vHTML string, tree *parse.Tree) {
-	tree = parse.Parse("", []byte(markdown), lute.ParseOptions)
+	vHTML, tree, err := lute.md2BlockDOMTree(context.Background(), markdown, reserveEmptyParagraph)
+	if nil != err {
+		panic(err)
+	}
+	return
+}
+
+func (lute *Lute) md2BlockDOMTree(ctx context.Context, markdown string, reserveEmptyParagraph bool) (vHTML string, tree *parse.Tree, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree, err = lute.parseMarkdownContext(ctx, "", []byte(markdown))
+	if nil != err {
+		return
+	}
 
 	parse.TextMarks2Inlines(tree) // 先将 TextMark 转换为 Inlines https://github.com/siyuan-note/siyuan/issues/13056
 	parse.NestedInlines2FlattedSpansHybrid(tree, false)
@@ -187,16 +209,24 @@ func (lute *Lute) Md2BlockDOMTree(markdown string, reserveEmptyParagraph bool) (
 	}
 
 	renderer := render.NewProtyleRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	base = renderer.BaseRenderer
 	for nodeType, rendererFunc := range lute.Md2BlockDOMRendererFuncs {
 		renderer.ExtRendererFuncs[nodeType] = rendererFunc
 	}
+	renderer.SetContext(ctx)
 	output := renderer.Render()
+	if err = renderer.CheckOutput(output); nil != err {
+		return
+	}
 	vHTML = util.BytesToStr(output)
 	return
 }
 
 func (lute *Lute) InlineMd2BlockDOM(markdown string) (vHTML string) {
 	tree := parse.Inline("", []byte(markdown), lute.ParseOptions)
+	if err := lute.ApplyTransforms(tree); nil != err {
+		panic(err)
+	}
 	parse.NestedInlines2FlattedSpansHybrid(tree, false)
 	renderer := render.NewProtyleRenderer(tree, lute.RenderOptions, lute.ParseOptions)
 	for nodeType, rendererFunc := range lute.Md2BlockDOMRendererFuncs {
@@ -213,6 +243,23 @@ func (lute *Lute) BlockDOM2Md(htmlStr string) (kramdown string) {
 	return
 }
 
+// BlockDOM2MdContext 将 Protyle 块 DOM 转换为 kramdown，在 ctx 取消、超出渲染限制或者转换过程中发生 panic 时返回错误。
+func (lute *Lute) BlockDOM2MdContext(ctx context.Context, htmlStr string) (kramdown string, err error) {
+	var base *render.BaseRenderer
+	defer recoverNodeError(&err, &base)
+
+	tree := lute.BlockDOM2Tree(htmlStr)
+	renderer := lute.newBlockDOMFormatRenderer(tree)
+	base = renderer.BaseRenderer
+	renderer.SetContext(ctx)
+	formatted := renderer.Render()
+	if err = renderer.CheckOutput(formatted); nil != err {
+		return
+	}
+	kramdown = strings.ReplaceAll(string(formatted), editor.Zwsp, "")
+	return
+}
+
 func (lute *Lute) BlockDOM2StdMd(htmlStr string) (markdown string) {
 	keepEscaped := lute.ParseOptions.KeepEscaped
 	lute.ParseOptions.KeepEscaped = false
@@ -979,8 +1026,15 @@ func (lute *Lute) blockDOM2Md(htmlStr string) (markdown string) {
 }
 
 func (lute *Lute) blockDOMTree2Md(tree *parse.Tree) (markdown string) {
-
 	// 将 AST 进行 Markdown 格式化渲染
+	renderer := lute.newBlockDOMFormatRenderer(tree)
+	formatted := renderer.Render()
+	markdown = string(formatted)
+	return
+}
+
+// newBlockDOMFormatRenderer 创建将块 DOM 转换得到的语法树格式化为 kramdown 的渲染器。
+func (lute *Lute) newBlockDOMFormatRenderer(tree *parse.Tree) *render.FormatRenderer {
 	options := render.NewOptions()
 	options.AutoSpace = false
 	options.FixTermTypo = false
@@ -990,10 +1044,7 @@ func (lute *Lute) blockDOMTree2Md(tree *parse.Tree) (markdown string) {
 	options.ProtyleWYSIWYG = true
 	options.SuperBlock = true
 	options.UnorderedListMarker = lute.RenderOptions.UnorderedListMarker
-	renderer := render.NewFormatRenderer(tree, options, lute.ParseOptions)
-	formatted := renderer.Render()
-	markdown = string(formatted)
-	return
+	return render.NewFormatRenderer(tree, options, lute.ParseOptions)
 }
 
 func normalizeSpinCaretNewline(tree *parse.Tree) {
@@ -1397,6 +1448,21 @@ func (lute *Lute) genASTByBlockDOM(n *html.Node, tree *parse.Tree) {
 		tree.Context.Tip.AppendChild(node)
 		tree.Context.Tip = node
 		defer tree.Context.ParentTip()
+	case ast.NodeContainerDirective:
+		node.Type = ast.NodeContainerDirective
+		node.DirectiveName = util.DomAttrValue(n, "data-subtype")
+		node.DirectiveLabel = util.DomAttrValue(n, "data-label")
+		node.DirectiveAttrs = parse.Tokens2DirectiveAttrs([]byte(util.DomAttrValue(n, "data-attrs")))
+		tree.Context.Tip.AppendChild(node)
+		tree.Context.Tip = node
+		defer tree.Context.ParentTip()
+	case ast.NodeLeafDirective:
+		node.Type = ast.NodeLeafDirective
+		node.DirectiveName = util.DomAttrValue(n, "data-subtype")
+		node.DirectiveAttrs = parse.Tokens2DirectiveAttrs([]byte(util.DomAttrValue(n, "data-attrs")))
+		tree.Context.Tip.AppendChild(node)
+		tree.Context.Tip = node
+		defer tree.Context.ParentTip()
 	case ast.NodeCallout:
 		node.Type = ast.NodeCallout
 		node.CalloutType = util.DomAttrValue(n, "data-subtype")
@@ -0,0 +1,323 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+)
+
+// matchList 判断节点 n 是否匹配选择器列表中的任意一个选择器，scope 为 :has 的作用域节点。
+func matchList(list []*complexSelector, n, scope *ast.Node) bool {
+	for _, c := range list {
+		if matchComplex(c, len(c.compounds)-1, n, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchComplex 从右向左匹配，判断节点 n 是否匹配 c.compounds[i] 并且左侧的部分能够匹配 n 的祖先或者兄弟节点。
+func matchComplex(c *complexSelector, i int, n, scope *ast.Node) bool {
+	if !matchCompound(c.compounds[i], n, scope) {
+		return false
+	}
+	if 0 == i {
+		return true
+	}
+
+	switch c.combinators[i-1] {
+	case '>':
+		return nil != n.Parent && matchComplex(c, i-1, n.Parent, scope)
+	case '+':
+		prev := previous(n)
+		return nil != prev && matchComplex(c, i-1, prev, scope)
+	case '~':
+		for prev := previous(n); nil != prev; prev = previous(prev) {
+			if matchComplex(c, i-1, prev, scope) {
+				return true
+			}
+		}
+	default:
+		for p := n.Parent; nil != p; p = p.Parent {
+			if matchComplex(c, i-1, p, scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchCompound(c *compound, n, scope *ast.Node) bool {
+	if c.scope {
+		return n == scope
+	}
+	if c.hasType {
+		if c.typ != n.Type {
+			return false
+		}
+	} else if ast.NodeKramdownBlockIAL == n.Type {
+		// 块级 IAL 节点是所属节点的属性，仅在指定类型时匹配
+		return false
+	}
+
+	for _, attr := range c.attrs {
+		if !matchAttr(attr, n) {
+			return false
+		}
+	}
+	for _, pseudo := range c.pseudos {
+		if !matchPseudo(pseudo, n, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAttr(attr *attrSelector, n *ast.Node) bool {
+	value, ok := attrValue(n, attr.name)
+	if "!=" == attr.op {
+		return !ok || value != attr.value
+	}
+	if !ok {
+		return false
+	}
+
+	switch attr.op {
+	case "=":
+		return value == attr.value
+	case "~=":
+		for _, word := range strings.Fields(value) {
+			if word == attr.value {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return value == attr.value || strings.HasPrefix(value, attr.value+"-")
+	case "^=":
+		return "" != attr.value && strings.HasPrefix(value, attr.value)
+	case "$=":
+		return "" != attr.value && strings.HasSuffix(value, attr.value)
+	case "*=":
+		return "" != attr.value && strings.Contains(value, attr.value)
+	}
+	return true
+}
+
+func matchPseudo(pseudo *pseudo, n, scope *ast.Node) bool {
+	switch pseudo.name {
+	case "first-child":
+		return nil != n.Parent && nil == previous(n)
+	case "last-child":
+		return nil != n.Parent && nil == next(n)
+	case "only-child":
+		return nil != n.Parent && nil == previous(n) && nil == next(n)
+	case "nth-child", "nth-last-child":
+		if nil == n.Parent {
+			return false
+		}
+		index := 1
+		if "nth-child" == pseudo.name {
+			for prev := previous(n); nil != prev; prev = previous(prev) {
+				index++
+			}
+		} else {
+			for nxt := next(n); nil != nxt; nxt = next(nxt) {
+				index++
+			}
+		}
+		if 0 == pseudo.a {
+			return index == pseudo.b
+		}
+		return 0 <= (index-pseudo.b)/pseudo.a && 0 == (index-pseudo.b)%pseudo.a
+	case "empty":
+		return nil == firstChild(n)
+	case "root":
+		return nil == n.Parent
+	case "not":
+		return !matchList(pseudo.list, n, scope)
+	case "has":
+		for _, c := range pseudo.list {
+			// 相对选择器的最右侧节点在后代中，兄弟组合器时在后续兄弟节点的子树中
+			candidates := n
+			if combinator := c.combinators[0]; '+' == combinator || '~' == combinator {
+				if candidates = n.Parent; nil == candidates {
+					continue
+				}
+			}
+			found := false
+			ast.Walk(candidates, func(m *ast.Node, entering bool) ast.WalkStatus {
+				if entering && m != candidates && matchComplex(c, len(c.compounds)-1, m, n) {
+					found = true
+					return ast.WalkStop
+				}
+				return ast.WalkContinue
+			})
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attrs 是除了 ial-name 以外可用的属性，节点没有对应的值时属性不存在。
+var attrs = map[string]func(n *ast.Node) string{
+	"id": func(n *ast.Node) string { return n.ID },
+	"level": func(n *ast.Node) string {
+		if ast.NodeHeading == n.Type {
+			return strconv.Itoa(n.HeadingLevel)
+		}
+		return ""
+	},
+	"type": func(n *ast.Node) string { // 文本标记类型、提示块类型，列表为 unordered、ordered 或者 task
+		switch n.Type {
+		case ast.NodeTextMark:
+			return n.TextMarkType
+		case ast.NodeCallout:
+			return n.CalloutType
+		case ast.NodeList, ast.NodeListItem:
+			if nil != n.ListData {
+				switch n.ListData.Typ {
+				case 0:
+					return "unordered"
+				case 1:
+					return "ordered"
+				case 3:
+					return "task"
+				}
+			}
+		}
+		return ""
+	},
+	"checked": func(n *ast.Node) string { // 任务列表项标记符和任务列表项是否勾选，true 或者 false
+		if ast.NodeListItem == n.Type && nil != n.FirstChild {
+			n = n.FirstChild.FirstChild
+		}
+		if nil != n && ast.NodeTaskListItemMarker == n.Type {
+			return strconv.FormatBool(n.TaskListItemChecked)
+		}
+		return ""
+	},
+	"marker": func(n *ast.Node) string { // 任务列表项标记符方括号内的字符，列表和列表项的标记符
+		switch n.Type {
+		case ast.NodeTaskListItemMarker:
+			return string(n.TaskListItemMarker)
+		case ast.NodeList, ast.NodeListItem:
+			if nil != n.ListData {
+				return string(n.ListData.Marker)
+			}
+		}
+		return ""
+	},
+	"href": func(n *ast.Node) string { // 链接和图片地址、超链接文本标记地址、维基链接目标
+		switch n.Type {
+		case ast.NodeLink, ast.NodeImage:
+			return childTokens(n, ast.NodeLinkDest)
+		case ast.NodeTextMark:
+			return n.TextMarkAHref
+		case ast.NodeWikiLink, ast.NodeWikiLinkEmbed:
+			return n.WikiLinkTarget
+		}
+		return ""
+	},
+	"title": func(n *ast.Node) string { // 链接和图片标题、超链接文本标记标题、提示块标题
+		switch n.Type {
+		case ast.NodeLink, ast.NodeImage:
+			return childTokens(n, ast.NodeLinkTitle)
+		case ast.NodeTextMark:
+			return n.TextMarkATitle
+		case ast.NodeCallout:
+			return n.CalloutTitle
+		}
+		return ""
+	},
+	"ref-id": func(n *ast.Node) string { // 内容块引用的被引用块 ID
+		switch n.Type {
+		case ast.NodeBlockRef:
+			return childTokens(n, ast.NodeBlockRefID)
+		case ast.NodeTextMark:
+			return n.TextMarkBlockRefID
+		}
+		return ""
+	},
+	"subtype": func(n *ast.Node) string { // 内容块引用文本标记的锚文本类型，s 或者 d
+		return n.TextMarkBlockRefSubtype
+	},
+	"name": func(n *ast.Node) string { // 指令名称
+		return n.DirectiveName
+	},
+	"lang": func(n *ast.Node) string { // 代码块语言
+		if ast.NodeCodeBlock == n.Type {
+			if info := strings.Fields(string(n.CodeBlockInfo)); 0 < len(info) {
+				return info[0]
+			}
+		}
+		return ""
+	},
+	"text": func(n *ast.Node) string { // 节点的纯文本
+		return n.Text()
+	},
+}
+
+// attrValue 返回节点 n 的属性 name 的值，属性不存在时 ok 为 false。
+func attrValue(n *ast.Node, name string) (value string, ok bool) {
+	if strings.HasPrefix(name, "ial-") {
+		name = name[len("ial-"):]
+		for _, kv := range n.KramdownIAL {
+			if 1 < len(kv) && name == kv[0] {
+				return kv[1], true
+			}
+		}
+		return "", false
+	}
+	if attr := attrs[name]; nil != attr {
+		value = attr(n)
+	}
+	return value, "" != value
+}
+
+func childTokens(n *ast.Node, childType ast.NodeType) string {
+	if child := n.ChildByType(childType); nil != child {
+		return child.TokensStr()
+	}
+	return ""
+}
+
+// previous 返回节点 n 前一个不被跳过的兄弟节点。
+func previous(n *ast.Node) (ret *ast.Node) {
+	for ret = n.Previous; nil != ret && skipped(ret); ret = ret.Previous {
+	}
+	return
+}
+
+// next 返回节点 n 后一个不被跳过的兄弟节点。
+func next(n *ast.Node) (ret *ast.Node) {
+	for ret = n.Next; nil != ret && skipped(ret); ret = ret.Next {
+	}
+	return
+}
+
+// firstChild 返回节点 n 第一个不被跳过的子节点。
+func firstChild(n *ast.Node) (ret *ast.Node) {
+	for ret = n.FirstChild; nil != ret && skipped(ret); ret = ret.Next {
+	}
+	return
+}
+
+// skipped 判断节点 n 是否不参与兄弟节点、子节点位置和 :empty 的计算，块级 IAL 节点和语法标记符节点（比如 NodeBlockquoteMarker、
+// NodeCodeBlockFenceOpenMarker）会被跳过。
+func skipped(n *ast.Node) bool {
+	return ast.NodeKramdownBlockIAL == n.Type || strings.HasSuffix(n.Type.String(), "Marker")
+}
@@ -0,0 +1,336 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+)
+
+// complexSelector 描述了使用组合器连接的复合选择器，combinators[i] 为 compounds[i] 和 compounds[i+1] 之间的组合器。
+type complexSelector struct {
+	compounds   []*compound
+	combinators []byte // ' '、'>'、'+' 或者 '~'
+}
+
+// compound 描述了复合选择器，比如 heading[level=2]:first-child。
+type compound struct {
+	typ     ast.NodeType
+	hasType bool // 是否指定了类型，* 和仅有属性、伪类的选择器不指定类型
+	scope   bool // 是否为 :has 相对选择器隐含的作用域节点
+	attrs   []*attrSelector
+	pseudos []*pseudo
+}
+
+// attrSelector 描述了属性选择器，op 为空时仅判断属性是否存在。
+type attrSelector struct {
+	name, op, value string
+}
+
+// pseudo 描述了伪类，a 和 b 为 :nth-child(an+b) 的参数，list 为 :not 和 :has 的参数。
+type pseudo struct {
+	name string
+	a, b int
+	list []*complexSelector
+}
+
+// types 维护了小写的节点类型名（去掉 Node 前缀）到节点类型的映射。
+var types = map[string]ast.NodeType{}
+
+func init() {
+	for t := ast.NodeDocument; t < ast.NodeTypeMaxVal; t++ {
+		if name := t.String(); strings.HasPrefix(name, "Node") && !strings.HasPrefix(name, "NodeType(") {
+			types[strings.ToLower(name[len("Node"):])] = t
+		}
+	}
+}
+
+// parser 用于解析选择器。
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) parseList(relative bool) (ret []*complexSelector, err error) {
+	for {
+		p.skipSpace()
+		c, err := p.parseComplex(relative)
+		if nil != err {
+			return nil, err
+		}
+		ret = append(ret, c)
+		p.skipSpace()
+		if !p.eat(',') {
+			break
+		}
+	}
+	return
+}
+
+func (p *parser) parseComplex(relative bool) (ret *complexSelector, err error) {
+	ret = &complexSelector{}
+	if relative {
+		combinator := byte(' ')
+		if c := p.peek(); '>' == c || '+' == c || '~' == c {
+			combinator = c
+			p.pos++
+			p.skipSpace()
+		}
+		ret.compounds = append(ret.compounds, &compound{scope: true})
+		ret.combinators = append(ret.combinators, combinator)
+	}
+
+	c, err := p.parseCompound()
+	if nil != err {
+		return nil, err
+	}
+	ret.compounds = append(ret.compounds, c)
+	for {
+		space := p.skipSpace()
+		next := p.peek()
+		if 0 == next || ',' == next || ')' == next {
+			return
+		}
+		combinator := byte(' ')
+		if '>' == next || '+' == next || '~' == next {
+			combinator = next
+			p.pos++
+			p.skipSpace()
+		} else if !space {
+			return nil, p.error("unexpected character")
+		}
+		if c, err = p.parseCompound(); nil != err {
+			return nil, err
+		}
+		ret.compounds = append(ret.compounds, c)
+		ret.combinators = append(ret.combinators, combinator)
+	}
+}
+
+func (p *parser) parseCompound() (ret *compound, err error) {
+	ret = &compound{}
+	start := p.pos
+	if !p.eat('*') {
+		if name := p.ident(); "" != name {
+			typ, ok := types[strings.ToLower(name)]
+			if !ok {
+				p.pos = start
+				return nil, p.error("unknown node type [" + name + "]")
+			}
+			ret.typ, ret.hasType = typ, true
+		}
+	}
+
+	for {
+		switch p.peek() {
+		case '[':
+			attr, err := p.parseAttr()
+			if nil != err {
+				return nil, err
+			}
+			ret.attrs = append(ret.attrs, attr)
+		case ':':
+			pseudo, err := p.parsePseudo()
+			if nil != err {
+				return nil, err
+			}
+			ret.pseudos = append(ret.pseudos, pseudo)
+		default:
+			if start == p.pos {
+				return nil, p.error("expected selector")
+			}
+			return
+		}
+	}
+}
+
+func (p *parser) parseAttr() (ret *attrSelector, err error) {
+	p.pos++ // [
+	p.skipSpace()
+	ret = &attrSelector{name: p.ident()}
+	if "" == ret.name {
+		return nil, p.error("expected attribute name")
+	}
+	if _, ok := attrs[ret.name]; !ok && !strings.HasPrefix(ret.name, "ial-") {
+		return nil, p.error("unknown attribute [" + ret.name + "]")
+	}
+	p.skipSpace()
+	if p.eat(']') {
+		return
+	}
+
+	for _, op := range []string{"=", "!=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			ret.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if "" == ret.op {
+		return nil, p.error("expected attribute operator")
+	}
+	p.skipSpace()
+	if ret.value, err = p.value(); nil != err {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eat(']') {
+		return nil, p.error("expected ]")
+	}
+	return
+}
+
+func (p *parser) parsePseudo() (ret *pseudo, err error) {
+	p.pos++ // :
+	ret = &pseudo{name: strings.ToLower(p.ident())}
+	switch ret.name {
+	case "first-child", "last-child", "only-child", "empty", "root":
+		return
+	case "nth-child", "nth-last-child":
+		if !p.eat('(') {
+			return nil, p.error("expected (")
+		}
+		end := strings.IndexByte(p.src[p.pos:], ')')
+		if 0 > end {
+			return nil, p.error("expected )")
+		}
+		var ok bool
+		if ret.a, ret.b, ok = parseNth(p.src[p.pos : p.pos+end]); !ok {
+			return nil, p.error("invalid :" + ret.name + " argument")
+		}
+		p.pos += end + 1
+		return
+	case "not", "has":
+		if !p.eat('(') {
+			return nil, p.error("expected (")
+		}
+		if ret.list, err = p.parseList("has" == ret.name); nil != err {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.eat(')') {
+			return nil, p.error("expected )")
+		}
+		return
+	}
+	return nil, p.error("unknown pseudo-class [" + ret.name + "]")
+}
+
+// parseNth 解析 :nth-child 的参数 an+b、odd 或者 even。
+func parseNth(arg string) (a, b int, ok bool) {
+	arg = strings.ToLower(strings.Join(strings.Fields(arg), ""))
+	switch arg {
+	case "odd":
+		return 2, 1, true
+	case "even":
+		return 2, 0, true
+	}
+	n := strings.IndexByte(arg, 'n')
+	if 0 > n {
+		b, err := strconv.Atoi(arg)
+		return 0, b, nil == err
+	}
+	switch aStr := arg[:n]; aStr {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		var err error
+		if a, err = strconv.Atoi(aStr); nil != err {
+			return
+		}
+	}
+	if bStr := arg[n+1:]; "" != bStr {
+		if '+' != bStr[0] && '-' != bStr[0] {
+			return
+		}
+		var err error
+		if b, err = strconv.Atoi(bStr); nil != err {
+			return
+		}
+	}
+	return a, b, true
+}
+
+// value 解析属性值，属性值可以使用单引号或者双引号，引号中可以使用反斜杠转义。
+func (p *parser) value() (ret string, err error) {
+	quote := p.peek()
+	if '"' != quote && '\'' != quote {
+		start := p.pos
+		for ; p.pos < len(p.src) && ']' != p.src[p.pos] && !isSpace(p.src[p.pos]); p.pos++ {
+		}
+		if start == p.pos {
+			return "", p.error("expected attribute value")
+		}
+		return p.src[start:p.pos], nil
+	}
+
+	p.pos++
+	buf := &strings.Builder{}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		if quote == c {
+			return buf.String(), nil
+		}
+		if '\\' == c && p.pos < len(p.src) {
+			c = p.src[p.pos]
+			p.pos++
+		}
+		buf.WriteByte(c)
+	}
+	return "", p.error("unclosed quote")
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for ; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		if !('a' <= c && 'z' >= c || 'A' <= c && 'Z' >= c || '0' <= c && '9' >= c || '-' == c || '_' == c) {
+			break
+		}
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) skipSpace() (ret bool) {
+	for ; p.pos < len(p.src) && isSpace(p.src[p.pos]); p.pos++ {
+		ret = true
+	}
+	return
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) eat(c byte) bool {
+	if c == p.peek() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) error(msg string) error {
+	return errors.New("invalid selector [" + p.src + "]: " + msg + " at offset " + strconv.Itoa(p.pos))
+}
+
+func isSpace(c byte) bool {
+	return ' ' == c || '\t' == c || '\n' == c || '\r' == c || '\f' == c
+}
@@ -0,0 +1,127 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package query 实现了在 Lute 语法树上使用类似 CSS 选择器的语法查找节点，比如：
+//
+//	heading[level=2]
+//	list > listItem:has(taskListItemMarker)
+//	textMark[type~=block-ref]
+//	[ial-custom-foo=bar]
+//	paragraph:first-child
+//
+// 支持的语法如下：
+//
+//   - 类型选择器：节点类型去掉 Node 前缀，不区分大小写，比如 heading、listItem、textMark，* 匹配任意节点
+//   - 属性选择器：[attr] 属性存在，[attr=v] 等于，[attr!=v] 不等于，[attr~=v] 空格分隔的单词之一等于，
+//     [attr|=v] 等于或者以 v- 开头，[attr^=v] 以 v 开头，[attr$=v] 以 v 结尾，[attr*=v] 包含，值可以使用单引号或者双引号
+//   - 伪类：:first-child、:last-child、:only-child、:nth-child(an+b)、:nth-last-child(an+b)、:empty、:root、
+//     :not(选择器列表) 和 :has(相对选择器列表)，:has 的参数可以以 >、+、~ 开头
+//   - 组合器：后代（空格）、子节点 >、相邻兄弟 + 和后续兄弟 ~，选择器之间使用逗号分隔
+//
+// 可用的属性如下，节点没有对应的值时属性不存在：
+//
+//   - ial-name：Kramdown 内联属性列表（IAL）中的 name 属性，比如 ial-custom-foo
+//   - id：节点 ID
+//   - level：标题级别
+//   - type：文本标记类型（多个类型使用空格分隔）、提示块类型，列表和列表项为 unordered、ordered 或者 task
+//   - checked：任务列表项标记符和任务列表项是否勾选，true 或者 false
+//   - marker：任务列表项标记符方括号内的字符，列表和列表项的标记符
+//   - href、title：链接和图片、超链接文本标记的地址和标题，维基链接的 href 为链接目标，提示块的 title 为标题
+//   - ref-id、subtype：内容块引用的被引用块 ID 和锚文本类型
+//   - name：指令名称
+//   - lang：代码块语言
+//   - text：节点的纯文本
+//
+// 块级 IAL 节点（NodeKramdownBlockIAL）是所属节点的属性，仅被 kramdownBlockIAL 类型选择器匹配，不参与兄弟节点、子节点位置和 :empty 的计算。
+// 语法标记符节点（类型以 Marker 结尾，比如 NodeBlockquoteMarker、NodeCodeBlockFenceOpenMarker）同样不参与这些计算，但仍然可以被选择器匹配。
+package query
+
+import (
+	"github.com/88250/lute/ast"
+)
+
+// Selector 描述了编译后的选择器，可以重复使用，并发使用是安全的。
+type Selector struct {
+	source string
+	list   []*complexSelector
+}
+
+// Compile 编译选择器 selector。
+func Compile(selector string) (ret *Selector, err error) {
+	p := &parser{src: selector}
+	list, err := p.parseList(false)
+	if nil != err {
+		return
+	}
+	if p.pos < len(p.src) {
+		return nil, p.error("unexpected character")
+	}
+	return &Selector{source: selector, list: list}, nil
+}
+
+// MustCompile 编译选择器 selector，编译失败时 panic，用于初始化全局变量。
+func MustCompile(selector string) *Selector {
+	ret, err := Compile(selector)
+	if nil != err {
+		panic(err)
+	}
+	return ret
+}
+
+// String 返回选择器的源码。
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Match 判断节点 n 是否匹配选择器。
+func (s *Selector) Match(n *ast.Node) bool {
+	return matchList(s.list, n, nil)
+}
+
+// QueryAll 按照文档顺序返回以 root 为根的子树（包括 root）中所有匹配选择器的节点。
+func (s *Selector) QueryAll(root *ast.Node) (ret []*ast.Node) {
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && s.Match(n) {
+			ret = append(ret, n)
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+// Query 按照文档顺序返回以 root 为根的子树（包括 root）中第一个匹配选择器的节点，没有匹配时返回 nil。
+func (s *Selector) Query(root *ast.Node) (ret *ast.Node) {
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && s.Match(n) {
+			ret = n
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+// QueryAll 编译选择器 selector 并返回以 root 为根的子树中所有匹配的节点。
+func QueryAll(root *ast.Node, selector string) ([]*ast.Node, error) {
+	s, err := Compile(selector)
+	if nil != err {
+		return nil, err
+	}
+	return s.QueryAll(root), nil
+}
+
+// Query 编译选择器 selector 并返回以 root 为根的子树中第一个匹配的节点。
+func Query(root *ast.Node, selector string) (*ast.Node, error) {
+	s, err := Compile(selector)
+	if nil != err {
+		return nil, err
+	}
+	return s.Query(root), nil
+}
@@ -38,16 +38,17 @@ func (r *HtmlRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkSt
 			// 缩进代码块处理
 			rendered := false
 			tokens := node.FirstChild.Tokens
+			var attrs [][]string
+			r.handleKramdownBlockIAL(node)
+			attrs = append(attrs, node.KramdownIAL...)
+			attrs = r.sourcePosAttrs(node, attrs)
 			if r.Options.CodeSyntaxHighlight {
-				rendered = highlightChroma(node, tokens, "", r)
+				rendered = highlightChroma(attrs, tokens, "", r)
 				if !rendered {
 					tokens = html.EscapeHTML(tokens)
 					r.Write(tokens)
 				}
 			} else {
-				var attrs [][]string
-				r.handleKramdownBlockIAL(node)
-				attrs = append(attrs, node.KramdownIAL...)
 				r.Tag("pre", attrs, false)
 				r.WriteString("<code>")
 				tokens = html.EscapeHTML(tokens)
@@ -74,6 +75,7 @@ func (r *HtmlRenderer) renderCodeBlockCode(node *ast.Node, entering bool) ast.Wa
 		var attrs [][]string
 		r.handleKramdownBlockIAL(node.Parent)
 		attrs = append(attrs, node.Parent.KramdownIAL...)
+		attrs = r.sourcePosAttrs(node.Parent, attrs)
 
 		tokens := node.Tokens
 		if 0 < len(node.Previous.CodeBlockInfo) {
@@ -94,7 +96,7 @@ func (r *HtmlRenderer) renderCodeBlockCode(node *ast.Node, entering bool) ast.Wa
 				rendered = true
 			} else {
 				if r.Options.CodeSyntaxHighlight && !preDiv {
-					rendered = highlightChroma(node.Parent, tokens, language, r)
+					rendered = highlightChroma(attrs, tokens, language, r)
 				}
 			}
 
@@ -113,7 +115,7 @@ func (r *HtmlRenderer) renderCodeBlockCode(node *ast.Node, entering bool) ast.Wa
 		} else {
 			rendered := false
 			if r.Options.CodeSyntaxHighlight {
-				rendered = highlightChroma(node.Parent, tokens, "", r)
+				rendered = highlightChroma(attrs, tokens, "", r)
 				if !rendered {
 					tokens = html.EscapeHTML(tokens)
 					r.Write(tokens)
@@ -144,11 +146,8 @@ func (r *HtmlRenderer) renderCodeBlockCode(node *ast.Node, entering bool) ast.Wa
 	return ast.WalkContinue
 }
 
-func highlightChroma(codeNode *ast.Node, tokens []byte, language string, r *HtmlRenderer) (rendered bool) {
-	var attrs [][]string
-	r.handleKramdownBlockIAL(codeNode)
-	attrs = append(attrs, codeNode.KramdownIAL...)
-
+// highlightChroma 使用 Chroma 高亮代码 tokens，attrs 为 pre 标签的属性。
+func highlightChroma(attrs [][]string, tokens []byte, language string, r *HtmlRenderer) (rendered bool) {
 	codeBlock := util.BytesToStr(tokens)
 	var lexer chroma.Lexer
 	if "" != language {
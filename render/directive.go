@@ -0,0 +1,125 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+)
+
+// directiveHTMLAttrs 返回指令渲染为 HTML 标签时的属性，指令名称作为第一个类名，属性值已经转义。
+func directiveHTMLAttrs(node *ast.Node) (ret [][]string) {
+	class := node.DirectiveName
+	for _, kv := range node.DirectiveAttrs {
+		if "class" == kv[0] {
+			class += " " + kv[1]
+			continue
+		}
+		ret = append(ret, []string{kv[0], html.EscapeHTMLStr(kv[1])})
+	}
+	return append([][]string{{"class", html.EscapeHTMLStr(class)}}, ret...)
+}
+
+// directiveFence 返回容器指令的围栏标记符。
+func directiveFence(node *ast.Node) string {
+	fenceLen := node.DirectiveFenceLen
+	if 3 > fenceLen {
+		fenceLen = 3
+	}
+	return strings.Repeat(":", fenceLen)
+}
+
+// directiveOpenMarker 返回指令在 Markdown 原文中位于内容之前的部分，容器指令为起始行，叶子指令和文本指令为名称和标签的左方括号。
+func directiveOpenMarker(node *ast.Node) string {
+	switch node.Type {
+	case ast.NodeContainerDirective:
+		ret := directiveFence(node) + node.DirectiveName
+		if "" != node.DirectiveLabel {
+			ret += "[" + node.DirectiveLabel + "]"
+		}
+		return ret + string(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs))
+	case ast.NodeLeafDirective:
+		ret := "::" + node.DirectiveName
+		if nil != node.FirstChild {
+			ret += "["
+		}
+		return ret
+	}
+	ret := ":" + node.DirectiveName
+	if nil != node.FirstChild {
+		ret += "["
+	}
+	return ret
+}
+
+// directiveCloseMarker 返回指令在 Markdown 原文中位于内容之后的部分，容器指令为闭合行，叶子指令和文本指令为标签的右方括号和属性。
+func directiveCloseMarker(node *ast.Node) string {
+	if ast.NodeContainerDirective == node.Type {
+		return directiveFence(node)
+	}
+	ret := ""
+	if nil != node.FirstChild {
+		ret = "]"
+	}
+	return ret + string(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs))
+}
+
+// directiveAttrs 返回指令节点的标签属性，打开过滤时会去掉不安全的属性。
+func (r *BaseRenderer) directiveAttrs(node *ast.Node) [][]string {
+	return r.sanitizeNodeIAL(directiveHTMLAttrs(node))
+}
+
+// renderContainerDirectiveHTML 将容器指令渲染为 <div class="name">，标签渲染为 <div class="directive-label">。
+func (r *BaseRenderer) renderContainerDirectiveHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+		r.Tag("div", r.directiveAttrs(node), false)
+		r.Newline()
+		if "" != node.DirectiveLabel {
+			r.WriteString("<div class=\"directive-label\">")
+			if labelTree := calloutInlineTree(node.DirectiveLabel, r.ParseOptions); nil != labelTree {
+				r.Write(NewHtmlRenderer(labelTree, r.Options, r.ParseOptions).Render())
+			}
+			r.WriteString("</div>")
+			r.Newline()
+		}
+	} else {
+		r.Newline()
+		r.WriteString("</div>")
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+// renderLeafDirectiveHTML 将叶子指令渲染为 <div class="name">，标签作为内容。
+func (r *BaseRenderer) renderLeafDirectiveHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+		r.Tag("div", r.directiveAttrs(node), false)
+	} else {
+		r.WriteString("</div>")
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+// renderTextDirectiveHTML 将文本指令渲染为 <span class="name">，标签作为内容。
+func (r *BaseRenderer) renderTextDirectiveHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("span", r.directiveAttrs(node), false)
+	} else {
+		r.Tag("/span", nil, false)
+	}
+	return ast.WalkContinue
+}
@@ -0,0 +1,156 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+)
+
+// LosslessFormatRenderer 描述了无损格式化渲染器：自 parse.Tree.Snapshot 后没有修改过的块原样输出原始文本，
+// 只有修改过的块使用 FormatRenderer 重新格式化，从而让格式化结果和原始文本的差异最小。引述、列表和列表项本身没有修改过时
+// 只重新格式化其中修改过的子块。
+//
+// 语法树没有调用过 Snapshot 时退化为 FormatRenderer。
+type LosslessFormatRenderer struct {
+	*FormatRenderer
+}
+
+// NewLosslessFormatRenderer 创建一个无损格式化渲染器。
+func NewLosslessFormatRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *LosslessFormatRenderer {
+	ret := &LosslessFormatRenderer{NewFormatRenderer(tree, options, parseOptions)}
+	ret.lossless = true
+	return ret
+}
+
+// Render 执行无损格式化。
+func (r *LosslessFormatRenderer) Render() (output []byte) {
+	segments := r.Tree.LosslessSegments()
+	if nil == segments {
+		r.lossless = false
+		return r.FormatRenderer.Render()
+	}
+
+	style := r.formatStyle()
+	if "reference" == style.Link {
+		r.collectLinkRefDefs()
+	}
+	buf := &bytes.Buffer{}
+	for i, segment := range segments {
+		if nil != segment.Source {
+			buf.Write(limitBlankLines(segment.Source, style.MaxBlankLines))
+			continue
+		}
+		if segment.Nested {
+			r.writeNested(buf, segment)
+			if nil != r.err {
+				break
+			}
+			continue
+		}
+
+		formatted := bytes.TrimRight(r.format(segment.Nodes), "\r\n")
+		if nil != r.err {
+			break
+		}
+		if 1 > len(formatted) {
+			continue
+		}
+
+		// 重新格式化的块前后都使用空行分隔
+		if 0 < buf.Len() {
+			if _, ok := trimLineEnding(buf.Bytes()); !ok {
+				buf.WriteByte(lex.ItemNewline)
+			}
+			if line, _ := trimLineEnding(buf.Bytes()); !bytes.HasSuffix(line, []byte{lex.ItemNewline}) && !bytes.HasSuffix(line, []byte{lex.ItemCarriageReturn}) {
+				buf.WriteByte(lex.ItemNewline)
+			}
+		}
+		buf.Write(formatted)
+		buf.WriteByte(lex.ItemNewline)
+		if i < len(segments)-1 {
+			buf.WriteByte(lex.ItemNewline)
+		}
+	}
+
+	// 重新格式化的块中转换为引用链接时新生成的定义追加在文档末尾
+	if 0 < len(r.linkRefDefs) {
+		output = bytes.TrimRight(buf.Bytes(), " \t\n")
+		buf = bytes.NewBuffer(output)
+		if 0 < buf.Len() {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(strings.Join(r.linkRefDefs, "\n") + "\n")
+	}
+	// 原样输出的第一个片段可能已经包含了 BOM
+	output = r.restoreLineEnding(buf.Bytes(), !bytes.HasPrefix(buf.Bytes(), lex.BOM))
+	return
+}
+
+// writeNested 重新格式化容器块中的子块 segment.Nodes，每行加上容器块的前缀后写入 buf。
+func (r *LosslessFormatRenderer) writeNested(buf *bytes.Buffer, segment *parse.LosslessSegment) {
+	formatted := bytes.TrimRight(r.format(segment.Nodes), "\r\n")
+	if nil != r.err {
+		return
+	}
+	if 0 < len(formatted) {
+		blankIndent := bytes.TrimRight(segment.Indent, " \t")
+		for i, line := range bytes.Split(formatted, []byte{lex.ItemNewline}) {
+			if 0 == i {
+				buf.Write(segment.Prefix)
+			} else if 1 > len(line) {
+				buf.Write(blankIndent)
+				line = nil
+			} else {
+				buf.Write(segment.Indent)
+			}
+			buf.Write(line)
+			buf.WriteByte(lex.ItemNewline)
+		}
+	}
+	buf.Write(limitBlankLines(segment.Trailing, r.formatStyle().MaxBlankLines))
+}
+
+// trimLineEnding 去掉 b 末尾的一个换行符（\n、\r\n 或者 \r），ok 说明 b 是否以换行符结尾。
+func trimLineEnding(b []byte) (ret []byte, ok bool) {
+	if bytes.HasSuffix(b, []byte{lex.ItemNewline}) {
+		return bytes.TrimSuffix(b[:len(b)-1], []byte{lex.ItemCarriageReturn}), true
+	}
+	if bytes.HasSuffix(b, []byte{lex.ItemCarriageReturn}) {
+		return b[:len(b)-1], true
+	}
+	return b, false
+}
+
+// format 使用 FormatRenderer 格式化顶层块 nodes。格式化时 nodes 会被临时移动到一个新的文档节点下，完成后再放回原处。
+func (r *LosslessFormatRenderer) format(nodes []*ast.Node) []byte {
+	root := r.Tree.Root
+	anchor := &ast.Node{Type: ast.NodeText}
+	nodes[0].InsertBefore(anchor)
+	doc := &ast.Node{Type: ast.NodeDocument}
+	for _, n := range nodes {
+		doc.AppendChild(n)
+	}
+
+	r.Tree.Root = doc
+	defer func() {
+		r.Tree.Root = root
+		for _, n := range nodes {
+			anchor.InsertBefore(n)
+		}
+		anchor.Unlink()
+	}()
+	return r.FormatRenderer.Render()
+}
@@ -29,6 +29,15 @@ import (
 type FormatRenderer struct {
 	*BaseRenderer
 	NodeWriterStack []*bytes.Buffer // 节点输出缓冲栈
+
+	wrapping      bool              // 是否正在输出需要折行的段落
+	wrapBreaks    []int             // 折行段落输出缓冲中可以换行的空格位置
+	wrapHold      bool              // 是否正在输出引用链接的链接文本，此时不记录可以换行的位置
+	linkRefLabels map[string]string // 内联链接转换为引用链接时，链接引用定义内容到 label 的映射
+	linkRefDefs   []string          // 转换为引用链接时收集的链接引用定义，在文档末尾输出
+	linkRefNum    int               // 转换为引用链接时最后使用的 label 序号
+	linkRefUsed   map[string]bool   // 已经使用的链接引用 label，键为小写的 label
+	lossless      bool              // 是否用于无损格式化，此时保留原有的链接引用定义并由无损格式化渲染器输出收集的定义
 }
 
 // NewFormatRenderer 创建一个格式化渲染器。
@@ -171,6 +180,12 @@ func NewFormatRenderer(tree *parse.Tree, options *Options, parseOptions *parse.O
 	ret.RendererFuncs[ast.NodeHTMLTagOpen] = ret.renderHTMLTagOpen
 	ret.RendererFuncs[ast.NodeHTMLTagClose] = ret.renderHTMLTagClose
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirective
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirective
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirective
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeCustomInline] = ret.renderCustomInline
 	return ret
 }
 
@@ -191,6 +206,75 @@ func (r *FormatRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkSt
 	return ast.WalkContinue
 }
 
+func (r *FormatRenderer) renderContainerDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+		r.WriteString(directiveFence(node))
+		r.WriteString(node.DirectiveName)
+		if "" != node.DirectiveLabel {
+			r.WriteString("[" + node.DirectiveLabel + "]")
+		}
+		r.Write(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs))
+		r.WriteByte(lex.ItemNewline)
+	} else {
+		// 去掉子块末尾的空行，闭合行紧跟在内容后面
+		r.Writer.Truncate(len(bytes.TrimRight(r.Writer.Bytes(), "\n")))
+		r.WriteByte(lex.ItemNewline)
+		r.WriteString(directiveFence(node))
+		r.Newline()
+		if !r.isLastNode(r.Tree.Root, node) {
+			if r.withoutKramdownBlockIAL(node) {
+				r.WriteByte(lex.ItemNewline)
+			}
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *FormatRenderer) renderLeafDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.newlineBeforeBlock(node)
+		r.WriteString("::")
+		r.WriteString(node.DirectiveName)
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemOpenBracket)
+		}
+	} else {
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemCloseBracket)
+		}
+		r.Write(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs))
+		if r.withoutKramdownBlockIAL(node) {
+			r.Newline()
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *FormatRenderer) renderTextDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteByte(lex.ItemColon)
+		r.WriteString(node.DirectiveName)
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemOpenBracket)
+		}
+	} else {
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemCloseBracket)
+		}
+		r.Write(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs))
+	}
+	return ast.WalkContinue
+}
+
+func (r *FormatRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(wikiLinkMarkdown(node))
+	}
+	return ast.WalkContinue
+}
+
 func (r *FormatRenderer) renderHTMLTag(node *ast.Node, entering bool) ast.WalkStatus {
 	return ast.WalkContinue
 }
@@ -227,6 +311,13 @@ func (r *FormatRenderer) renderCustomBlock(node *ast.Node, entering bool) ast.Wa
 	return ast.WalkContinue
 }
 
+func (r *FormatRenderer) renderCustomInline(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Write(node.Tokens)
+	}
+	return ast.WalkSkipChildren
+}
+
 func (r *FormatRenderer) renderAttributeView(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
@@ -533,6 +624,9 @@ func (r *FormatRenderer) renderSuperBlockCloseMarker(node *ast.Node, entering bo
 }
 
 func (r *FormatRenderer) renderLinkRefDefBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && r.dropLinkRefDefs() {
+		return ast.WalkSkipChildren
+	}
 	return ast.WalkContinue
 }
 
@@ -968,11 +1062,15 @@ func (r *FormatRenderer) renderTableHead(node *ast.Node, entering bool) ast.Walk
 				continue
 			}
 
+			width := th.TableCellContentMaxWidth
+			if r.formatStyle().TableCompact {
+				width = 3
+			}
 			align := th.TableCellAlign
 			switch align {
 			case 0:
 				r.WriteString("| -")
-				if padding := th.TableCellContentMaxWidth - 1; 0 < padding {
+				if padding := width - 1; 0 < padding {
 					r.Write(bytes.Repeat([]byte{lex.ItemHyphen}, padding))
 				}
 				if !r.Options.ProtyleWYSIWYG {
@@ -980,7 +1078,7 @@ func (r *FormatRenderer) renderTableHead(node *ast.Node, entering bool) ast.Walk
 				}
 			case 1:
 				r.WriteString("| :-")
-				if padding := th.TableCellContentMaxWidth - 2; 0 < padding {
+				if padding := width - 2; 0 < padding {
 					r.Write(bytes.Repeat([]byte{lex.ItemHyphen}, padding))
 				}
 				if !r.Options.ProtyleWYSIWYG {
@@ -988,13 +1086,13 @@ func (r *FormatRenderer) renderTableHead(node *ast.Node, entering bool) ast.Walk
 				}
 			case 2:
 				r.WriteString("| :-")
-				if padding := th.TableCellContentMaxWidth - 3; 0 < padding {
+				if padding := width - 3; 0 < padding {
 					r.Write(bytes.Repeat([]byte{lex.ItemHyphen}, padding))
 				}
 				r.WriteString(": ")
 			case 3:
 				r.WriteString("| -")
-				if padding := th.TableCellContentMaxWidth - 2; 0 < padding {
+				if padding := width - 2; 0 < padding {
 					r.Write(bytes.Repeat([]byte{lex.ItemHyphen}, padding))
 				}
 				r.WriteString(": ")
@@ -1052,8 +1150,11 @@ func (r *FormatRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStat
 					maxWidth = cells[row][col].TableCellContentWidth
 				}
 			}
+			if r.formatStyle().TableCompact {
+				maxWidth = 0
+			}
 			for row := 0; row < len(cells) && col < len(cells[row]); row++ {
-				cells[row][col].TableCellContentMaxWidth = maxWidth
+				cells[row][col].TableCellContentMaxWidth = max(maxWidth, cells[row][col].TableCellContentWidth)
 			}
 			maxWidth = 0
 		}
@@ -1107,6 +1208,12 @@ func (r *FormatRenderer) renderStrikethrough2CloseMarker(node *ast.Node, enterin
 
 func (r *FormatRenderer) renderLinkTitle(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.referenceOutput(node.Parent) {
+			return ast.WalkContinue
+		}
+		if nil == node.Previous || ast.NodeLinkSpace != node.Previous.Type {
+			r.WriteByte(lex.ItemSpace)
+		}
 		r.WriteByte(lex.ItemDoublequote)
 		r.Write(html.EscapeHTML(node.Tokens))
 		r.WriteByte(lex.ItemDoublequote)
@@ -1116,6 +1223,9 @@ func (r *FormatRenderer) renderLinkTitle(node *ast.Node, entering bool) ast.Walk
 
 func (r *FormatRenderer) renderLinkDest(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.referenceOutput(node.Parent) {
+			return ast.WalkContinue
+		}
 		tokens := node.Tokens
 		tokens = r.LinkPath(tokens)
 		r.Write(tokens)
@@ -1125,6 +1235,9 @@ func (r *FormatRenderer) renderLinkDest(node *ast.Node, entering bool) ast.WalkS
 
 func (r *FormatRenderer) renderLinkSpace(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.referenceOutput(node.Parent) {
+			return ast.WalkContinue
+		}
 		r.WriteByte(lex.ItemSpace)
 	}
 	return ast.WalkContinue
@@ -1138,13 +1251,16 @@ func (r *FormatRenderer) renderLinkText(node *ast.Node, entering bool) ast.WalkS
 		} else {
 			tokens = node.Tokens
 		}
-		r.Write(tokens)
+		r.writeWrappable(tokens)
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderCloseParen(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.referenceOutput(node.Parent) {
+			return ast.WalkContinue
+		}
 		r.WriteByte(lex.ItemCloseParen)
 	}
 	return ast.WalkContinue
@@ -1152,6 +1268,9 @@ func (r *FormatRenderer) renderCloseParen(node *ast.Node, entering bool) ast.Wal
 
 func (r *FormatRenderer) renderOpenParen(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.referenceOutput(node.Parent) {
+			return ast.WalkContinue
+		}
 		r.WriteByte(lex.ItemOpenParen)
 	}
 	return ast.WalkContinue
@@ -1188,6 +1307,14 @@ func (r *FormatRenderer) renderOpenBrace(node *ast.Node, entering bool) ast.Walk
 func (r *FormatRenderer) renderCloseBracket(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.WriteByte(lex.ItemCloseBracket)
+		if link := node.Parent; r.referenceOutput(link) {
+			if 3 != link.LinkType {
+				r.WriteString("[" + r.linkRefLabel(link) + "]")
+			} else if text := link.ChildByType(ast.NodeLinkText); nil == text || text != node.Previous || text.Previous != link.FirstChild || !bytes.Equal(text.Tokens, link.LinkRefLabel) {
+				// 链接文本和 label 相同时输出为简写形式 [label]
+				r.WriteString("[" + util.BytesToStr(link.LinkRefLabel) + "]")
+			}
+		}
 	}
 	return ast.WalkContinue
 }
@@ -1213,14 +1340,10 @@ func (r *FormatRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStat
 func (r *FormatRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.LinkTextAutoSpacePrevious(node)
-		if 3 == node.LinkType {
-			text := node.ChildByType(ast.NodeLinkText).Tokens
-			if bytes.Equal(text, node.LinkRefLabel) {
-				r.WriteString("[" + util.BytesToStr(text) + "]")
-			} else {
-				r.WriteString("[" + util.BytesToStr(text) + "][" + util.BytesToStr(node.LinkRefLabel) + "]")
-			}
-			return ast.WalkSkipChildren
+		if r.referenceOutput(node) {
+			// 引用链接的链接文本由子节点输出，label 在 ] 之后输出，链接文本中间折行后再次格式化时可能无法匹配
+			r.wrapHold = true
+			return ast.WalkContinue
 		}
 		if 1 == node.LinkType {
 			dest := node.ChildByType(ast.NodeLinkDest).Tokens
@@ -1228,6 +1351,7 @@ func (r *FormatRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatu
 			return ast.WalkSkipChildren
 		}
 	} else {
+		r.wrapHold = false
 		r.LinkTextAutoSpaceNext(node)
 	}
 	return ast.WalkContinue
@@ -1260,6 +1384,9 @@ func (r *FormatRenderer) renderDocument(node *ast.Node, entering bool) ast.WalkS
 	if entering {
 		r.Writer = &bytes.Buffer{}
 		r.NodeWriterStack = append(r.NodeWriterStack, r.Writer)
+		if !r.lossless && "reference" == r.formatStyle().Link {
+			r.collectLinkRefDefs()
+		}
 	} else {
 		r.NodeWriterStack = r.NodeWriterStack[:len(r.NodeWriterStack)-1]
 		var buf []byte
@@ -1271,13 +1398,40 @@ func (r *FormatRenderer) renderDocument(node *ast.Node, entering bool) ast.WalkS
 		}
 		r.Writer.Reset()
 		r.Write(buf)
+		if !r.lossless && 0 < len(r.linkRefDefs) {
+			if 0 < len(buf) {
+				r.WriteString("\n\n")
+			}
+			r.WriteString(strings.Join(r.linkRefDefs, "\n"))
+		}
 		r.WriteByte(lex.ItemNewline)
+		if !r.lossless { // 无损格式化时由无损格式化渲染器统一还原
+			buf = r.restoreLineEnding(r.Writer.Bytes(), true)
+			r.Writer.Reset()
+			r.Write(buf)
+		}
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
-	if !entering {
+	if entering {
+		if r.wrapParagraph(node) {
+			r.wrapping = true
+			r.wrapBreaks = nil
+			r.Writer = &bytes.Buffer{}
+			r.NodeWriterStack = append(r.NodeWriterStack, r.Writer)
+		}
+	} else {
+		if r.wrapping {
+			writer := r.NodeWriterStack[len(r.NodeWriterStack)-1]
+			r.NodeWriterStack = r.NodeWriterStack[:len(r.NodeWriterStack)-1]
+			r.Writer = r.NodeWriterStack[len(r.NodeWriterStack)-1]
+			r.wrapping = false
+			r.Write(r.wrap(writer.Bytes(), r.wrapBreaks, wrapIndent(node)))
+			r.wrapBreaks = nil
+		}
+
 		if !r.Options.KeepParagraphBeginningSpace && nil != node.FirstChild {
 			node.FirstChild.Tokens = bytes.TrimSpace(node.FirstChild.Tokens)
 		}
@@ -1346,7 +1500,7 @@ func (r *FormatRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatu
 			}
 		}
 
-		r.Write(tokens)
+		r.writeWrappable(tokens)
 	}
 	return ast.WalkContinue
 }
@@ -1497,7 +1651,7 @@ func (r *FormatRenderer) renderMathBlock(node *ast.Node, entering bool) ast.Walk
 func (r *FormatRenderer) renderCodeBlockCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
-		r.Write(node.Tokens)
+		r.Write(r.codeBlockFence(node.Parent, node.Tokens))
 		r.Newline()
 		if !r.isLastNode(r.Tree.Root, node) {
 			if r.withoutKramdownBlockIAL(node.Parent) {
@@ -1525,7 +1679,7 @@ func (r *FormatRenderer) renderCodeBlockInfoMarker(node *ast.Node, entering bool
 
 func (r *FormatRenderer) renderCodeBlockOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Write(node.Tokens)
+		r.Write(r.codeBlockFence(node.Parent, node.Tokens))
 	}
 	return ast.WalkContinue
 }
@@ -1534,10 +1688,11 @@ func (r *FormatRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.Walk
 	if entering {
 		r.Newline()
 		if !node.IsFencedCodeBlock {
-			r.Write(bytes.Repeat([]byte{lex.ItemBacktick}, 3))
+			fence := r.codeBlockFence(node, bytes.Repeat([]byte{lex.ItemBacktick}, 3))
+			r.Write(fence)
 			r.WriteByte(lex.ItemNewline)
 			r.Write(node.FirstChild.Tokens)
-			r.Write(bytes.Repeat([]byte{lex.ItemBacktick}, 3))
+			r.Write(fence)
 			r.Newline()
 			if !r.isLastNode(r.Tree.Root, node) {
 				if r.withoutKramdownBlockIAL(node) {
@@ -1561,28 +1716,28 @@ func (r *FormatRenderer) renderEmphasis(node *ast.Node, entering bool) ast.WalkS
 
 func (r *FormatRenderer) renderEmAsteriskOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteByte(lex.ItemAsterisk)
+		r.WriteString(r.emphasisMarker(node.Parent, "*"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderEmAsteriskCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteByte(lex.ItemAsterisk)
+		r.WriteString(r.emphasisMarker(node.Parent, "*"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderEmUnderscoreOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteByte(lex.ItemUnderscore)
+		r.WriteString(r.emphasisMarker(node.Parent, "_"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderEmUnderscoreCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteByte(lex.ItemUnderscore)
+		r.WriteString(r.emphasisMarker(node.Parent, "_"))
 	}
 	return ast.WalkContinue
 }
@@ -1598,28 +1753,28 @@ func (r *FormatRenderer) renderStrong(node *ast.Node, entering bool) ast.WalkSta
 
 func (r *FormatRenderer) renderStrongA6kOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("**")
+		r.WriteString(r.strongMarker(node.Parent, "**"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderStrongA6kCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("**")
+		r.WriteString(r.strongMarker(node.Parent, "**"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderStrongU8eOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("__")
+		r.WriteString(r.strongMarker(node.Parent, "__"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderStrongU8eCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("__")
+		r.WriteString(r.strongMarker(node.Parent, "__"))
 	}
 	return ast.WalkContinue
 }
@@ -1686,12 +1841,12 @@ func (r *FormatRenderer) renderBlockquoteMarker(node *ast.Node, entering bool) a
 func (r *FormatRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.newlineBeforeBlock(node)
-		if !node.HeadingSetext {
+		if !r.headingSetext(node) {
 			r.Write(bytes.Repeat([]byte{lex.ItemCrosshatch}, node.HeadingLevel))
 			r.WriteByte(lex.ItemSpace)
 		}
 	} else {
-		if node.HeadingSetext {
+		if r.headingSetext(node) {
 			r.WriteByte(lex.ItemNewline)
 			contentLen := r.setextHeadingLen(node)
 			if 1 == node.HeadingLevel {
@@ -1791,7 +1946,7 @@ func (r *FormatRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkS
 			if 0 == node.ListData.Num && 0 == node.ListData.Delimiter {
 				listItemBuf.Write(node.ListData.Marker)
 			} else {
-				listItemBuf.WriteString(strconv.Itoa(node.ListData.Num) + string(node.ListData.Delimiter))
+				listItemBuf.WriteString(strconv.Itoa(r.listItemNum(node)) + string(node.ListData.Delimiter))
 			}
 		} else {
 			if "" != r.Options.UnorderedListMarker {
@@ -1840,7 +1995,7 @@ func (r *FormatRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.
 		if node.ParentIs(ast.NodeTableCell) {
 			r.WriteString("<hr/>")
 		} else {
-			r.WriteString("---")
+			r.WriteString(r.thematicBreak())
 			if r.withoutKramdownBlockIAL(node) {
 				r.WriteByte(lex.ItemNewline)
 				r.WriteByte(lex.ItemNewline)
@@ -1857,6 +2012,9 @@ func (r *FormatRenderer) renderHardBreak(node *ast.Node, entering bool) ast.Walk
 		} else {
 			if node.ParentIs(ast.NodeTableCell) {
 				r.WriteString("<br/>")
+			} else if r.wrapping {
+				// 折行时软换行会被合并，硬换行需要保留为 \ 换行
+				r.WriteString("\\\n")
 			} else {
 				r.WriteByte(lex.ItemNewline)
 			}
@@ -1867,6 +2025,11 @@ func (r *FormatRenderer) renderHardBreak(node *ast.Node, entering bool) ast.Walk
 
 func (r *FormatRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
+		if r.wrapping {
+			// 软换行由折行重新决定是否换行
+			r.writeWrappable([]byte{lex.ItemSpace})
+			return ast.WalkContinue
+		}
 		r.Newline()
 	}
 	return ast.WalkContinue
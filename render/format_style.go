@@ -0,0 +1,480 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/util"
+)
+
+// FormatStyle 描述了 FormatRenderer 输出 Markdown 的风格，字段为零值或者不支持的值时保持默认的格式化行为。
+// 字段带有 JSON 标签，可以直接从风格配置文件中反序列化。
+type FormatStyle struct {
+	// Emphasis 设置强调的标记符，"*" 或者 "_"。单词内部的强调使用 "_" 时不会被解析，这种情况下保持使用 "*"。
+	Emphasis string `json:"emphasis,omitempty"`
+	// Strong 设置加粗的标记符，"**" 或者 "__"，单词内部的加粗同样保持使用 "**"。
+	Strong string `json:"strong,omitempty"`
+	// Heading 设置标题风格，"atx" 或者 "setext"。setext 只能用于一二级标题，包含换行的标题不能转换为 atx。
+	Heading string `json:"heading,omitempty"`
+	// CodeFence 设置代码块围栏字符，"`" 或者 "~"。代码块信息中包含 "`" 时只能使用 "~"。
+	CodeFence string `json:"codeFence,omitempty"`
+	// CodeFenceLength 设置代码块围栏的最小长度，代码中包含更长的围栏时会自动加长。
+	CodeFenceLength int `json:"codeFenceLength,omitempty"`
+	// OrderedList 设置有序列表的编号方式，"increment" 递增编号，"one" 所有列表项都使用列表的起始编号（比如都使用 1.）。
+	OrderedList string `json:"orderedList,omitempty"`
+	// ThematicBreak 设置分隔线，比如 "***"、"___" 或者 "- - -"，不是合法的分隔线时使用 "---"。
+	ThematicBreak string `json:"thematicBreak,omitempty"`
+	// TableCompact 设置表格是否不补齐单元格空格来对齐各列。
+	TableCompact bool `json:"tableCompact,omitempty"`
+	// MaxBlankLines 设置块之间最多保留的连续空行数。FormatRenderer 在块之间只输出一个空行，该设置用于限制无损格式化时原样保留的空行。
+	MaxBlankLines int `json:"maxBlankLines,omitempty"`
+	// Wrap 设置段落折行方式，"column" 按列宽折行，"sentence" 每个句子一行，"none" 将段落中的软换行合并为一行。
+	// 折行只发生在原有的空格和软换行处，不会在中日韩字符之间断开。
+	Wrap string `json:"wrap,omitempty"`
+	// WrapColumn 设置按列宽折行时的列宽，默认为 80，中日韩字符和全角字符的宽度计为 2。
+	WrapColumn int `json:"wrapColumn,omitempty"`
+	// Link 设置链接风格，"inline" 使用内联链接，"reference" 使用引用链接并将链接引用定义统一放在文档末尾。
+	Link string `json:"link,omitempty"`
+}
+
+// formatStyle 返回格式化风格，没有设置时返回空风格。
+func (r *FormatRenderer) formatStyle() *FormatStyle {
+	if nil == r.Options.FormatStyle {
+		return &FormatStyle{}
+	}
+	return r.Options.FormatStyle
+}
+
+// intraword 判断强调或者加粗节点 node 是否位于单词内部，比如 foo*bar*baz。
+func intraword(node *ast.Node) bool {
+	if prev := node.Previous; nil != prev && ast.NodeText == prev.Type {
+		if last, _ := utf8.DecodeLastRune(prev.Tokens); unicode.IsLetter(last) || unicode.IsDigit(last) {
+			return true
+		}
+	}
+	if next := node.Next; nil != next && ast.NodeText == next.Type {
+		if first, _ := utf8.DecodeRune(next.Tokens); unicode.IsLetter(first) || unicode.IsDigit(first) {
+			return true
+		}
+	}
+	return false
+}
+
+// emphasisMarker 返回强调节点 node 使用的标记符，marker 是原始标记符。
+func (r *FormatRenderer) emphasisMarker(node *ast.Node, marker string) string {
+	switch r.formatStyle().Emphasis {
+	case "*":
+		return "*"
+	case "_":
+		if !intraword(node) {
+			return "_"
+		}
+		return "*"
+	}
+	return marker
+}
+
+// strongMarker 返回加粗节点 node 使用的标记符，marker 是原始标记符。
+func (r *FormatRenderer) strongMarker(node *ast.Node, marker string) string {
+	switch r.formatStyle().Strong {
+	case "**":
+		return "**"
+	case "__":
+		if !intraword(node) {
+			return "__"
+		}
+		return "**"
+	}
+	return marker
+}
+
+// headingSetext 判断标题 node 是否使用 setext 风格输出。
+func (r *FormatRenderer) headingSetext(node *ast.Node) bool {
+	switch r.formatStyle().Heading {
+	case "atx":
+		// 多行的 setext 标题无法转换为 ATX 标题
+		return node.HeadingSetext && multiline(node)
+	case "setext":
+		// 内容为空的 setext 标题会被解析为段落或者分隔线
+		return 2 >= node.HeadingLevel && "" != strings.TrimSpace(node.Text())
+	}
+	return node.HeadingSetext
+}
+
+// multiline 判断 node 的内容中是否包含换行。
+func multiline(node *ast.Node) (ret bool) {
+	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && (ast.NodeSoftBreak == n.Type || ast.NodeHardBreak == n.Type) {
+			ret = true
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+// codeBlockFence 返回代码块 codeBlock 使用的围栏，marker 是原始围栏。
+func (r *FormatRenderer) codeBlockFence(codeBlock *ast.Node, marker []byte) []byte {
+	style := r.formatStyle()
+	if "" == style.CodeFence && 1 > style.CodeFenceLength {
+		return marker
+	}
+
+	fenceChar := byte(lex.ItemBacktick)
+	if 0 < len(marker) {
+		fenceChar = marker[0]
+	}
+	if "`" == style.CodeFence || "~" == style.CodeFence {
+		fenceChar = style.CodeFence[0]
+	}
+	if info := codeBlock.ChildByType(ast.NodeCodeBlockFenceInfoMarker); lex.ItemBacktick == fenceChar && nil != info && bytes.Contains(info.CodeBlockInfo, []byte("`")) {
+		fenceChar = lex.ItemTilde
+	}
+
+	length := max(style.CodeFenceLength, 3)
+	if 1 > style.CodeFenceLength && 0 < len(marker) && fenceChar == marker[0] {
+		length = len(marker)
+	}
+
+	// 围栏需要比代码中出现的同一字符的行首序列更长
+	var code []byte
+	if c := codeBlock.ChildByType(ast.NodeCodeBlockCode); nil != c {
+		code = c.Tokens
+	} else if nil != codeBlock.FirstChild {
+		code = codeBlock.FirstChild.Tokens
+	}
+	for _, line := range bytes.Split(code, []byte{lex.ItemNewline}) {
+		line = bytes.TrimLeft(line, " ")
+		run := 0
+		for ; run < len(line) && fenceChar == line[run]; run++ {
+		}
+		if run >= length {
+			length = run + 1
+		}
+	}
+	return bytes.Repeat([]byte{fenceChar}, length)
+}
+
+// listItemNum 返回有序列表项 node 的编号。
+func (r *FormatRenderer) listItemNum(node *ast.Node) int {
+	if "one" != r.formatStyle().OrderedList || nil == node.Parent || nil == node.Parent.ListData {
+		return node.ListData.Num
+	}
+	return node.Parent.ListData.Start
+}
+
+// thematicBreak 返回分隔线。
+func (r *FormatRenderer) thematicBreak() string {
+	if ret := r.formatStyle().ThematicBreak; isThematicBreak(ret) {
+		return ret
+	}
+	return "---"
+}
+
+// isThematicBreak 判断 s 是否是合法的分隔线，即由 3 个以上相同的 - * _ 和空格组成。
+func isThematicBreak(s string) bool {
+	var marker byte
+	count := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case lex.ItemSpace:
+		case lex.ItemHyphen, lex.ItemAsterisk, lex.ItemUnderscore:
+			if 0 != marker && marker != c {
+				return false
+			}
+			marker = c
+			count++
+		default:
+			return false
+		}
+	}
+	return 3 <= count && lex.ItemSpace != s[0]
+}
+
+// limitBlankLines 将 source 末尾的空行限制为最多 maxBlankLines 行，maxBlankLines 小于 1 时不限制。source 可以使用 \n、\r\n 或者 \r 换行。
+func limitBlankLines(source []byte, maxBlankLines int) []byte {
+	if 1 > maxBlankLines {
+		return source
+	}
+
+	content := len(bytes.TrimRight(source, " \t\r\n"))
+	lineEnd := bytes.IndexAny(source[content:], "\r\n")
+	if 0 > lineEnd {
+		return source
+	}
+	lineEnd += content
+	lineEnding := source[lineEnd : lineEnd+1]
+	if lex.ItemCarriageReturn == source[lineEnd] && lineEnd+1 < len(source) && lex.ItemNewline == source[lineEnd+1] {
+		lineEnding = source[lineEnd : lineEnd+2]
+	}
+	lineEnd += len(lineEnding)
+	blankLines := bytes.Count(source[lineEnd:], []byte{lex.ItemNewline}) + bytes.Count(source[lineEnd:], []byte{lex.ItemCarriageReturn}) - bytes.Count(source[lineEnd:], []byte("\r\n"))
+	if blankLines <= maxBlankLines {
+		return source
+	}
+	ret := append([]byte{}, source[:lineEnd]...)
+	return append(ret, bytes.Repeat(lineEnding, maxBlankLines)...)
+}
+
+// referenceOutput 判断 node 是否是以引用链接形式输出的链接：没有设置转换为内联链接的引用链接，或者需要转换为引用链接的内联链接和自动链接。
+func (r *FormatRenderer) referenceOutput(node *ast.Node) bool {
+	if nil == node || ast.NodeLink != node.Type {
+		return false
+	}
+	switch node.LinkType {
+	case 0, 2:
+		// 自动链接格式化时会输出为内联链接，所以也需要转换
+		return "reference" == r.formatStyle().Link
+	case 3:
+		return "inline" != r.formatStyle().Link
+	}
+	return false
+}
+
+// dropLinkRefDefs 判断是否不在原处输出链接引用定义：转换为内联链接时不再需要定义，转换为引用链接时定义统一在文档末尾输出。
+// 无损格式化时原样保留的块可能还在使用这些定义，所以总是保留。
+func (r *FormatRenderer) dropLinkRefDefs() bool {
+	if r.lossless {
+		return false
+	}
+	link := r.formatStyle().Link
+	return "inline" == link || "reference" == link
+}
+
+// linkRefDefContent 返回链接 link 对应的链接引用定义内容，即链接地址和可选的标题。
+func (r *FormatRenderer) linkRefDefContent(link *ast.Node) string {
+	buf := &bytes.Buffer{}
+	if dest := link.ChildByType(ast.NodeLinkDest); nil != dest && 0 < len(dest.Tokens) {
+		buf.Write(r.LinkPath(dest.Tokens))
+	} else {
+		buf.WriteString("<>")
+	}
+	if title := link.ChildByType(ast.NodeLinkTitle); nil != title && 0 < len(title.Tokens) {
+		buf.WriteString(" \"")
+		buf.Write(html.EscapeHTML(title.Tokens))
+		buf.WriteByte(lex.ItemDoublequote)
+	}
+	return buf.String()
+}
+
+// collectLinkRefDefs 收集文档中已有的链接引用定义，内容相同的链接会复用已有的 label，新生成的 label 也不会和已有的重复。
+// 非无损格式化时已有的定义会和新生成的定义一起在文档末尾输出。
+func (r *FormatRenderer) collectLinkRefDefs() {
+	r.linkRefLabels, r.linkRefUsed = map[string]string{}, map[string]bool{}
+	r.linkRefDefs, r.linkRefNum = nil, 0
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeLinkRefDef != n.Type || nil == n.FirstChild {
+			return ast.WalkContinue
+		}
+
+		label := util.BytesToStr(n.Tokens)
+		if r.linkRefUsed[strings.ToLower(label)] { // 重复的定义不会生效
+			return ast.WalkSkipChildren
+		}
+		r.linkRefUsed[strings.ToLower(label)] = true
+		content := r.linkRefDefContent(n.FirstChild)
+		if _, ok := r.linkRefLabels[content]; !ok {
+			r.linkRefLabels[content] = label
+		}
+		if !r.lossless {
+			r.linkRefDefs = append(r.linkRefDefs, "["+label+"]: "+content)
+		}
+		return ast.WalkSkipChildren
+	})
+}
+
+// linkRefLabel 返回内联链接 link 转换为引用链接后使用的 label，没有内容相同的定义时生成一个数字 label 并收集对应的定义。
+func (r *FormatRenderer) linkRefLabel(link *ast.Node) string {
+	if nil == r.linkRefLabels {
+		r.collectLinkRefDefs()
+	}
+
+	content := r.linkRefDefContent(link)
+	if ret, ok := r.linkRefLabels[content]; ok {
+		return ret
+	}
+	ret := ""
+	for {
+		r.linkRefNum++
+		if ret = strconv.Itoa(r.linkRefNum); !r.linkRefUsed[ret] {
+			break
+		}
+	}
+	r.linkRefLabels[content] = ret
+	r.linkRefUsed[ret] = true
+	r.linkRefDefs = append(r.linkRefDefs, "["+ret+"]: "+content)
+	return ret
+}
+
+// wrapParagraph 判断段落 node 是否需要折行。
+func (r *FormatRenderer) wrapParagraph(node *ast.Node) bool {
+	switch r.formatStyle().Wrap {
+	case "column", "sentence", "none":
+		return !node.ParentIs(ast.NodeTableCell)
+	}
+	return false
+}
+
+// writeWrappable 输出行级文本 tokens，段落折行时记录其中的空格作为可以换行的位置。
+func (r *FormatRenderer) writeWrappable(tokens []byte) {
+	if r.wrapping && !r.wrapHold {
+		offset := r.Writer.Len()
+		for i, b := range tokens {
+			if lex.ItemSpace == b {
+				r.wrapBreaks = append(r.wrapBreaks, offset+i)
+			}
+		}
+	}
+	r.Write(tokens)
+}
+
+// wrapIndent 返回段落 node 所在的列表项和引述块在行首产生的缩进宽度。
+func wrapIndent(node *ast.Node) (ret int) {
+	for p := node.Parent; nil != p; p = p.Parent {
+		switch p.Type {
+		case ast.NodeBlockquote:
+			ret += 2
+		case ast.NodeListItem:
+			if nil != p.ListData {
+				ret += len(p.ListData.Marker) + 1
+			}
+		}
+	}
+	return
+}
+
+// wrap 按照折行风格对段落内容 paragraph 重新折行，breaks 是 paragraph 中可以换行的空格位置，indent 是段落所在容器的缩进宽度。
+func (r *FormatRenderer) wrap(paragraph []byte, breaks []int, indent int) []byte {
+	style := r.formatStyle()
+	column := style.WrapColumn
+	if 1 > column {
+		column = 80
+	}
+	column = max(column-indent, 20)
+
+	// 连续的空格视为一个换行位置，将段落切分为单词
+	var words [][]byte
+	start := 0
+	for i := 0; i < len(breaks); i++ {
+		end := breaks[i]
+		for i+1 < len(breaks) && breaks[i+1] == breaks[i]+1 {
+			i++
+		}
+		words = append(words, paragraph[start:end])
+		start = breaks[i] + 1
+	}
+	words = append(words, paragraph[start:])
+
+	// 段落以 [label]: 开头时折行会使第一行被解析为链接引用定义
+	refDef := bytes.HasPrefix(words[0], []byte("[")) && bytes.HasSuffix(words[0], []byte("]:"))
+	buf := &bytes.Buffer{}
+	width := 0
+	angle := false // 是否有未闭合的 <，在 < 和 > 之间换行会使其被解析为自动链接
+	for i, word := range words {
+		if 0 < i {
+			newline := false
+			switch style.Wrap {
+			case "column":
+				newline = 0 < width && column < width+1+textWidth(firstLine(word))
+			case "sentence":
+				newline = endsSentence(words[i-1])
+			}
+			if newline && !refDef && !angle && !startsBlock(word) {
+				buf.WriteByte(lex.ItemNewline)
+				width = 0
+			} else {
+				buf.WriteByte(lex.ItemSpace)
+				width++
+			}
+		}
+		buf.Write(word)
+		if less, greater := bytes.LastIndexByte(word, lex.ItemLess), bytes.LastIndexByte(word, lex.ItemGreater); less > greater {
+			angle = true
+		} else if 0 <= greater {
+			angle = false
+		}
+		if idx := bytes.LastIndexByte(word, lex.ItemNewline); 0 <= idx {
+			width = textWidth(word[idx+1:])
+		} else {
+			width += textWidth(word)
+		}
+	}
+	return buf.Bytes()
+}
+
+// firstLine 返回 text 的第一行。
+func firstLine(text []byte) []byte {
+	if idx := bytes.IndexByte(text, lex.ItemNewline); 0 <= idx {
+		return text[:idx]
+	}
+	return text
+}
+
+// textWidth 返回 text 的显示宽度，中日韩字符和全角字符的宽度计为 2。
+func textWidth(text []byte) (ret int) {
+	for _, r := range util.BytesToStr(text) {
+		if isCJK(r) || isFullWidth(r) {
+			ret += 2
+		} else {
+			ret++
+		}
+	}
+	return
+}
+
+// endsSentence 判断单词 word 是否以句末标点结尾，句末标点后可以跟右引号和右括号。
+func endsSentence(word []byte) bool {
+	word = bytes.TrimRight(word, "\"')]*_")
+	if 1 > len(word) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRune(word)
+	switch last {
+	case '.', '!', '?', '。', '！', '？':
+		return true
+	}
+	return false
+}
+
+// startsBlock 判断单词 word 位于行首时是否可能被解析为块级元素的开始，这样的单词不能换行到行首。
+func startsBlock(word []byte) bool {
+	if 1 > len(word) {
+		return false
+	}
+
+	switch word[0] {
+	case lex.ItemCrosshatch, lex.ItemGreater, lex.ItemEqual, lex.ItemPipe, lex.ItemLess, lex.ItemDollar, lex.ItemBacktick, lex.ItemTilde, lex.ItemColon, lex.ItemOpenBrace:
+		return true
+	case lex.ItemHyphen, lex.ItemPlus, lex.ItemAsterisk, lex.ItemUnderscore:
+		return 1 == len(word) || isThematicBreak(string(word)) || lex.ItemSpace == word[1]
+	case lex.ItemOpenBracket:
+		return bytes.Contains(word, []byte("]:")) || bytes.HasPrefix(word, []byte("[^"))
+	}
+
+	// 有序列表标记 1. 或者 1)
+	digits := 0
+	for ; digits < len(word) && lex.IsDigit(word[digits]); digits++ {
+	}
+	if 0 < digits && digits == len(word)-1 {
+		if _, err := strconv.Atoi(string(word[:digits])); nil == err {
+			return lex.ItemDot == word[digits] || lex.ItemCloseParen == word[digits]
+		}
+	}
+	return false
+}
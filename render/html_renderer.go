@@ -167,6 +167,12 @@ func NewHtmlRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Opt
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirectiveHTML
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirectiveHTML
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirectiveHTML
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeCustomInline] = ret.renderCustomInline
 	return ret
 }
 
@@ -233,6 +239,13 @@ func (r *HtmlRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStat
 	return ast.WalkContinue
 }
 
+func (r *HtmlRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.renderWikiLinkHTML(node)
+	}
+	return ast.WalkContinue
+}
+
 func (r *HtmlRenderer) renderCustomBlock(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
@@ -247,6 +260,21 @@ func (r *HtmlRenderer) renderCustomBlock(node *ast.Node, entering bool) ast.Walk
 	return ast.WalkContinue
 }
 
+func (r *HtmlRenderer) renderCustomInline(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("span", [][]string{
+			{"data-type", "NodeCustomInline"},
+			{"data-info", util.BytesToStr(html.EscapeHTML([]byte(node.CustomBlockInfo)))},
+		}, false)
+		if nil == node.FirstChild {
+			r.Write(html.EscapeHTML(node.Tokens))
+		}
+	} else {
+		r.Tag("/span", nil, false)
+	}
+	return ast.WalkContinue
+}
+
 func (r *HtmlRenderer) renderAttributeView(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
@@ -428,9 +456,7 @@ func (r *HtmlRenderer) renderVideo(node *ast.Node, entering bool) ast.WalkStatus
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -442,9 +468,7 @@ func (r *HtmlRenderer) renderAudio(node *ast.Node, entering bool) ast.WalkStatus
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -456,9 +480,7 @@ func (r *HtmlRenderer) renderIFrame(node *ast.Node, entering bool) ast.WalkStatu
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -470,9 +492,7 @@ func (r *HtmlRenderer) renderWidget(node *ast.Node, entering bool) ast.WalkStatu
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -509,7 +529,7 @@ func (r *HtmlRenderer) renderGitConflict(node *ast.Node, entering bool) ast.Walk
 	if entering {
 		attrs := [][]string{{"class", "language-git-conflict"}}
 		r.handleKramdownBlockIAL(node)
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node)...)
 		r.Tag("div", attrs, false)
 	} else {
 		r.Tag("/div", nil, false)
@@ -552,7 +572,7 @@ func (r *HtmlRenderer) renderTag(node *ast.Node, entering bool) ast.WalkStatus {
 
 func (r *HtmlRenderer) renderTagOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.nodeIAL(node.Parent), false)
 		r.WriteByte(lex.ItemCrosshatch)
 	}
 	return ast.WalkContinue
@@ -585,7 +605,7 @@ func (r *HtmlRenderer) renderMark(node *ast.Node, entering bool) ast.WalkStatus
 
 func (r *HtmlRenderer) renderMark1OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("mark", node.Parent.KramdownIAL, false)
+		r.Tag("mark", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -599,7 +619,7 @@ func (r *HtmlRenderer) renderMark1CloseMarker(node *ast.Node, entering bool) ast
 
 func (r *HtmlRenderer) renderMark2OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("mark", node.Parent.KramdownIAL, false)
+		r.Tag("mark", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -738,7 +758,7 @@ func (r *HtmlRenderer) renderYamlFrontMatterContent(node *ast.Node, entering boo
 func (r *HtmlRenderer) renderYamlFrontMatterOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		attrs := [][]string{{"class", "vditor-yml-front-matter"}}
-		attrs = append(attrs, node.Parent.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node.Parent)...)
 		r.Tag("pre", attrs, false)
 		r.WriteString("<code class=\"language-yaml\">")
 	}
@@ -804,22 +824,36 @@ func (r *HtmlRenderer) RenderFootnotes() []byte {
 	buf.WriteString("<ol class=\"footnotes-defs-ol\">")
 	for i, def := range r.FootnotesDefs {
 		buf.WriteString("<li id=\"footnotes-def-" + strconv.Itoa(i+1) + "\">")
+		// 渲染时会临时移动脚注定义节点并插入回跳链接，渲染完成后需要还原，避免破坏语法树（增量解析会复用语法树）
+		parent, next := def.Parent, def.Next
 		footnotesTree := &parse.Tree{Name: "", Context: r.Tree.Context}
 		footnotesTree.Context.Tree = footnotesTree
 		footnotesTree.Root = &ast.Node{Type: ast.NodeDocument}
 		footnotesTree.Root.AppendChild(def)
 		defRenderer := NewHtmlRenderer(footnotesTree, r.Options, r.ParseOptions)
 		lc := footnotesTree.Root.LastDeepestChild()
+		var links []*ast.Node
 		for i = len(def.FootnotesRefs) - 1; 0 <= i; i-- {
 			ref := def.FootnotesRefs[i]
 			gotoRef := " <a href=\"#footnotes-ref-" + ref.FootnotesRefId + "\" class=\"vditor-footnotes__goto-ref\">↩</a>"
 			link := &ast.Node{Type: ast.NodeInlineHTML, Tokens: util.StrToBytes(gotoRef)}
 			lc.InsertAfter(link)
+			links = append(links, link)
 		}
 		defRenderer.RenderingFootnotes = true
 		defContent := defRenderer.Render()
 		buf.Write(defContent)
 		buf.WriteString("</li>\n")
+
+		for _, link := range links {
+			link.Unlink()
+		}
+		if nil != next {
+			next.InsertBefore(def)
+		} else if nil != parent {
+			parent.AppendChild(def)
+		}
+		r.Tree.Context.Tree = r.Tree
 	}
 	buf.WriteString("</ol></div>")
 	return buf.Bytes()
@@ -932,7 +966,8 @@ func (r *HtmlRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkSt
 	if entering {
 		attrs := [][]string{{"class", "language-math"}}
 		r.handleKramdownBlockIAL(node)
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node)...)
+		attrs = r.sourcePosAttrs(node, attrs)
 		r.Tag("div", attrs, false)
 	}
 	return ast.WalkContinue
@@ -999,7 +1034,10 @@ func (r *HtmlRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus
 
 	if entering {
 		r.handleKramdownBlockIAL(node)
-		r.Tag("table", node.KramdownIAL, false)
+		var attrs [][]string
+		attrs = append(attrs, r.nodeIAL(node)...)
+		attrs = r.sourcePosAttrs(node, attrs)
+		r.Tag("table", attrs, false)
 		r.Newline()
 	} else {
 		if nil != node.FirstChild.Next {
@@ -1023,7 +1061,7 @@ func (r *HtmlRenderer) renderStrikethrough(node *ast.Node, entering bool) ast.Wa
 
 func (r *HtmlRenderer) renderStrikethrough1OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("del", node.Parent.KramdownIAL, false)
+		r.Tag("del", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1037,7 +1075,7 @@ func (r *HtmlRenderer) renderStrikethrough1CloseMarker(node *ast.Node, entering
 
 func (r *HtmlRenderer) renderStrikethrough2OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("del", node.Parent.KramdownIAL, false)
+		r.Tag("del", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1148,13 +1186,11 @@ func (r *HtmlRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus
 			r.Tag("/span", nil, false)
 		}
 
-		if r.Options.Sanitize {
+		if r.sanitizing() {
 			buf := r.Writer.Bytes()
 			idx := bytes.LastIndex(buf, []byte("<img src="))
 			imgBuf := buf[idx:]
-			if r.Options.Sanitize {
-				imgBuf = sanitize(imgBuf)
-			}
+			imgBuf = r.sanitizeTokens(imgBuf)
 			r.Writer.Truncate(idx)
 			r.Writer.Write(imgBuf)
 		}
@@ -1168,13 +1204,7 @@ func (r *HtmlRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus
 
 		dest := node.ChildByType(ast.NodeLinkDest)
 		destTokens := dest.Tokens
-		if r.Options.Sanitize {
-			tokens := bytes.TrimSpace(destTokens)
-			tokens = bytes.ToLower(tokens)
-			if bytes.HasPrefix(tokens, []byte("javascript:")) {
-				destTokens = nil
-			}
-		}
+		destTokens = r.sanitizeLinkDest(destTokens)
 		destTokens = r.LinkPath(destTokens)
 		attrs := [][]string{{"href", util.BytesToStr(html.EscapeHTML(destTokens))}}
 		if title := node.ChildByType(ast.NodeLinkTitle); nil != title && nil != title.Tokens {
@@ -1193,10 +1223,9 @@ func (r *HtmlRenderer) renderHTML(node *ast.Node, entering bool) ast.WalkStatus
 	if entering {
 		r.Newline()
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
+		tokens = r.tagFilter(tokens)
 		r.Write(tokens)
 		r.Newline()
 	}
@@ -1206,9 +1235,8 @@ func (r *HtmlRenderer) renderHTML(node *ast.Node, entering bool) ast.WalkStatus
 func (r *HtmlRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
+		tokens = r.tagFilter(tokens)
 		r.Write(tokens)
 	}
 	return ast.WalkContinue
@@ -1227,7 +1255,8 @@ func (r *HtmlRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkSt
 		r.Newline()
 		r.handleKramdownBlockIAL(node)
 		var attrs [][]string
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node)...)
+		attrs = r.sourcePosAttrs(node, attrs)
 		if r.Options.ChineseParagraphBeginningSpace && ast.NodeDocument == node.Parent.Type {
 			if !r.ParagraphContainImgOnly(node) {
 				attrs = append(attrs, []string{"class", "indent--2"})
@@ -1283,7 +1312,7 @@ func (r *HtmlRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkSta
 
 func (r *HtmlRenderer) renderCodeSpanOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("code", node.Parent.KramdownIAL, false)
+		r.Tag("code", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1313,7 +1342,7 @@ func (r *HtmlRenderer) renderEmphasis(node *ast.Node, entering bool) ast.WalkSta
 
 func (r *HtmlRenderer) renderEmAsteriskOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1327,7 +1356,7 @@ func (r *HtmlRenderer) renderEmAsteriskCloseMarker(node *ast.Node, entering bool
 
 func (r *HtmlRenderer) renderEmUnderscoreOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1350,7 +1379,7 @@ func (r *HtmlRenderer) renderStrong(node *ast.Node, entering bool) ast.WalkStatu
 
 func (r *HtmlRenderer) renderStrongA6kOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("strong", node.Parent.KramdownIAL, false)
+		r.Tag("strong", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1364,7 +1393,7 @@ func (r *HtmlRenderer) renderStrongA6kCloseMarker(node *ast.Node, entering bool)
 
 func (r *HtmlRenderer) renderStrongU8eOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("strong", node.Parent.KramdownIAL, false)
+		r.Tag("strong", r.nodeIAL(node.Parent), false)
 	}
 	return ast.WalkContinue
 }
@@ -1380,7 +1409,10 @@ func (r *HtmlRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkS
 	if entering {
 		r.Newline()
 		r.handleKramdownBlockIAL(node)
-		r.Tag("blockquote", node.KramdownIAL, false)
+		var attrs [][]string
+		attrs = append(attrs, r.nodeIAL(node)...)
+		attrs = r.sourcePosAttrs(node, attrs)
+		r.Tag("blockquote", attrs, false)
 		r.Newline()
 	} else {
 		r.Newline()
@@ -1406,10 +1438,10 @@ func (r *HtmlRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStat
 			r.WriteString(" id=\"" + id + "\"")
 			if r.Options.KramdownBlockIAL {
 				if "id" != r.Options.KramdownIALIDRenderName && 0 < len(node.KramdownIAL) {
-					r.WriteString(" " + r.Options.KramdownIALIDRenderName + "=\"" + node.KramdownIAL[0][1] + "\"")
+					r.WriteString(" " + r.Options.KramdownIALIDRenderName + "=\"" + html.EscapeHTMLStr(html.UnescapeHTMLStr(node.KramdownIAL[0][1])) + "\"")
 				}
 				if 1 < len(node.KramdownIAL) {
-					for _, attr := range node.KramdownIAL {
+					for _, attr := range r.nodeIAL(node) {
 						if "id" == attr[0] {
 							continue
 						}
@@ -1418,6 +1450,9 @@ func (r *HtmlRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStat
 				}
 			}
 		}
+		for _, attr := range r.sourcePosAttrs(node, nil) {
+			r.WriteString(" " + attr[0] + "=\"" + attr[1] + "\"")
+		}
 		r.WriteString(">")
 	} else {
 		if r.Options.HeadingAnchor {
@@ -1453,7 +1488,8 @@ func (r *HtmlRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus
 			attrs = append(attrs, []string{"start", strconv.Itoa(node.ListData.Start)})
 		}
 		r.handleKramdownBlockIAL(node)
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node)...)
+		attrs = r.sourcePosAttrs(node, attrs)
 		r.Tag(tag, attrs, false)
 		r.Newline()
 	} else {
@@ -1468,7 +1504,7 @@ func (r *HtmlRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkSta
 	if entering {
 		var attrs [][]string
 		r.handleKramdownBlockIAL(node)
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.nodeIAL(node)...)
 		if 3 == node.ListData.Typ && "" != r.Options.GFMTaskListItemClass && nil != node.FirstChild &&
 			((ast.NodeTaskListItemMarker == node.FirstChild.Type) ||
 				(nil != node.FirstChild.FirstChild && ast.NodeTaskListItemMarker == node.FirstChild.FirstChild.Type)) {
@@ -1482,6 +1518,7 @@ func (r *HtmlRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkSta
 			}
 			attrs = append(attrs, []string{"class", taskClass})
 		}
+		attrs = r.sourcePosAttrs(node, attrs)
 		r.Tag("li", attrs, false)
 	} else {
 		r.Tag("/li", nil, false)
@@ -1509,7 +1546,7 @@ func (r *HtmlRenderer) renderTaskListItemMarker(node *ast.Node, entering bool) a
 func (r *HtmlRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
-		r.Tag("hr", nil, true)
+		r.Tag("hr", r.sourcePosAttrs(node, nil), true)
 		r.Newline()
 	}
 	return ast.WalkContinue
@@ -1581,6 +1618,19 @@ func (r *HtmlRenderer) renderTextMarkAttrs(node *ast.Node) (attrs [][]string) {
 	return
 }
 
+// sourcePosAttrs 在打开 SourcePos 渲染选项时为 attrs 追加节点的 data-sourcepos 属性。
+func (r *HtmlRenderer) sourcePosAttrs(node *ast.Node, attrs [][]string) [][]string {
+	if r.Options.SourcePos && nil != node.Pos {
+		attrs = append(attrs, []string{"data-sourcepos", node.Pos.String()})
+	}
+	return attrs
+}
+
 func (r *HtmlRenderer) spanNodeAttrs(node *ast.Node, attrs *[][]string) {
-	*attrs = append(*attrs, node.KramdownIAL...)
+	*attrs = append(*attrs, r.nodeIAL(node)...)
+}
+
+// nodeIAL 返回节点 node 需要渲染的 IAL 属性，启用 XSS 安全过滤时会移除不安全的属性。
+func (r *HtmlRenderer) nodeIAL(node *ast.Node) [][]string {
+	return r.sanitizeNodeIAL(node.KramdownIAL)
 }
@@ -12,6 +12,7 @@ package render
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/88250/lute/ast"
 	"github.com/88250/lute/parse"
@@ -35,6 +36,10 @@ func NewJSONRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Opt
 		return ast.WalkContinue
 	})
 	for _, ial := range ials {
+		if root := tree.Root; root == ial.Parent && root.LastChild == ial && 1 > len(root.KramdownIAL) {
+			// 解析时生成的文档 IAL 没有记录在根节点上
+			root.KramdownIAL = parse.Tokens2IAL(ial.Tokens)
+		}
 		ial.Unlink()
 	}
 
@@ -48,20 +53,26 @@ func (r *JSONRenderer) renderNode(node *ast.Node, entering bool) ast.WalkStatus
 		if nil != node.Previous {
 			r.WriteString(",")
 		}
+		spec := node.Spec
+		if ast.NodeDocument == node.Type && "" == spec {
+			node.Spec = parse.JSONSpec
+		}
 		node.Data, node.TypeStr = util.BytesToStr(node.Tokens), node.Type.String()
-		node.Properties = ial2Map(node.KramdownIAL)
-		delete(node.Properties, "refcount")
-		delete(node.Properties, "av-names")
+		node.Properties = nil // 属性由 IAL 生成
 		data, err := json.Marshal(node)
-		node.Data, node.TypeStr = "", ""
-		node.Properties = nil
+		node.Data, node.TypeStr, node.Spec = "", "", spec
+		if nil == err {
+			data = data[:len(data)-1] // 去掉结尾的 }
+			if ast.NodeDocument == node.Type && nil != r.Tree {
+				data = appendTreeFields(data, r.Tree)
+			}
+			data, err = appendProperties(data, node.KramdownIAL)
+		}
 		if nil != err {
-			panic("marshal node to json failed: " + err.Error())
+			r.err = ast.NewNodeError(node, errors.New("marshal node to json failed: "+err.Error()))
 			return ast.WalkStop
 		}
-		n := util.BytesToStr(data)
-		n = n[:len(n)-1] // 去掉结尾的 }
-		r.WriteString(n)
+		r.Write(data)
 		if nil != node.FirstChild {
 			r.WriteString(",\"Children\":[")
 		} else {
@@ -76,10 +87,52 @@ func (r *JSONRenderer) renderNode(node *ast.Node, entering bool) ast.WalkStatus
 	return ast.WalkContinue
 }
 
-func ial2Map(ial [][]string) (ret map[string]string) {
-	ret = map[string]string{}
+// appendTreeFields 在根节点上输出语法树原始文本的换行符 LineEnding 和 BOM，换行符为 \n 和没有 BOM 时省略。
+func appendTreeFields(data []byte, tree *parse.Tree) []byte {
+	if "\r\n" == tree.LineEnding {
+		data = append(data, `,"LineEnding":"\r\n"`...)
+	} else if "\r" == tree.LineEnding {
+		data = append(data, `,"LineEnding":"\r"`...)
+	}
+	if tree.BOM {
+		data = append(data, `,"BOM":true`...)
+	}
+	return data
+}
+
+// appendProperties 按照 IAL 中的属性顺序输出 Properties，重复的属性以最后一个值为准。
+func appendProperties(data []byte, ial [][]string) ([]byte, error) {
+	var names []string
+	values := map[string]string{}
 	for _, kv := range ial {
-		ret[kv[0]] = kv[1]
+		if "refcount" == kv[0] || "av-names" == kv[0] {
+			continue
+		}
+		if _, ok := values[kv[0]]; !ok {
+			names = append(names, kv[0])
+		}
+		values[kv[0]] = kv[1]
+	}
+	if 1 > len(names) {
+		return data, nil
+	}
+
+	data = append(data, `,"Properties":{`...)
+	for i, name := range names {
+		if 0 < i {
+			data = append(data, ',')
+		}
+		k, err := json.Marshal(name)
+		if nil != err {
+			return nil, err
+		}
+		v, err := json.Marshal(values[name])
+		if nil != err {
+			return nil, err
+		}
+		data = append(data, k...)
+		data = append(data, ':')
+		data = append(data, v...)
 	}
-	return
+	return append(data, '}'), nil
 }
@@ -0,0 +1,75 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"context"
+	"errors"
+
+	"github.com/88250/lute/ast"
+)
+
+// ErrOutputTooLarge 表示渲染输出超过了 Options.MaxOutputBytes。
+var ErrOutputTooLarge = errors.New("output exceeds max output bytes")
+
+// cancelCheckInterval 指定每渲染多少个节点检查一次上下文是否已经取消。
+const cancelCheckInterval = 64
+
+// SetContext 设置渲染使用的上下文，上下文取消后渲染会尽快停止，停止原因可以通过 Err 获取。
+func (r *BaseRenderer) SetContext(ctx context.Context) {
+	if nil != ctx.Done() {
+		r.ctx = ctx
+	}
+}
+
+// Err 返回中止渲染的原因，比如上下文被取消或者输出超过了 Options.MaxOutputBytes，正常完成渲染时返回 nil。
+func (r *BaseRenderer) Err() error {
+	return r.err
+}
+
+// CurrentNode 返回正在渲染的节点，渲染过程中发生 panic 时可用于定位出错的节点。
+func (r *BaseRenderer) CurrentNode() *ast.Node {
+	return r.node
+}
+
+// CheckOutput 检查最终输出 output 是否超过了 Options.MaxOutputBytes。渲染器可能在遍历语法树之后追加输出（比如脚注），所以调用方需要检查最终的输出。
+func (r *BaseRenderer) CheckOutput(output []byte) error {
+	if nil != r.err {
+		return r.err
+	}
+	if nil == r.Options {
+		return nil
+	}
+	if max := r.Options.MaxOutputBytes; 0 < max && len(output) > max {
+		return ErrOutputTooLarge
+	}
+	return nil
+}
+
+// limitExceeded 在遍历节点时检查上下文是否已经取消以及输出是否超过了 Options.MaxOutputBytes，返回 true 时需要停止渲染。
+func (r *BaseRenderer) limitExceeded() bool {
+	if nil != r.err {
+		return true
+	}
+
+	if nil != r.Options && 0 < r.Options.MaxOutputBytes && r.Writer.Len() > r.Options.MaxOutputBytes {
+		r.err = ErrOutputTooLarge
+		return true
+	}
+
+	if nil != r.ctx {
+		r.checks++
+		if 0 == r.checks%cancelCheckInterval {
+			r.err = r.ctx.Err()
+		}
+	}
+	return nil != r.err
+}
@@ -0,0 +1,51 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"github.com/88250/lute/lex"
+)
+
+// restoreLineEnding 将 Markdown 输出 output 中的换行符 \n 还原为语法树原始文本使用的换行符，bom 为 true 并且原始文本以 UTF-8 BOM
+// 开头时还会在开头加上 BOM。output 中已经和原始文本一致的换行符（比如无损格式化时原样输出的原始文本）保持不变。
+func (r *BaseRenderer) restoreLineEnding(output []byte, bom bool) []byte {
+	if nil == r.Tree {
+		return output
+	}
+
+	lineEnding := r.Tree.LineEnding
+	bom = bom && r.Tree.BOM
+	if "\r\n" != lineEnding && "\r" != lineEnding && !bom {
+		return output
+	}
+
+	ret := make([]byte, 0, len(output)+len(output)/16+len(lex.BOM))
+	if bom {
+		ret = append(ret, lex.BOM...)
+	}
+	for i, b := range output {
+		if lex.ItemNewline != b || ("\r\n" != lineEnding && "\r" != lineEnding) {
+			ret = append(ret, b)
+			continue
+		}
+
+		crlf := 0 < i && lex.ItemCarriageReturn == output[i-1]
+		if "\r\n" == lineEnding {
+			if !crlf {
+				ret = append(ret, lex.ItemCarriageReturn)
+			}
+			ret = append(ret, lex.ItemNewline)
+		} else if !crlf {
+			ret = append(ret, lex.ItemCarriageReturn)
+		}
+	}
+	return ret
+}
@@ -165,6 +165,9 @@ func NewProtyleExportDocxRenderer(tree *parse.Tree, options *Options, parseOptio
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirectiveHTML
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirectiveHTML
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirectiveHTML
 	return ret
 }
 
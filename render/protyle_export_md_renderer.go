@@ -115,6 +115,8 @@ func NewProtyleExportMdRenderer(tree *parse.Tree, options *Options, parseOptions
 	ret.RendererFuncs[ast.NodeYamlFrontMatterContent] = ret.renderYamlFrontMatterContent
 	ret.RendererFuncs[ast.NodeYamlFrontMatterCloseMarker] = ret.renderYamlFrontMatterCloseMarker
 	ret.RendererFuncs[ast.NodeBlockRef] = ret.renderBlockRef
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
 	ret.RendererFuncs[ast.NodeBlockRefID] = ret.renderBlockRefID
 	ret.RendererFuncs[ast.NodeBlockRefSpace] = ret.renderBlockRefSpace
 	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderBlockRefText
@@ -166,6 +168,9 @@ func NewProtyleExportMdRenderer(tree *parse.Tree, options *Options, parseOptions
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirective
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirective
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirective
 	return ret
 }
 
@@ -186,6 +191,49 @@ func (r *ProtyleExportMdRenderer) renderCallout(node *ast.Node, entering bool) a
 	return ast.WalkContinue
 }
 
+func (r *ProtyleExportMdRenderer) renderContainerDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+		r.WriteString(directiveOpenMarker(node))
+		r.WriteByte(lex.ItemNewline)
+	} else {
+		// 去掉子块末尾的空行，闭合行紧跟在内容后面
+		r.Writer.Truncate(len(bytes.TrimRight(r.Writer.Bytes(), "\n")))
+		r.WriteByte(lex.ItemNewline)
+		r.WriteString(directiveCloseMarker(node))
+		r.Newline()
+		if !r.isLastNode(r.Tree.Root, node) {
+			if r.withoutKramdownBlockIAL(node) {
+				r.WriteByte(lex.ItemNewline)
+			}
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *ProtyleExportMdRenderer) renderLeafDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+		r.WriteString(directiveOpenMarker(node))
+	} else {
+		r.WriteString(directiveCloseMarker(node))
+		if r.withoutKramdownBlockIAL(node) {
+			r.Newline()
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *ProtyleExportMdRenderer) renderTextDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(directiveOpenMarker(node))
+	} else {
+		r.WriteString(directiveCloseMarker(node))
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtyleExportMdRenderer) renderCustomBlock(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
@@ -923,6 +971,13 @@ func (r *ProtyleExportMdRenderer) renderBlockQueryEmbed(node *ast.Node, entering
 	return ast.WalkContinue
 }
 
+func (r *ProtyleExportMdRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(wikiLinkMarkdown(node))
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtyleExportMdRenderer) renderBlockRef(node *ast.Node, entering bool) ast.WalkStatus {
 	return ast.WalkContinue
 }
@@ -1499,6 +1554,9 @@ func (r *ProtyleExportMdRenderer) renderDocument(node *ast.Node, entering bool)
 		r.Writer.Reset()
 		r.Write(buf)
 		r.WriteByte(lex.ItemNewline)
+		buf = r.restoreLineEnding(r.Writer.Bytes(), true)
+		r.Writer.Reset()
+		r.Write(buf)
 	}
 	return ast.WalkContinue
 }
@@ -114,6 +114,8 @@ func NewProtyleExportRenderer(tree *parse.Tree, options *Options, parseOptions *
 	ret.RendererFuncs[ast.NodeYamlFrontMatterContent] = ret.renderYamlFrontMatterContent
 	ret.RendererFuncs[ast.NodeYamlFrontMatterCloseMarker] = ret.renderYamlFrontMatterCloseMarker
 	ret.RendererFuncs[ast.NodeBlockRef] = ret.renderBlockRef
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
 	ret.RendererFuncs[ast.NodeBlockRefID] = ret.renderBlockRefID
 	ret.RendererFuncs[ast.NodeBlockRefSpace] = ret.renderBlockRefSpace
 	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderBlockRefText
@@ -165,6 +167,9 @@ func NewProtyleExportRenderer(tree *parse.Tree, options *Options, parseOptions *
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirectiveHTML
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirectiveHTML
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirectiveHTML
 	return ret
 }
 
@@ -493,6 +498,13 @@ func (r *ProtyleExportRenderer) renderIFrame(node *ast.Node, entering bool) ast.
 	return ast.WalkContinue
 }
 
+func (r *ProtyleExportRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.renderWikiLinkHTML(node)
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtyleExportRenderer) renderBlockRef(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		idNode := node.ChildByType(ast.NodeBlockRefID)
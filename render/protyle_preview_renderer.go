@@ -114,6 +114,8 @@ func NewProtylePreviewRenderer(tree *parse.Tree, options *Options, parseOptions
 	ret.RendererFuncs[ast.NodeYamlFrontMatterContent] = ret.renderYamlFrontMatterContent
 	ret.RendererFuncs[ast.NodeYamlFrontMatterCloseMarker] = ret.renderYamlFrontMatterCloseMarker
 	ret.RendererFuncs[ast.NodeBlockRef] = ret.renderBlockRef
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
 	ret.RendererFuncs[ast.NodeBlockRefID] = ret.renderBlockRefID
 	ret.RendererFuncs[ast.NodeBlockRefSpace] = ret.renderBlockRefSpace
 	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderBlockRefText
@@ -165,6 +167,9 @@ func NewProtylePreviewRenderer(tree *parse.Tree, options *Options, parseOptions
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirectiveHTML
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirectiveHTML
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirectiveHTML
 	return ret
 }
 
@@ -335,9 +340,7 @@ func (r *ProtylePreviewRenderer) renderVideo(node *ast.Node, entering bool) ast.
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -349,9 +352,7 @@ func (r *ProtylePreviewRenderer) renderAudio(node *ast.Node, entering bool) ast.
 	if entering {
 		r.Tag("div", [][]string{{"class", "iframe"}}, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -368,9 +369,7 @@ func (r *ProtylePreviewRenderer) renderIFrame(node *ast.Node, entering bool) ast
 		}
 		r.Tag("div", attrs, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -381,13 +380,11 @@ func (r *ProtylePreviewRenderer) renderIFrame(node *ast.Node, entering bool) ast
 func (r *ProtylePreviewRenderer) renderWidget(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		var attrs [][]string
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		attrs = append(attrs, []string{"class", "iframe"})
 		r.Tag("div", attrs, false)
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Tag("/div", nil, false)
@@ -423,7 +420,7 @@ func (r *ProtylePreviewRenderer) renderGitConflict(node *ast.Node, entering bool
 	r.Newline()
 	if entering {
 		attrs := [][]string{{"class", "language-git-conflict"}}
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		r.Tag("div", attrs, false)
 	} else {
 		r.Tag("/div", nil, false)
@@ -466,7 +463,7 @@ func (r *ProtylePreviewRenderer) renderTag(node *ast.Node, entering bool) ast.Wa
 
 func (r *ProtylePreviewRenderer) renderTagOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 		r.WriteByte(lex.ItemCrosshatch)
 	}
 	return ast.WalkContinue
@@ -499,7 +496,7 @@ func (r *ProtylePreviewRenderer) renderMark(node *ast.Node, entering bool) ast.W
 
 func (r *ProtylePreviewRenderer) renderMark1OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("mark", node.Parent.KramdownIAL, false)
+		r.Tag("mark", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -513,7 +510,7 @@ func (r *ProtylePreviewRenderer) renderMark1CloseMarker(node *ast.Node, entering
 
 func (r *ProtylePreviewRenderer) renderMark2OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("mark", node.Parent.KramdownIAL, false)
+		r.Tag("mark", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -581,6 +578,13 @@ func (r *ProtylePreviewRenderer) renderBlockQueryEmbedScript(node *ast.Node, ent
 	return ast.WalkContinue
 }
 
+func (r *ProtylePreviewRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.renderWikiLinkHTML(node)
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtylePreviewRenderer) renderBlockRef(node *ast.Node, entering bool) ast.WalkStatus {
 	return ast.WalkContinue
 }
@@ -660,7 +664,7 @@ func (r *ProtylePreviewRenderer) renderYamlFrontMatterContent(node *ast.Node, en
 func (r *ProtylePreviewRenderer) renderYamlFrontMatterOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		attrs := [][]string{{"class", "vditor-yml-front-matter"}}
-		attrs = append(attrs, node.Parent.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.Parent.KramdownIAL)...)
 		r.Tag("pre", attrs, false)
 		r.WriteString("<code class=\"language-yaml\">")
 	}
@@ -759,7 +763,7 @@ func (r *ProtylePreviewRenderer) renderCodeBlock(node *ast.Node, entering bool)
 			tokens = bytes.TrimSpace(tokens)
 			attrs = append(attrs, []string{"data-content", util.BytesToStr(tokens)})
 			attrs = append(attrs, []string{"data-subtype", language})
-			attrs = append(attrs, node.KramdownIAL...)
+			attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 			r.Tag("div", attrs, false)
 			r.Tag("div", [][]string{{"spin", "1"}}, false)
 			r.Tag("/div", nil, false)
@@ -768,7 +772,7 @@ func (r *ProtylePreviewRenderer) renderCodeBlock(node *ast.Node, entering bool)
 		}
 
 		attrs := [][]string{{"class", "code-block"}, {"data-language", language}}
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		r.Tag("pre", attrs, false)
 		r.WriteString("<code class=\"hljs\">")
 	} else {
@@ -872,7 +876,7 @@ func (r *ProtylePreviewRenderer) renderMathBlock(node *ast.Node, entering bool)
 		tokens = bytes.TrimSpace(tokens)
 		attrs = append(attrs, []string{"data-content", util.BytesToStr(tokens)})
 		attrs = append(attrs, []string{"data-subtype", "math"})
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		r.Tag("div", attrs, false)
 		r.Tag("div", [][]string{{"spin", "1"}}, false)
 		r.Tag("/div", nil, false)
@@ -978,7 +982,7 @@ func (r *ProtylePreviewRenderer) renderTable(node *ast.Node, entering bool) ast.
 		delete(ials, "caption")
 		delete(ials, "updated")
 		delete(ials, "colgroup")
-		r.Tag("table", parse.Map2IAL(ials), false)
+		r.Tag("table", r.sanitizeNodeIAL(parse.Map2IAL(ials)), false)
 		r.Newline()
 		caption := node.IALAttr("caption")
 		if "" != caption {
@@ -1009,7 +1013,7 @@ func (r *ProtylePreviewRenderer) renderStrikethrough(node *ast.Node, entering bo
 
 func (r *ProtylePreviewRenderer) renderStrikethrough1OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("del", node.Parent.KramdownIAL, false)
+		r.Tag("del", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1023,7 +1027,7 @@ func (r *ProtylePreviewRenderer) renderStrikethrough1CloseMarker(node *ast.Node,
 
 func (r *ProtylePreviewRenderer) renderStrikethrough2OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("del", node.Parent.KramdownIAL, false)
+		r.Tag("del", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1133,7 +1137,11 @@ func (r *ProtylePreviewRenderer) renderImage(node *ast.Node, entering bool) ast.
 		r.Tag("/span", nil, false)
 	} else {
 		destTokens := node.ChildByType(ast.NodeLinkDest).Tokens
-		if r.Options.Sanitize {
+		if nil != r.Options.SanitizePolicy {
+			if !r.Options.SanitizePolicy.AllowURL("src", util.BytesToStr(destTokens)) {
+				destTokens = nil
+			}
+		} else if r.Options.Sanitize {
 			destTokens = sanitize(destTokens)
 		}
 		destTokens = bytes.ReplaceAll(destTokens, editor.CaretTokens, nil)
@@ -1173,9 +1181,7 @@ func (r *ProtylePreviewRenderer) renderImage(node *ast.Node, entering bool) ast.
 		buf := r.Writer.Bytes()
 		idx := bytes.LastIndex(buf, []byte("<img src="))
 		imgBuf := buf[idx:]
-		if r.Options.Sanitize {
-			imgBuf = sanitize(imgBuf)
-		}
+		imgBuf = r.sanitizeTokens(imgBuf)
 		imgBuf = r.tagSrcPath(imgBuf)
 		r.Writer.Truncate(idx)
 		r.Writer.Write(imgBuf)
@@ -1208,13 +1214,7 @@ func (r *ProtylePreviewRenderer) renderLink(node *ast.Node, entering bool) ast.W
 
 		dest := node.ChildByType(ast.NodeLinkDest)
 		destTokens := dest.Tokens
-		if r.Options.Sanitize {
-			tokens := bytes.TrimSpace(destTokens)
-			tokens = bytes.ToLower(tokens)
-			if bytes.HasPrefix(tokens, []byte("javascript:")) {
-				destTokens = nil
-			}
-		}
+		destTokens = r.sanitizeLinkDest(destTokens)
 		destTokens = r.LinkPath(destTokens)
 		attrs := [][]string{{"href", util.BytesToStr(html.EscapeHTML(destTokens))}}
 		if title := node.ChildByType(ast.NodeLinkTitle); nil != title && nil != title.Tokens {
@@ -1233,9 +1233,7 @@ func (r *ProtylePreviewRenderer) renderHTML(node *ast.Node, entering bool) ast.W
 	if entering {
 		r.Newline()
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		tokens = r.tagSrcPath(tokens)
 		r.Write(tokens)
 		r.Newline()
@@ -1246,9 +1244,7 @@ func (r *ProtylePreviewRenderer) renderHTML(node *ast.Node, entering bool) ast.W
 func (r *ProtylePreviewRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		tokens := node.Tokens
-		if r.Options.Sanitize {
-			tokens = sanitize(tokens)
-		}
+		tokens = r.sanitizeTokens(tokens)
 		r.Write(tokens)
 	}
 	return ast.WalkContinue
@@ -1262,7 +1258,7 @@ func (r *ProtylePreviewRenderer) renderParagraph(node *ast.Node, entering bool)
 	if entering {
 		r.Newline()
 		var attrs [][]string
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		r.Tag("p", attrs, false)
 		if r.Options.ChineseParagraphBeginningSpace && ast.NodeDocument == node.Parent.Type {
 			if !r.ParagraphContainImgOnly(node) {
@@ -1314,7 +1310,7 @@ func (r *ProtylePreviewRenderer) renderCodeSpan(node *ast.Node, entering bool) a
 
 func (r *ProtylePreviewRenderer) renderCodeSpanOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("code", node.Parent.KramdownIAL, false)
+		r.Tag("code", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1344,7 +1340,7 @@ func (r *ProtylePreviewRenderer) renderEmphasis(node *ast.Node, entering bool) a
 
 func (r *ProtylePreviewRenderer) renderEmAsteriskOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1358,7 +1354,7 @@ func (r *ProtylePreviewRenderer) renderEmAsteriskCloseMarker(node *ast.Node, ent
 
 func (r *ProtylePreviewRenderer) renderEmUnderscoreOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("em", node.Parent.KramdownIAL, false)
+		r.Tag("em", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1381,7 +1377,7 @@ func (r *ProtylePreviewRenderer) renderStrong(node *ast.Node, entering bool) ast
 
 func (r *ProtylePreviewRenderer) renderStrongA6kOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("strong", node.Parent.KramdownIAL, false)
+		r.Tag("strong", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1395,7 +1391,7 @@ func (r *ProtylePreviewRenderer) renderStrongA6kCloseMarker(node *ast.Node, ente
 
 func (r *ProtylePreviewRenderer) renderStrongU8eOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.Tag("strong", node.Parent.KramdownIAL, false)
+		r.Tag("strong", r.sanitizeNodeIAL(node.Parent.KramdownIAL), false)
 	}
 	return ast.WalkContinue
 }
@@ -1410,7 +1406,7 @@ func (r *ProtylePreviewRenderer) renderStrongU8eCloseMarker(node *ast.Node, ente
 func (r *ProtylePreviewRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
-		r.Tag("blockquote", node.KramdownIAL, false)
+		r.Tag("blockquote", r.sanitizeNodeIAL(node.KramdownIAL), false)
 		r.Newline()
 	} else {
 		r.Newline()
@@ -1440,7 +1436,7 @@ func (r *ProtylePreviewRenderer) renderHeading(node *ast.Node, entering bool) as
 					r.WriteString(" " + r.Options.KramdownIALIDRenderName + "=\"" + node.HeadingNormalizedID + "\"")
 				}
 				if 1 < len(node.KramdownIAL) {
-					for _, attr := range node.KramdownIAL {
+					for _, attr := range r.sanitizeNodeIAL(node.KramdownIAL) {
 						if "id" == attr[0] {
 							continue
 						}
@@ -1483,7 +1479,7 @@ func (r *ProtylePreviewRenderer) renderList(node *ast.Node, entering bool) ast.W
 		if 0 == node.ListData.BulletChar && 1 != node.ListData.Start {
 			attrs = append(attrs, []string{"start", strconv.Itoa(node.ListData.Start)})
 		}
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		r.Tag(tag, attrs, false)
 		r.Newline()
 	} else {
@@ -1497,7 +1493,7 @@ func (r *ProtylePreviewRenderer) renderList(node *ast.Node, entering bool) ast.W
 func (r *ProtylePreviewRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		var attrs [][]string
-		attrs = append(attrs, node.KramdownIAL...)
+		attrs = append(attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 		if 3 == node.ListData.Typ && nil != node.FirstChild && ((ast.NodeTaskListItemMarker == node.FirstChild.Type) ||
 			(nil != node.FirstChild.FirstChild && ast.NodeTaskListItemMarker == node.FirstChild.FirstChild.Type)) {
 			taskListItemMarker := node.FirstChild.FirstChild
@@ -1603,7 +1599,7 @@ func (r *ProtylePreviewRenderer) renderTextMarkAttrs(node *ast.Node) (attrs [][]
 }
 
 func (r *ProtylePreviewRenderer) spanNodeAttrs(node *ast.Node, attrs *[][]string) {
-	*attrs = append(*attrs, node.KramdownIAL...)
+	*attrs = append(*attrs, r.sanitizeNodeIAL(node.KramdownIAL)...)
 }
 
 func (r *ProtylePreviewRenderer) Render() (output []byte) {
@@ -168,6 +168,11 @@ func NewProtyleRenderer(tree *parse.Tree, options *Options, parseOptions *parse.
 	ret.RendererFuncs[ast.NodeAttributeView] = ret.renderAttributeView
 	ret.RendererFuncs[ast.NodeCustomBlock] = ret.renderCustomBlock
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirective
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirective
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirective
+	ret.RendererFuncs[ast.NodeWikiLink] = ret.renderWikiLink
+	ret.RendererFuncs[ast.NodeWikiLinkEmbed] = ret.renderWikiLink
 	return ret
 }
 
@@ -222,6 +227,66 @@ func (r *ProtyleRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkS
 	return ast.WalkContinue
 }
 
+func (r *ProtyleRenderer) renderContainerDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		attrs := [][]string{
+			{"data-subtype", node.DirectiveName},
+			{"data-label", html.EscapeHTMLStr(node.DirectiveLabel)},
+			{"data-attrs", string(html.EscapeHTML(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs)))},
+		}
+		r.blockNodeAttrs(node, &attrs, "directive")
+		r.Tag("div", attrs, false)
+	} else {
+		r.renderIAL(node)
+		r.Tag("/div", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+func (r *ProtyleRenderer) renderLeafDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		attrs := [][]string{
+			{"data-subtype", node.DirectiveName},
+			{"data-attrs", string(html.EscapeHTML(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs)))},
+		}
+		r.blockNodeAttrs(node, &attrs, "directive")
+		r.Tag("div", attrs, false)
+		attrs = [][]string{}
+		r.contenteditable(node, &attrs)
+		r.spellcheck(&attrs)
+		r.Tag("div", attrs, false)
+	} else {
+		r.Tag("/div", nil, false)
+		r.renderIAL(node)
+		r.Tag("/div", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+// renderTextDirective 按照 Markdown 原文渲染文本指令，转换回 Markdown 时重新解析。
+func (r *ProtyleRenderer) renderTextDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(":" + node.DirectiveName)
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemOpenBracket)
+		}
+	} else {
+		if nil != node.FirstChild {
+			r.WriteByte(lex.ItemCloseBracket)
+		}
+		r.Write(html.EscapeHTML(parse.DirectiveAttrs2Tokens(node.DirectiveAttrs)))
+	}
+	return ast.WalkContinue
+}
+
+// renderWikiLink 按照 Markdown 原文渲染维基链接，转换回 Markdown 时重新解析。
+func (r *ProtyleRenderer) renderWikiLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(html.EscapeHTMLStr(wikiLinkMarkdown(node)))
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtyleRenderer) renderCustomBlock(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		attrs := [][]string{
@@ -12,6 +12,7 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"strconv"
 	"strings"
 	"unicode"
@@ -78,11 +79,15 @@ type Options struct {
 	// Sanitize 设置是否启用 XSS 安全过滤 https://github.com/88250/lute/issues/51
 	// 注意：Lute 目前的实现存在一些漏洞，请不要依赖它来防御 XSS 攻击。
 	Sanitize bool
+	// SanitizePolicy 设置基于白名单的 XSS 安全过滤策略，非 nil 时即使没有打开 Sanitize 也会使用该策略过滤，为 nil 时 Sanitize 沿用原有的过滤实现。
+	SanitizePolicy *Policy
+	// WikiLinkResolver 设置维基链接目标解析器，为 nil 时直接使用目标和标题作为链接地址。
+	WikiLinkResolver WikiLinkResolver
 	// FixTermTypo 设置是否对普通文本中出现的术语进行修正。
 	// https://github.com/sparanoid/chinese-copywriting-guidelines
 	// 注意：开启术语修正的话会默认在中西文之间插入空格。
 	FixTermTypo bool
-	// Terms 将传入的 terms 合并覆盖到已有的 Terms 字典。
+	// Terms 设置术语字典，默认和其他选项共享内置的只读字典，修改请使用 PutTerms。
 	Terms map[string]string
 	// ToC 设置是否打开“目录”支持。
 	ToC bool
@@ -95,6 +100,9 @@ type Options struct {
 	HeadingAnchor bool
 	// GFMTaskListItemClass 作为 GFM 任务列表项类名，默认为 "vditor-task"。
 	GFMTaskListItemClass string
+	// GFMTagFilter 设置是否打开“GFM 过滤 HTML 标签”支持，打开后 HTML 块和内联 HTML 中的 <script>、<style> 等标签的 < 会被转义为 &lt;。
+	// 仅在 HTML 渲染器 HtmlRenderer 中支持。
+	GFMTagFilter bool
 	// DataTask 设置是否渲染任务列表项的 data-task 属性，用于多状态任务列表。
 	DataTask bool
 	// VditorCodeBlockPreview 设置 Vditor 代码块是否需要渲染预览部分
@@ -129,6 +137,57 @@ type Options struct {
 	// ExportNormalizeTaskListMarker 设置是否将非标准的任务列表标记符（如 [/]、[>]、[!] 等）统一导出为完成标记 [X]。
 	// 开启后 [ ] 和 [X] 保持不变，其余标记符均转换为 [X]，以兼容不支持自定义标记符的 Markdown 解析器。
 	ExportNormalizeTaskListMarker bool
+	// SourcePos 设置是否在 HTML 块级元素上渲染 data-sourcepos 属性，需要同时打开 SourcePos 解析选项。
+	SourcePos bool
+	// MaxOutputBytes 设置渲染输出的最大字节数，0 表示不限制。超过后渲染会停止，通过 BaseRenderer.Err 获取错误。
+	MaxOutputBytes int
+	// LosslessFormat 设置格式化时是否使用无损模式：没有修改过的顶层块原样输出原始文本，只重新格式化修改过的顶层块。
+	LosslessFormat bool
+	// FormatStyle 设置格式化输出 Markdown 的风格，为 nil 时使用默认风格。
+	FormatStyle *FormatStyle
+
+	termsOwned bool // Terms 是否由当前选项独占，未独占时需要先复制再写入
+}
+
+// PutTerms 将 termMap 合并覆盖到术语字典。第一次写入时会复制共享的字典，不会影响其他选项。
+func (options *Options) PutTerms(termMap map[string]string) {
+	if !options.termsOwned {
+		terms := make(map[string]string, len(options.Terms)+len(termMap))
+		for k, v := range options.Terms {
+			terms[k] = v
+		}
+		options.Terms = terms
+		options.termsOwned = true
+	}
+
+	for k, v := range termMap {
+		options.Terms[k] = v
+	}
+}
+
+// SetTerms 使用 terms 替换术语字典，terms 会被共享，之后调用 PutTerms 时再复制。
+func (options *Options) SetTerms(terms map[string]string) {
+	options.Terms = terms
+	options.termsOwned = false
+}
+
+// Clone 复制一份渲染选项，复制后两份选项互不影响。内置的只读术语字典继续共享，自定义过的术语字典会被复制；
+// SanitizePolicy 和 FormatStyle 会被复制，WikiLinkResolver 是函数，按引用共享。
+func (options *Options) Clone() (ret *Options) {
+	o := *options
+	ret = &o
+	if options.termsOwned {
+		ret.termsOwned = false
+		ret.PutTerms(nil)
+	}
+	if nil != options.SanitizePolicy {
+		ret.SanitizePolicy = options.SanitizePolicy.Clone()
+	}
+	if nil != options.FormatStyle {
+		style := *options.FormatStyle
+		ret.FormatStyle = &style
+	}
+	return
 }
 
 func NewOptions() *Options {
@@ -162,7 +221,7 @@ func NewOptions() *Options {
 		ProtyleContenteditable:         true,
 		ProtyleMarkNetImg:              true,
 		Spellcheck:                     false,
-		Terms:                          NewTerms(),
+		Terms:                          terms,
 	}
 }
 
@@ -179,6 +238,11 @@ type BaseRenderer struct {
 	DisableTags         int                              // 标签嵌套计数器，用于判断不可能出现标签嵌套的情况，比如语法树允许图片节点包含链接节点，但是 HTML <img> 不能包含 <a>
 	FootnotesDefs       []*ast.Node                      // 脚注定义集
 	RenderingFootnotes  bool                             // 是否正在渲染脚注定义
+
+	ctx    context.Context // 渲染使用的上下文，通过 SetContext 设置
+	err    error           // 中止渲染的原因
+	checks int             // 检查上下文取消的计数
+	node   *ast.Node       // 正在渲染的节点
 }
 
 // renderTableByHTML 渲染合并单元格表格的 HTML 结构（table/colgroup/thead/tbody/tr/td + colspan/rowspan/class）。
@@ -366,7 +430,13 @@ func (r *BaseRenderer) Render() (output []byte) {
 	r.Writer = &bytes.Buffer{}
 	r.Writer.Grow(4096)
 
+	limited := nil != r.ctx || (nil != r.Options && 0 < r.Options.MaxOutputBytes)
 	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if limited && r.limitExceeded() {
+			return ast.WalkStop
+		}
+		r.node = n
+
 		extRender := r.ExtRendererFuncs[n.Type]
 		if nil != extRender {
 			output, status := extRender(n, entering)
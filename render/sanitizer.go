@@ -36,6 +36,8 @@ var setOfElementsToSkipContent = map[string]interface{}{
 	"script":   nil,
 	"style":    nil,
 	"title":    nil,
+	"applet":   nil,
+	"template": nil,
 }
 
 func Sanitize(str string) string {
@@ -188,12 +190,71 @@ func writeLinkableBuf(buff *bytes.Buffer, token *html.Token) {
 // urlAttrs 列出承载 URL 的属性，这些属性都需要做危险协议（javascript: / data:text/html 等）过滤。
 // 除了常见的 src/srcset/href 外，还包括 <form action> 和 SVG <a xlink:href>，
 // 否则 <form action="javascript:..."> 与 <svg><a xlink:href="javascript:..."> 会绕过过滤。
+// SVG 动画元素的 to/from/values 可以将 href 修改为 javascript:，所以也需要过滤。
 var urlAttrs = map[string]interface{}{
 	"src":        nil,
 	"srcset":     nil,
 	"href":       nil,
 	"action":     nil,
 	"xlink:href": nil,
+	"poster":     nil,
+	"data":       nil,
+	"background": nil,
+	"to":         nil,
+	"from":       nil,
+	"values":     nil,
+}
+
+// unsafeURLPrefixes 是会执行脚本的地址前缀。
+var unsafeURLPrefixes = []string{"javascript:", "vbscript:", "data:image/svg+xml", "data:text/html"}
+
+// unsafeURL 判断地址 val 是否会执行脚本，val 需要已经是反转义后的值。srcset、values 这类属性的值是使用逗号或者分号分隔的多个地址，需要逐个判断。
+func unsafeURL(val string) bool {
+	val = strings.ToLower(removeControls(val))
+	for _, u := range strings.FieldsFunc(val, func(r rune) bool { return ',' == r || ';' == r }) {
+		for _, prefix := range unsafeURLPrefixes {
+			if strings.HasPrefix(u, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unsafeStyle 判断内联样式 style 是否包含脚本，比如 IE 的 expression() 以及 url(javascript:...)。
+func unsafeStyle(style string) bool {
+	style = strings.ToLower(removeControls(style))
+	return strings.Contains(style, "expression(") || strings.Contains(style, "javascript:") || strings.Contains(style, "vbscript:")
+}
+
+// sanitizeIAL 过滤 IAL 属性，移除事件处理器、名称中包含非法字符的属性、值中包含双引号的属性以及危险的地址和样式，用于直接拼接到标签中的场景。
+func sanitizeIAL(ial [][]string) (ret [][]string) {
+	for _, kv := range ial {
+		if !allowAttr(kv[0]) || !validAttrName(kv[0]) || strings.Contains(kv[1], "\"") {
+			continue
+		}
+		if _, ok := urlAttrs[strings.ToLower(kv[0])]; ok && unsafeURL(html.UnescapeAttrVal(kv[1])) {
+			continue
+		}
+		if "style" == strings.ToLower(kv[0]) && unsafeStyle(html.UnescapeAttrVal(kv[1])) {
+			continue
+		}
+		ret = append(ret, kv)
+	}
+	return
+}
+
+// validAttrName 判断 name 是否是合法的属性名，仅允许字母、数字、-、_、: 和 .。
+func validAttrName(name string) bool {
+	if "" == name {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && 'z' >= r || 'A' <= r && 'Z' >= r || '0' <= r && '9' >= r || '-' == r || '_' == r || ':' == r || '.' == r) {
+			return false
+		}
+	}
+	return true
 }
 
 func sanitizeAttrs(attrs []*html.Attribute) (ret []*html.Attribute) {
@@ -206,10 +267,21 @@ func sanitizeAttrs(attrs []*html.Attribute) (ret []*html.Attribute) {
 			continue
 		}
 
+		if "style" == attr.Key && unsafeStyle(attr.Val) {
+			continue
+		}
+
+		if "attributename" == attr.Key {
+			// SVG 动画元素可以修改事件处理器和链接属性
+			if val := strings.ToLower(strings.TrimSpace(attr.Val)); strings.HasPrefix(val, "on") || strings.HasSuffix(val, "href") {
+				continue
+			}
+		}
+
 		if _, ok := urlAttrs[attr.Key]; ok {
 			val := strings.ToLower(strings.TrimSpace(attr.Val))
 			val = removeSpace(val)
-			if strings.HasPrefix(val, "data:image/svg+xml") || strings.HasPrefix(val, "data:text/html") || strings.HasPrefix(val, "javascript") {
+			if unsafeURL(val) || strings.HasPrefix(val, "javascript") {
 				continue
 			}
 
@@ -223,6 +295,16 @@ func sanitizeAttrs(attrs []*html.Attribute) (ret []*html.Attribute) {
 	return
 }
 
+// removeControls 移除 s 中的空白和控制字符，浏览器解析地址时会忽略这些字符，比如 java\tscript:。
+func removeControls(s string) string {
+	return strings.Map(func(r rune) rune {
+		if ' ' >= r || 0x7F == r || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
 func removeSpace(s string) string {
 	rr := make([]rune, 0, len(s))
 	for _, r := range s {
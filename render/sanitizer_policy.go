@@ -0,0 +1,398 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/util"
+)
+
+// Policy 描述了基于白名单的 XSS 安全过滤策略，用于过滤 Markdown 中的 HTML 以及链接、图片地址。
+//
+// 不在白名单中的元素会被移除但保留其文本内容，SkipContentElements 中的元素则连同内容一起移除；不在白名单中的属性会被移除，
+// 事件处理器属性（以 on 开头）总是会被移除。
+type Policy struct {
+	// Elements 允许的元素及该元素允许的属性，元素名和属性名均为小写。
+	Elements map[string][]string
+	// GlobalAttrs 所有允许的元素都可以使用的属性。
+	GlobalAttrs []string
+	// SkipContentElements 需要连同内容一起移除的元素，在 Elements 中允许的元素不会被移除。
+	SkipContentElements []string
+	// URLSchemes 承载 URL 的属性允许使用的协议，键为属性名，值为小写的协议名（不包含冒号）。相对地址总是允许的。
+	URLSchemes map[string][]string
+	// StyleProperties 允许的 CSS 属性，style 属性中其他的 CSS 属性会被移除。
+	StyleProperties []string
+	// IFrameHosts 允许 iframe 元素 src 属性使用的主机，src 指向其他主机或者没有 src 的 iframe 会连同内容一起移除。
+	IFrameHosts []string
+	// RequireNoopener 设置是否为带有 target 属性的 a 元素强制添加 rel="noopener noreferrer"。
+	RequireNoopener bool
+}
+
+// NewPolicy 创建一个严格的默认策略，仅允许常用的排版元素，链接只允许 http、https 和 mailto 协议，不允许内联样式和 iframe，
+// 适用于渲染不可信的用户输入（比如评论）。
+func NewPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"a":          {"href", "title", "target", "rel"},
+			"abbr":       {"title"},
+			"b":          nil,
+			"blockquote": {"cite"},
+			"br":         nil,
+			"code":       nil,
+			"dd":         nil,
+			"del":        nil,
+			"details":    {"open"},
+			"div":        nil,
+			"dl":         nil,
+			"dt":         nil,
+			"em":         nil,
+			"h1":         nil,
+			"h2":         nil,
+			"h3":         nil,
+			"h4":         nil,
+			"h5":         nil,
+			"h6":         nil,
+			"hr":         nil,
+			"i":          nil,
+			"img":        {"src", "alt", "title", "width", "height"},
+			"ins":        nil,
+			"kbd":        nil,
+			"li":         nil,
+			"mark":       nil,
+			"ol":         {"start", "reversed"},
+			"p":          nil,
+			"pre":        nil,
+			"q":          {"cite"},
+			"s":          nil,
+			"small":      nil,
+			"span":       nil,
+			"strong":     nil,
+			"sub":        nil,
+			"summary":    nil,
+			"sup":        nil,
+			"table":      nil,
+			"tbody":      nil,
+			"td":         {"align", "colspan", "rowspan"},
+			"tfoot":      nil,
+			"th":         {"align", "colspan", "rowspan"},
+			"thead":      nil,
+			"tr":         nil,
+			"u":          nil,
+			"ul":         nil,
+		},
+		SkipContentElements: []string{"script", "style", "title", "textarea", "select", "template", "iframe", "frame", "frameset",
+			"object", "embed", "noembed", "noframes", "noscript", "nostyle", "svg", "math"},
+		URLSchemes: map[string][]string{
+			"href": {"http", "https", "mailto"},
+			"src":  {"http", "https"},
+			"cite": {"http", "https"},
+		},
+		RequireNoopener: true,
+	}
+}
+
+// Clone 复制一份策略，复制后两份策略互不影响。
+func (p *Policy) Clone() (ret *Policy) {
+	ret = &Policy{
+		GlobalAttrs:         append([]string(nil), p.GlobalAttrs...),
+		SkipContentElements: append([]string(nil), p.SkipContentElements...),
+		StyleProperties:     append([]string(nil), p.StyleProperties...),
+		IFrameHosts:         append([]string(nil), p.IFrameHosts...),
+		RequireNoopener:     p.RequireNoopener,
+	}
+	if nil != p.Elements {
+		ret.Elements = make(map[string][]string, len(p.Elements))
+		for element, attrs := range p.Elements {
+			ret.Elements[element] = append([]string(nil), attrs...)
+		}
+	}
+	if nil != p.URLSchemes {
+		ret.URLSchemes = make(map[string][]string, len(p.URLSchemes))
+		for attr, schemes := range p.URLSchemes {
+			ret.URLSchemes[attr] = append([]string(nil), schemes...)
+		}
+	}
+	return
+}
+
+// Sanitize 使用策略 p 过滤 HTML 字符串 str。
+func (p *Policy) Sanitize(str string) string {
+	return string(p.sanitize([]byte(str)))
+}
+
+func (p *Policy) sanitize(tokens []byte) []byte {
+	var (
+		buff          bytes.Buffer
+		skipping      []string // 正在连同内容一起移除的元素
+		mostRecentTag string   // 最近一个开始的元素
+	)
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(tokens))
+	for {
+		if html.ErrorToken == tokenizer.Next() {
+			if err := tokenizer.Err(); io.EOF != err {
+				return util.StrToBytes(err.Error())
+			}
+			return buff.Bytes()
+		}
+
+		token := tokenizer.Token()
+		switch token.Type {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			allowed := p.allowElement(token.Data)
+			if allowed && 1 > len(skipping) {
+				var ok bool
+				if token.Attr, ok = p.sanitizeAttrs(token.Data, token.Attr); ok {
+					mostRecentTag = token.Data
+					writeLinkableBuf(&buff, &token)
+					break
+				}
+			}
+			if html.StartTagToken == token.Type && (!allowed && p.skipContent(token.Data) || allowed && 1 > len(skipping)) {
+				skipping = append(skipping, token.Data)
+			}
+		case html.EndTagToken:
+			if 0 < len(skipping) {
+				if skipping[len(skipping)-1] == token.Data {
+					skipping = skipping[:len(skipping)-1]
+				}
+				break
+			}
+			if !p.allowElement(token.Data) {
+				break
+			}
+
+			if mostRecentTag == token.Data {
+				mostRecentTag = ""
+			}
+			buff.WriteString(token.String())
+		case html.TextToken:
+			if 0 < len(skipping) {
+				break
+			}
+
+			if "script" == mostRecentTag || "style" == mostRecentTag {
+				// 策略允许脚本或者样式元素时不能转义其内容
+				buff.WriteString(token.Data)
+			} else {
+				buff.WriteString(token.String())
+			}
+		}
+	}
+}
+
+// sanitizeAttrs 过滤元素 element 的属性 attrs。返回 false 说明整个元素都需要移除，比如 iframe 指向了不允许的主机。
+func (p *Policy) sanitizeAttrs(element string, attrs []*html.Attribute) (ret []*html.Attribute, ok bool) {
+	var rel *html.Attribute
+	target := false
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if strings.HasPrefix(key, "on") || !p.allowAttr(element, key) {
+			continue
+		}
+
+		if "style" == key {
+			if attr.Val = p.sanitizeStyle(attr.Val); "" == attr.Val {
+				continue
+			}
+		}
+		if "iframe" == element && "src" == key && !p.allowIFrameSrc(attr.Val) {
+			return nil, false
+		}
+		if !p.AllowURL(key, attr.Val) {
+			continue
+		}
+
+		if "target" == key {
+			target = true
+		} else if "rel" == key {
+			rel = attr
+		}
+		ret = append(ret, attr)
+	}
+
+	if "iframe" == element {
+		// 没有 src 的 iframe 同样需要移除，避免通过 srcdoc 等方式加载内容
+		hasSrc := false
+		for _, attr := range ret {
+			hasSrc = hasSrc || "src" == attr.Key
+		}
+		if !hasSrc {
+			return nil, false
+		}
+	}
+
+	if "a" == element && target && p.RequireNoopener {
+		if nil == rel {
+			rel = &html.Attribute{Key: "rel"}
+			ret = append(ret, rel)
+		}
+		for _, val := range []string{"noopener", "noreferrer"} {
+			if !containsFold(strings.Fields(rel.Val), val) {
+				rel.Val = strings.TrimSpace(rel.Val + " " + val)
+			}
+		}
+	}
+	return ret, true
+}
+
+// AllowURL 判断属性 attr 的值 val 是否是策略允许的地址。attr 不是 URLSchemes 中配置的属性时总是返回 true。
+func (p *Policy) AllowURL(attr, val string) bool {
+	schemes, ok := p.URLSchemes[attr]
+	if !ok {
+		return true
+	}
+
+	if "srcset" == attr {
+		for _, candidate := range strings.Split(val, ",") {
+			if fields := strings.Fields(candidate); 0 < len(fields) && !allowScheme(schemes, fields[0]) {
+				return false
+			}
+		}
+		return true
+	}
+	return allowScheme(schemes, val)
+}
+
+// allowScheme 判断地址 val 的协议是否在 schemes 中，相对地址总是允许的。
+func allowScheme(schemes []string, val string) bool {
+	// 浏览器会忽略地址中的空白和控制字符，比如 java\tscript:
+	val = strings.Map(func(r rune) rune {
+		if ' ' >= r || 0x7F == r {
+			return -1
+		}
+		return r
+	}, val)
+	u, err := url.Parse(val)
+	if nil != err {
+		return false
+	}
+	if "" == u.Scheme {
+		return true
+	}
+	return containsFold(schemes, u.Scheme)
+}
+
+// allowIFrameSrc 判断 iframe 的 src 是否指向了允许的主机。
+func (p *Policy) allowIFrameSrc(src string) bool {
+	u, err := url.Parse(strings.TrimSpace(src))
+	if nil != err || ("http" != u.Scheme && "https" != u.Scheme && "" != u.Scheme) {
+		return false
+	}
+	return containsFold(p.IFrameHosts, u.Hostname())
+}
+
+// sanitizeStyle 过滤内联样式 style，仅保留允许的 CSS 属性。
+func (p *Policy) sanitizeStyle(style string) string {
+	var declarations []string
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, found := strings.Cut(declaration, ":")
+		if !found {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+		if !containsFold(p.StyleProperties, property) || "" == value {
+			continue
+		}
+		lower := strings.ToLower(value)
+		if strings.Contains(lower, "url(") || strings.Contains(lower, "expression(") || strings.Contains(lower, "javascript:") || strings.Contains(lower, "\\") {
+			continue
+		}
+		declarations = append(declarations, property+": "+value)
+	}
+	return strings.Join(declarations, "; ")
+}
+
+// sanitizing 判断渲染时是否需要进行 XSS 安全过滤。
+func (r *BaseRenderer) sanitizing() bool {
+	return r.Options.Sanitize || nil != r.Options.SanitizePolicy
+}
+
+// sanitizeTokens 过滤 HTML tokens，配置了 SanitizePolicy 时使用该策略，否则在打开 Sanitize 时使用原有的过滤实现。
+func (r *BaseRenderer) sanitizeTokens(tokens []byte) []byte {
+	if nil != r.Options.SanitizePolicy {
+		return r.Options.SanitizePolicy.sanitize(tokens)
+	}
+	if r.Options.Sanitize {
+		return sanitize(tokens)
+	}
+	return tokens
+}
+
+// sanitizeNodeIAL 返回需要渲染到标签上的 IAL 属性 ial，打开过滤时移除不安全的属性，配置了 SanitizePolicy 时还会使用该策略过滤地址和内联样式。
+func (r *BaseRenderer) sanitizeNodeIAL(ial [][]string) [][]string {
+	if !r.sanitizing() {
+		return ial
+	}
+
+	ret := sanitizeIAL(ial)
+	policy := r.Options.SanitizePolicy
+	if nil == policy {
+		return ret
+	}
+	var filtered [][]string
+	for _, kv := range ret {
+		key, val := strings.ToLower(kv[0]), html.UnescapeAttrVal(kv[1])
+		if !policy.AllowURL(key, val) {
+			continue
+		}
+		if "style" == key {
+			if val = policy.sanitizeStyle(val); "" == val {
+				continue
+			}
+			kv = []string{kv[0], html.EscapeAttrVal(val)}
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
+
+// sanitizeLinkDest 过滤链接地址 dest，地址不安全时返回 nil。
+func (r *BaseRenderer) sanitizeLinkDest(dest []byte) []byte {
+	if nil != r.Options.SanitizePolicy {
+		if !r.Options.SanitizePolicy.AllowURL("href", util.BytesToStr(dest)) {
+			return nil
+		}
+		return dest
+	}
+	if r.Options.Sanitize && unsafeURL(util.BytesToStr(dest)) {
+		return nil
+	}
+	return dest
+}
+
+func (p *Policy) allowElement(element string) bool {
+	_, ok := p.Elements[element]
+	return ok
+}
+
+func (p *Policy) allowAttr(element, attr string) bool {
+	return containsFold(p.Elements[element], attr) || containsFold(p.GlobalAttrs, attr)
+}
+
+func (p *Policy) skipContent(element string) bool {
+	return containsFold(p.SkipContentElements, element)
+}
+
+func containsFold(list []string, str string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, str) {
+			return true
+		}
+	}
+	return false
+}
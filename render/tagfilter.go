@@ -0,0 +1,64 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+
+	"github.com/88250/lute/lex"
+)
+
+// gfmDisallowedTags 是 GFM 规范中不允许的原始 HTML 标签名。
+// https://github.github.com/gfm/#disallowed-raw-html-extension-
+var gfmDisallowedTags = [][]byte{
+	[]byte("title"), []byte("textarea"), []byte("style"), []byte("xmp"), []byte("iframe"),
+	[]byte("noembed"), []byte("noframes"), []byte("script"), []byte("plaintext"),
+}
+
+// tagFilter 在打开 GFMTagFilter 时将 tokens 中不允许的 HTML 开始和结束标签的 < 转义为 &lt;。
+func (r *BaseRenderer) tagFilter(tokens []byte) []byte {
+	if !r.Options.GFMTagFilter || 0 > bytes.IndexByte(tokens, lex.ItemLess) {
+		return tokens
+	}
+
+	var ret []byte
+	last := 0
+	for i, b := range tokens {
+		if lex.ItemLess == b && isDisallowedTag(tokens[i+1:]) {
+			ret = append(ret, tokens[last:i]...)
+			ret = append(ret, "&lt;"...)
+			last = i + 1
+		}
+	}
+	if nil == ret {
+		return tokens
+	}
+	return append(ret, tokens[last:]...)
+}
+
+// isDisallowedTag 判断 < 之后的 tokens 是否以不允许的标签名开头，标签名不区分大小写，之后需要紧跟空白、> 或者 />。
+func isDisallowedTag(tokens []byte) bool {
+	if 0 < len(tokens) && lex.ItemSlash == tokens[0] {
+		tokens = tokens[1:]
+	}
+
+	for _, tag := range gfmDisallowedTags {
+		length := len(tag)
+		if len(tokens) <= length || !bytes.EqualFold(tokens[:length], tag) {
+			continue
+		}
+		next := tokens[length]
+		if lex.IsWhitespace(next) || lex.ItemGreater == next || (lex.ItemSlash == next && length+1 < len(tokens) && lex.ItemGreater == tokens[length+1]) {
+			return true
+		}
+	}
+	return false
+}
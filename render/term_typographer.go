@@ -76,6 +76,7 @@ func isNotTerm(token byte) bool {
 	return token >= utf8.RuneSelf || lex.IsWhitespace(token) || lex.IsASCIIPunct(token)
 }
 
+// NewTerms 返回一份内置术语字典的副本。
 func NewTerms() (ret map[string]string) {
 	ret = make(map[string]string, len(terms))
 	for k, v := range terms {
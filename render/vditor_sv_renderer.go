@@ -103,6 +103,9 @@ func NewVditorSVRenderer(tree *parse.Tree, options *Options, parseOptions *parse
 	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
 	ret.RendererFuncs[ast.NodeBlockquoteMarker] = ret.renderBlockquoteMarker
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirective
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirective
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirective
 	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
 	ret.RendererFuncs[ast.NodeHeadingC8hMarker] = ret.renderHeadingC8hMarker
 	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderHeadingID
@@ -1096,6 +1099,50 @@ func (r *VditorSVRenderer) renderBlockquoteMarker(node *ast.Node, entering bool)
 	return ast.WalkContinue
 }
 
+// renderContainerDirective 按照 Markdown 原文渲染容器指令的起始行和闭合行。
+func (r *VditorSVRenderer) renderContainerDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("span", [][]string{{"data-type", "text"}, {"class", "vditor-sv__marker"}}, false)
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+		r.Tag("/span", nil, false)
+		r.Newline()
+	} else {
+		r.Tag("span", [][]string{{"data-type", "text"}, {"class", "vditor-sv__marker"}}, false)
+		r.WriteString(directiveCloseMarker(node))
+		r.Tag("/span", nil, false)
+		r.Newline()
+		r.Write(NewlineSV)
+	}
+	return ast.WalkContinue
+}
+
+// renderLeafDirective 按照 Markdown 原文渲染叶子指令。
+func (r *VditorSVRenderer) renderLeafDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	r.Tag("span", [][]string{{"data-type", "text"}, {"class", "vditor-sv__marker"}}, false)
+	if entering {
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+		r.Tag("/span", nil, false)
+	} else {
+		r.WriteString(html.EscapeHTMLStr(directiveCloseMarker(node)))
+		r.Tag("/span", nil, false)
+		r.Newline()
+		r.Write(NewlineSV)
+	}
+	return ast.WalkContinue
+}
+
+// renderTextDirective 按照 Markdown 原文渲染文本指令。
+func (r *VditorSVRenderer) renderTextDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	r.Tag("span", [][]string{{"data-type", "text"}, {"class", "vditor-sv__marker"}}, false)
+	if entering {
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+	} else {
+		r.WriteString(html.EscapeHTMLStr(directiveCloseMarker(node)))
+	}
+	r.Tag("/span", nil, false)
+	return ast.WalkContinue
+}
+
 func (r *VditorSVRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Writer = &bytes.Buffer{}
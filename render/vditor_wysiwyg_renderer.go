@@ -68,6 +68,9 @@ func NewVditorRenderer(tree *parse.Tree, options *Options, parseOptions *parse.O
 	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
 	ret.RendererFuncs[ast.NodeBlockquoteMarker] = ret.renderBlockquoteMarker
 	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeContainerDirective] = ret.renderContainerDirective
+	ret.RendererFuncs[ast.NodeLeafDirective] = ret.renderLeafDirective
+	ret.RendererFuncs[ast.NodeTextDirective] = ret.renderTextDirective
 	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
 	ret.RendererFuncs[ast.NodeHeadingC8hMarker] = ret.renderHeadingC8hMarker
 	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderHeadingID
@@ -1003,6 +1006,42 @@ func (r *VditorRenderer) renderBlockquoteMarker(node *ast.Node, entering bool) a
 	return ast.WalkContinue
 }
 
+// renderContainerDirective 按照 Markdown 原文渲染容器指令的起始行和闭合行。
+func (r *VditorRenderer) renderContainerDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("p", [][]string{{"data-block", "0"}}, false)
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+		r.Tag("/p", nil, false)
+	} else {
+		r.Tag("p", [][]string{{"data-block", "0"}}, false)
+		r.WriteString(directiveCloseMarker(node))
+		r.Tag("/p", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+// renderLeafDirective 按照 Markdown 原文渲染叶子指令。
+func (r *VditorRenderer) renderLeafDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("p", [][]string{{"data-block", "0"}}, false)
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+	} else {
+		r.WriteString(html.EscapeHTMLStr(directiveCloseMarker(node)))
+		r.Tag("/p", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+// renderTextDirective 按照 Markdown 原文渲染文本指令。
+func (r *VditorRenderer) renderTextDirective(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(html.EscapeHTMLStr(directiveOpenMarker(node)))
+	} else {
+		r.WriteString(html.EscapeHTMLStr(directiveCloseMarker(node)))
+	}
+	return ast.WalkContinue
+}
+
 func (r *VditorRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Tag("blockquote", [][]string{
@@ -0,0 +1,112 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"path"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/util"
+)
+
+// WikiLinkResolver 将维基链接目标 target 和其中的标题 heading（可能为空）解析为链接地址，目标不存在时 ok 返回 false，维基链接会被渲染为失效链接。
+type WikiLinkResolver func(target, heading string) (dest string, ok bool)
+
+// wikiLinkImageExts 是维基链接嵌入渲染为图片的目标扩展名。
+var wikiLinkImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".bmp": true, ".avif": true,
+}
+
+// wikiLinkContent 返回维基链接标记符 [[ ]] 中的内容 target#heading|alias。
+func wikiLinkContent(node *ast.Node) string {
+	ret := node.WikiLinkTarget
+	if "" != node.WikiLinkHeading {
+		ret += "#" + node.WikiLinkHeading
+	}
+	if "" != node.WikiLinkAlias {
+		ret += "|" + node.WikiLinkAlias
+	}
+	return ret
+}
+
+// wikiLinkMarkdown 返回维基链接的 Markdown 文本，表格中的别名分隔符 | 需要转义。
+func wikiLinkMarkdown(node *ast.Node) string {
+	content := wikiLinkContent(node)
+	if "" != node.WikiLinkAlias && node.ParentIs(ast.NodeTableCell) {
+		idx := strings.LastIndex(content, "|"+node.WikiLinkAlias)
+		content = content[:idx] + "\\" + content[idx:]
+	}
+	ret := "[[" + content + "]]"
+	if ast.NodeWikiLinkEmbed == node.Type {
+		ret = "!" + ret
+	}
+	return ret
+}
+
+// wikiLinkText 返回维基链接的显示文本，有别名时使用别名。
+func wikiLinkText(node *ast.Node) string {
+	if "" != node.WikiLinkAlias {
+		return node.WikiLinkAlias
+	}
+	if "" == node.WikiLinkHeading {
+		return node.WikiLinkTarget
+	}
+	if "" == node.WikiLinkTarget {
+		return node.WikiLinkHeading
+	}
+	return node.WikiLinkTarget + " > " + node.WikiLinkHeading
+}
+
+// wikiLinkDest 使用 Options.WikiLinkResolver 解析维基链接地址，没有设置解析器时使用编码后的目标和标题作为链接地址。
+func (r *BaseRenderer) wikiLinkDest(node *ast.Node) (dest string, ok bool) {
+	if nil != r.Options.WikiLinkResolver {
+		return r.Options.WikiLinkResolver(node.WikiLinkTarget, node.WikiLinkHeading)
+	}
+
+	dest = util.BytesToStr(html.EncodeDestination([]byte(node.WikiLinkTarget)))
+	if "" != node.WikiLinkHeading {
+		dest += "#" + util.BytesToStr(html.EncodeDestination([]byte(node.WikiLinkHeading)))
+	}
+	return dest, true
+}
+
+// renderWikiLinkHTML 将维基链接渲染为 <a class="wikilink">，图片嵌入渲染为 <img class="wikilink-embed">。
+// data-wikilink 属性记录了维基链接的内容，HTML 转换 Markdown 时用于还原维基链接。
+func (r *BaseRenderer) renderWikiLinkHTML(node *ast.Node) {
+	dest, ok := r.wikiLinkDest(node)
+	if ok && r.sanitizing() {
+		ok = nil != r.sanitizeLinkDest([]byte(dest))
+	}
+	text := html.EscapeHTMLStr(wikiLinkText(node))
+	content := html.EscapeHTMLStr(wikiLinkContent(node))
+	embed := ast.NodeWikiLinkEmbed == node.Type
+	if ok && embed && wikiLinkImageExts[strings.ToLower(path.Ext(node.WikiLinkTarget))] {
+		r.Tag("img", [][]string{{"src", html.EscapeHTMLStr(dest)}, {"alt", text}, {"class", "wikilink-embed"}, {"data-wikilink", content}}, true)
+		return
+	}
+
+	class := "wikilink"
+	if embed {
+		class += " wikilink-embed"
+	}
+	var attrs [][]string
+	if ok {
+		attrs = append(attrs, []string{"href", html.EscapeHTMLStr(dest)})
+	} else {
+		class += " wikilink-broken"
+	}
+	attrs = append(attrs, []string{"class", class}, []string{"data-wikilink", content})
+	r.Tag("a", attrs, false)
+	r.WriteString(text)
+	r.Tag("/a", nil, false)
+}
@@ -0,0 +1,137 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// newBinaryLute 返回用于二进制编码测试的引擎，打开了各种扩展语法和源码位置记录。
+func newBinaryLute() *lute.Lute {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSuperBlock(true)
+	luteEngine.SetCallout(true)
+	luteEngine.SetMark(true)
+	luteEngine.SetTag(true)
+	luteEngine.SetBlockRef(true)
+	luteEngine.SetFootnotes(true)
+	luteEngine.SetArbitraryTaskListItemMarker(true)
+	luteEngine.SetSourcePos(true)
+	return luteEngine
+}
+
+func TestBinary2Tree(t *testing.T) {
+	luteEngine := newBinaryLute()
+
+	tests := json2TreeTests
+	for _, test := range formatTests {
+		tests = append(tests, parseTest{"format-" + test.name, test.original, ""})
+	}
+	for _, test := range tests {
+		tree := parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)
+		tree.Box, tree.Path, tree.Marks, tree.Created, tree.BOM = "box", "/"+test.name+".sy", []string{"mark"}, -1, true
+		data := parse.Tree2Binary(tree)
+		decoded, err := parse.Binary2Tree(data, luteEngine.ParseOptions)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if got := parse.Tree2Binary(decoded); !bytes.Equal(data, got) {
+			t.Fatalf("test case [%s] failed: re-encoded binary is different", test.name)
+		}
+		if tree.Name != decoded.Name || tree.Path != decoded.Path || -1 != decoded.Created || !decoded.BOM || "mark" != decoded.Marks[0] {
+			t.Fatalf("test case [%s] failed: unexpected tree fields", test.name)
+		}
+
+		expected := string(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+		if got := string(render.NewFormatRenderer(decoded, luteEngine.RenderOptions, luteEngine.ParseOptions).Render()); expected != got {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, expected, got)
+		}
+	}
+}
+
+func TestBinary2TreeLinks(t *testing.T) {
+	luteEngine := newBinaryLute()
+	tree := parse.Parse("", []byte("foo[^1] bar[^1]\n\n[^1]: note\n"), luteEngine.ParseOptions)
+	decoded, err := parse.Binary2Tree(parse.Tree2Binary(tree), luteEngine.ParseOptions)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var refs []*ast.Node
+	ast.Walk(decoded.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		if ast.NodeFootnotesRef == n.Type {
+			refs = append(refs, n)
+		}
+		for c := n.FirstChild; nil != c; c = c.Next {
+			if n != c.Parent || (nil != c.Next && c != c.Next.Previous) || (nil == c.Next && c != n.LastChild) {
+				t.Fatalf("broken links around node [%s]", c.Type)
+			}
+		}
+		return ast.WalkContinue
+	})
+	def := decoded.Root.ChildByType(ast.NodeFootnotesDefBlock).FirstChild
+	if 2 != len(refs) || 2 != len(def.FootnotesRefs) || refs[0] != def.FootnotesRefs[0] || refs[1] != def.FootnotesRefs[1] {
+		t.Fatal("footnotes refs should be relinked")
+	}
+	if nil == def.Pos || nil == decoded.Root.FirstChild.Pos || *tree.Root.FirstChild.Pos != *decoded.Root.FirstChild.Pos {
+		t.Fatal("source positions should be decoded")
+	}
+}
+
+func TestBinary2TreeMalformed(t *testing.T) {
+	luteEngine := newBinaryLute()
+	tree := parse.Parse("", []byte("* [x] foo[^1]\n\n[^1]: bar\n{: id=\"20200101000000-abcdefg\" custom-a=\"1\"}\n"), luteEngine.ParseOptions)
+	data := parse.Tree2Binary(tree)
+
+	for i := 0; i < len(data); i++ {
+		if _, err := parse.Binary2Tree(data[:i], luteEngine.ParseOptions); nil == err {
+			t.Fatalf("truncated data [%d] should be rejected", i)
+		}
+	}
+	if _, err := parse.Binary2Tree(append(data, 0), luteEngine.ParseOptions); nil == err {
+		t.Fatal("trailing data should be rejected")
+	}
+	if _, err := parse.Binary2Tree([]byte("LUTE\x02"), luteEngine.ParseOptions); nil == err || "unsupported binary tree version [2]" != err.Error() {
+		t.Fatalf("unsupported version should be rejected: %v", err)
+	}
+	// 字符串表为空，根节点类型为 1024
+	if _, err := parse.Binary2Tree([]byte("LUTE\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x80\x08\x00\x00"), luteEngine.ParseOptions); nil == err {
+		t.Fatal("unknown node type should be rejected")
+	}
+}
+
+func FuzzBinary2Tree(f *testing.F) {
+	luteEngine := newBinaryLute()
+	for _, test := range json2TreeTests {
+		f.Add(parse.Tree2Binary(parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)))
+	}
+	f.Add([]byte("LUTE\x01"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tree, err := parse.Binary2Tree(data, luteEngine.ParseOptions)
+		if nil != err {
+			return
+		}
+		encoded := parse.Tree2Binary(tree)
+		if _, err = parse.Binary2Tree(encoded, luteEngine.ParseOptions); nil != err {
+			t.Fatalf("re-encoded tree should be decoded: %s", err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+func TestEmojiIsolation(t *testing.T) {
+	tenant1, tenant2 := lute.New(), lute.New()
+	tenant1.PutEmojis(map[string]string{"tenant": "1.png"})
+	tenant2.PutEmojis(map[string]string{"tenant": "2.png"})
+
+	if html := tenant1.MarkdownStr("", ":tenant:"); "<p><img alt=\"tenant\" class=\"emoji\" src=\"1.png\" title=\"tenant\" /></p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+	if html := tenant2.MarkdownStr("", ":tenant:"); "<p><img alt=\"tenant\" class=\"emoji\" src=\"2.png\" title=\"tenant\" /></p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+	if html := lute.New().MarkdownStr("", ":tenant: :heart:"); "<p>:tenant: ❤️</p>\n" != html {
+		t.Fatalf("custom emoji leaked to other engines: %q", html)
+	}
+	if _, ok := parse.EmojiAliasUnicode["tenant"]; ok {
+		t.Fatalf("custom emoji leaked to the built-in dictionary")
+	}
+}
+
+func TestTermsIsolation(t *testing.T) {
+	tenant := lute.New()
+	tenant.SetFixTermTypo(true)
+	tenant.PutTerms(map[string]string{"isolatedterm": "IsolatedTerm"})
+	tenant.GetTerms()["github"] = "GITHUB"
+	if html := tenant.MarkdownStr("", "isolatedterm github\n"); "<p>IsolatedTerm GitHub</p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+
+	other := lute.New()
+	other.SetFixTermTypo(true)
+	if html := other.MarkdownStr("", "isolatedterm github\n"); "<p>isolatedterm GitHub</p>\n" != html {
+		t.Fatalf("custom term leaked to other engines: %q", html)
+	}
+}
+
+func TestClone(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetFixTermTypo(true)
+	luteEngine.PutEmojis(map[string]string{"tenant": "1.png"})
+	luteEngine.PutTerms(map[string]string{"isolatedterm": "IsolatedTerm"})
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error { return nil })
+	luteEngine.SetSanitizePolicy(render.NewPolicy())
+	luteEngine.SetFormatStyle(&render.FormatStyle{Emphasis: "*"})
+
+	clone := luteEngine.Clone()
+	clone.RenderOptions.SanitizePolicy.Elements["iframe"] = []string{"src"}
+	clone.RenderOptions.SanitizePolicy.URLSchemes["href"][0] = "javascript"
+	clone.RenderOptions.FormatStyle.Emphasis = "_"
+	clone.SetFixTermTypo(false)
+	clone.PutEmojis(map[string]string{"tenant": "2.png", "clone": "clone.png"})
+	clone.PutTerms(map[string]string{"isolatedterm": "ISOLATEDTERM"})
+	clone.Md2HTMLRendererFuncs[ast.NodeText] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		return "", ast.WalkContinue
+	}
+	clone.ClearTransforms()
+
+	if !luteEngine.RenderOptions.FixTermTypo || 0 < len(luteEngine.Md2HTMLRendererFuncs) {
+		t.Fatalf("clone options leaked to the original engine")
+	}
+	if policy := luteEngine.RenderOptions.SanitizePolicy; nil != policy.Elements["iframe"] || "javascript" == policy.URLSchemes["href"][0] || "*" != luteEngine.RenderOptions.FormatStyle.Emphasis {
+		t.Fatalf("clone sanitize policy or format style leaked to the original engine")
+	}
+	if html := luteEngine.MarkdownStr("", ":tenant: :clone: isolatedterm\n"); "<p><img alt=\"tenant\" class=\"emoji\" src=\"1.png\" title=\"tenant\" /> :clone: IsolatedTerm</p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+	if "ISOLATEDTERM" != clone.GetTerms()["isolatedterm"] || "clone.png" != clone.GetEmojis()["clone"] {
+		t.Fatalf("clone dictionaries are not updated")
+	}
+
+	// 修改原引擎也不会影响克隆
+	luteEngine.PutEmojis(map[string]string{"original": "original.png"})
+	if _, ok := clone.GetEmojis()["original"]; ok {
+		t.Fatalf("original emoji leaked to the clone")
+	}
+}
+
+func TestEmojiConcurrent(t *testing.T) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			luteEngine := lute.New()
+			src := strconv.Itoa(i) + ".png"
+			luteEngine.PutEmojis(map[string]string{"tenant": src})
+			for j := 0; j < 16; j++ {
+				expected := "<p><img alt=\"tenant\" class=\"emoji\" src=\"" + src + "\" title=\"tenant\" /> ❤️</p>\n"
+				if html := luteEngine.MarkdownStr("", ":tenant: :heart:"); expected != html {
+					t.Errorf("unexpected html: %q", html)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,76 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+var diagnosticTests = []parseTest{
+
+	{"13", "| a | b |\n| - | - |\n| [x][y] | c ![i][z] |\n", "3:3: warning unresolved-link-ref: link reference [y] is not defined\n3:14: warning unresolved-link-ref: link reference [z] is not defined"},
+	{"12", "foo ![bar][baz] ![q][]\n", "1:5: warning unresolved-link-ref: link reference [baz] is not defined\n1:17: warning unresolved-link-ref: link reference [q] is not defined"},
+	{"11", "[x]: /u\n\n[a][x] [x][] [^n] [y] [ ]\n\n[^n]: foo\n", ""},
+	{"10", "# a {#x}\n\n# b {#y}\n\n## c {#x}\n", "5:1: error duplicate-heading-id: duplicate heading id [x]"},
+	{"9", "foo [^1]\n", "1:5: error undefined-footnote: footnote [^1] is not defined"},
+	{"8", "foo [bar][baz] [q][] [x]\n", "1:5: warning unresolved-link-ref: link reference [baz] is not defined\n1:16: warning unresolved-link-ref: link reference [q] is not defined"},
+	{"7", "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> x\n", ""},
+	{"6", "foo\n\n<<<<<<< HEAD\na\n=======\nb\n", "3:1: error unclosed-git-conflict: git conflict marker is not closed"},
+	{"5", "{{{row\nfoo\n}}}\n", ""},
+	{"4", "{{{row\nfoo\n", "1:1: error unclosed-super-block: super block is not closed"},
+	{"3", "$$\nx\n$$\n\n$$\ny\n", "5:1: error unclosed-math-block: math block is not closed"},
+	{"2", "> ```\n> a\n\nb\n", "1:3: error unclosed-code-block: fenced code block is not closed"},
+	{"1", "```go\nfoo\n```\n\n~~~\nbar\n", "5:1: error unclosed-code-block: fenced code block is not closed"},
+	{"0", "foo *bar*\n", ""},
+}
+
+func TestDiagnostics(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSuperBlock(true)
+	luteEngine.SetGitConflict(true)
+	luteEngine.SetHeadingID(true)
+
+	for _, test := range diagnosticTests {
+		diagnostics, err := luteEngine.Check("", []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		var lines []string
+		for _, d := range diagnostics {
+			lines = append(lines, d.String())
+		}
+		if actual := strings.Join(lines, "\n"); test.to != actual {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\nactual\n\t%q\noriginal\n\t%q", test.name, test.to, actual, test.from)
+		}
+	}
+
+	if luteEngine.ParseOptions.SourcePos || luteEngine.ParseOptions.Diagnostics {
+		t.Fatalf("check should not change parse options")
+	}
+}
+
+func TestDiagnosticsOption(t *testing.T) {
+	luteEngine := lute.New()
+	tree := parse.Parse("", []byte("```\nfoo\n"), luteEngine.ParseOptions)
+	if 0 != len(tree.Diagnostics) {
+		t.Fatalf("diagnostics should not be collected by default")
+	}
+
+	luteEngine.SetDiagnostics(true)
+	tree = parse.Parse("", []byte("```\nfoo\n"), luteEngine.ParseOptions)
+	if 1 != len(tree.Diagnostics) || parse.DiagUnclosedCodeBlock != tree.Diagnostics[0].Code || nil != tree.Diagnostics[0].Pos {
+		t.Fatalf("unexpected diagnostics: %v", tree.Diagnostics)
+	}
+}
@@ -0,0 +1,150 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+var directiveTests = []parseTest{
+
+	{"11", ":::: warning{title=\"a}b\"}\nfoo\n::::\n", "<div class=\"warning\" title=\"a}b\">\n<p>foo</p>\n</div>\n"},
+	{"10", "::youtube{#x title='}{'} and :abbr[A]{title=\"x } y\"}\n", "<p>::youtube{#x title='}{'} and <span class=\"abbr\" title=\"x } y\">A</span></p>\n"},
+	{"9.1", "::video{title='}{'}\n", "<div class=\"video\" title=\"}{\"></div>\n"},
+	{"9.0", "::: tip\nfoo\n:::\n", "<div class=\"tip\">\n<p>foo</p>\n</div>\n"},
+	{"9", ":::note{onclick=\"alert(1)\" href=\"javascript:alert(1)\" title=ok}\nx\n:::\n", "<div class=\"note\" onclick=\"alert(1)\" href=\"javascript:alert(1)\" title=\"ok\">\n<p>x</p>\n</div>\n"},
+	{"8", ":: leaf\n", "<p>:: leaf</p>\n"},
+	{"7", ":::unclosed\nfoo\n", "<div class=\"unclosed\">\n<p>foo</p>\n</div>\n"},
+	{"6", "a:b[c], 12:30, :name, ::a[b] and https://b3log.org\n", "<p>a:b[c], 12:30, :name, ::a[b] and <a href=\"https://b3log.org\">https://b3log.org</a></p>\n"},
+	{"5", "Some :abbr[HTML]{title=\"HyperText Markup Language\"} text, :badge{.new} and :em[*x*]\n", "<p>Some <span class=\"abbr\" title=\"HyperText Markup Language\">HTML</span> text, <span class=\"badge new\"></span> and <span class=\"em\"><em>x</em></span></p>\n"},
+	{"4", "::youtube[Video of a **cat**]{#01ab2cd3efg}\n", "<div class=\"youtube\" id=\"01ab2cd3efg\">Video of a <strong>cat</strong></div>\n"},
+	{"3", "- :::note\n  foo\n  :::\n- bar\n", "<ul>\n<li>\n<div class=\"note\">\n<p>foo</p>\n</div>\n</li>\n<li>bar</li>\n</ul>\n"},
+	{"2", "> :::note\n> foo\n> :::\n", "<blockquote>\n<div class=\"note\">\n<p>foo</p>\n</div>\n</blockquote>\n"},
+	{"1", "::::outer\n:::inner\nfoo\n:::\n::::\n", "<div class=\"outer\">\n<div class=\"inner\">\n<p>foo</p>\n</div>\n</div>\n"},
+	{"0", ":::tip[Note **this**]{#x .a .b key=\"v 1\"}\nfoo\n:::\nbar\n", "<div class=\"tip a b\" id=\"x\" key=\"v 1\">\n<div class=\"directive-label\">Note <strong>this</strong></div>\n<p>foo</p>\n</div>\n<p>bar</p>\n"},
+}
+
+func TestDirective(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetDirective(true)
+	for _, test := range directiveTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestDirectiveDisabled(t *testing.T) {
+	luteEngine := lute.New()
+	html := luteEngine.MarkdownStr("", ":::note\nfoo :abbr[x]\n:::\n")
+	if "<p>:::note<br />\nfoo :abbr[x]<br />\n:::</p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+}
+
+var directiveSanitizeTests = []parseTest{
+
+	{"1", "::video{src=\"javascript:alert(1)\" style=\"color:red\"}\n", "<div class=\"video\" style=\"color:red\"></div>\n"},
+	{"0", ":::note{onclick=\"alert(1)\" href=\"javascript:alert(1)\" title=ok}\nx :y{onmouseover=alert(1)}\n:::\n", "<div class=\"note\" title=\"ok\">\n<p>x <span class=\"y\"></span></p>\n</div>\n"},
+}
+
+func TestDirectiveSanitize(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetDirective(true)
+	luteEngine.SetSanitize(true)
+	for _, test := range directiveSanitizeTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var directiveFormatTests = []parseTest{
+
+	{"4", "- :::note\n  foo\n  :::\n- bar\n", "- :::note\n  foo\n  :::\n- bar\n"},
+	{"3", "Some :abbr[HTML]{title='HyperText \"Markup\" Language'} and :badge{ .new  .hot }\n", "Some :abbr[HTML]{title='HyperText \"Markup\" Language'} and :badge{.new .hot}\n"},
+	{"2", "::youtube[Video of a __cat__]{#01ab2cd3efg}\nfoo\n", "::youtube[Video of a __cat__]{#01ab2cd3efg}\n\nfoo\n"},
+	{"1", "::::outer\n:::inner\nfoo\n:::\n::::\n", "::::outer\n:::inner\nfoo\n:::\n::::\n"},
+	{"0", ":::tip[Note **this**]{key=v  .a #x .b}\nfoo\n\n\n* bar\n:::\nbaz\n", ":::tip[Note **this**]{key=\"v\" .a .b #x}\nfoo\n\n* bar\n:::\n\nbaz\n"},
+}
+
+func TestDirectiveFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetDirective(true)
+	for _, test := range directiveFormatTests {
+		formatted := luteEngine.FormatStr(test.name, test.from)
+		if test.to != formatted {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, formatted, test.from)
+		}
+		if again := luteEngine.FormatStr(test.name, formatted); formatted != again {
+			t.Fatalf("test case [%s] failed\nformatting is not idempotent\n\t%q\n\t%q", test.name, formatted, again)
+		}
+	}
+}
+
+func TestDirectiveProtyle(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetProtyleWYSIWYG(true)
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetDirective(true)
+
+	markdowns := []string{
+		":::tip[Note **this**]{#x .a key=\"v 1\"}\nfoo\n\n* bar\n:::\nbaz\n",
+		"::::outer\n:::inner\nfoo\n:::\n::::\n",
+		"> :::note\n> foo\n> :::\n",
+		"::youtube[Video]{#01ab2cd3efg}\n\nSome :abbr[HTML]{title=\"HyperText\"} text\n",
+	}
+	for i, markdown := range markdowns {
+		dom := luteEngine.Md2BlockDOM(markdown, true)
+		if !strings.Contains(dom, "Directive\" class=\"directive\"") {
+			t.Fatalf("test case [%d] failed, directive block not found in dom: %s", i, dom)
+		}
+
+		kramdown := luteEngine.BlockDOM2Md(dom)
+		if again := luteEngine.Md2BlockDOM(kramdown, true); dom != again {
+			t.Fatalf("test case [%d] failed\nexpected\n\t%s\ngot\n\t%s\nkramdown\n\t%q", i, dom, again, kramdown)
+		}
+	}
+}
+
+func TestDirectiveRenderers(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetDirective(true)
+	markdown := "::: tip[Note]{title=\"a}b\"}\nfoo :abbr[HTML]{title=\"x\"}\n:::\n\n::youtube[Video]{#v}\n"
+
+	tree := parse.Parse("", []byte(markdown), luteEngine.ParseOptions)
+	renderers := map[string]render.Renderer{
+		"protyle-preview": render.NewProtylePreviewRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions),
+		"protyle-export":  render.NewProtyleExportRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions),
+		"vditor-ir":       render.NewVditorIRRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions),
+		"vditor-sv":       render.NewVditorSVRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions),
+		"vditor-wysiwyg":  render.NewVditorRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions),
+	}
+	for name, renderer := range renderers {
+		output := string(renderer.Render())
+		if !strings.Contains(output, "abbr") || !strings.Contains(output, "youtube") {
+			t.Fatalf("renderer [%s] failed, directive not rendered: %s", name, output)
+		}
+	}
+
+	exportMd := string(render.NewProtyleExportMdRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+	expected := ":::tip[Note]{title=\"a}b\"}\nfoo :abbr[HTML]{title=\"x\"}\n:::\n\n::youtube[Video]{#v}\n"
+	if expected != exportMd {
+		t.Fatalf("export markdown failed\nexpected\n\t%q\ngot\n\t%q", expected, exportMd)
+	}
+}
@@ -0,0 +1,134 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var errBoom = errors.New("boom")
+
+func boomRenderer(node *ast.Node, entering bool) (string, ast.WalkStatus) {
+	panic(errBoom)
+}
+
+func checkNodeError(t *testing.T, name string, err error, nodeType ast.NodeType, pos string) {
+	var nodeErr *ast.NodeError
+	if !errors.As(err, &nodeErr) {
+		t.Fatalf("test case [%s] failed\nexpected *ast.NodeError\nactual\n\t%v", name, err)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("test case [%s] failed\nexpected wrapped error [%v]\nactual\n\t%v", name, errBoom, nodeErr.Err)
+	}
+	if nodeType != nodeErr.NodeType {
+		t.Fatalf("test case [%s] failed\nexpected node type [%s]\nactual\n\t%s", name, nodeType, nodeErr.NodeType)
+	}
+	actualPos := ""
+	if nil != nodeErr.Pos {
+		actualPos = nodeErr.Pos.String()
+	}
+	if pos != actualPos {
+		t.Fatalf("test case [%s] failed\nexpected pos [%s]\nactual\n\t%s", name, pos, actualPos)
+	}
+	if "" == nodeErr.Stack {
+		t.Fatalf("test case [%s] failed\nexpected stack", name)
+	}
+}
+
+func TestNodeErrorParse(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.AddInlineSyntax(&parse.InlineSyntax{
+		Name:     "boom",
+		Triggers: []byte("%"),
+		Parse: func(block *ast.Node, tokens []byte) (*ast.Node, int) {
+			panic(errBoom)
+		},
+	})
+
+	_, err := luteEngine.MarkdownContext(context.Background(), "", []byte("foo\n\n# bar %\n"))
+	checkNodeError(t, "parse", err, ast.NodeHeading, "3:1-3:7")
+
+	err = panicError(func() { luteEngine.MarkdownStr("", "foo\n\n# bar %\n") })
+	checkNodeError(t, "markdown", err, ast.NodeHeading, "3:1-3:7")
+}
+
+func TestNodeErrorRender(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.Md2HTMLRendererFuncs[ast.NodeEmphasis] = boomRenderer
+	_, err := luteEngine.MarkdownContext(context.Background(), "", []byte("foo\n\nbar *baz*\n"))
+	checkNodeError(t, "markdown", err, ast.NodeEmphasis, "3:5-3:9")
+
+	luteEngine = lute.New()
+	luteEngine.HTML2MdRendererFuncs[ast.NodeStrong] = boomRenderer
+	_, err = luteEngine.HTML2Markdown("<p>foo <b>bar</b></p>")
+	checkNodeError(t, "html2markdown", err, ast.NodeStrong, "")
+
+	luteEngine = lute.New()
+	luteEngine.Md2BlockDOMRendererFuncs[ast.NodeTextMark] = boomRenderer
+	_, err = luteEngine.Md2BlockDOMContext(context.Background(), "foo `bar`\n", false)
+	checkNodeError(t, "md2blockdom", err, ast.NodeTextMark, "")
+	err = panicError(func() { luteEngine.Md2BlockDOMTree("foo `bar`\n", false) })
+	checkNodeError(t, "md2blockdomtree", err, ast.NodeTextMark, "")
+}
+
+// panicError 调用 fn 并返回其 panic 时抛出的错误。
+func panicError(fn func()) (err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err, _ = r.(error)
+		}
+	}()
+	fn()
+	return
+}
+
+func TestNodeErrorTransform(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		panic(errBoom)
+	})
+	_, err := luteEngine.RenderJSONContext(context.Background(), "foo\n")
+	checkNodeError(t, "renderjson", err, ast.NodeDocument, "")
+}
+
+func TestErrorReturningVariants(t *testing.T) {
+	luteEngine := lute.New()
+	json, err := luteEngine.RenderJSONContext(context.Background(), "foo *bar*\n")
+	if nil != err || luteEngine.RenderJSON("foo *bar*\n") != json {
+		t.Fatalf("render json failed: %v", err)
+	}
+
+	luteEngine.SetProtyleWYSIWYG(true)
+	luteEngine.SetKramdownIAL(true)
+
+	markdown := "foo **bar**\n{: id=\"20210110123456-abcdefg\" updated=\"20210110123456\"}\n"
+	vHTML, err := luteEngine.Md2BlockDOMContext(context.Background(), markdown, false)
+	if nil != err || luteEngine.Md2BlockDOM(markdown, false) != vHTML {
+		t.Fatalf("md2blockdom failed: %v", err)
+	}
+
+	kramdown, err := luteEngine.BlockDOM2MdContext(context.Background(), vHTML)
+	if nil != err || luteEngine.BlockDOM2Md(vHTML) != kramdown {
+		t.Fatalf("blockdom2md failed: %v", err)
+	}
+	if !strings.Contains(kramdown, "id=\"20210110123456-abcdefg\"") {
+		t.Fatalf("unexpected kramdown: %s", kramdown)
+	}
+}
@@ -0,0 +1,153 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+func TestFlavorCommonMark(t *testing.T) {
+	bytes, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: %s", err.Error())
+	}
+
+	var testcases []testcase
+	if err = json.Unmarshal(bytes, &testcases); nil != err {
+		t.Fatalf("read spec test case failed: %s", err.Error())
+	}
+
+	luteEngine := lute.NewWithFlavor(lute.FlavorCommonMark)
+	for _, test := range testcases {
+		testName := test.Section + " " + strconv.Itoa(test.Example)
+		html := luteEngine.MarkdownStr(testName, test.Markdown)
+		if test.HTML != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", testName, test.HTML, html, test.Markdown)
+		}
+	}
+}
+
+func TestFlavorGFM(t *testing.T) {
+	parse.AddAutoLinkDomainSuffix("baz")
+
+	for _, flavor := range []lute.Flavor{lute.FlavorGFM, lute.FlavorGitLab} {
+		luteEngine := lute.NewWithFlavor(flavor)
+		for _, test := range gfmSpecTests {
+			html := luteEngine.MarkdownStr(test.name, test.from)
+			if test.to != html {
+				t.Fatalf("flavor [%s] test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", flavor, test.name, test.to, html, test.from)
+			}
+		}
+	}
+}
+
+func TestFlavorObsidian(t *testing.T) {
+	luteEngine := lute.NewWithFlavor(lute.FlavorObsidian)
+	for _, test := range wikiLinkTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+
+	luteEngine.SetDataTask(true)
+	luteEngine.SetGFMTaskListItemClass("vditor-task")
+	for _, test := range calloutTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestFlavorMathBlock(t *testing.T) {
+	for _, test := range []struct {
+		flavor lute.Flavor
+		to     string
+	}{
+		{lute.FlavorObsidian, "<div class=\"language-math\">x</div>\n"},
+		{lute.FlavorGitLab, "<div class=\"language-math\">x</div>\n"},
+		{lute.FlavorGFM, "<p>$$\nx\n$$</p>\n"},
+		{lute.FlavorCommonMark, "<p>$$\nx\n$$</p>\n"},
+		{lute.FlavorDefault, "<div class=\"language-math\">x</div>\n"},
+	} {
+		html := lute.NewWithFlavor(test.flavor).MarkdownStr("", "$$\nx\n$$\n")
+		if test.to != html {
+			t.Fatalf("flavor [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.flavor, test.to, html)
+		}
+	}
+}
+
+func TestFlavorSiYuan(t *testing.T) {
+	luteEngine := lute.NewWithFlavor(lute.FlavorSiYuan)
+	luteEngine.SetDataTask(true)
+	luteEngine.SetAutoSpace(true)
+	luteEngine.PutEmojis(map[string]string{"1/b3log": "/emojis/1/b3log.png"})
+
+	ast.Testing = true
+	for _, test := range md2BlockDOMTests {
+		result := luteEngine.Md2BlockDOM(test.from, true)
+		if test.to != result {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, result, test.from)
+		}
+	}
+	ast.Testing = false
+}
+
+func TestFlavorOverride(t *testing.T) {
+	luteEngine := lute.NewWithFlavor(lute.FlavorGFM, func(l *lute.Lute) { l.SetGFMTable(false) })
+	if luteEngine.ParseOptions.GFMTable || !luteEngine.ParseOptions.GFMAutoLink {
+		t.Fatalf("flavor options should be applied before opts")
+	}
+
+	luteEngine.SetFlavor(lute.FlavorCommonMark)
+	if luteEngine.ParseOptions.GFMAutoLink || luteEngine.RenderOptions.GFMTagFilter {
+		t.Fatalf("flavor should reset previous options")
+	}
+
+	luteEngine.SetFlavor(lute.FlavorDefault)
+	if !luteEngine.ParseOptions.GFMTable || !luteEngine.RenderOptions.SoftBreak2HardBreak || !luteEngine.RenderOptions.CodeSyntaxHighlight {
+		t.Fatalf("default flavor should use default options")
+	}
+}
+
+func TestFlavorKeepOptions(t *testing.T) {
+	luteEngine := lute.New()
+	parseOptions, renderOptions := luteEngine.ParseOptions, luteEngine.RenderOptions
+	policy := render.NewPolicy()
+	luteEngine.SetSanitizePolicy(policy)
+	luteEngine.SetWikiLinkResolver(func(target, heading string) (string, bool) { return "/" + target, true })
+	luteEngine.PutEmojis(map[string]string{"lute": "🎻"})
+	luteEngine.PutTerms(map[string]string{"lute": "Lute"})
+	luteEngine.ParseOptions.MaxBlockDepth = 8
+	luteEngine.RenderOptions.LinkBase = "https://b3log.org/"
+
+	luteEngine.SetFlavor(lute.FlavorObsidian)
+	if parseOptions != luteEngine.ParseOptions || renderOptions != luteEngine.RenderOptions {
+		t.Fatal("flavor should not replace options")
+	}
+	if policy != luteEngine.RenderOptions.SanitizePolicy || nil == luteEngine.RenderOptions.WikiLinkResolver ||
+		"🎻" != luteEngine.ParseOptions.AliasEmoji["lute"] || "Lute" != luteEngine.RenderOptions.Terms["lute"] ||
+		8 != luteEngine.ParseOptions.MaxBlockDepth || "https://b3log.org/" != luteEngine.RenderOptions.LinkBase {
+		t.Fatal("flavor should keep non-flag options")
+	}
+	if !luteEngine.ParseOptions.WikiLink || luteEngine.RenderOptions.GFMTagFilter {
+		t.Fatal("flavor flags should be applied")
+	}
+}
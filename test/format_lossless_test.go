@@ -0,0 +1,129 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var losslessFormatTests = []parseTest{
+
+	{"9", "* a\n\n  ## Sub\n\n  ```\n  x  \n\n  ```\n* b  *c*\n", "* a\n\n  ### Sub\n\n  ```\n  x  \n\n  ```\n* b  *c*\n"},
+	{"8", "1. a\n\n   > ## Sub\n   > q  *x*\n2. b\n", "1. a\n\n   > ### Sub\n   > q  *x*\n2. b\n"},
+	{"7", "*   a  *x*\n*   ## Sub\n*   c\n", "*   a  *x*\n*   ### Sub\n*   c\n"},
+	{"6", "> foo  \n> bar\n>\n> ## Sub  ##\n>\n> baz  *x*\n", "> foo  \n> bar\n>\n> ### Sub\n>\n> baz  *x*\n"},
+	{"5", "# Title\n\n*   a\n*   b\n\n## Sub  ##\n\nSome   *text*  \n", "# Title\n\n*   a\n*   b\n\n### Sub\n\nSome   *text*  \n"},
+	{"4", "Title\n=====\n\nfoo\nbar\n## Sub\n\n- [ ] task\n", "Title\n=====\n\nfoo\nbar\n\n### Sub\n\n- [ ] task\n"},
+	{"3", "## Sub\n\n\n\n+ a\n+ b\n", "### Sub\n\n+ a\n+ b\n"},
+	{"2", "__strong__\n\n## Sub", "__strong__\n\n### Sub\n"},
+	{"1", "[foo]\n\n## Sub\n\n[foo]:  /url  'title'\n", "[foo]\n\n### Sub\n\n[foo]:  /url  'title'\n"},
+	{"0", "text  \n\n  ```go\n  code\n  ```\n", "text  \n\n  ```go\n  code\n  ```\n"},
+}
+
+func TestLosslessFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetLosslessFormat(true)
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if entering && ast.NodeHeading == n.Type && 2 == n.HeadingLevel {
+				n.HeadingLevel = 3
+			}
+			return ast.WalkContinue
+		})
+		return nil
+	})
+
+	for _, test := range losslessFormatTests {
+		result := luteEngine.FormatStr(test.name, test.from)
+		if test.to != result {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, result, test.from)
+		}
+	}
+}
+
+func TestLosslessFormatStructure(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetLosslessFormat(true)
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		// 删除第一个段落，在代码块后插入一个分隔线
+		for n := tree.Root.FirstChild; nil != n; n = n.Next {
+			if ast.NodeCodeBlock == n.Type {
+				n.InsertAfter(&ast.Node{Type: ast.NodeThematicBreak})
+				break
+			}
+		}
+		tree.Root.FirstChild.Unlink()
+		return nil
+	})
+
+	from := "remove me\n\nkeep  *me*\n\n~~~\ncode\n~~~\n\n* * *\n"
+	expected := "keep  *me*\n\n~~~\ncode\n~~~\n\n---\n\n* * *\n"
+	if result := luteEngine.FormatStr("", from); expected != result {
+		t.Fatalf("lossless format failed\nexpected\n\t%q\ngot\n\t%q", expected, result)
+	}
+}
+
+func TestLosslessFormatSpec(t *testing.T) {
+	data, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: %s", err.Error())
+	}
+
+	var testcases []testcase
+	if err = json.Unmarshal(data, &testcases); nil != err {
+		t.Fatalf("read spec test case failed: %s", err.Error())
+	}
+
+	// 没有修改语法树时无损格式化的结果和原始文本完全一致
+	luteEngine := lute.New()
+	luteEngine.SetLosslessFormat(true)
+	for _, test := range testcases {
+		if result := luteEngine.FormatStr("", test.Markdown); test.Markdown != result {
+			t.Fatalf("spec example [%d] failed\nexpected\n\t%q\ngot\n\t%q", test.Example, test.Markdown, result)
+		}
+	}
+}
+
+func TestLosslessSegmentsIAL(t *testing.T) {
+	options := lute.New().ParseOptions
+	options.KramdownBlockIAL = true
+	options.SourcePos = true
+	tree := parse.Parse("", []byte("foo\n{: id=\"20200101000000-abcdefg\"}\n\n> bar\n> {: id=\"20200101000000-hijklmn\"}\n>\n> baz\n\n{: id=\"20200101000000-docdocd\" type=\"doc\"}\n"), options)
+	if err := tree.Snapshot(); nil != err {
+		t.Fatal(err)
+	}
+
+	// 只修改 IAL 的块需要连同其 IAL 重新格式化，容器块中只重新格式化修改过的子块
+	quote := tree.Root.FirstChild.Next.Next
+	tree.Root.FirstChild.SetIALAttr("custom-a", "1")
+	quote.ChildByType(ast.NodeParagraph).SetIALAttr("custom-b", "2")
+	var got []string
+	for _, segment := range tree.LosslessSegments() {
+		if nil != segment.Source {
+			got = append(got, "source:"+string(segment.Source))
+		} else if segment.Nested {
+			got = append(got, "nested:"+string(segment.Prefix)+segment.Nodes[0].Type.String())
+		} else {
+			got = append(got, "format:"+segment.Nodes[0].Type.String())
+		}
+	}
+	expected := "format:NodeParagraph|nested:> NodeParagraph|source:> {: id=\"20200101000000-hijklmn\"}\n>\n|source:> baz\n|source:\n{: id=\"20200101000000-docdocd\" type=\"doc\"}\n"
+	if actual := strings.Join(got, "|"); expected != actual {
+		t.Fatalf("unexpected segments\nexpected\n\t%q\ngot\n\t%q", expected, actual)
+	}
+}
@@ -0,0 +1,130 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+var formatStyleTests = []struct {
+	name      string
+	style     *render.FormatStyle
+	original  string
+	formatted string
+}{
+
+	{"17", &render.FormatStyle{Wrap: "column", WrapColumn: 20}, "[foo]: /url \"title\" ok aaaa bbbb cccc\n", "[foo]: /url \"title\" ok aaaa bbbb cccc\n"},
+	{"16", &render.FormatStyle{Wrap: "none"}, "foo\\\nbar\nbaz\n", "foo\\\nbar baz\n"},
+	{"15", &render.FormatStyle{Link: "reference"}, "<http://foo> <a@b.com>\n", "[http://foo][1] <a@b.com>\n\n[1]: http://foo\n"},
+	{"14", &render.FormatStyle{Link: "reference", Wrap: "column", WrapColumn: 20}, "[a *b* c](http://x.com) [aaaa bbbb cccc dddd][x]\n\n[x]: /x\n", "[a *b* c][1]\n[aaaa bbbb cccc dddd][x]\n\n[x]: /x\n[1]: http://x.com\n"},
+	{"13", &render.FormatStyle{Link: "inline"}, "[foo][x] ![img][x]\n\n[x]: /url \"title\"\n", "[foo](/url \"title\") ![img](/url \"title\")\n"},
+	{"12", &render.FormatStyle{Link: "reference"}, "[a](/a) [b](/b \"B\") [c](/a)\n\n[1]: /one\n", "[a][2] [b][3] [c][2]\n\n[1]: /one\n[2]: /a\n[3]: /b \"B\"\n"},
+	{"11", &render.FormatStyle{Wrap: "sentence"}, "One. Two!\nThree? Four\nfive.\n", "One.\nTwo!\nThree?\nFour five.\n"},
+	{"10", &render.FormatStyle{Wrap: "none"}, "foo\nbar\nbaz\n", "foo bar baz\n"},
+	{"9", &render.FormatStyle{Wrap: "column", WrapColumn: 20}, "中文中文中文中文 中文中文中文 foo\n", "中文中文中文中文\n中文中文中文 foo\n"},
+	{"8", &render.FormatStyle{Wrap: "column", WrapColumn: 20}, "aaaa bbbb cccc dddd - eeee # ffff\n", "aaaa bbbb cccc dddd -\neeee # ffff\n"},
+	{"7", &render.FormatStyle{TableCompact: true}, "| a | bbbb |\n| :-: | -- |\n| ccc | d |\n", "| a | bbbb |\n| :-: | --- |\n| ccc | d |\n"},
+	{"6", &render.FormatStyle{ThematicBreak: "* * *"}, "foo\n\n___\n", "foo\n\n* * *\n"},
+	{"5", &render.FormatStyle{OrderedList: "one"}, "3. a\n4. b\n", "3. a\n3. b\n"},
+	{"4", &render.FormatStyle{CodeFence: "~", CodeFenceLength: 4}, "```go\n~~~~~\n```\n", "~~~~~~go\n~~~~~\n~~~~~~\n"},
+	{"3", &render.FormatStyle{CodeFence: "`"}, "~~~ a`b\ncode\n~~~\n", "~~~a`b\ncode\n~~~\n"},
+	{"2", &render.FormatStyle{Heading: "setext"}, "# foo\n\n### bar\n", "foo\n===\n\n### bar\n"},
+	{"1", &render.FormatStyle{Heading: "atx"}, "foo\n---\n", "## foo\n"},
+	{"0", &render.FormatStyle{Emphasis: "_", Strong: "__"}, "*a* **b** foo*bar*baz\n", "_a_ __b__ foo*bar*baz\n"},
+}
+
+func TestFormatStyle(t *testing.T) {
+	for _, test := range formatStyleTests {
+		luteEngine := lute.New()
+		luteEngine.SetFormatStyle(test.style)
+		formatted := luteEngine.FormatStr(test.name, test.original)
+		if test.formatted != formatted {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.formatted, formatted, test.original)
+		}
+	}
+}
+
+func TestLosslessFormatStyle(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetLosslessFormat(true)
+	luteEngine.SetFormatStyle(&render.FormatStyle{Link: "reference", MaxBlankLines: 1})
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		tree.Root.FirstChild.Next.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: []byte("!")})
+		return nil
+	})
+
+	from := "keep [a](/a)\n\n\n\n[b](/b) [x][x]\n\n[x]: /x\n"
+	expected := "keep [a](/a)\n\n[b][1] [x]!\n\n[x]: /x\n\n[1]: /b\n"
+	if result := luteEngine.FormatStr("", from); expected != result {
+		t.Fatalf("lossless format failed\nexpected\n\t%q\ngot\n\t%q", expected, result)
+	}
+}
+
+func TestFormatStyleIdempotent(t *testing.T) {
+	styles := []*render.FormatStyle{
+		{Emphasis: "_", Strong: "__"},
+		{Heading: "setext"},
+		{Heading: "atx"},
+		{CodeFence: "~", CodeFenceLength: 4},
+		{CodeFence: "`"},
+		{OrderedList: "one"},
+		{OrderedList: "increment"},
+		{ThematicBreak: "* * *"},
+		{TableCompact: true},
+		{MaxBlankLines: 1},
+		{Wrap: "column", WrapColumn: 20},
+		{Wrap: "sentence"},
+		{Wrap: "none"},
+		{Link: "inline"},
+		{Link: "reference"},
+	}
+
+	var tests []parseTest
+	for _, test := range formatStyleTests {
+		tests = append(tests, parseTest{"style-" + test.name, test.original, ""})
+	}
+	data, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: %s", err.Error())
+	}
+	var testcases []testcase
+	if err = json.Unmarshal(data, &testcases); nil != err {
+		t.Fatalf("read spec test case failed: %s", err.Error())
+	}
+	luteEngine := lute.New()
+	for _, test := range testcases {
+		// 只使用默认格式化后语义不变并且幂等的用例，其他用例是格式化本身的问题，和格式化风格无关
+		formatted := luteEngine.FormatStr("", test.Markdown)
+		if luteEngine.MarkdownStr("", formatted) != luteEngine.MarkdownStr("", test.Markdown) || luteEngine.FormatStr("", formatted) != formatted {
+			continue
+		}
+		tests = append(tests, parseTest{"spec-" + strconv.Itoa(test.Example), test.Markdown, ""})
+	}
+
+	for i, style := range styles {
+		luteEngine = lute.New()
+		luteEngine.SetFormatStyle(style)
+		for _, test := range tests {
+			formatted := luteEngine.FormatStr(test.name, test.from)
+			if result := luteEngine.FormatStr(test.name, formatted); formatted != result {
+				t.Fatalf("test case [%s] with style [%d] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, i, formatted, result, test.from)
+			}
+		}
+	}
+}
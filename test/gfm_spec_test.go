@@ -61,6 +61,10 @@ var gfmSpecTests = []parseTest{
 	{"auto email link2", "a.b-c_d@a.b-\n", "<p>a.b-c_d@a.b-</p>\n"},
 	{"auto email link3", "a.b-c_d@a.b_\n", "<p>a.b-c_d@a.b_</p>\n"},
 	{"gfm631", "a.b-c_d@a.b\n\na.b-c_d@a.b.\n\na.b-c_d@a.b-\n\na.b-c_d@a.b_\n", "<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a></p>\n<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a>.</p>\n<p>a.b-c_d@a.b-</p>\n<p>a.b-c_d@a.b_</p>\n"},
+	{"gfm653", "<strong> <title> <style> <em>\n\n<blockquote>\n  <xmp> is disallowed.  <XMP> is also disallowed.\n</blockquote>\n", "<p><strong> &lt;title> &lt;style> <em></p>\n<blockquote>\n  &lt;xmp> is disallowed.  &lt;XMP> is also disallowed.\n</blockquote>\n"},
+	{"tagfilter2", "<scripts> <iframe/> <textarea rows=\"1\"> </Script >\n", "<p><scripts> &lt;iframe/> &lt;textarea rows=\"1\"> &lt;/Script ></p>\n"},
+	{"tagfilter1", "<script>\nalert(1)\n</script>\n", "&lt;script>\nalert(1)\n&lt;/script>\n"},
+	{"tagfilter0", "<style>p{}</style><b>b</b>\n", "&lt;style>p{}&lt;/style><b>b</b>\n"},
 }
 
 func TestGFMSpec(t *testing.T) {
@@ -68,6 +72,7 @@ func TestGFMSpec(t *testing.T) {
 	luteEngine.RenderOptions.SoftBreak2HardBreak = false
 	luteEngine.RenderOptions.AutoSpace = false
 	luteEngine.RenderOptions.GFMTaskListItemClass = "" // 关闭类名渲染
+	luteEngine.SetGFMTagFilter(true)
 	parse.AddAutoLinkDomainSuffix("baz")
 
 	for _, test := range gfmSpecTests {
@@ -0,0 +1,159 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+var json2TreeTests = []parseTest{
+
+	{"7", "\ufefffoo\r\rbar\r", ""},
+	{"6", "\ufeff# foo\n\n* bar\n", ""},
+	{"5", "foo\r\nbar\r\n\r\n- -\r\n", ""},
+	{"4", "* [/] doing\n* [x] done\n\n| a | b |\n| :- | -: |\n| 1 | 2 |\n", ""},
+	{"3", "foo[^1] bar[^1]\n\n[^1]: note\n", ""},
+	{"2", "> [!TIP] 🔥 Title\n> body\n>\n> two\n{: id=\"20200101000000-abcdefg\" custom-b=\"2\" custom-a=\"1\"}\n\n{{{row\nfoo ==mark== #tag# ((20200101000000-hijklmn \"anchor\"))\n{: id=\"20200101000000-opqrstu\"}\n}}}\n{: id=\"20200101000000-vwxyzab\"}\n", ""},
+	{"1", "* {: id=\"20200101000000-cdefghi\"}foo\n  {: id=\"20200101000000-jklmnop\"}\n{: id=\"20200101000000-qrstuvw\"}\n\n*x*{: style=\"color: red\"}\n\n\n{: id=\"20200101000000-docdocd\" title=\"doc\" type=\"doc\"}\n", ""},
+	{"0", "foo\n", ""},
+}
+
+// TestJSON2Tree 检查 Markdown → Tree → JSON → Tree → Markdown 是否稳定。
+func TestJSON2Tree(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSuperBlock(true)
+	luteEngine.SetCallout(true)
+	luteEngine.SetMark(true)
+	luteEngine.SetTag(true)
+	luteEngine.SetBlockRef(true)
+	luteEngine.SetFootnotes(true)
+	luteEngine.SetArbitraryTaskListItemMarker(true)
+
+	tests := json2TreeTests
+	for _, test := range formatTests {
+		tests = append(tests, parseTest{"format-" + test.name, test.original, ""})
+	}
+	data, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: %s", err.Error())
+	}
+	var testcases []testcase
+	if err = json.Unmarshal(data, &testcases); nil != err {
+		t.Fatalf("read spec test case failed: %s", err.Error())
+	}
+	for _, test := range testcases {
+		tests = append(tests, parseTest{"spec-" + strconv.Itoa(test.Example), test.Markdown, ""})
+	}
+
+	for _, test := range tests {
+		// 格式化渲染会修改语法树，并且生成的 ID 是随机的，所以先格式化固定文档 IAL，然后分别解析用于格式化和 JSON 渲染
+		md := []byte(luteEngine.FormatStr(test.name, test.from))
+		tree := parse.Parse(test.name, md, luteEngine.ParseOptions)
+		expected := string(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+		if string(md) != expected {
+			// 格式化结果不稳定（比如 IAL 没有被识别而重新生成了随机 ID），无法比较
+			continue
+		}
+		tree = parse.Parse(test.name, md, luteEngine.ParseOptions)
+		if hasDetachedIAL(tree) {
+			// 解析时补全的块（比如空引述中的段落）有 IAL 但没有 IAL 节点，转换后会生成 IAL 节点
+			continue
+		}
+		jsonStr := string(render.NewJSONRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+
+		// 格式化渲染和 JSON 渲染都会修改语法树，所以分别转换
+		tree, err = luteEngine.JSON2Tree(test.name, jsonStr)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if got := string(render.NewJSONRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render()); jsonStr != got {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%s\ngot\n\t%s", test.name, jsonStr, got)
+		}
+		tree, _ = luteEngine.JSON2Tree(test.name, jsonStr)
+		if got := string(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render()); expected != got {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\njson\n\t%s", test.name, expected, got, jsonStr)
+		}
+	}
+}
+
+// hasDetachedIAL 判断语法树中是否存在有 IAL 但后面没有跟随 IAL 节点的块。
+func hasDetachedIAL(tree *parse.Tree) (ret bool) {
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || !n.IsBlock() || ast.NodeDocument == n.Type || ast.NodeKramdownBlockIAL == n.Type || 1 > len(n.KramdownIAL) {
+			return ast.WalkContinue
+		}
+		if nil == n.Next || ast.NodeKramdownBlockIAL != n.Next.Type {
+			ret = true
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+func TestJSON2TreeLinks(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetFootnotes(true)
+	tree, err := luteEngine.JSON2Tree("", luteEngine.RenderJSON("* [x] foo[^1]\n\n[^1]: bar\n{: id=\"20200101000000-abcdefg\" custom-a=\"1\"}\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		for c := n.FirstChild; nil != c; c = c.Next {
+			if n != c.Parent || (nil != c.Next && c != c.Next.Previous) || (nil == c.Next && c != n.LastChild) {
+				t.Fatalf("broken links around node [%s]", c.Type)
+			}
+		}
+		return ast.WalkContinue
+	})
+
+	list := tree.Root.FirstChild
+	if ast.NodeList != list.Type || 3 != list.ListData.Typ || !list.FirstChild.FirstChild.FirstChild.TaskListItemChecked {
+		t.Fatalf("unexpected list %s", list.Type)
+	}
+	def := tree.Root.ChildByType(ast.NodeFootnotesDefBlock).FirstChild
+	ref := list.FirstChild.FirstChild.ChildByType(ast.NodeFootnotesRef)
+	if 1 != len(def.FootnotesRefs) || ref != def.FootnotesRefs[0] {
+		t.Fatal("footnotes refs should be relinked")
+	}
+	if ial := def.Parent.Next; nil == ial || ast.NodeKramdownBlockIAL != ial.Type || "{: id=\"20200101000000-abcdefg\" custom-a=\"1\"}" != ial.TokensStr() {
+		t.Fatalf("unexpected footnotes def block ial %v", ial)
+	}
+
+	jsonStr := luteEngine.RenderJSON("\ufefffoo\r\n")
+	if tree, err = luteEngine.JSON2Tree("", jsonStr); nil != err || "\r\n" != tree.LineEnding || !tree.BOM {
+		t.Fatalf("line ending and bom should be kept %s", jsonStr)
+	}
+	if got := string(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render()); !strings.HasPrefix(got, "\ufefffoo\r\n\r\n") {
+		t.Fatalf("unexpected markdown %q", got)
+	}
+	if _, err = luteEngine.JSON2Tree("", `{"Spec":"2","Type":"NodeDocument"}`); nil == err {
+		t.Fatal("unsupported spec should be rejected")
+	}
+	if _, err = luteEngine.JSON2Tree("", `{"Type":"NodeDocument","Children":[{"Type":"NodeUnknown"}]}`); nil == err {
+		t.Fatal("unknown node type should be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+var limitTests = []struct {
+	name     string
+	from     string
+	setLimit func(luteEngine *lute.Lute)
+	err      error
+}{
+	{"11", strings.Repeat("![", 4) + "a" + strings.Repeat("](/u)", 4) + "\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 3 }, parse.ErrInlineTooDeep},
+	{"10", "*a **b _c ~~d~~ c_ b** a*\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 3 }, parse.ErrInlineTooDeep},
+	{"9", strings.Repeat("[*a* _b_](/u) ", 64) + "\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 2 }, nil},
+	{"8", strings.Repeat("*a* **b** a_b_c ~~d~~ ", 64) + "\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 2 }, nil},
+	{"7", strings.Repeat("foo\n\n", 100), func(luteEngine *lute.Lute) { luteEngine.RenderOptions.MaxOutputBytes = 64 }, render.ErrOutputTooLarge},
+	{"6", "foo[^1]\n\n[^1]: " + strings.Repeat("bar ", 32), func(luteEngine *lute.Lute) { luteEngine.RenderOptions.MaxOutputBytes = 64 }, render.ErrOutputTooLarge},
+	{"5", strings.Repeat("_a ", 40), func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 32 }, nil},
+	{"4", strings.Repeat("[x ", 40), func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 32 }, nil},
+	{"3", "[[[[foo]]]] *a* *b*\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInlineNesting = 4 }, nil},
+	{"2", strings.Repeat("- ", 8) + "foo\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxBlockDepth = 8 }, parse.ErrBlockTooDeep},
+	{"1", "> > foo\n\n- > - bar\n", func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxBlockDepth = 8 }, nil},
+	{"0", strings.Repeat("foo", 10), func(luteEngine *lute.Lute) { luteEngine.ParseOptions.MaxInputBytes = 16 }, parse.ErrInputTooLarge},
+}
+
+func TestLimits(t *testing.T) {
+	for _, test := range limitTests {
+		luteEngine := lute.New()
+		test.setLimit(luteEngine)
+		html, err := luteEngine.MarkdownContext(context.Background(), test.name, []byte(test.from))
+		if !errors.Is(err, test.err) {
+			t.Fatalf("test case [%s] failed\nexpected error\n\t%v\ngot\n\t%v", test.name, test.err, err)
+		}
+		if nil != err {
+			if nil != html {
+				t.Fatalf("test case [%s] failed: unexpected html %q", test.name, html)
+			}
+			if err = panicError(func() { luteEngine.MarkdownStr(test.name, test.from) }); !errors.Is(err, test.err) {
+				t.Fatalf("test case [%s] failed: markdown should panic with [%v], got [%v]", test.name, test.err, err)
+			}
+			if err = panicError(func() { luteEngine.FormatStr(test.name, test.from) }); render.ErrOutputTooLarge != test.err && !errors.Is(err, test.err) {
+				t.Fatalf("test case [%s] failed: format should panic with [%v], got [%v]", test.name, test.err, err)
+			}
+			continue
+		}
+		if expected := lute.New().MarkdownStr(test.name, test.from); expected != string(html) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, expected, html)
+		}
+	}
+}
+
+func TestLimitsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	luteEngine := lute.New()
+	if _, err := luteEngine.MarkdownContext(ctx, "", []byte("foo")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := luteEngine.FormatContext(ctx, "", []byte("foo")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 渲染过程中取消
+	markdown := []byte(strings.Repeat("foo\n\nbar\n\n", 1024))
+	ctx, cancel = context.WithCancel(context.Background())
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		cancel()
+		return nil
+	})
+	if _, err := luteEngine.MarkdownContext(ctx, "", markdown); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 没有限制时 Parse 不受影响
+	luteEngine.ParseOptions.MaxBlockDepth = 1
+	if tree := parse.Parse("", []byte("> > foo\n"), luteEngine.ParseOptions); nil == tree.Root.FirstChild {
+		t.Fatalf("parse should ignore limits")
+	}
+}
@@ -0,0 +1,100 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var lineEndingFormatTests = []parseTest{
+
+	{"5", "foo\x00bar\r\n", "foo�bar\r\n"},
+	{"4", "\xEF\xBB\xBF# foo\n", "\xEF\xBB\xBF# foo\n"},
+	{"3", "\xEF\xBB\xBF#  foo\r\n\r\nbar\r\n", "\xEF\xBB\xBF# foo\r\n\r\nbar\r\n"},
+	{"2", "*   foo\r*   bar\r", "* foo\r* bar\r"},
+	{"1", "```\r\ncode\r\n```\r\n\r\n> quote\r\nlazy\r\n", "```\r\ncode\r\n```\r\n\r\n> quote\r\n> lazy\r\n"},
+	{"0", "#  foo\r\nbar\r\nbaz", "# foo\r\n\r\nbar\r\nbaz\r\n"},
+}
+
+func TestLineEndingFormat(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range lineEndingFormatTests {
+		input := []byte(test.from)
+		result := string(luteEngine.Format(test.name, input))
+		if test.to != result {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, result, test.from)
+		}
+		if test.from != string(input) {
+			t.Fatalf("test case [%s] failed: input is modified to %q", test.name, input)
+		}
+	}
+}
+
+func TestLineEndingTree(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range []struct {
+		markdown   string
+		lineEnding string
+		bom        bool
+	}{
+		{"foo\r\nbar\n", "\r\n", false},
+		{"\xEF\xBB\xBFfoo\rbar", "\r", true},
+		{"foo", "", false},
+	} {
+		tree := parse.Parse("", []byte(test.markdown), luteEngine.ParseOptions)
+		if test.lineEnding != tree.LineEnding || test.bom != tree.BOM {
+			t.Fatalf("expected line ending %q and BOM [%v] of %q, got %q and [%v]", test.lineEnding, test.bom, test.markdown, tree.LineEnding, tree.BOM)
+		}
+	}
+}
+
+func TestLineEndingSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.ParseOptions.SourcePos = true
+	markdown := "\xEF\xBB\xBFfoo\r\n\r\nbar\r\n"
+	tree := parse.Parse("", []byte(markdown), luteEngine.ParseOptions)
+	para := tree.Root.FirstChild.Next
+	if ast.NodeParagraph != para.Type || 3 != para.Pos.StartLine || "bar" != markdown[para.Pos.StartOffset:para.Pos.EndOffset] {
+		t.Fatalf("unexpected position %+v of the second paragraph", para.Pos)
+	}
+}
+
+func TestLineEndingLosslessFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetLosslessFormat(true)
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		tree.Root.LastChild.HeadingLevel = 3
+		return nil
+	})
+
+	from := "\xEF\xBB\xBFkeep  *me*\r\n\r\n## Sub  ##\r\n"
+	expected := "\xEF\xBB\xBFkeep  *me*\r\n\r\n### Sub\r\n"
+	if result := luteEngine.FormatStr("", from); expected != result {
+		t.Fatalf("lossless format failed\nexpected\n\t%q\ngot\n\t%q", expected, result)
+	}
+}
+
+func TestLineEndingLargeInput(t *testing.T) {
+	markdown := bytes.Repeat([]byte("foo bar\r\n\r\n"), 100000)
+	tree := parse.Parse("", markdown, lute.New().ParseOptions)
+	count := 0
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		count++
+	}
+	if 100000 != count {
+		t.Fatalf("expected 100000 paragraphs, got %d", count)
+	}
+}
@@ -0,0 +1,151 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/lint"
+)
+
+func problemsStr(problems []*lint.Problem) string {
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.Pos.String()+" "+p.RuleID+" "+p.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var lintTests = []parseTest{
+
+	{"12", "# A\n\n| a | b |\n| - | - |\n| https://b3log.org | x https://github.com |\n", "5:3-5:19 MD034 Bare URL used: https://b3log.org\n5:25-5:42 MD034 Bare URL used: https://github.com"},
+	{"11", "# A\n\n- a\n* b\n+ c\n", "4:1-4:3 MD004 Expected: -; Actual: *\n5:1-5:3 MD004 Expected: -; Actual: +"},
+	{"10", "# A\n\n![](a.png) ![b](b.png)\n", "3:1-3:10 MD045 Image without alt text"},
+	{"9", "# A\n\n[x]() [y](#) [z](/z)\n", "3:1-3:5 MD042 No empty links: x\n3:7-3:12 MD042 No empty links: y"},
+	{"8", "foo\n\n# A\n", "1:1-1:3 MD041 First line should be a h1 heading"},
+	{"7", "# A\n\n```\nfoo\n```\n\n```go\nbar\n```\n", "3:1-5:3 MD040 Fenced code block without language"},
+	{"6", "# A\n\nsee https://b3log.org and <https://github.com> or\n> www.example.com\n", "3:5-3:21 MD034 Bare URL used: https://b3log.org\n4:3-4:17 MD034 Bare URL used: www.example.com"},
+	{"5", "# A.\n\n## B：\n\n## C?\n", "1:1-1:4 MD026 Punctuation: '.'\n3:1-3:7 MD026 Punctuation: '：'"},
+	{"4", "# A\n\n# B\n\n> # C\n", "3:1-3:3 MD025 Multiple top-level headings: B"},
+	{"3", "# A\n\n" + strings.Repeat("foo ", 21) + "\n\n" + strings.Repeat("x", 100) + "\n", "3:81-3:84 MD013 Expected: 80; Actual: 84"},
+	{"2", "# A\n\n* a\n* b\n\n- c\n\n+ d\n", "6:1-6:3 MD004 Expected: *; Actual: -\n8:1-8:3 MD004 Expected: *; Actual: +"},
+	{"1", "# A\n\n### B\n\n#### C\n\n## D\n", "3:1-3:5 MD001 Expected: h2; Actual: h3\n5:1-5:6 MD001 Expected: h3; Actual: h4"},
+	{"0", "# A\n\nfoo *bar*\n", ""},
+}
+
+func TestLint(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range lintTests {
+		problems, err := luteEngine.Lint("", []byte(test.from), nil)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if actual := problemsStr(problems); test.to != actual {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\nactual\n\t%q\noriginal\n\t%q", test.name, test.to, actual, test.from)
+		}
+	}
+}
+
+var lintFixTests = []parseTest{
+
+	{"4", "# A\n\n### B\n\n#### C\n\n## D\n", "# A\n\n## B\n\n### C\n\n## D\n"},
+	{"3", "# A\n\nsee https://b3log.org\n", "# A\n\nsee [https://b3log.org](https://b3log.org)\n"},
+	{"2", "# A.\n\n## B：\n", "# A\n\n## B\n"},
+	{"1", "# A\n\n- a\n- b\n\nfoo\n\n* c\n\nbar\n\n+ d\n", "# A\n\n- a\n- b\n\nfoo\n\n- c\n\nbar\n\n- d\n"},
+	{"0", "# A\n\n### B\n\n## C\n", "# A\n\n## B\n\n## C\n"},
+}
+
+func TestLintFix(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range lintFixTests {
+		fixed, problems, err := luteEngine.LintFix("", []byte(test.from), nil)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(fixed) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\nactual\n\t%q\noriginal\n\t%q", test.name, test.to, fixed, test.from)
+		}
+		if 0 != len(problems) {
+			t.Fatalf("test case [%s] failed\nunexpected problems\n\t%s", test.name, problemsStr(problems))
+		}
+	}
+}
+
+func TestLintFixULStyleAdjacentLists(t *testing.T) {
+	// 相邻的列表修改为相同的标记后会合并为一个列表，不能自动修复
+	luteEngine := lute.New()
+	from := "# A\n\n- a\n* b\n+ c\n"
+	fixed, problems, err := luteEngine.LintFix("", []byte(from), nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected := luteEngine.FormatStr("", from); expected != string(fixed) {
+		t.Fatalf("expected\n\t%q\nactual\n\t%q", expected, fixed)
+	}
+	if 2 != len(problems) || problems[0].Fixable || problems[1].Fixable {
+		t.Fatalf("unexpected problems\n\t%s", problemsStr(problems))
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	luteEngine := lute.New()
+	md := []byte("foo\n\n* a\n\n- b\n\n" + strings.Repeat("foo ", 12) + "\n")
+
+	config, err := lint.ParseConfig([]byte(`{"default": false, "ul-style": {"style": "dash"}, "MD013": {"line_length": 40}}`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	problems, _ := luteEngine.Lint("", md, config)
+	expected := "3:1-3:3 MD004 Expected: -; Actual: *\n7:41-7:48 MD013 Expected: 40; Actual: 48"
+	if actual := problemsStr(problems); expected != actual {
+		t.Fatalf("expected\n\t%q\nactual\n\t%q", expected, actual)
+	}
+
+	problems, _ = luteEngine.Lint("", md, lint.Config{"MD041": false, "md004": false, "line-length": false})
+	if 0 != len(problems) {
+		t.Fatalf("unexpected problems\n\t%s", problemsStr(problems))
+	}
+}
+
+func TestLintReport(t *testing.T) {
+	luteEngine := lute.New()
+	source := []byte("# A\n\n中文😀 ![](a.png)\n")
+	problems, _ := luteEngine.Lint("", source, nil)
+
+	data, err := lint.JSON("a.md", problems)
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected := `{"name":"a.md","problems":[{"ruleId":"MD045","ruleAlias":"no-alt-text","message":"Image without alt text","pos":{"StartOffset":16,"EndOffset":26,"StartLine":3,"StartColumn":12,"EndLine":3,"EndColumn":21},"fixable":false}]}`
+	if expected != string(data) {
+		t.Fatalf("expected\n\t%s\nactual\n\t%s", expected, data)
+	}
+
+	data, err = lint.SARIF("a.md", source, problems)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err = json.Unmarshal(data, &log); nil != err {
+		t.Fatal(err)
+	}
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+	result := run["results"].([]interface{})[0].(map[string]interface{})
+	region := result["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})["region"].(map[string]interface{})
+	if "2.1.0" != log["version"] || "MD045" != result["ruleId"] || "3" != strconv.Itoa(int(region["startLine"].(float64))) ||
+		6 != region["startColumn"].(float64) || 16 != region["endColumn"].(float64) {
+		t.Fatalf("unexpected sarif\n\t%s", data)
+	}
+}
@@ -0,0 +1,223 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/mdast"
+	"github.com/88250/lute/parse"
+)
+
+var mdastTests = []struct {
+	name     string
+	markdown string
+}{
+
+	{"16", "~~~ a`b\nfoo\n```\n~~~\n"},
+	{"15", "Foo *bar\nbaz*\n===\n\nqux\\\nquux\n---\n"},
+	{"14", "> [!TIP] 🔥 My title\n> body\n>\n> two\n\n> [!NOTE]\n> foo\n> bar\n"},
+	{"13", "::leaf[label *em*]{#i .c k=\"v\"}\n\n:::box{x=\"1\"}\ninside :txt[t]\n:::\n"},
+	{"12", "{{{row\nfoo ((20200101000000-abcdefg \"anchor\")) ((20200101000000-abcdefg 'dyn')) #tag *x*# ==mk== ^sup^ ~sub~\n\n[[Page#H|Al]] ![[Emb]]\n}}}\n"},
+	{"11", "[toc]\n\n{{select * from blocks}}\n"},
+	{"10", "---\ntitle: foo\n---\n\n$$\na^2\n$$\n\nfoo $x$ bar\n"},
+	{"9", "foo[^1] bar[^note] baz[^1]\n\n[^1]: one\n[^note]: two\n\n    more\n"},
+	{"8", "| a | b | c |\n| :- | :-: | -: |\n| 1 | `a\\|b` | 3 |\n"},
+	{"7", "[a](/u \"t\") ![i j](/p) [r] [s][r] <http://x.com>\n\n[r]: /ref \"T\"\n"},
+	{"6", "```go\nfunc main() {}\n```\n\n````\n```\n````\n\n<div>\nhtml\n</div>\n"},
+	{"5", "* [x] done\n* [ ] todo\n\n1. one\n2. two\n\n   para\n3. three\n"},
+	{"4", "> quote\n> line\\\n> hard\n\n---\n"},
+	{"3", "# Title {#hid}\n\n## *em* and **strong** ~~del~~\n"},
+	{"2", "a_b_c \\* \\_ \\[x\\] \\# `code` <b>inline</b>\n"},
+	{"1", "\\# not heading\n\n\\- not list\n\n1\\. not list\n"},
+	{"0", "foo\nbar\n"},
+}
+
+func newMdastLute() *lute.Lute {
+	ret := lute.New()
+	ret.SetBlockRef(true)
+	ret.SetTag(true)
+	ret.SetMark(true)
+	ret.SetSup(true)
+	ret.SetSub(true)
+	ret.SetSuperBlock(true)
+	ret.SetWikiLink(true)
+	ret.SetDirective(true)
+	ret.SetToC(true)
+	ret.SetInlineMath(true)
+	ret.SetCallout(true)
+	return ret
+}
+
+func TestMdast(t *testing.T) {
+	luteEngine := newMdastLute()
+	for _, test := range mdastTests {
+		data, err := luteEngine.Md2Mdast(test.name, []byte(test.markdown))
+		if nil != err {
+			t.Fatalf("test case [%s] export failed: %s", test.name, err)
+		}
+		markdown, err := luteEngine.Mdast2Md(test.name, data)
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		expected := luteEngine.FormatStr(test.name, test.markdown)
+		if expected != string(markdown) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\nmdast\n\t%s", test.name, expected, markdown, data)
+		}
+	}
+}
+
+func TestMdastTree(t *testing.T) {
+	luteEngine := newMdastLute()
+	for _, test := range mdastTests {
+		tree := parse.Parse(test.name, []byte(test.markdown), luteEngine.ParseOptions)
+		data, err := luteEngine.Md2Mdast(test.name, []byte(test.markdown))
+		if nil != err {
+			t.Fatalf("test case [%s] export failed: %s", test.name, err)
+		}
+		imported, err := luteEngine.Mdast2Tree(test.name, data)
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		expected, got := dumpTree(tree.Root), dumpTree(imported.Root)
+		if expected != got {
+			t.Fatalf("test case [%s] failed\nexpected\n%s\ngot\n%s", test.name, expected, got)
+		}
+	}
+}
+
+// dumpTree 输出节点类型和内容，用于比较语法树结构。
+func dumpTree(root *ast.Node) string {
+	buf := &strings.Builder{}
+	depth := 0
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			depth--
+			return ast.WalkContinue
+		}
+		var tokens string
+		switch n.Type {
+		case ast.NodeText, ast.NodeLinkText, ast.NodeLinkDest, ast.NodeLinkTitle, ast.NodeCodeSpanContent, ast.NodeCodeBlockCode,
+			ast.NodeInlineMathContent, ast.NodeMathBlockContent, ast.NodeBackslashContent, ast.NodeBlockRefID, ast.NodeFootnotesRef:
+			// 只比较内容节点的 Tokens，其他节点的 Tokens 可能是原始文本
+			tokens = n.TokensStr()
+		}
+		buf.WriteString(strings.Repeat("  ", depth) + n.Type.String() + " " + tokens + "\n")
+		depth++
+		return ast.WalkContinue
+	})
+	return buf.String()
+}
+
+var mdastImportTests = []parseTest{
+
+	{"2", `{"type":"root","children":[{"type":"code","lang":"a` + "`" + `b","value":"~~~"}]}`, "~~~~a`b\n~~~\n~~~~\n"},
+	{"1", `{"type":"root","children":[{"type":"heading","depth":2,"children":[{"type":"text","value":"foo"},{"type":"break"},{"type":"text","value":"bar"}]}]}`, "foo\nbar\n------\n"},
+	{"0", `{"type":"root","children":[{"type":"heading","depth":3,"children":[{"type":"text","value":"foo\n"},{"type":"emphasis","children":[{"type":"text","value":"bar"}]}]}]}`, "### foo *bar*\n"},
+}
+
+func TestMdastImport(t *testing.T) {
+	luteEngine := newMdastLute()
+	for _, test := range mdastImportTests {
+		markdown, err := luteEngine.Mdast2Md(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		if test.to != string(markdown) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, test.to, markdown)
+		}
+	}
+}
+
+func TestMdastJSON(t *testing.T) {
+	luteEngine := newMdastLute()
+	luteEngine.SetSourcePos(true)
+	data, err := luteEngine.Md2Mdast("", []byte("# foo {#bar}\n\n* [x] a ((20200101000000-abcdefg \"b\"))\n\n| a |\n| -: |\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	root := map[string]interface{}{}
+	if err = json.Unmarshal(data, &root); nil != err {
+		t.Fatal(err)
+	}
+	children := root["children"].([]interface{})
+	heading := children[0].(map[string]interface{})
+	if "heading" != heading["type"] || 1.0 != heading["depth"] || "bar" != heading["data"].(map[string]interface{})["id"] {
+		t.Fatalf("unexpected heading %v", heading)
+	}
+	start := heading["position"].(map[string]interface{})["start"].(map[string]interface{})
+	if 1.0 != start["line"] || 1.0 != start["column"] || 0.0 != start["offset"] {
+		t.Fatalf("unexpected heading position %v", heading["position"])
+	}
+
+	item := children[1].(map[string]interface{})["children"].([]interface{})[0].(map[string]interface{})
+	if true != item["checked"] {
+		t.Fatalf("unexpected list item %v", item)
+	}
+	para := item["children"].([]interface{})[0].(map[string]interface{})
+	text := para["children"].([]interface{})[0].(map[string]interface{})
+	ref := para["children"].([]interface{})[1].(map[string]interface{})
+	if "a " != text["value"] || "blockRef" != ref["type"] || "20200101000000-abcdefg" != ref["data"].(map[string]interface{})["id"] {
+		t.Fatalf("unexpected list item paragraph %v", para)
+	}
+
+	table := children[2].(map[string]interface{})
+	if "right" != table["align"].([]interface{})[0] {
+		t.Fatalf("unexpected table %v", table)
+	}
+
+	if _, err = luteEngine.Mdast2Md("", []byte(`{"type":"root","children":[{"type":"unknown"}]}`)); nil == err {
+		t.Fatal("unknown mdast node should be rejected")
+	}
+	if _, err = mdast.ToTree("", &mdast.Node{Type: "paragraph"}, luteEngine.ParseOptions); nil == err {
+		t.Fatal("mdast root node should be required")
+	}
+}
+
+func TestMdastSiYuan(t *testing.T) {
+	luteEngine := lute.NewWithFlavor(lute.FlavorSiYuan)
+	for _, test := range []parseTest{
+		{"1", "<<<<<<< HEAD\nfoo\n=======\nbar\n>>>>>>> branch\n{: id=\"20200101000000-abcdefg\"}\n\nbaz\n{: id=\"20200101000000-hijklmn\"}\n\n{: id=\"20200101000000-docdocd\" type=\"doc\"}\n", ""},
+		{"0", "{{select * from blocks}}\n{: id=\"20200101000000-abcdefg\"}\n\nfoo\n{: id=\"20200101000000-hijklmn\"}\n\n{: id=\"20200101000000-docdocd\" type=\"doc\"}\n", ""},
+	} {
+		tree := parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)
+		data, err := luteEngine.Md2Mdast(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] export failed: %s", test.name, err)
+		}
+		imported, err := luteEngine.Mdast2Tree(test.name, data)
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		// 块 IAL 需要保留在所属的块后面，不能多出文档 IAL
+		if expected, got := dumpIALs(tree.Root), dumpIALs(imported.Root); expected != got {
+			t.Fatalf("test case [%s] failed\nexpected\n%s\ngot\n%s", test.name, expected, got)
+		}
+	}
+}
+
+// dumpIALs 输出语法树中的块类型和块 IAL 节点内容，用于比较块 IAL 的位置。
+func dumpIALs(root *ast.Node) string {
+	buf := &strings.Builder{}
+	for n := root.FirstChild; nil != n; n = n.Next {
+		buf.WriteString(n.Type.String())
+		if ast.NodeKramdownBlockIAL == n.Type {
+			buf.WriteString(" " + strings.TrimSpace(n.TokensStr()))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,147 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+var pandocTests = []struct {
+	name     string
+	markdown string
+}{
+
+	{"5", "Foo *bar\nbaz*\n===\n\n~~~~ a`b\n~~~\n~~~~\n"},
+	{"4", "[ß] [x][Ss] ![y][ẞ]\n\n[SS]: /u \"t\"\n"},
+	{"3", "foo[^1]\n\n[^1]: one\n[^unused]: two [^nested]\n\n[^nested]: three\n"},
+	{"2", "a  b\n\n* [/] doing\n* [ ] todo\n"},
+	{"1", "{{{col\n> [!WARNING] 🔥 Title\n> body\n>\n> two\n\nfoo ==mark== #tag#\n}}}\n"},
+	{"0", "foo\nbar\n"},
+}
+
+func TestPandoc(t *testing.T) {
+	luteEngine := newMdastLute()
+	luteEngine.SetArbitraryTaskListItemMarker(true)
+	tests := pandocTests
+	for _, test := range mdastTests {
+		tests = append(tests, struct {
+			name     string
+			markdown string
+		}{"mdast-" + test.name, test.markdown})
+	}
+	for _, test := range tests {
+		data, err := luteEngine.Md2Pandoc(test.name, []byte(test.markdown))
+		if nil != err {
+			t.Fatalf("test case [%s] export failed: %s", test.name, err)
+		}
+		markdown, err := luteEngine.Pandoc2Md(test.name, data)
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		expected := luteEngine.FormatStr(test.name, test.markdown)
+		if expected != string(markdown) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\npandoc\n\t%s", test.name, expected, markdown, data)
+		}
+	}
+}
+
+func TestPandocIAL(t *testing.T) {
+	luteEngine := newMdastLute()
+	luteEngine.SetKramdownIAL(true)
+	markdown := "# Title {#hid}\n{: id=\"20200101000000-abcdefg\" custom-a=\"b\"}\n\nfoo\n{: id=\"20200101000000-hijklmn\"}\n\n* {: id=\"20200101000000-opqrstu\"}a\n  {: id=\"20200101000000-vwxyzab\"}\n{: id=\"20200101000000-cdefghi\"}\n\n\n{: id=\"20200101000000-docdocd\" title=\"doc\" type=\"doc\"}\n"
+	data, err := luteEngine.Md2Pandoc("", []byte(markdown))
+	if nil != err {
+		t.Fatal(err)
+	}
+	got, err := luteEngine.Pandoc2Md("", data)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected := luteEngine.FormatStr("", markdown); expected != string(got) {
+		t.Fatalf("expected\n\t%q\ngot\n\t%q\npandoc\n\t%s", expected, got, data)
+	}
+}
+
+func TestPandocJSON(t *testing.T) {
+	luteEngine := newMdastLute()
+	data, err := luteEngine.Md2Pandoc("", []byte("> [!TIP] Title\n> body\n\nfoo ((20200101000000-abcdefg \"anchor\")) #tag#\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`{"t":"Div","c":[["",["callout","tip"],[["callout-type","TIP"],["callout-icon","💡"]]],[{"t":"Div","c":[["",["title"],[]],[{"t":"Para","c":[{"t":"Str","c":"Title"}]}]]}`,
+		`{"t":"Span","c":[["",["block-ref"],[["ref-id","20200101000000-abcdefg"],["ref-subtype","s"]]],[{"t":"Str","c":"anchor"}]]}`,
+		`{"t":"Span","c":[["",["tag"],[]],[{"t":"Str","c":"tag"}]]}`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Fatalf("expected [%s] in\n%s", expected, data)
+		}
+	}
+
+	// pandoc -f gfm+alerts -t json 的输出
+	markdown, err := luteEngine.Pandoc2Md("", []byte(`{"pandoc-api-version":[1,23,1],"meta":{},"blocks":[`+
+		`{"t":"Div","c":[["",["note"],[]],[{"t":"Div","c":[["",["title"],[]],[{"t":"Para","c":[{"t":"Str","c":"Note"}]}]]},{"t":"Para","c":[{"t":"Str","c":"body"}]}]]},`+
+		`{"t":"Para","c":[{"t":"Str","c":"foo"},{"t":"Note","c":[{"t":"Para","c":[{"t":"Str","c":"bar"}]}]},{"t":"Space"},{"t":"Underline","c":[{"t":"Str","c":"*u*"}]}]}]}`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected := "> [!NOTE] Note\n> body\n\nfoo[^1] \\*u\\*\n\n[^1]: bar\n"; expected != string(markdown) {
+		t.Fatalf("expected\n\t%q\ngot\n\t%q", expected, markdown)
+	}
+
+	if _, err = luteEngine.Pandoc2Md("", []byte(`{"pandoc-api-version":[1,17],"meta":{},"blocks":[]}`)); nil == err {
+		t.Fatal("old pandoc api version should be rejected")
+	}
+	if _, err = luteEngine.Pandoc2Md("", []byte(`{"pandoc-api-version":[1,23,1],"meta":{},"blocks":[{"t":"Unknown"}]}`)); nil == err {
+		t.Fatal("unknown pandoc block should be rejected")
+	}
+}
+
+var pandocImportTests = []parseTest{
+
+	{"1", `[{"t":"CodeBlock","c":[["",["a` + "`" + `b"],[]],"foo"]}]`, "~~~a`b\nfoo\n~~~\n"},
+	{"0", `[{"t":"Header","c":[1,["",[],[]],[{"t":"Str","c":"foo"},{"t":"SoftBreak"},{"t":"Str","c":"bar"}]]},{"t":"Header","c":[4,["",[],[]],[{"t":"Str","c":"baz"},{"t":"LineBreak"},{"t":"Str","c":"qux"}]]}]`, "foo\nbar\n===\n\n#### baz qux\n"},
+}
+
+func TestPandocImport(t *testing.T) {
+	luteEngine := newMdastLute()
+	for _, test := range pandocImportTests {
+		markdown, err := luteEngine.Pandoc2Md(test.name, []byte(`{"pandoc-api-version":[1,23,1],"meta":{},"blocks":`+test.from+`}`))
+		if nil != err {
+			t.Fatalf("test case [%s] import failed: %s", test.name, err)
+		}
+		if test.to != string(markdown) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, test.to, markdown)
+		}
+	}
+}
+
+func TestPandocSiYuan(t *testing.T) {
+	luteEngine := lute.NewWithFlavor(lute.FlavorSiYuan)
+	luteEngine.SetYamlFrontMatter(true)
+	markdown := "---\ntitle: foo\n---\n{: id=\"20200101000000-yamlyam\"}\n\n{{select * from blocks}}\n{: id=\"20200101000000-abcdefg\"}\n\nfoo\n{: id=\"20200101000000-hijklmn\"}\n\n{: id=\"20200101000000-docdocd\" type=\"doc\"}\n"
+	tree := parse.Parse("", []byte(markdown), luteEngine.ParseOptions)
+	data, err := luteEngine.Md2Pandoc("", []byte(markdown))
+	if nil != err {
+		t.Fatal(err)
+	}
+	imported, err := luteEngine.Pandoc2Tree("", data)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if expected, got := dumpIALs(tree.Root), dumpIALs(imported.Root); expected != got {
+		t.Fatalf("expected\n%s\ngot\n%s\npandoc\n\t%s", expected, got, data)
+	}
+}
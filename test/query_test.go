@@ -0,0 +1,101 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/query"
+)
+
+type queryTest struct {
+	name     string
+	selector string
+	from     string
+	to       string // 匹配节点的类型和纯文本，使用 | 分隔
+}
+
+var queryTests = []queryTest{
+
+	{"19", "codeBlock > codeBlockCode:only-child, emphasis > text:first-child:last-child", "```go\nfoo\n```\n\n*bar*\n", "NodeCodeBlockCode:|NodeText:bar"},
+	{"18", "listItem:has(> paragraph > taskListItemMarker)", "* [ ] foo\n* bar\n  * [x] baz\n", "NodeListItem: foo|NodeListItem: baz"},
+	{"17", "heading:has(+ paragraph):not(:last-child)", "# a\n\nfoo\n\n## b\n\n> c\n\n### d\n", "NodeHeading:a"},
+	{"16", "blockquote paragraph:first-child", "> foo\n>\n> bar\n\nbaz\n", "NodeParagraph:foo"},
+	{"15", "paragraph ~ list, heading + paragraph", "# h\n\nfoo\n\nbar\n\n* a\n", "NodeParagraph:foo|NodeList:a"},
+	{"14", "listItem:nth-child(odd)", "1. a\n2. b\n3. c\n", "NodeListItem:a|NodeListItem:c"},
+	{"13", "listItem:nth-last-child(-n+2)", "* a\n* b\n* c\n", "NodeListItem:b|NodeListItem:c"},
+	{"12", "[title=\"a ]b\"]", "[x](/u \"a ]b\") [y](/v)\n", "NodeLink:x"},
+	{"11", "codeBlock[lang=go]", "```go linenums\nfoo\n```\n\n```js\nbar\n```\n", "NodeCodeBlock:"},
+	{"10", "link[href^=https]", "[a](https://b3log.org) [b](/foo)\n", "NodeLink:a"},
+	{"9", "callout[type=TIP] > paragraph", "> [!TIP]\n> foo\n\n> [!NOTE]\n> bar\n", "NodeParagraph:foo"},
+	{"8", "kramdownBlockIAL", "foo\n{: id=\"20200101000000-abcdefg\"}\n", "NodeKramdownBlockIAL:|NodeKramdownBlockIAL:"},
+	{"7", "*[ial-id] + *", "foo\n{: id=\"20200101000000-abcdefg\"}\n\nbar\n", "NodeParagraph:bar"},
+	{"6", "paragraph:empty", "foo\n\n> \n", "NodeParagraph:"},
+	{"5", "listItem[checked=true]", "* [x] a\n* [ ] b\n", "NodeListItem: a"},
+	{"4", "paragraph:first-child", "* foo\n\n  bar\n* baz\n\nqux\n", "NodeParagraph:foo|NodeParagraph:baz"},
+	{"3", "[ial-custom-foo=bar]", "foo\n{: custom-foo=\"bar\"}\n\nbaz\n{: custom-foo=\"baz\"}\n", "NodeParagraph:foo"},
+	{"2", "textMark[type~=block-ref]", "", "NodeTextMark:anchor"},
+	{"1", "list > listItem:has(taskListItemMarker)", "* [ ] foo\n* bar\n  * [x] baz\n", "NodeListItem: foo|NodeListItem:bar baz|NodeListItem: baz"},
+	{"0", "heading[level=2]", "# a\n\n## b\n\n### c\n\n## d\n", "NodeHeading:b|NodeHeading:d"},
+}
+
+func TestQuery(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetCallout(true)
+
+	for _, test := range queryTests {
+		var tree *parse.Tree
+		if "" == test.from {
+			tree = luteEngine.BlockDOM2Tree("<div data-node-id=\"20200101000000-abcdefg\" data-type=\"NodeParagraph\" class=\"p\"><div contenteditable=\"true\" spellcheck=\"false\">foo <span data-type=\"strong block-ref\" data-id=\"20200101000000-hijklmn\" data-subtype=\"s\">anchor</span> <span data-type=\"strong\">bar</span></div><div class=\"protyle-attr\" contenteditable=\"false\"></div></div>")
+		} else {
+			tree = parse.Parse("", []byte(test.from), luteEngine.ParseOptions)
+		}
+		selector, err := query.Compile(test.selector)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+
+		var matched []string
+		for _, n := range selector.QueryAll(tree.Root) {
+			matched = append(matched, n.Type.String()+":"+n.Text())
+		}
+		if got := strings.Join(matched, "|"); test.to != got {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, test.to, got)
+		}
+		if first := selector.Query(tree.Root); (nil == first) != ("" == test.to) {
+			t.Fatalf("test case [%s] failed: unexpected first match %v", test.name, first)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	tree := parse.Parse("", []byte("# foo\n"), lute.New().ParseOptions)
+	heading := tree.Root.FirstChild
+	if !query.MustCompile("document > heading:only-child").Match(heading) || query.MustCompile("heading:root").Match(heading) {
+		t.Fatal("unexpected match result")
+	}
+	if nodes, err := query.QueryAll(tree.Root, ":root, text"); nil != err || 2 != len(nodes) || ast.NodeDocument != nodes[0].Type || ast.NodeText != nodes[1].Type {
+		t.Fatalf("unexpected query result %v %v", nodes, err)
+	}
+}
+
+func TestQueryCompileError(t *testing.T) {
+	for _, selector := range []string{"", "foo", "heading[", "heading[level", "heading[level=]", "heading[level=\"2]", "[bar=1]", ":first", ":nth-child(x)", ":not(heading", "heading >", "heading,", "heading)", "heading!"} {
+		if _, err := query.Compile(selector); nil == err {
+			t.Fatalf("selector [%s] should be rejected", selector)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+type reparseTest struct {
+	name     string
+	from     string
+	offset   int
+	deleted  int
+	inserted string
+	added    int // 期望新解析的顶层节点数
+}
+
+var reparseTests = []reparseTest{
+
+	{"8", "# a\r\n\r\nfoo\r\n\r\nbar\r\n", 7, 3, "*baz*", 2},
+	{"7", "[x]\n\n[y]\n\n[x]: /u\n", 6, 0, "x", 2},
+	{"6", "[x]\n\n[y]\n\n[x]: /u\n", 15, 1, "v", 3},
+	{"5", "a\n\nb\n\nc\n\nd\n", 3, 0, "```\n", 2},
+	{"4", "a\n\nb\n\nc\n\nd\n", 3, 0, "```\n```\n", 3},
+	{"3", "foo\n\nbar\n\nbaz\n", 9, 0, "===\n", 2},
+	{"2", "foo\n\n# bar\n\nbaz\n", 4, 1, "", 2},
+	{"1", "foo\n\nbar\n", 9, 0, "\nbaz", 3},
+	{"0", "# a\n\nfoo\n\nbar\n\n- b\n", 6, 0, "x", 2},
+}
+
+func TestReparse(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	for _, test := range reparseTests {
+		tree := parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)
+		_, added, err := tree.Reparse(&parse.Edit{Offset: test.offset, Deleted: test.deleted, Inserted: []byte(test.inserted)})
+		if nil != err {
+			t.Fatalf("test case [%s] reparse failed: %s", test.name, err)
+		}
+
+		edited := test.from[:test.offset] + test.inserted + test.from[test.offset+test.deleted:]
+		expected := luteEngine.Tree2HTML(parse.Parse(test.name, []byte(edited), luteEngine.ParseOptions), luteEngine.RenderOptions, luteEngine.ParseOptions)
+		html := luteEngine.Tree2HTML(tree, luteEngine.RenderOptions, luteEngine.ParseOptions)
+		if expected != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\nedited markdown text\n\t%q", test.name, expected, html, edited)
+		}
+		if test.added != len(added) {
+			t.Fatalf("test case [%s] expected [%d] added nodes, got [%d]", test.name, test.added, len(added))
+		}
+	}
+}
+
+func TestReparseWithoutSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	tree := parse.Parse("", []byte("foo\n"), luteEngine.ParseOptions)
+	if _, _, err := tree.Reparse(&parse.Edit{Offset: 0, Inserted: []byte("bar")}); parse.ErrNoSource != err {
+		t.Fatalf("expected error [%v], got [%v]", parse.ErrNoSource, err)
+	}
+}
+
+func TestReparseRandomEdits(t *testing.T) {
+	original := "# Title\n\nfoo *bar*\nbaz\n\n> quote\n> - item\n\n- a\n- b\n\n  c\n\n```go\ncode\n```\n\n| x | y |\n| - | - |\n| 1 | 2 |\n\nsetext\n---\n\n[link][ref] and [^1]\n\n[ref]: /url\n[^1]: note\n"
+	snippets := []string{"", "\n", "\n\n", "#", "> ", "- ", "```", "*", "=", "|", "x", "[ref]", "    "}
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	luteEngine.SetFootnotes(true)
+	for seed := int64(0); seed < 10; seed++ {
+		md := original
+		random := rand.New(rand.NewSource(seed))
+		tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+		for i := 0; i < 1000; i++ {
+			offset := random.Intn(len(md) + 1)
+			deleted := random.Intn(min(4, len(md)-offset) + 1)
+			inserted := snippets[random.Intn(len(snippets))]
+			if _, _, err := tree.Reparse(&parse.Edit{Offset: offset, Deleted: deleted, Inserted: []byte(inserted)}); nil != err {
+				t.Fatalf("reparse failed: %s", err)
+			}
+			md = md[:offset] + inserted + md[offset+deleted:]
+
+			expected := luteEngine.Tree2HTML(parse.Parse("", []byte(md), luteEngine.ParseOptions), luteEngine.RenderOptions, luteEngine.ParseOptions)
+			html := luteEngine.Tree2HTML(tree, luteEngine.RenderOptions, luteEngine.ParseOptions)
+			if expected != html {
+				t.Fatalf("seed [%d] edit [%d] failed\nexpected\n\t%q\ngot\n\t%q\nmarkdown text\n\t%q", seed, i, expected, html, md)
+			}
+		}
+	}
+}
+
+var reparseKramdownBlockIALTests = []reparseTest{
+
+	{"4", "{{{row\nfoo\n{: id=\"a\"}\n}}}\n{: id=\"s\"}\n\nbar\n\nbaz\n", 38, 0, "q", 0},
+	{"3", "\n``\n>{: x\"}", 0, 3, "\n", 0},
+	{"2", "}\n\n{: w\n```i\n}", 12, 1, "```", 0},
+	{"1", "}\n>{: x\"}\now\n", 10, 3, "- ", 0},
+	{"0", "foo\n{: id=\"b\"}\n\nbar\n\nbaz\n", 16, 0, "q", 0},
+}
+
+func TestReparseKramdownBlockIAL(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	luteEngine.SetKramdownBlockIAL(true)
+	luteEngine.SetSuperBlock(true)
+	// 没有 IAL 的块会生成随机 ID 和更新时间，比较时去掉
+	nodeID := regexp.MustCompile(`\d{14}(-[0-9a-z]{7})?`)
+	render := func(tree *parse.Tree) string {
+		return nodeID.ReplaceAllString(luteEngine.Tree2HTML(tree, luteEngine.RenderOptions, luteEngine.ParseOptions), "")
+	}
+
+	for _, test := range reparseKramdownBlockIALTests {
+		tree := parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)
+		if _, _, err := tree.Reparse(&parse.Edit{Offset: test.offset, Deleted: test.deleted, Inserted: []byte(test.inserted)}); nil != err {
+			t.Fatalf("test case [%s] reparse failed: %s", test.name, err)
+		}
+
+		edited := test.from[:test.offset] + test.inserted + test.from[test.offset+test.deleted:]
+		if expected, html := render(parse.Parse(test.name, []byte(edited), luteEngine.ParseOptions)), render(tree); expected != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\nedited markdown text\n\t%q", test.name, expected, html, edited)
+		}
+	}
+
+	original := "foo\n{: id=\"b\"}\n\nbar\n\nbaz\n\n{{{row\nqux\n{: id=\"c\"}\n\n- a\n  {: id=\"d\"}\n{: id=\"e\"}\n}}}\n{: id=\"s\"}\n\n> quote\n{: id=\"f\"}\n\n# head\n{: id=\"g\"}\n"
+	snippets := []string{"", "\n", "\n\n", "#", "> ", "- ", "```", "q", "{: id=\"x\"}\n", "{{{row\n", "}}}\n", "    "}
+	for seed := int64(0); seed < 10; seed++ {
+		md := original
+		random := rand.New(rand.NewSource(seed))
+		tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+		for i := 0; i < 500; i++ {
+			offset := random.Intn(len(md) + 1)
+			deleted := random.Intn(min(4, len(md)-offset) + 1)
+			inserted := snippets[random.Intn(len(snippets))]
+			if _, _, err := tree.Reparse(&parse.Edit{Offset: offset, Deleted: deleted, Inserted: []byte(inserted)}); nil != err {
+				t.Fatalf("reparse failed: %s", err)
+			}
+			md = md[:offset] + inserted + md[offset+deleted:]
+
+			if expected, html := render(parse.Parse("", []byte(md), luteEngine.ParseOptions)), render(tree); expected != html {
+				t.Fatalf("seed [%d] edit [%d] failed\nexpected\n\t%q\ngot\n\t%q\nmarkdown text\n\t%q", seed, i, expected, html, md)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+var sanitizerPolicyTests = []parseTest{
+
+	{"9", "<details open ontoggle=\"alert(1)\"><summary>s</summary>d</details>", "<details open=\"\"><summary>s</summary>d</details>\n"},
+	{"8", "<svg><a xlink:href=\"javascript:alert(1)\"><text>x</text></a></svg>foo", "<p><a>x</a>foo</p>\n"},
+	{"7", "![a](javascript:alert(1))", "<p><img alt=\"a\" /></p>\n"},
+	{"6", "<img src=\"data:image/svg+xml;base64,PHN2Zz4=\" onerror=\"alert(1)\">", "<img>\n"},
+	{"5", "<a href=\"https://b3log.org\" target=\"_blank\">b3log</a>", "<p><a href=\"https://b3log.org\" target=\"_blank\" rel=\"noopener noreferrer\">b3log</a></p>\n"},
+	{"4", "<a href=\"java&#x09;script:alert(1)\">foo</a>", "<p><a>foo</a></p>\n"},
+	{"3", "<div onclick=\"alert(1)\" class=\"foo\"><p>bar</p></div>", "<div><p>bar</p></div>\n"},
+	{"2", "<script>alert(1)</script>\n\n<style>p{}</style>", ""},
+	{"1", "[foo](JavaScript:alert(1)) [bar](https://b3log.org) [baz](mailto:a@b3log.org)", "<p><a href=\"\">foo</a> <a href=\"https://b3log.org\">bar</a> <a href=\"mailto:a@b3log.org\">baz</a></p>\n"},
+	{"0", "<form action=\"/\"><input></form><b title=\"x\">bold</b>", "<b>bold</b>\n"},
+}
+
+func TestSanitizerPolicy(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSanitizePolicy(render.NewPolicy())
+
+	for _, test := range sanitizerPolicyTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var sanitizerPolicyCustomTests = []parseTest{
+
+	{"3", "<iframe src=\"https://www.youtube.com/embed/x\" srcdoc=\"<script>alert(1)</script>\"></iframe>", "<iframe src=\"https://www.youtube.com/embed/x\"></iframe>\n"},
+	{"2", "<iframe src=\"https://evil.com/\">fallback</iframe>", ""},
+	{"1", "<iframe srcdoc=\"<script>alert(1)</script>\"></iframe>", ""},
+	{"0", "<span style=\"color: red; background: url(javascript:alert(1)); position: fixed\">foo</span>", "<p><span style=\"color: red\">foo</span></p>\n"},
+}
+
+func TestSanitizerPolicyCustom(t *testing.T) {
+	policy := render.NewPolicy()
+	policy.Elements["iframe"] = []string{"src"}
+	policy.Elements["span"] = []string{"style"}
+	policy.StyleProperties = []string{"color"}
+	policy.IFrameHosts = []string{"www.youtube.com"}
+
+	luteEngine := lute.New()
+	luteEngine.SetSanitizePolicy(policy)
+	for _, test := range sanitizerPolicyCustomTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var sanitizerPolicyProtylePreviewTests = []parseTest{
+
+	{"4", "# foo\n{: id=\"20200101000000-abcdefg\" onclick=\"alert(1)\" custom-a=\"1\"}\n", "<h1 id=\"20200101000000-abcdefg\" custom-a=\"1\">foo</h1>\n"},
+	{"3", "> foo\n{: style=\"color: red; background: url(javascript:alert(1))\" href=\"javascript:alert(1)\"}\n", "<blockquote>\n<p>foo</p>\n</blockquote>\n"},
+	{"2", "*foo*{: onmouseover=\"alert(1)\" custom-b=\"2\"}\n{: onclick=\"alert(1)\" custom-a=\"1\"}\n", "<p custom-a=\"1\"><em custom-b=\"2\">foo</em></p>\n"},
+	{"1", "[foo](javascript:alert(1))", "<p><a href=\"\">foo</a></p>\n"},
+	{"0", "<div onmouseover=\"alert(1)\">foo</div>", "<div>foo</div>\n"},
+}
+
+func TestSanitizerPolicyProtylePreview(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSanitizePolicy(render.NewPolicy())
+	for _, test := range sanitizerPolicyProtylePreviewTests {
+		html := luteEngine.ProtylePreviewStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestPolicySanitize(t *testing.T) {
+	output := render.NewPolicy().Sanitize("<a href=\"http://b3log.org\" target=\"_blank\" rel=\"nofollow\" onclick=\"alert(1)\">foo</a><script>alert(1)</script>")
+	if "<a href=\"http://b3log.org\" target=\"_blank\" rel=\"nofollow noopener noreferrer\">foo</a>" != output {
+		t.Fatalf("policy sanitize failed: %s", output)
+	}
+}
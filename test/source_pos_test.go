@@ -0,0 +1,101 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var sourcePosTests = []parseTest{
+
+	{"7", "- a\n\n      code\n", "<ul data-sourcepos=\"1:1-3:10\">\n<li data-sourcepos=\"1:1-3:10\">\n<p data-sourcepos=\"1:3-1:3\">a</p>\n<pre data-sourcepos=\"3:3-3:10\"><code>code\n</code></pre>\n</li>\n</ul>\n"},
+	{"6", "    foo\n", "<pre data-sourcepos=\"1:1-1:7\"><code>foo\n</code></pre>\n"},
+	{"5", "foo\n| a |\n| - |\n", "<p data-sourcepos=\"1:1-1:3\">foo</p>\n<table data-sourcepos=\"2:1-3:5\">\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n</table>\n"},
+	{"4", "```go\ncode\n```\n\n---\n", "<pre data-sourcepos=\"1:1-3:3\"><code class=\"language-go\">code\n</code></pre>\n<hr data-sourcepos=\"5:1-5:3\" />\n"},
+	{"3", "- a\n- b\n\n  c\n", "<ul data-sourcepos=\"1:1-4:3\">\n<li data-sourcepos=\"1:1-1:3\">\n<p data-sourcepos=\"1:3-1:3\">a</p>\n</li>\n<li data-sourcepos=\"2:1-4:3\">\n<p data-sourcepos=\"2:3-2:3\">b</p>\n<p data-sourcepos=\"4:3-4:3\">c</p>\n</li>\n</ul>\n"},
+	{"2", "> foo\nbar\n", "<blockquote data-sourcepos=\"1:1-2:3\">\n<p data-sourcepos=\"1:3-2:3\">foo\nbar</p>\n</blockquote>\n"},
+	{"1", "# foo\r\n\r\nbar\r\n", "<h1 data-sourcepos=\"1:1-1:5\">foo</h1>\n<p data-sourcepos=\"3:1-3:3\">bar</p>\n"},
+	{"0", "foo", "<p data-sourcepos=\"1:1-1:3\">foo</p>\n"},
+}
+
+func TestSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetSoftBreak2HardBreak(false)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	for _, test := range sourcePosTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var sourcePosHighlightTests = []parseTest{
+
+	{"1", "\tfoo\n", "<pre data-sourcepos=\"1:1-1:4\"><code class=\"highlight-chroma\"><span class=\"highlight-line\"><span class=\"highlight-cl\">foo\n</span></span></code></pre>\n"},
+	{"0", "```go\ncode\n```\n", "<pre data-sourcepos=\"1:1-3:3\"><code class=\"language-go highlight-chroma\"><span class=\"highlight-line\"><span class=\"highlight-cl\"><span class=\"highlight-nx\">code</span><span class=\"highlight-w\">\n</span></span></span></code></pre>\n"},
+}
+
+func TestSourcePosHighlight(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	for _, test := range sourcePosHighlightTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestSourcePosInline(t *testing.T) {
+	md := "# Hi *there*\r\n\r\n> quote\n> more **b** [link](/url)\n"
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+
+	expected := map[ast.NodeType][]string{
+		ast.NodeEmphasis:            {"*there*"},
+		ast.NodeEmA6kOpenMarker:     {"*"},
+		ast.NodeStrong:              {"**b**"},
+		ast.NodeStrongA6kOpenMarker: {"**"},
+		ast.NodeLink:                {"[link](/url)"},
+	}
+	got := map[ast.NodeType][]string{}
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || nil == n.Pos {
+			return ast.WalkContinue
+		}
+		if _, ok := expected[n.Type]; ok {
+			got[n.Type] = append(got[n.Type], md[n.Pos.StartOffset:n.Pos.EndOffset])
+		}
+		return ast.WalkContinue
+	})
+	for typ, sources := range expected {
+		if len(sources) != len(got[typ]) {
+			t.Fatalf("node type [%s] expected %q, got %q", typ, sources, got[typ])
+		}
+		for i := range sources {
+			if sources[i] != got[typ][i] {
+				t.Fatalf("node type [%s] expected %q, got %q", typ, sources[i], got[typ][i])
+			}
+		}
+	}
+
+	strong := tree.Root.ChildByType(ast.NodeBlockquote).ChildByType(ast.NodeParagraph).ChildByType(ast.NodeStrong)
+	if "4:8-4:12" != strong.Pos.String() {
+		t.Fatalf("expected strong source pos [4:8-4:12], got [%s]", strong.Pos.String())
+	}
+}
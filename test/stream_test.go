@@ -0,0 +1,116 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+)
+
+var streamTests = []parseTest{
+
+	{"6", "[foo]\n\n[foo]: /url\n", "<p>[foo]</p>\n"},
+	{"5", "[foo]: /url\n\n[foo]\n", "<p><a href=\"/url\">foo</a></p>\n"},
+	{"4", "[^1]: note\n\nfoo[^1]\n\nbar\n", "<p>foo<sup class=\"footnotes-ref\" id=\"footnotes-ref-1\"><a href=\"#footnotes-def-1\">1</a></sup></p>\n<p>bar</p>\n<div class=\"footnotes-defs-div\"><hr class=\"footnotes-defs-hr\" />\n<ol class=\"footnotes-defs-ol\"><li id=\"footnotes-def-1\"><p>note <a href=\"#footnotes-ref-1\" class=\"vditor-footnotes__goto-ref\">↩</a></p>\n</li>\n</ol></div>"},
+	{"3", "foo\rbar\r\n===\r\n\r\n\u0000", "<h1>foo<br />\nbar</h1>\n<p>�</p>\n"},
+	{"2", "| a |\n| - |\n| b |\n\n- c\n- d\n\n  e\n", "<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>b</td>\n</tr>\n</tbody>\n</table>\n<ul>\n<li>\n<p>c</p>\n</li>\n<li>\n<p>d</p>\n<p>e</p>\n</li>\n</ul>\n"},
+	{"1", "# foo\n```\ncode\n", "<h1>foo</h1>\n<pre><code>code\n</code></pre>\n"},
+	{"0", "", ""},
+}
+
+func TestMarkdownTo(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetFootnotes(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	for _, test := range streamTests {
+		buf := &bytes.Buffer{}
+		if err := luteEngine.MarkdownTo(buf, iotest.OneByteReader(strings.NewReader(test.from))); nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if html := buf.String(); test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestMarkdownToSpec(t *testing.T) {
+	data, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: %s", err.Error())
+	}
+
+	var testcases []testcase
+	if err = json.Unmarshal(data, &testcases); nil != err {
+		t.Fatalf("read spec test case failed: %s", err.Error())
+	}
+
+	luteEngine := lute.New()
+	for _, test := range testcases {
+		if strings.Contains(test.Markdown, "]:") {
+			// 流式处理时链接引用定义只对其后出现的引用生效
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err := luteEngine.MarkdownTo(buf, strings.NewReader(test.Markdown)); nil != err {
+			t.Fatalf("spec example [%d] failed: %s", test.Example, err)
+		}
+		if expected := luteEngine.MarkdownStr("", test.Markdown); expected != buf.String() {
+			t.Fatalf("spec example [%d] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.Example, expected, buf.String(), test.Markdown)
+		}
+	}
+}
+
+func TestMarkdownToReadError(t *testing.T) {
+	luteEngine := lute.New()
+	readErr := errors.New("read error")
+	if err := luteEngine.MarkdownTo(&bytes.Buffer{}, iotest.ErrReader(readErr)); readErr != err {
+		t.Fatalf("expected error [%v], got [%v]", readErr, err)
+	}
+}
+
+func TestMarkdownToContext(t *testing.T) {
+	for _, test := range limitTests {
+		luteEngine := lute.New()
+		test.setLimit(luteEngine)
+		buf := &bytes.Buffer{}
+		err := luteEngine.MarkdownToContext(context.Background(), buf, strings.NewReader(test.from))
+		if !errors.Is(err, test.err) {
+			t.Fatalf("test case [%s] failed\nexpected error\n\t%v\ngot\n\t%v", test.name, test.err, err)
+		}
+		if nil != err {
+			continue
+		}
+		if expected := lute.New().MarkdownStr(test.name, test.from); expected != buf.String() {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, expected, buf.String())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := lute.New().MarkdownToContext(ctx, &bytes.Buffer{}, strings.NewReader("foo")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	luteEngine := lute.New()
+	luteEngine.Md2HTMLRendererFuncs[ast.NodeEmphasis] = boomRenderer
+	err := luteEngine.MarkdownToContext(context.Background(), &bytes.Buffer{}, strings.NewReader("foo\n\nbar *baz*\n"))
+	checkNodeError(t, "markdownto", err, ast.NodeEmphasis, "")
+}
@@ -0,0 +1,213 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// containerSyntax 是 ::: name 开始、::: 结束的容器块，使用 NodeBlockquote 承载子块。
+var containerSyntax = &parse.BlockSyntax{
+	Name:    "container",
+	Markers: []byte(":"),
+	Start: func(context *parse.Context, container *ast.Node) int {
+		if 3 < context.Indent() {
+			return 0
+		}
+		context.AdvanceNextNonspace()
+		line := context.Line()
+		if !bytes.HasPrefix(line, []byte(":::")) {
+			return 0
+		}
+		name := bytes.TrimSpace(line[3:])
+		if 1 > len(name) {
+			return 0
+		}
+
+		node := context.OpenBlock(ast.NodeBlockquote)
+		node.CustomBlockInfo = string(name)
+		context.Advance(len(line) - 1)
+		return 1
+	},
+	Continue: func(context *parse.Context, node *ast.Node) int {
+		if last := node.LastChild; nil != last && !last.Close && ast.NodeBlockquote == last.Type && "" != last.CustomBlockInfo {
+			// 结束行优先留给嵌套的容器块
+			return 0
+		}
+		if 3 >= context.Indent() {
+			if line := bytes.TrimSpace(context.Line()); bytes.Equal(line, []byte(":::")) {
+				return 2
+			}
+		}
+		return 0
+	},
+}
+
+// leafSyntax 是 %%% info 开始、%%% 结束的叶子块，使用 NodeCustomBlock 承载文本行。
+var leafSyntax = &parse.BlockSyntax{
+	Name:    "leaf",
+	Markers: []byte("%"),
+	Start: func(context *parse.Context, container *ast.Node) int {
+		context.AdvanceNextNonspace()
+		line := context.Line()
+		if !bytes.HasPrefix(line, []byte("%%%")) {
+			return 0
+		}
+
+		node := context.OpenBlock(ast.NodeCustomBlock)
+		node.CustomBlockInfo = string(bytes.TrimSpace(line[3:]))
+		context.Advance(3)
+		return 2
+	},
+	Continue: func(context *parse.Context, node *ast.Node) int {
+		if bytes.Equal(bytes.TrimSpace(context.Line()), []byte("%%%")) {
+			return 2
+		}
+		return 0
+	},
+}
+
+// wikiLinkSyntax 是 [[target]] 形式的行级语法，生成 NodeCustomInline。
+var wikiLinkSyntax = &parse.InlineSyntax{
+	Name:     "wikilink",
+	Triggers: []byte("["),
+	Parse: func(block *ast.Node, tokens []byte) (*ast.Node, int) {
+		if !bytes.HasPrefix(tokens, []byte("[[")) {
+			return nil, 0
+		}
+		end := bytes.Index(tokens, []byte("]]"))
+		if 2 >= end || bytes.ContainsAny(tokens[2:end], "[\n") {
+			return nil, 0
+		}
+		return &ast.Node{Type: ast.NodeCustomInline, CustomBlockInfo: string(tokens[2:end])}, end + 2
+	},
+}
+
+// mentionSyntax 是 @name 形式的行级语法，生成已有的 NodeStrong 节点。
+var mentionSyntax = &parse.InlineSyntax{
+	Name:     "mention",
+	Triggers: []byte("@"),
+	Parse: func(block *ast.Node, tokens []byte) (*ast.Node, int) {
+		i := 1
+		for ; i < len(tokens) && ('a' <= tokens[i] && 'z' >= tokens[i]); i++ {
+		}
+		if 2 > i {
+			return nil, 0
+		}
+		strong := &ast.Node{Type: ast.NodeStrong}
+		strong.AppendChild(&ast.Node{Type: ast.NodeStrongA6kOpenMarker, Tokens: []byte("**")})
+		strong.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: tokens[:i]})
+		strong.AppendChild(&ast.Node{Type: ast.NodeStrongA6kCloseMarker, Tokens: []byte("**")})
+		return strong, i
+	},
+}
+
+func newSyntaxLute() *lute.Lute {
+	luteEngine := lute.New()
+	luteEngine.AddBlockSyntax(containerSyntax)
+	luteEngine.AddBlockSyntax(leafSyntax)
+	luteEngine.AddInlineSyntax(wikiLinkSyntax)
+	luteEngine.AddInlineSyntax(mentionSyntax)
+	luteEngine.Md2HTMLRendererFuncs[ast.NodeBlockquote] = func(node *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if "" == node.CustomBlockInfo {
+			if entering {
+				return "<blockquote>\n", ast.WalkContinue
+			}
+			return "</blockquote>\n", ast.WalkContinue
+		}
+		if entering {
+			return "<div class=\"" + node.CustomBlockInfo + "\">\n", ast.WalkContinue
+		}
+		return "</div>\n", ast.WalkContinue
+	}
+	return luteEngine
+}
+
+var syntaxTests = []parseTest{
+
+	{"7", "a:b @ c [[]] [foo](bar)\n", "<p>a:b @ c [[]] <a href=\"bar\">foo</a></p>\n"},
+	{"6", "hi @vanessa and @\n", "<p>hi <strong>@vanessa</strong> and @</p>\n"},
+	{"5", "see [[foo bar]] and *[[baz]]*\n", "<p>see <span data-type=\"NodeCustomInline\" data-info=\"foo bar\">[[foo bar]]</span> and <em><span data-type=\"NodeCustomInline\" data-info=\"baz\">[[baz]]</span></em></p>\n"},
+	{"4", "%%% chart\nfoo\n  bar\n%%%\nbaz\n", "<div data-type=\"NodeCustomBlock\" data-info=\"chart\" data-content=\"foo\n  bar\n\"></div>\n<p>baz</p>\n"},
+	{"3", "> ::: tip\n> foo\n> :::\n", "<blockquote>\n<div class=\"tip\">\n<p>foo</p>\n</div>\n</blockquote>\n"},
+	{"2", "::: outer\n::: inner\nfoo\n:::\n:::\n", "<div class=\"outer\">\n<div class=\"inner\">\n<p>foo</p>\n</div>\n</div>\n"},
+	{"1", "::: warning\n# foo\n\n* bar\n:::\nbaz\n", "<div class=\"warning\">\n<h1>foo</h1>\n<ul>\n<li>bar</li>\n</ul>\n</div>\n<p>baz</p>\n"},
+	{"0", ":::\nfoo\n", "<p>:::<br />\nfoo</p>\n"},
+}
+
+func TestSyntax(t *testing.T) {
+	luteEngine := newSyntaxLute()
+	for _, test := range syntaxTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestSyntaxFormat(t *testing.T) {
+	luteEngine := newSyntaxLute()
+	formatted := luteEngine.FormatStr("", "see [[foo bar]] @vanessa")
+	if "see [[foo bar]] **@vanessa**\n" != formatted {
+		t.Fatalf("unexpected formatted: %q", formatted)
+	}
+}
+
+func TestSyntaxFinalize(t *testing.T) {
+	var finalized []string
+	syntax := *leafSyntax
+	syntax.Finalize = func(context *parse.Context, node *ast.Node) {
+		finalized = append(finalized, node.CustomBlockInfo)
+		node.Type = ast.NodeCodeBlock
+		node.AppendChild(&ast.Node{Type: ast.NodeCodeBlockCode, Tokens: bytes.TrimSpace(node.Tokens)})
+		node.Tokens = nil
+	}
+
+	options := parse.NewOptions()
+	options.BlockSyntaxes = []*parse.BlockSyntax{&syntax}
+	tree := parse.Parse("", []byte("%%% info\nfoo\n"), options)
+	if 1 != len(finalized) || "info" != finalized[0] {
+		t.Fatalf("unexpected finalized: %v", finalized)
+	}
+	renderOptions := render.NewOptions()
+	renderOptions.CodeSyntaxHighlight = false
+	html := string(render.NewHtmlRenderer(tree, renderOptions, options).Render())
+	if "<pre><code>info\nfoo</code></pre>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+}
+
+func TestSyntaxStartRollback(t *testing.T) {
+	// 前进后不匹配的扩展不应该影响内置的起始判断
+	greedy := &parse.BlockSyntax{
+		Name: "greedy",
+		Start: func(context *parse.Context, container *ast.Node) int {
+			context.AdvanceNextNonspace()
+			context.Advance(1)
+			return 0
+		},
+	}
+	options := parse.NewOptions()
+	options.BlockSyntaxes = []*parse.BlockSyntax{greedy}
+	tree := parse.Parse("", []byte("    code\n# foo\n> bar\n"), options)
+	renderOptions := render.NewOptions()
+	renderOptions.CodeSyntaxHighlight = false
+	html := string(render.NewHtmlRenderer(tree, renderOptions, options).Render())
+	if "<pre><code>code\n</code></pre>\n<h1>foo</h1>\n<blockquote>\n<p>bar</p>\n</blockquote>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+}
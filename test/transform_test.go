@@ -0,0 +1,151 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+// rewriteLinkDest 将链接地址中的 http:// 改写为 https://。
+func rewriteLinkDest(tree *parse.Tree) error {
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeLinkDest == n.Type {
+			n.Tokens = bytes.Replace(n.Tokens, []byte("http://"), []byte("https://"), 1)
+		}
+		return ast.WalkContinue
+	})
+	return nil
+}
+
+// numberHeadings 为二级标题添加编号。
+func numberHeadings(tree *parse.Tree) error {
+	i := 0
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeHeading == n.Type && 2 == n.HeadingLevel {
+			i++
+			n.PrependChild(&ast.Node{Type: ast.NodeText, Tokens: []byte(strconv.Itoa(i) + ". ")})
+		}
+		return ast.WalkContinue
+	})
+	return nil
+}
+
+var transformTests = []parseTest{
+
+	{"2", "## foo\n\n## bar\n", "<h2 id=\"1--foo\">1. foo</h2>\n<h2 id=\"2--bar\">2. bar</h2>\n"},
+	{"1", "[foo](http://b3log.org)\n", "<p><a href=\"https://b3log.org\">foo</a></p>\n"},
+	{"0", "foo\n", "<p>foo</p>\n"},
+}
+
+func TestTransform(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.AddTransform(0, rewriteLinkDest)
+	luteEngine.AddTransform(0, numberHeadings)
+
+	for _, test := range transformTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+
+	formatted := luteEngine.FormatStr("", "## foo\n\n[bar](http://b3log.org)\n")
+	if "## 1. foo\n\n[bar](https://b3log.org)\n" != formatted {
+		t.Fatalf("format transform failed: %q", formatted)
+	}
+
+	blockDOM := luteEngine.Md2BlockDOM("## foo", false)
+	if !strings.Contains(blockDOM, "1. foo") {
+		t.Fatalf("block DOM transform failed: %s", blockDOM)
+	}
+
+	var html strings.Builder
+	if err := luteEngine.MarkdownTo(&html, strings.NewReader("## foo\n\n[bar](http://b3log.org)\n")); nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), "1. foo") || !strings.Contains(html.String(), "https://b3log.org") {
+		t.Fatalf("stream transform failed: %s", html.String())
+	}
+}
+
+func TestTransformOrder(t *testing.T) {
+	var order []string
+	record := func(name string) lute.Transform {
+		return func(tree *parse.Tree) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	luteEngine := lute.New()
+	luteEngine.AddTransform(10, record("c"))
+	luteEngine.AddTransform(-1, record("a"))
+	luteEngine.AddTransform(10, record("d"))
+	luteEngine.AddTransform(0, record("b"))
+	luteEngine.MarkdownStr("", "foo")
+	if "a b c d" != strings.Join(order, " ") {
+		t.Fatalf("unexpected transform order: %v", order)
+	}
+
+	order = nil
+	luteEngine.ClearTransforms()
+	luteEngine.MarkdownStr("", "foo")
+	if 0 != len(order) {
+		t.Fatalf("transforms should be cleared: %v", order)
+	}
+}
+
+func TestTransformError(t *testing.T) {
+	called := false
+	luteEngine := lute.New()
+	luteEngine.AddTransform(0, func(tree *parse.Tree) error {
+		return errors.New("transform failed")
+	})
+	luteEngine.AddTransform(1, func(tree *parse.Tree) error {
+		called = true
+		return nil
+	})
+
+	for name, fn := range map[string]func(){
+		"markdown":    func() { luteEngine.MarkdownStr("", "foo") },
+		"format":      func() { luteEngine.FormatStr("", "foo") },
+		"md2blockdom": func() { luteEngine.Md2BlockDOM("foo", true) },
+		"json":        func() { luteEngine.RenderJSON("foo") },
+	} {
+		if err := panicError(fn); nil == err || "transform failed" != err.Error() {
+			t.Fatalf("%s should panic with the transform error, got %v", name, err)
+		}
+	}
+	if html, err := luteEngine.MarkdownContext(context.Background(), "", []byte("foo")); nil != html || nil == err || "transform failed" != err.Error() {
+		t.Fatalf("unexpected result: %q %v", html, err)
+	}
+	if called {
+		t.Fatalf("transform after a failed one should not be called")
+	}
+
+	tree := parse.Parse("", []byte("foo"), luteEngine.ParseOptions)
+	if err := luteEngine.ApplyTransforms(tree); nil == err || "transform failed" != err.Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := luteEngine.MarkdownTo(&strings.Builder{}, strings.NewReader("foo")); nil == err {
+		t.Fatalf("stream should return transform error")
+	}
+}
@@ -0,0 +1,140 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var wikiLinkTests = []parseTest{
+
+	{"5", "[[a\nb]] [[a]b]] [[]] [[ | x]]\n", "<p>[[a<br />\nb]] [[a]b]] [[]] [[ | x]]</p>\n"},
+	{"4", "[[<script>]]\n", "<p><a href=\"%3Cscript%3E\" class=\"wikilink\" data-wikilink=\"&lt;script&gt;\">&lt;script&gt;</a></p>\n"},
+	{"3", "| a |\n|---|\n| [[P\\|al]] |\n", "<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td><a href=\"P\" class=\"wikilink\" data-wikilink=\"P|al\">al</a></td>\n</tr>\n</tbody>\n</table>\n"},
+	{"2", "![[cat.png]] ![[doc.pdf#p|x]]\n", "<p><img src=\"cat.png\" alt=\"cat.png\" class=\"wikilink-embed\" data-wikilink=\"cat.png\" /> <a href=\"doc.pdf#p\" class=\"wikilink wikilink-embed\" data-wikilink=\"doc.pdf#p|x\">x</a></p>\n"},
+	{"1", "see [[Page#Head|Alias]] and [[#Local]]\n", "<p>see <a href=\"Page#Head\" class=\"wikilink\" data-wikilink=\"Page#Head|Alias\">Alias</a> and <a href=\"#Local\" class=\"wikilink\" data-wikilink=\"#Local\">Local</a></p>\n"},
+	{"0", "[[My Page]]\n", "<p><a href=\"My%20Page\" class=\"wikilink\" data-wikilink=\"My Page\">My Page</a></p>\n"},
+}
+
+func TestWikiLink(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetWikiLink(true)
+	for _, test := range wikiLinkTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+
+	luteEngine = lute.New()
+	html := luteEngine.MarkdownStr("", "[[Page]]\n")
+	if "<p>[[Page]]</p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+}
+
+var wikiLinkResolverTests = []parseTest{
+
+	{"1", "[[xmissing|M]] ![[xmissing.png]]\n", "<p><a class=\"wikilink wikilink-broken\" data-wikilink=\"xmissing|M\">M</a> <a class=\"wikilink wikilink-embed wikilink-broken\" data-wikilink=\"xmissing.png\">xmissing.png</a></p>\n"},
+	{"0", "[[Page#H]] ![[a.png]]\n", "<p><a href=\"/wiki/Page#H\" class=\"wikilink\" data-wikilink=\"Page#H\">Page &gt; H</a> <img src=\"/wiki/a.png\" alt=\"a.png\" class=\"wikilink-embed\" data-wikilink=\"a.png\" /></p>\n"},
+}
+
+func TestWikiLinkResolver(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetWikiLink(true)
+	luteEngine.SetWikiLinkResolver(func(target, heading string) (string, bool) {
+		if strings.HasPrefix(target, "x") {
+			return "", false
+		}
+		dest := "/wiki/" + target
+		if "" != heading {
+			dest += "#" + heading
+		}
+		return dest, true
+	})
+	for _, test := range wikiLinkResolverTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+
+	luteEngine.SetSanitize(true)
+	luteEngine.SetWikiLinkResolver(func(target, heading string) (string, bool) {
+		return "javascript:alert(1)", true
+	})
+	html := luteEngine.MarkdownStr("", "[[Page]]\n")
+	if "<p><a class=\"wikilink wikilink-broken\" data-wikilink=\"Page\">Page</a></p>\n" != html {
+		t.Fatalf("unexpected html: %q", html)
+	}
+}
+
+var wikiLinkFormatTests = []parseTest{
+
+	{"2", "| a |\n|---|\n| [[P \\| al]] |\n", "| a         |\n| --------- |\n| [[P\\|al]] |\n"},
+	{"1", "![[ cat.png ]] and ![[doc.pdf#p|x]]\n", "![[cat.png]] and ![[doc.pdf#p|x]]\n"},
+	{"0", "see [[ Page # Head | Alias ]] and [[#Local]]\n", "see [[Page#Head|Alias]] and [[#Local]]\n"},
+}
+
+func TestWikiLinkFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetWikiLink(true)
+	for _, test := range wikiLinkFormatTests {
+		formatted := luteEngine.FormatStr(test.name, test.from)
+		if test.to != formatted {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, formatted, test.from)
+		}
+	}
+}
+
+var wikiLinkHTML2MdTests = []parseTest{
+
+	{"2", "| a |\n|---|\n| [[P\\|al]] |\n", "| a         |\n| ----------- |\n| [[P\\|al]] |\n"},
+	{"1", "![[cat.png]] ![[doc.pdf#p|x]]\n", "![[cat.png]] ![[doc.pdf#p|x]]\n"},
+	{"0", "see [[Page#Head|Alias]] and [[#Local]]\n", "see [[Page#Head|Alias]] and [[#Local]]\n"},
+}
+
+func TestWikiLinkHTML2Md(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetWikiLink(true)
+	for _, test := range wikiLinkHTML2MdTests {
+		md, err := luteEngine.HTML2Markdown(luteEngine.MarkdownStr(test.name, test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != md {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, md, test.from)
+		}
+	}
+}
+
+func TestWikiLinkProtyle(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetProtyleWYSIWYG(true)
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetWikiLink(true)
+
+	dom := luteEngine.Md2BlockDOM("a [[P|x]] and ![[cat.png]]\n", true)
+	if !strings.Contains(dom, "a [[P|x]] and ![[cat.png]]") {
+		t.Fatalf("wikilink source not found in dom: %s", dom)
+	}
+
+	kramdown := luteEngine.BlockDOM2Md(dom)
+	if !strings.HasPrefix(kramdown, "a [[P|x]] and ![[cat.png]]\n") {
+		t.Fatalf("unexpected kramdown: %q", kramdown)
+	}
+	if again := luteEngine.Md2BlockDOM(kramdown, true); dom != again {
+		t.Fatalf("expected\n\t%s\ngot\n\t%s\nkramdown\n\t%q", dom, again, kramdown)
+	}
+}
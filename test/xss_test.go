@@ -0,0 +1,264 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// xssVectors 是已知的 XSS 攻击向量，打开 Sanitize 后渲染结果中不能包含可执行的内容。
+var xssVectors = []string{
+	// 链接地址中的 javascript:
+	"[foo](javascript:alert(1))",
+	"[foo](JaVaScRiPt:alert(1))",
+	"[foo]( javascript:alert(1) )",
+	"[foo](<javascript:alert(1)>)",
+	"[foo](java&#x09;script:alert(1))",
+	"[foo](java&#115;cript:alert(1))",
+	"[foo](&#106;avascript:alert(1))",
+	"[foo](vbscript:msgbox(1))",
+	"[foo][bar]\n\n[bar]: javascript:alert(1)",
+	"<javascript:alert(1)>",
+	"<a href=\"javascript:alert(1)\">foo</a>",
+	"<a href=\"  javascript:alert(1)\">foo</a>",
+	"<a href=\"java\tscript:alert(1)\">foo</a>",
+	"<a href=\"jav&#x0A;ascript:alert(1)\">foo</a>",
+	"<a href=\"&#0000106&#0000097&#0000118&#0000097&#0000115&#0000099&#0000114&#0000105&#0000112&#0000116&#0000058alert(1)\">foo</a>",
+	"<a href=\"&Tab;javascript:alert(1)\">foo</a>",
+	"<form action=\"javascript:alert(1)\"><button>foo</button></form>",
+	"<form><button formaction=\"javascript:alert(1)\">foo</button></form>",
+	"<math><a xlink:href=\"javascript:alert(1)\">foo</a></math>",
+	// SVG
+	"<svg onload=alert(1)>",
+	"<svg/onload=alert(1)>",
+	"<svg><script>alert(1)</script></svg>",
+	"<svg><animate onbegin=alert(1) attributeName=x dur=1s>",
+	"<svg><a xlink:href=\"javascript:alert(1)\"><text x=\"20\" y=\"20\">foo</text></a></svg>",
+	"<svg><set attributeName=\"onmouseover\" to=\"alert(1)\"/></svg>",
+	"<svg><use href=\"data:image/svg+xml;base64,PHN2ZyBpZD0neCcgeG1sbnM9J2h0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnJz48aW1hZ2UgaHJlZj0nMScgb25lcnJvcj0nYWxlcnQoMSknLz48L3N2Zz4=#x\"/></svg>",
+	// 图片和 data: URI
+	"![foo](data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9YWxlcnQoMSk+)",
+	"![foo](javascript:alert(1))",
+	"![foo](\"onerror=\"alert(1))",
+	"![foo](x \"\\\" onerror=\\\"alert(1)\")",
+	"<img src=x onerror=alert(1)>",
+	"<img src=\"x\" ONERROR=\"alert(1)\">",
+	"<img src=\"data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==\">",
+	"<img srcset=\"javascript:alert(1) 1x\">",
+	"<iframe src=\"data:text/html,<script>alert(1)</script>\"></iframe>",
+	"<iframe srcdoc=\"<script>alert(1)</script>\"></iframe>",
+	"<object data=\"javascript:alert(1)\"></object>",
+	"<embed src=\"javascript:alert(1)\">",
+	"<video><source onerror=\"alert(1)\"></video>",
+	"<video poster=javascript:alert(1)></video>",
+	"<meta http-equiv=\"refresh\" content=\"0;url=javascript:alert(1)\">",
+	"<base href=\"javascript:alert(1)//\">",
+	"<link rel=\"import\" href=\"data:text/html,<script>alert(1)</script>\">",
+	// 脚本和样式
+	"<script>alert(1)</script>",
+	"<SCRIPT SRC=//xss.rocks/.j></SCRIPT>",
+	"<scr<script>ipt>alert(1)</script>",
+	"<<script>script>alert(1)</script>",
+	"<style>@import 'javascript:alert(1)';</style>",
+	"<div style=\"background:url(javascript:alert(1))\">foo</div>",
+	"<div style=\"width: expression(alert(1))\">foo</div>",
+	"<body onload=alert(1)>",
+	"<details open ontoggle=alert(1)>",
+	"<div onmouseover\n=\"alert(1)\">foo</div>",
+	"<div/onclick=alert(1)>foo</div>",
+	"foo <span onclick=alert(1)>bar</span>",
+	"<!--<img src=\"--><img src=x onerror=alert(1)//\">",
+	"<![CDATA[<img src=x onerror=alert(1)>]]>",
+	// 基于 noscript、template 等的 mutation XSS
+	"<noscript><p title=\"</noscript><img src=x onerror=alert(1)>\"></noscript>",
+	"<template><img src=x onerror=alert(1)></template>",
+	"<template><script>alert(1)</script></template>",
+	"<noembed><img title=\"</noembed><img src=x onerror=alert(1)>\"></noembed>",
+	"<noframes><img title=\"</noframes><img src=x onerror=alert(1)>\"></noframes>",
+	"<title><img title=\"</title><img src=x onerror=alert(1)>\"></title>",
+	"<textarea><img title=\"</textarea><img src=x onerror=alert(1)>\"></textarea>",
+	"<xmp><img title=\"</xmp><img src=x onerror=alert(1)>\"></xmp>",
+	"<svg><style><img src=x onerror=alert(1)></style></svg>",
+	"<math><mtext><table><mglyph><style><img src=x onerror=alert(1)></style></mglyph></table></mtext></math>",
+	"<svg></p><style><a id=\"</style><img src=1 onerror=alert(1)>\">",
+	"<form><math><mtext></form><form><mglyph><style></math><img src onerror=alert(1)>",
+	// IAL 中的属性
+	"foo\n{: onclick=\"alert(1)\"}",
+	"foo\n{: id=\"x\" title=\"\\\" onclick=\\\"alert(1)\"}",
+	"foo\n{: title=\"x\\\"><script>alert(1)</script>\"}",
+	"# foo\n{: id=\"x\" onmouseover=\"alert(1)\"}",
+	"# foo\n{: id=\"x\\\" onmouseover=\\\"alert(1)\"}",
+	"# foo\n{: id=\"x&quot; onmouseover=&quot;alert(1)\"}",
+	"[foo](bar){: href=\"javascript:alert(1)\"}",
+	"# foo\n{: id=\"x\" title=\"&quot; onmouseover=&quot;alert(1)\"}",
+	"![foo](bar){: onerror=\"alert(1)\"}",
+	"![foo](bar){: style=\"x\" onerror=\"alert(1)\"}",
+	"*foo*{: onclick=\"alert(1)\"}",
+	"* foo\n  {: onclick=\"alert(1)\"}",
+	"> foo\n{: onclick=\"alert(1)\"}",
+	"{: onclick=\"alert(1)\"}\n",
+	"# foo\n{: id=\"x\\\"><script>alert(1)</script>\"}",
+	"# foo\n{: id=\"&quot;&gt;&lt;script&gt;alert(1)&lt;/script&gt;\"}",
+	"# foo\n{: ONCLICK=\"alert(1)\"}",
+	"foo\n{: style=\"background:url(javascript:alert(1))\"}",
+	"foo\n{: href=\"javascript:alert(1)\"}",
+	"foo\n{: id=\"x\" on\nclick=\"alert(1)\"}",
+}
+
+func TestXSSVectors(t *testing.T) {
+	for _, engine := range xssEngines() {
+		for i, vector := range xssVectors {
+			output := engine.MarkdownStr("", vector)
+			if reason := executable(output); "" != reason {
+				t.Fatalf("xss vector [%d] is executable: %s\nvector\n\t%q\ngot\n\t%q", i, reason, vector, output)
+			}
+		}
+	}
+}
+
+func TestXSSHeadingIAL(t *testing.T) {
+	// 语法树变换或者 JSON 等方式构建的 IAL 值中可能包含未转义的引号
+	for _, engine := range xssEngines()[1:] {
+		tree := parse.Parse("", []byte("# foo\n{: id=\"x\" title=\"y\"}\n"), engine.ParseOptions)
+		heading := tree.Root.FirstChild
+		heading.KramdownIAL[0][1] = "x\" onmouseover=\"alert(1)"
+		heading.KramdownIAL[1][1] = "y\"><script>alert(1)</script>"
+		output := string(render.NewHtmlRenderer(tree, engine.RenderOptions, engine.ParseOptions).Render())
+		if reason := executable(output); "" != reason {
+			t.Fatalf("heading ial is executable: %s\ngot\n\t%q", reason, output)
+		}
+	}
+}
+
+func TestXSSSanitize(t *testing.T) {
+	for i, vector := range xssVectors {
+		output := render.Sanitize(vector)
+		if reason := executable(output); "" != reason {
+			t.Fatalf("xss vector [%d] is executable: %s\nvector\n\t%q\ngot\n\t%q", i, reason, vector, output)
+		}
+	}
+}
+
+func FuzzXSSMarkdown(f *testing.F) {
+	for _, vector := range xssVectors {
+		f.Add(vector)
+	}
+	engines := xssEngines()
+	f.Fuzz(func(t *testing.T, markdown string) {
+		for _, engine := range engines {
+			tree := parse.Parse("", []byte(markdown), engine.ParseOptions)
+			renderer := render.NewHtmlRenderer(tree, engine.RenderOptions, engine.ParseOptions)
+			output := string(renderer.Render())
+			if reason := executable(output); "" != reason {
+				t.Fatalf("markdown is executable: %s\nmarkdown\n\t%q\ngot\n\t%q", reason, markdown, output)
+			}
+		}
+	})
+}
+
+func FuzzXSSSanitize(f *testing.F) {
+	for _, vector := range xssVectors {
+		f.Add(vector)
+	}
+	f.Fuzz(func(t *testing.T, str string) {
+		output := render.Sanitize(str)
+		if reason := executable(output); "" != reason {
+			t.Fatalf("html is executable: %s\nhtml\n\t%q\ngot\n\t%q", reason, str, output)
+		}
+	})
+}
+
+// xssEngines 返回用于 XSS 测试的引擎，分别使用默认选项、打开 Kramdown IAL 的选项以及自定义 IAL id 属性名的选项。
+func xssEngines() (ret []*lute.Lute) {
+	luteEngine := lute.New()
+	luteEngine.SetSanitize(true)
+	ret = append(ret, luteEngine)
+
+	luteEngine = lute.New()
+	luteEngine.SetSanitize(true)
+	luteEngine.SetKramdownIAL(true)
+	ret = append(ret, luteEngine)
+
+	luteEngine = lute.New()
+	luteEngine.SetSanitize(true)
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetKramdownIALIDRenderName("data-block-id")
+	ret = append(ret, luteEngine)
+	return
+}
+
+// rawTextTags 用于模拟浏览器在外部内容（SVG、MathML）或者禁用脚本时不把这些元素的内容当作原始文本解析的情况。
+var rawTextTags = regexp.MustCompile(`(?i)<(/?)(noscript|noembed|noframes|template|style|title|textarea|xmp|iframe)`)
+
+// executable 判断 HTML 中是否包含可执行的内容，返回原因，不包含时返回空字符串。
+func executable(str string) string {
+	if reason := executableTokens(str); "" != reason {
+		return reason
+	}
+	return executableTokens(rawTextTags.ReplaceAllString(str, "<${1}div"))
+}
+
+func executableTokens(str string) string {
+	tokenizer := html.NewTokenizer(bytes.NewReader([]byte(str)))
+	for {
+		if html.ErrorToken == tokenizer.Next() {
+			if io.EOF != tokenizer.Err() {
+				return tokenizer.Err().Error()
+			}
+			return ""
+		}
+
+		token := tokenizer.Token()
+		if html.StartTagToken != token.Type && html.SelfClosingTagToken != token.Type {
+			continue
+		}
+		switch token.Data {
+		case "script", "applet", "frame", "frameset":
+			return "element " + token.Data
+		}
+		for _, attr := range token.Attr {
+			key := strings.ToLower(attr.Key)
+			val := strings.Map(func(r rune) rune {
+				if ' ' >= r || 0x7F == r {
+					return -1
+				}
+				return r
+			}, strings.ToLower(attr.Val))
+			switch {
+			case strings.HasPrefix(key, "on"):
+				return "event handler " + key
+			case "srcdoc" == key, "http-equiv" == key, "formaction" == key:
+				return "attribute " + key
+			case "style" == key && (strings.Contains(val, "expression(") || strings.Contains(val, "javascript:")):
+				return "style " + attr.Val
+			case "set" == token.Data && "attributename" == key && strings.HasPrefix(strings.ToLower(attr.Val), "on"):
+				return "svg set " + attr.Val
+			}
+			switch key {
+			case "href", "src", "srcset", "action", "xlink:href", "poster", "data", "background", "to", "from", "values":
+				for _, prefix := range []string{"javascript:", "vbscript:", "data:text/html", "data:image/svg+xml"} {
+					if strings.HasPrefix(val, prefix) || strings.Contains(val, ","+prefix) || strings.Contains(val, ";"+prefix) {
+						return "url " + key + "=" + attr.Val
+					}
+				}
+			}
+		}
+	}
+}
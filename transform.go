@@ -0,0 +1,73 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"context"
+	"sort"
+
+	"github.com/88250/lute/parse"
+)
+
+// Transform 描述了语法树变换，在解析完成后、渲染之前对语法树进行修改，比如改写链接地址、为标题添加编号。
+type Transform func(tree *parse.Tree) error
+
+// transform 描述了一个注册的语法树变换。
+type transform struct {
+	order int       // 执行顺序，越小越先执行
+	fn    Transform // 变换函数
+}
+
+// AddTransform 注册语法树变换 fn，order 越小越先执行，order 相同时按照注册顺序执行。
+//
+// 注册的变换会在 Markdown、Format、TextBundle、RenderJSON、Md2BlockDOM、Md2VditorDOM 等以 Markdown 文本为输入的方法中，
+// 在解析完成后、渲染之前执行；编辑器自旋（Spin*）以及 DOM 之间的转换不会执行变换，以免变换结果被写回用户内容。
+// 变换返回错误时这些方法会以该错误 panic，错误可以通过 MarkdownContext、FormatContext 等 *Context 方法获取。
+func (lute *Lute) AddTransform(order int, fn Transform) {
+	lute.transforms = append(lute.transforms, &transform{order: order, fn: fn})
+	sort.SliceStable(lute.transforms, func(i, j int) bool {
+		return lute.transforms[i].order < lute.transforms[j].order
+	})
+}
+
+// ClearTransforms 移除所有注册的语法树变换。
+func (lute *Lute) ClearTransforms() {
+	lute.transforms = nil
+}
+
+// ApplyTransforms 按照顺序对 tree 执行注册的语法树变换，某个变换返回错误时停止执行后续变换并返回该错误。
+// 直接使用 parse.Parse 构建语法树后再渲染的调用方可以使用该方法执行变换。
+func (lute *Lute) ApplyTransforms(tree *parse.Tree) error {
+	for _, t := range lute.transforms {
+		if err := t.fn(tree); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMarkdown 解析 markdown 并执行注册的语法树变换，超出解析限制或者变换返回错误时以该错误 panic，用于不返回错误的方法。
+func (lute *Lute) parseMarkdown(name string, markdown []byte) (tree *parse.Tree) {
+	tree, err := lute.parseMarkdownContext(context.Background(), name, markdown)
+	if nil != err {
+		panic(err)
+	}
+	return
+}
+
+// parseMarkdownContext 解析 markdown 并执行注册的语法树变换，ctx 取消或者超出解析限制时返回错误。
+func (lute *Lute) parseMarkdownContext(ctx context.Context, name string, markdown []byte) (tree *parse.Tree, err error) {
+	if tree, err = parse.ParseContext(ctx, name, markdown, lute.ParseOptions); nil != err {
+		return
+	}
+	err = lute.ApplyTransforms(tree)
+	return
+}
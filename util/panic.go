@@ -35,3 +35,8 @@ func RecoverPanic(err *error) {
 		}
 	}
 }
+
+// PanicStack 返回当前 goroutine 的调用栈，用于在恢复 panic 时记录出错的位置。
+func PanicStack() string {
+	return string(debug.Stack())
+}
@@ -64,7 +64,7 @@ func (lute *Lute) VditorIRDOM2HTML(vhtml string) (sHTML string) {
 
 // Md2VditorIRDOM 将 markdown 转换为 Vditor Instant-Rendering DOM，用于从源码模式切换至即时渲染模式。
 func (lute *Lute) Md2VditorIRDOM(markdown string) (vHTML string) {
-	tree := parse.Parse("", []byte(markdown), lute.ParseOptions)
+	tree := lute.parseMarkdown("", []byte(markdown))
 	renderer := render.NewVditorIRRenderer(tree, lute.RenderOptions, lute.ParseOptions)
 	for nodeType, rendererFunc := range lute.Md2VditorIRDOMRendererFuncs {
 		renderer.ExtRendererFuncs[nodeType] = rendererFunc
@@ -69,7 +69,7 @@ func (lute *Lute) VditorDOM2HTML(vhtml string) (sHTML string) {
 
 // Md2VditorDOM 将 markdown 转换为 Vditor DOM，用于从源码模式切换至所见即所得模式。
 func (lute *Lute) Md2VditorDOM(markdown string) (vHTML string) {
-	tree := parse.Parse("", []byte(markdown), lute.ParseOptions)
+	tree := lute.parseMarkdown("", []byte(markdown))
 	renderer := render.NewVditorRenderer(tree, lute.RenderOptions, lute.ParseOptions)
 	for nodeType, rendererFunc := range lute.Md2VditorDOMRendererFuncs {
 		renderer.ExtRendererFuncs[nodeType] = rendererFunc
@@ -89,7 +89,7 @@ func (lute *Lute) VditorDOM2Md(htmlStr string) (markdown string) {
 
 // RenderEChartsJSON 用于渲染 ECharts JSON 格式数据。
 func (lute *Lute) RenderEChartsJSON(markdown string) (json string) {
-	tree := parse.Parse("", []byte(markdown), lute.ParseOptions)
+	tree := lute.parseMarkdown("", []byte(markdown))
 	renderer := render.NewEChartsJSONRenderer(tree, lute.RenderOptions, lute.ParseOptions)
 	output := renderer.Render()
 	json = string(output)
@@ -98,7 +98,7 @@ func (lute *Lute) RenderEChartsJSON(markdown string) (json string) {
 
 // RenderKityMinderJSON 用于渲染 KityMinder JSON 格式数据。
 func (lute *Lute) RenderKityMinderJSON(markdown string) (json string) {
-	tree := parse.Parse("", []byte(markdown), lute.ParseOptions)
+	tree := lute.parseMarkdown("", []byte(markdown))
 	renderer := render.NewKityMinderJSONRenderer(tree, lute.RenderOptions, lute.ParseOptions)
 	output := renderer.Render()
 	json = string(output)